@@ -4,11 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/packages/param"
 	"github.com/invopop/jsonschema"
 )
 
@@ -37,6 +36,8 @@ type ToolDefinition struct {
 	Description string                         `json:"description"`
 	InputSchema anthropic.ToolInputSchemaParam `json:"input_schema"`
 	Function    func(input json.RawMessage) (string, error)
+	// EndsConversation makes Run return once this tool has been called successfully
+	EndsConversation bool `json:"-"`
 }
 
 // WebSearchToolDefinition represents the web search tool
@@ -45,9 +46,9 @@ var WebSearchToolDefinition = anthropic.ToolUnionParam{
 	OfWebSearchTool20250305: &anthropic.WebSearchTool20250305Param{
 		Type:    "web_search_20250305",
 		Name:    "web_search",
-		MaxUses: anthropic.Int(5), // Optional: limit searches per request
+		MaxUses: anthropic.Int(DefaultWebSearchMaxUses), // Replaced per agent by Options.WebSearchMaxUses
+		// AllowedDomains is set per agent from Options.WebSearchAllowedDomains
 		// Optional: Add domain filtering or location if needed
-		// AllowedDomains: []string{"example.com"},
 		// BlockedDomains: []string{"untrusted.com"},
 		// UserLocation: &anthropic.WebSearchTool20250305ParamUserLocation{
 		//     Type:     "approximate",
@@ -74,62 +75,48 @@ func GenerateSchema[T any]() anthropic.ToolInputSchemaParam {
 	}
 }
 
-// ReadFileInput defines the input for the read_file tool
-type ReadFileInput struct {
-	Path string `json:"path" jsonschema_description:"The relative path of a file in the working directory"`
-}
-
-var ReadFileInputSchema = GenerateSchema[ReadFileInput]()
-
-// ReadFileDefinition defines the read_file tool
-var ReadFileDefinition = ToolDefinition{
-	Name:        "read_file",
-	Description: "Read the contents of a given relative file path. Use this when you want to see what's inside a file. Do not use this with directory names.",
-	InputSchema: ReadFileInputSchema,
-	Function:    ReadFile,
-}
-
-// ListFilesInput defines the input for the list_files tool
-type ListFilesInput struct {
-	Path string `json:"path,omitempty" jsonschema_description:"Optional relative path to list files from. Defaults to current directory if not provided."`
-}
-
-var ListFilesInputSchema = GenerateSchema[ListFilesInput]()
-
-// ListFilesDefinition defines the list_files tool
-var ListFilesDefinition = ToolDefinition{
-	Name:        "list_files",
-	Description: "List files and directories at a given path. If no path is provided, lists files in the current directory.",
-	InputSchema: ListFilesInputSchema,
-	Function:    ListFiles,
-}
+// Defaults used for any Options field left at its zero value
+const (
+	DefaultModel            = "claude-sonnet-4-20250514"
+	DefaultMaxTokens        = 1024
+	DefaultWebSearchMaxUses = 5
+)
 
-// EditFileInput defines the input for the edit_file tool
-type EditFileInput struct {
-	Path   string `json:"path" jsonschema_definition:"The path to the file"`
-	OldStr string `json:"old_str" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
-	NewStr string `json:"new_str" jsonschema_description:"Text to replace old_str with"`
+// Options configures the model requests an agent makes
+type Options struct {
+	Model            string
+	MaxTokens        int64
+	WebSearch        bool
+	WebSearchMaxUses int64
+	// WebSearchAllowedDomains, if not empty, restricts web search to these
+	// domains (and their subdomains)
+	WebSearchAllowedDomains []string
 }
 
-var EditFileInputSchema = GenerateSchema[EditFileInput]()
-
-// EditFileDefinition defines the edit_file tool
-var EditFileDefinition = ToolDefinition{
-	Name: "edit_file",
-	Description: `Make edits to a text file.
-
-Replaces 'old_str' with 'new_str' in the given file. 'old_stir' and 'new_str' MUST be different from each other.
-
-If the file specified with path doesn't exist, it will be created.
-`,
-	InputSchema: EditFileInputSchema,
-	Function:    EditFile,
+// DefaultOptions returns the options used when nothing is configured
+func DefaultOptions() Options {
+	return Options{
+		Model:            DefaultModel,
+		MaxTokens:        DefaultMaxTokens,
+		WebSearch:        true,
+		WebSearchMaxUses: DefaultWebSearchMaxUses,
+	}
 }
 
-func NewAgent(client anthropic.Client, getUserMessage func() (string, bool), systemPrompt string, tools []ToolDefinition) *Agent {
+func NewAgent(client anthropic.Client, getUserMessage func() (string, bool), systemPrompt string, tools []ToolDefinition, opts Options) *Agent {
 	// Create a buffered channel for output events
 	outputChan := make(chan OutputEvent, 100)
 
+	if opts.Model == "" {
+		opts.Model = DefaultModel
+	}
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = DefaultMaxTokens
+	}
+	if opts.WebSearchMaxUses <= 0 {
+		opts.WebSearchMaxUses = DefaultWebSearchMaxUses
+	}
+
 	return &Agent{
 		client:          client,
 		getUserMessage:  getUserMessage,
@@ -137,7 +124,8 @@ func NewAgent(client anthropic.Client, getUserMessage func() (string, bool), sys
 		outputChan:      outputChan,
 		done:            make(chan struct{}),
 		tools:           tools,
-		enableWebSearch: true, // Enable web search by default
+		enableWebSearch: opts.WebSearch,
+		options:         opts,
 	}
 }
 
@@ -149,6 +137,13 @@ type Agent struct {
 	done            chan struct{}
 	tools           []ToolDefinition
 	enableWebSearch bool
+	options         Options
+	// session, if set, saves the conversation after every turn, and history
+	// is the saved conversation Run resumes from
+	session *Session
+	history []anthropic.MessageParam
+	// usage, if set, is told about every model request
+	usage UsageRecorder
 }
 
 // SetWebSearchEnabled allows enabling/disabling web search
@@ -156,12 +151,22 @@ func (a *Agent) SetWebSearchEnabled(enabled bool) {
 	a.enableWebSearch = enabled
 }
 
+// SetSession makes Run save the conversation to session after every turn,
+// continuing from history, the messages saved so far, when it is not empty
+func (a *Agent) SetSession(session *Session, history []anthropic.MessageParam) {
+	a.session = session
+	a.history = history
+}
+
 // OutputChannel returns the channel that emits output events
 func (a *Agent) OutputChannel() <-chan OutputEvent {
 	return a.outputChan
 }
 
-// Close shuts down the agent and closes the output channel
+// Close shuts down the agent and closes the output channel. It must be called
+// once Run or Complete has returned, as they send on the output channel until
+// then; a model request still streaming when they return is always stopped
+// first.
 func (a *Agent) Close() {
 	close(a.done)
 	close(a.outputChan)
@@ -196,24 +201,53 @@ func (a *Agent) executeTool(id, name string, input json.RawMessage) anthropic.Co
 	return anthropic.NewToolResultBlock(id, response, false)
 }
 
+// endsConversation reports whether the named tool is marked EndsConversation
+func (a *Agent) endsConversation(name string) bool {
+	for _, tool := range a.tools {
+		if tool.Name == name {
+			return tool.EndsConversation
+		}
+	}
+	return false
+}
+
 func (a *Agent) Run(ctx context.Context) error {
-	var messages []anthropic.MessageParam
-	
-	// Initial user message to start conversation using helper functions
-	startMessage := anthropic.NewUserMessage(anthropic.NewTextBlock("Start the conversation"))
-	messages = append(messages, startMessage)
+	messages := a.history
+
+	if len(messages) == 0 {
+		// Initial user message to start conversation using helper functions
+		startMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(startConversation))
+		messages = append(messages, startMessage)
+		if err := a.record(ctx, startMessage); err != nil {
+			return err
+		}
+	} else if messages[len(messages)-1].Role == anthropic.MessageParamRoleAssistant {
+		// A resumed conversation that stopped after Claude's reply goes on with the user
+		userMessage, ok := a.userTurn()
+		if !ok {
+			return nil
+		}
+		messages = append(messages, userMessage)
+		if err := a.record(ctx, userMessage); err != nil {
+			return err
+		}
+	}
 
 	for {
 		// We're letting Claude speak first, with streaming for responsiveness
 		a.outputChan <- OutputEvent{Type: EventAssistantPrefix, Content: "Claude: "}
-		message, err := a.runInference(ctx, messages)
+		message, turn, err := a.completeTurn(ctx, messages, func(part anthropic.MessageParam) error {
+			return a.record(ctx, part)
+		})
 		if err != nil {
 			return err
 		}
+		messages = turn
 		a.outputChan <- OutputEvent{Type: EventNewline}
 
 		// Process Claude's response and collect any tool results
 		var toolResults []anthropic.ContentBlockParamUnion
+		finished := false
 		
 		// Handle message content blocks - only process tool_use blocks
 		// Text content is already output during streaming, don't output it again here
@@ -221,37 +255,178 @@ func (a *Agent) Run(ctx context.Context) error {
 			if block.Type == "tool_use" {
 				result := a.executeTool(block.ID, block.Name, block.Input)
 				toolResults = append(toolResults, result)
+				if a.endsConversation(block.Name) && !result.OfToolResult.IsError.Value {
+					finished = true
+				}
 			}
 			// Skip outputting text content here since it was already handled in streaming
 		}
 		
-		// Add Claude's response to conversation using ToParam()
-		messages = append(messages, message.ToParam())
+		// completeTurn added Claude's response to the conversation; save it
+		if err := a.record(ctx, messages[len(messages)-1]); err != nil {
+			return err
+		}
+
+		// A conversation-ending tool has delivered its result, so there is nothing left to ask
+		if finished {
+			return a.finish(ctx, toolResults)
+		}
 
 		// If we have tool results, add them as a user message using the helper function
 		if len(toolResults) > 0 {
 			toolResultMessage := anthropic.NewUserMessage(toolResults...)
 			messages = append(messages, toolResultMessage)
+			if err := a.record(ctx, toolResultMessage); err != nil {
+				return err
+			}
 			// Skip getting user input and go straight to next Claude response
 			continue
 		}
 
 		// Now it's the user's turn
-		a.outputChan <- OutputEvent{Type: EventUserPrefix, Content: "You: "}
-		userInput, ok := a.getUserMessage()
+		userMessage, ok := a.userTurn()
 		if !ok {
 			break
 		}
-
-		// Add user's message to conversation using helper functions
-		userMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(userInput))
 		messages = append(messages, userMessage)
+		if err := a.record(ctx, userMessage); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// userTurn asks for the user's next message, reporting false if they ended
+// the conversation
+func (a *Agent) userTurn() (anthropic.MessageParam, bool) {
+	a.outputChan <- OutputEvent{Type: EventUserPrefix, Content: "You: "}
+	userInput, ok := a.getUserMessage()
+	if !ok {
+		return anthropic.MessageParam{}, false
+	}
+	return anthropic.NewUserMessage(anthropic.NewTextBlock(userInput)), true
+}
+
+// record saves a message to the session, if there is one
+func (a *Agent) record(ctx context.Context, message anthropic.MessageParam) error {
+	if a.session == nil {
+		return nil
+	}
+	return a.session.Record(ctx, message)
+}
+
+// finish saves the results of the tool calls that ended the conversation, so
+// the saved conversation stays valid to resume, and marks the session finished
+func (a *Agent) finish(ctx context.Context, toolResults []anthropic.ContentBlockParamUnion) error {
+	if a.session == nil {
+		return nil
+	}
+	if err := a.session.Record(ctx, anthropic.NewUserMessage(toolResults...)); err != nil {
+		return err
+	}
+	return a.session.Finish(ctx)
+}
+
+// Complete runs a single non-interactive exchange: it sends prompt as the user
+// message, executes any local tool calls Claude makes, and returns Claude's
+// final reply once it stops asking for tools. Output events are still emitted,
+// so a consumer must be draining OutputChannel while Complete runs.
+func (a *Agent) Complete(ctx context.Context, prompt string) (*anthropic.Message, error) {
+	messages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
+	}
+
+	for {
+		message, turn, err := a.completeTurn(ctx, messages, nil)
+		if err != nil {
+			return nil, err
+		}
+		messages = turn
+
+		var toolResults []anthropic.ContentBlockParamUnion
+		for _, block := range message.Content {
+			if block.Type == "tool_use" {
+				toolResults = append(toolResults, a.executeTool(block.ID, block.Name, block.Input))
+			}
+		}
+		if len(toolResults) == 0 {
+			return message, nil
+		}
+
+		messages = append(messages, anthropic.NewUserMessage(toolResults...))
+	}
+}
+
+// completeTurn gets Claude's next turn. A long server-side web search can
+// pause a turn; each paused part is added to the conversation, passed to
+// onPause if set, and sent back to resume the turn. The returned message holds
+// the blocks of the whole turn, so every search result and citation is kept,
+// and the returned conversation ends with the turn's last part.
+func (a *Agent) completeTurn(ctx context.Context, messages []anthropic.MessageParam, onPause func(anthropic.MessageParam) error) (*anthropic.Message, []anthropic.MessageParam, error) {
+	var paused []anthropic.ContentBlockUnion
+	for {
+		message, err := a.runInference(ctx, messages)
+		if err != nil {
+			return nil, messages, err
+		}
+		messages = append(messages, assistantParam(message))
+		if message.StopReason != anthropic.StopReasonPauseTurn {
+			message.Content = append(paused, message.Content...)
+			return message, messages, nil
+		}
+
+		paused = append(paused, message.Content...)
+		if onPause != nil {
+			if err := onPause(messages[len(messages)-1]); err != nil {
+				return nil, messages, err
+			}
+		}
+	}
+}
+
+// assistantParam turns Claude's reply back into a message to send. The SDK's
+// ToParam has no case for server-side web search blocks and turns them into
+// null, which the API rejects, so those are rebuilt field by field. Their raw
+// JSON cannot be reused: after streaming it holds every field of the union.
+func assistantParam(message *anthropic.Message) anthropic.MessageParam {
+	p := message.ToParam()
+	for i, block := range message.Content {
+		switch block.Type {
+		case "server_tool_use":
+			p.Content[i] = anthropic.ContentBlockParamUnion{OfServerToolUse: &anthropic.ServerToolUseBlockParam{
+				ID:    block.ID,
+				Input: block.Input,
+			}}
+		case "web_search_tool_result":
+			result := &anthropic.WebSearchToolResultBlockParam{ToolUseID: block.ToolUseID}
+			if block.Content.ErrorCode != "" {
+				result.Content.OfRequestWebSearchToolResultError = &anthropic.WebSearchToolRequestErrorParam{
+					ErrorCode: anthropic.WebSearchToolRequestErrorErrorCode(block.Content.ErrorCode),
+				}
+			} else {
+				result.Content.OfWebSearchToolResultBlockItem = []anthropic.WebSearchResultBlockParam{}
+				for _, hit := range block.Content.OfWebSearchResultBlockArray {
+					item := anthropic.WebSearchResultBlockParam{EncryptedContent: hit.EncryptedContent, Title: hit.Title, URL: hit.URL}
+					if hit.PageAge != "" {
+						item.PageAge = param.NewOpt(hit.PageAge)
+					}
+					result.Content.OfWebSearchToolResultBlockItem = append(result.Content.OfWebSearchToolResultBlockItem, item)
+				}
+			}
+			p.Content[i] = anthropic.ContentBlockParamUnion{OfWebSearchToolResult: result}
+		}
+	}
+	return p
+}
+
 func (a *Agent) runInference(ctx context.Context, messages []anthropic.MessageParam) (*anthropic.Message, error) {
+	if a.usage != nil {
+		if err := a.usage.Allow(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Convert tool definitions to Anthropic tool parameters
 	anthropicTools := []anthropic.ToolUnionParam{}
 
@@ -268,13 +443,16 @@ func (a *Agent) runInference(ctx context.Context, messages []anthropic.MessagePa
 
 	// Add web search tool if enabled
 	if a.enableWebSearch {
-		anthropicTools = append(anthropicTools, WebSearchToolDefinition)
+		webSearch := *WebSearchToolDefinition.OfWebSearchTool20250305
+		webSearch.MaxUses = anthropic.Int(a.options.WebSearchMaxUses)
+		webSearch.AllowedDomains = a.options.WebSearchAllowedDomains
+		anthropicTools = append(anthropicTools, anthropic.ToolUnionParam{OfWebSearchTool20250305: &webSearch})
 	}
 
 	// Create request params
 	req := anthropic.MessageNewParams{
-		Model:     "claude-sonnet-4-20250514",
-		MaxTokens: 1024,
+		Model:     anthropic.Model(a.options.Model),
+		MaxTokens: a.options.MaxTokens,
 		Messages:  messages,
 		Tools:     anthropicTools,
 	}
@@ -284,8 +462,14 @@ func (a *Agent) runInference(ctx context.Context, messages []anthropic.MessagePa
 		req.System = []anthropic.TextBlockParam{{Text: a.SystemPrompt}}
 	}
 
+	// The stream gets its own context so that shutting the agent down can
+	// abort a response that is still arriving
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Use streaming API for better responsiveness
-	stream := a.client.Messages.NewStreaming(ctx, req)
+	start := time.Now()
+	stream := a.client.Messages.NewStreaming(streamCtx, req)
 	if stream.Err() != nil {
 		return nil, stream.Err()
 	}
@@ -311,19 +495,25 @@ func (a *Agent) runInference(ctx context.Context, messages []anthropic.MessagePa
 			event := stream.Current()
 
 			// Send the raw event to the output channel
-			a.outputChan <- OutputEvent{Type: EventRaw, Raw: event}
+			if !a.emit(streamCtx, OutputEvent{Type: EventRaw, Raw: event}) {
+				return
+			}
 
 			// Extract and send text content for convenience
 			switch eventVariant := event.AsAny().(type) {
 			case anthropic.ContentBlockDeltaEvent:
 				switch deltaVariant := eventVariant.Delta.AsAny().(type) {
 				case anthropic.TextDelta:
-					a.outputChan <- OutputEvent{Type: EventContent, Content: deltaVariant.Text}
+					if !a.emit(streamCtx, OutputEvent{Type: EventContent, Content: deltaVariant.Text}) {
+						return
+					}
 				}
 			case anthropic.ContentBlockStartEvent:
 				// Handle web search events
 				if eventVariant.ContentBlock.Type == "server_tool_use" {
-					a.outputChan <- OutputEvent{Type: EventContent, Content: fmt.Sprintf("\n[Searching the web...]\n")}
+					if !a.emit(streamCtx, OutputEvent{Type: EventContent, Content: fmt.Sprintf("\n[Searching the web...]\n")}) {
+						return
+					}
 				}
 			}
 
@@ -333,10 +523,12 @@ func (a *Agent) runInference(ctx context.Context, messages []anthropic.MessagePa
 				errCh <- fmt.Errorf("error accumulating event: %w", err)
 				return
 			}
+			if delta, ok := event.AsAny().(anthropic.MessageDeltaEvent); ok {
+				mergeDeltaUsage(&finalMessage.Usage, delta.Usage)
+			}
 
 			// Check if context was cancelled between events
-			if ctx.Err() != nil {
-				errCh <- ctx.Err()
+			if streamCtx.Err() != nil {
 				return
 			}
 		}
@@ -348,137 +540,63 @@ func (a *Agent) runInference(ctx context.Context, messages []anthropic.MessagePa
 		}
 	}()
 
-	// Wait for either completion, error, or cancellation
+	// Wait for either completion, error, or cancellation. The goroutine is
+	// always waited for, so it never sends on the output channel once this
+	// returns and the caller may close the agent.
 	select {
 	case <-done:
-		// Stream completed successfully
-		// Ensure the message has content to avoid "messages.X.content: Field required" error
-		if finalMessage.Content == nil || len(finalMessage.Content) == 0 {
-			return nil, fmt.Errorf("accumulated message has no content")
-		}
-		return finalMessage, nil
-	case err := <-errCh:
-		// Error occurred during streaming
-		return nil, err
 	case <-ctx.Done():
-		// Context was cancelled - explicitly close the stream to force any blocking operations to stop
-		stream.Close()
-		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
+		// Context was cancelled - the request's context stops the stream
+		<-done
 	case <-a.done:
-		// Agent is being shut down
-		stream.Close()
-		return nil, fmt.Errorf("agent shutting down")
+		// Agent is being shut down - abort the request to stop the stream
+		cancel()
+		<-done
 	}
-}
 
-// Tool implementations
-
-func ReadFile(input json.RawMessage) (string, error) {
-	readFileInput := ReadFileInput{}
-	err := json.Unmarshal(input, &readFileInput)
-	if err != nil {
-		return "", err
-	}
-
-	content, err := os.ReadFile(readFileInput.Path)
-	if err != nil {
-		return "", err
-	}
-	return string(content), nil
-}
-
-func ListFiles(input json.RawMessage) (string, error) {
-	listFilesInput := ListFilesInput{}
-	err := json.Unmarshal(input, &listFilesInput)
-	if err != nil {
-		return "", err
+	select {
+	case <-a.done:
+		return nil, fmt.Errorf("agent shutting down")
+	default:
 	}
-
-	dir := "."
-	if listFilesInput.Path != "" {
-		dir = listFilesInput.Path
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("operation cancelled: %w", ctx.Err())
 	}
-
-	var files []string
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(dir, path)
-		if err != nil {
-			return err
-		}
-
-		if relPath != "." {
-			if info.IsDir() {
-				files = append(files, relPath+"/")
-			} else {
-				files = append(files, relPath)
+	select {
+	case err := <-errCh:
+		// Error occurred during streaming
+		return nil, err
+	default:
+		// Stream completed successfully
+		if a.usage != nil {
+			// The tokens were used even if the reply turns out to be unusable
+			model := string(finalMessage.Model)
+			if model == "" {
+				model = a.options.Model
+			}
+			call := Call{Model: model, Usage: finalMessage.Usage, Latency: time.Since(start)}
+			if err := a.usage.Record(ctx, call); err != nil {
+				return nil, fmt.Errorf("recording usage: %w", err)
 			}
 		}
-		return nil
-	})
-
-	if err != nil {
-		return "", err
-	}
-
-	result, err := json.Marshal(files)
-	if err != nil {
-		return "", err
-	}
-
-	return string(result), nil
-}
-
-func EditFile(input json.RawMessage) (string, error) {
-	editFileInput := EditFileInput{}
-	err := json.Unmarshal(input, &editFileInput)
-	if err != nil {
-		return "", err
-	}
-
-	if editFileInput.Path == "" || editFileInput.OldStr == editFileInput.NewStr {
-		return "", fmt.Errorf("invalid input parameters")
-	}
-
-	content, err := os.ReadFile(editFileInput.Path)
-	if err != nil {
-		if os.IsNotExist(err) && editFileInput.OldStr == "" {
-			return createNewFile(editFileInput.Path, editFileInput.NewStr)
+		// Ensure the message has content to avoid "messages.X.content: Field required" error
+		if finalMessage.Content == nil || len(finalMessage.Content) == 0 {
+			return nil, fmt.Errorf("accumulated message has no content")
 		}
-		return "", err
-	}
-
-	oldContent := string(content)
-	newContent := strings.Replace(oldContent, editFileInput.OldStr, editFileInput.NewStr, -1)
-
-	if oldContent == newContent && editFileInput.OldStr != "" {
-		return "", fmt.Errorf("old_str not found in file")
-	}
-
-	err = os.WriteFile(editFileInput.Path, []byte(newContent), 0644)
-	if err != nil {
-		return "", err
+		return finalMessage, nil
 	}
-
-	return "OK", nil
 }
 
-func createNewFile(filePath, content string) (string, error) {
-	dir := filepath.Dir(filePath)
-	if dir != "." {
-		err := os.MkdirAll(dir, 0755)
-		if err != nil {
-			return "", fmt.Errorf("failed to create directory: %w", err)
-		}
-	}
-
-	err := os.WriteFile(filePath, []byte(content), 0644)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+// emit sends an event on the output channel, giving up if ctx is cancelled
+// or the agent is shut down while it waits for a reader. It reports whether
+// the event was sent.
+func (a *Agent) emit(ctx context.Context, event OutputEvent) bool {
+	select {
+	case a.outputChan <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-a.done:
+		return false
 	}
-
-	return fmt.Sprintf("Successfully created file %s", filePath), nil
 }
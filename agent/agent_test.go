@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/anthropictest"
+)
+
+func TestCompleteReturnsReply(t *testing.T) {
+	server := anthropictest.NewServer(t, anthropictest.Reply{Text: "hello"})
+	a := NewAgent(server.Client(), nil, "system", nil, DefaultOptions())
+	a.SetWebSearchEnabled(false)
+	go NewConsoleClient(io.Discard).Run(a)
+	defer a.Close()
+
+	message, err := a.Complete(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if len(message.Content) != 1 || message.Content[0].Text != "hello" {
+		t.Errorf("Complete returned %+v, want a single \"hello\" text block", message.Content)
+	}
+	if message.Usage.OutputTokens != 20 {
+		t.Errorf("output tokens = %d, want 20", message.Usage.OutputTokens)
+	}
+}
+
+// Cancelling a request mid-stream used to return while the stream goroutine
+// could still send on the output channel, which then panicked once the
+// caller closed the agent
+func TestCompleteCancelledThenClosed(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		started := make(chan struct{})
+		server := anthropictest.NewServer(t, anthropictest.Reply{Text: "more ", Endless: true, Started: started})
+		a := NewAgent(server.Client(), nil, "system", nil, DefaultOptions())
+		a.SetWebSearchEnabled(false)
+		go NewConsoleClient(io.Discard).Run(a)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+
+		_, err := a.Complete(ctx, "hi")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Complete error = %v, want context.Canceled", err)
+		}
+		a.Close()
+	}
+}
+
+// A reader that has stopped must not leave the stream goroutine blocked
+// forever once the request is cancelled
+func TestCompleteCancelledWithoutReader(t *testing.T) {
+	started := make(chan struct{})
+	server := anthropictest.NewServer(t, anthropictest.Reply{Text: "more ", Endless: true, Started: started})
+	a := NewAgent(server.Client(), nil, "system", nil, DefaultOptions())
+	a.SetWebSearchEnabled(false)
+	// Fill the output buffer so the next event blocks
+	for i := 0; i < cap(a.outputChan); i++ {
+		a.outputChan <- OutputEvent{Type: EventNewline}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	finished := make(chan error, 1)
+	go func() {
+		_, err := a.Complete(ctx, "hi")
+		finished <- err
+	}()
+	select {
+	case err := <-finished:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Complete error = %v, want context.Canceled", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Complete did not return after its context was cancelled")
+	}
+	a.Close()
+}
+
+// A server-side web search that runs long pauses the turn, and the partial
+// turn is sent back to resume it. The SDK cannot turn web search blocks into
+// request parameters, so they used to go back as null and the API refused them.
+func TestCompleteResumesPausedWebSearch(t *testing.T) {
+	server := anthropictest.NewServer(t,
+		anthropictest.Reply{
+			StopReason: "pause_turn",
+			Blocks: []string{
+				`{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": {"query": "Alice Smith MIT"}}`,
+				`{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": [
+					{"type": "web_search_result", "url": "https://example.com/alice", "title": "Alice Smith", "encrypted_content": "abc", "page_age": "2 days ago"}
+				]}`,
+			},
+		},
+		anthropictest.Reply{Text: "Alice Smith is at MIT."},
+	)
+	a := NewAgent(server.Client(), nil, "system", nil, DefaultOptions())
+	go NewConsoleClient(io.Discard).Run(a)
+	defer a.Close()
+
+	message, err := a.Complete(context.Background(), "Find Alice Smith")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	// The returned message holds the whole turn, search included
+	var types []string
+	for _, block := range message.Content {
+		types = append(types, block.Type)
+	}
+	if got := strings.Join(types, ","); got != "server_tool_use,web_search_tool_result,text" {
+		t.Errorf("content blocks = %s, want the search followed by the answer", got)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(requests))
+	}
+	var resumed struct {
+		Messages []struct {
+			Role    string            `json:"role"`
+			Content []json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(requests[1], &resumed); err != nil {
+		t.Fatalf("decoding the resumed request: %v", err)
+	}
+	if len(resumed.Messages) != 2 || resumed.Messages[1].Role != "assistant" {
+		t.Fatalf("resumed request = %s, want the prompt and the paused turn", requests[1])
+	}
+	want := []string{
+		`{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"Alice Smith MIT"}}`,
+		`{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","url":"https://example.com/alice","title":"Alice Smith","encrypted_content":"abc","page_age":"2 days ago"}]}`,
+	}
+	blocks := resumed.Messages[1].Content
+	if len(blocks) != len(want) {
+		t.Fatalf("paused turn sent back as %s", requests[1])
+	}
+	for i, block := range blocks {
+		if !jsonEqual(t, block, []byte(want[i])) {
+			t.Errorf("block %d sent back as %s, want %s", i, block, want[i])
+		}
+	}
+}
+
+// The interactive loop resumes a paused turn too, rather than ending the turn
+// there and asking the user for the next message
+func TestRunResumesPausedWebSearch(t *testing.T) {
+	server := anthropictest.NewServer(t,
+		anthropictest.Reply{
+			StopReason: "pause_turn",
+			Blocks: []string{
+				`{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": {"query": "Alice Smith MIT"}}`,
+				`{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": [
+					{"type": "web_search_result", "url": "https://example.com/alice", "title": "Alice Smith", "encrypted_content": "abc", "page_age": "2 days ago"}
+				]}`,
+			},
+		},
+		anthropictest.Reply{Text: "Alice Smith is at MIT."},
+	)
+	prompts := 0
+	getUserMessage := func() (string, bool) {
+		prompts++
+		return "", false
+	}
+	a := NewAgent(server.Client(), getUserMessage, "system", nil, DefaultOptions())
+	go NewConsoleClient(io.Discard).Run(a)
+	defer a.Close()
+
+	if err := a.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if prompts != 1 {
+		t.Errorf("asked the user %d times, want once after the resumed turn", prompts)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(requests))
+	}
+	var resumed struct {
+		Messages []struct {
+			Role    string            `json:"role"`
+			Content []json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(requests[1], &resumed); err != nil {
+		t.Fatalf("decoding the resumed request: %v", err)
+	}
+	if len(resumed.Messages) != 2 || resumed.Messages[1].Role != "assistant" || len(resumed.Messages[1].Content) != 2 {
+		t.Fatalf("resumed request = %s, want the opening message and the paused turn", requests[1])
+	}
+}
+
+// jsonEqual reports whether two JSON documents hold the same value
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y any
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("decoding %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("decoding %s: %v", b, err)
+	}
+	return reflect.DeepEqual(x, y)
+}
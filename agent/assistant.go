@@ -0,0 +1,21 @@
+package agent
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// assistantSystemPrompt describes the research assistant's job and tools
+const assistantSystemPrompt = `You are a research assistant helping a recruiter find AI research talent on arXiv.
+
+The recruiter's saved searches, the papers fetched for them, relevance verdicts and candidate authors are stored in a database you can reach through your tools:
+- list_searches, get_search_stats and list_articles to see what has been collected
+- find_candidate to look people up, and link_candidate to attach them to a search
+- record_relevance to store your own verdict on a paper
+
+Look things up before answering instead of guessing, and quote IDs exactly as the tools return them. Only link candidates or record verdicts when the recruiter asks you to or agrees. Keep your messages short.`
+
+// NewResearchAssistant creates an interactive agent with the given tools,
+// usually NewResearchTools plus, if configured, a Workspace's file tools
+func NewResearchAssistant(client anthropic.Client, getUserMessage func() (string, bool), tools []ToolDefinition, opts Options) *Agent {
+	return NewAgent(client, getUserMessage, assistantSystemPrompt, tools, opts)
+}
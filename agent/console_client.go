@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+
+	"github.com/anthropics/anthropic-sdk-go"
 )
 
 // ConsoleClient implements a console-based output handler for the agent
@@ -34,3 +36,30 @@ func (c *ConsoleClient) Run(agent *Agent) {
 		}
 	}
 }
+
+// Replay prints the text of a saved conversation the way it looked when it
+// was live, before a resumed session carries on from it. Call it before Run.
+func (c *ConsoleClient) Replay(messages []anthropic.MessageParam) {
+	for _, message := range messages {
+		if isStartMessage(message) {
+			continue
+		}
+		if message.Role == anthropic.MessageParamRoleAssistant {
+			fmt.Fprint(c.writer, "\u001b[93mClaude: \u001b[0m")
+			for _, block := range message.Content {
+				if block.OfText != nil {
+					fmt.Fprint(c.writer, block.OfText.Text)
+				}
+			}
+			fmt.Fprintln(c.writer)
+			for _, block := range message.Content {
+				if block.OfToolUse != nil {
+					fmt.Fprintf(c.writer, "Using tool: %s\n", block.OfToolUse.Name)
+				}
+			}
+		} else if text := MessageText(message); text != "" {
+			fmt.Fprint(c.writer, "\u001b[94mYou: \u001b[0m")
+			fmt.Fprintln(c.writer, text)
+		}
+	}
+}
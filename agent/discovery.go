@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/jessewalker/reSearch/fetcher"
+)
+
+// CategoryProposal is the outcome of a category-discovery conversation
+type CategoryProposal struct {
+	Categories []string
+	Rationale  string
+	FeedURL    string
+}
+
+// ProposeCategoriesInput defines the input for the propose_categories tool
+type ProposeCategoriesInput struct {
+	Categories []string `json:"categories" jsonschema_description:"arXiv category codes to follow, e.g. [\"cs.LG\", \"cs.PL\"]"`
+	Rationale  string   `json:"rationale" jsonschema_description:"A short explanation of why these categories fit the search"`
+}
+
+var ProposeCategoriesInputSchema = GenerateSchema[ProposeCategoriesInput]()
+
+// NewCategoryDiscoveryAgent creates an agent that talks with the user about
+// their search and finishes by calling propose_categories. The returned
+// proposal is filled in once that tool call succeeds; if the user ends the
+// conversation first, its Categories are left empty.
+func NewCategoryDiscoveryAgent(client anthropic.Client, getUserMessage func() (string, bool), description string, opts Options) (*Agent, *CategoryProposal) {
+	proposal := &CategoryProposal{}
+
+	proposeCategories := ToolDefinition{
+		Name: "propose_categories",
+		Description: `Propose the final set of arXiv categories for this search. Only call this once the user has agreed with your suggestion.
+
+Every category must be one of the codes listed in the system prompt.`,
+		InputSchema: ProposeCategoriesInputSchema,
+		Function: func(input json.RawMessage) (string, error) {
+			proposeInput := ProposeCategoriesInput{}
+			err := json.Unmarshal(input, &proposeInput)
+			if err != nil {
+				return "", err
+			}
+
+			feedURL, err := fetcher.FeedURL(proposeInput.Categories)
+			if err != nil {
+				return "", err
+			}
+
+			proposal.Categories = proposeInput.Categories
+			proposal.Rationale = proposeInput.Rationale
+			proposal.FeedURL = feedURL
+			return fmt.Sprintf("Proposed feed %s", feedURL), nil
+		},
+		EndsConversation: true,
+	}
+
+	a := NewAgent(client, getUserMessage, discoverySystemPrompt(description), []ToolDefinition{proposeCategories}, opts)
+	a.SetWebSearchEnabled(false)
+	return a, proposal
+}
+
+// discoverySystemPrompt primes Claude with the search description and the arXiv taxonomy
+func discoverySystemPrompt(description string) string {
+	var sb strings.Builder
+	sb.WriteString("You are helping a recruiter set up a search for AI research talent on arXiv.\n\n")
+	fmt.Fprintf(&sb, "The recruiter described the search as: %s\n\n", description)
+	sb.WriteString(`Have a brief conversation to understand which research areas matter, then suggest a small set (usually 1-4) of arXiv categories to follow and explain your choice. Keep your messages short. When the recruiter agrees, call the propose_categories tool.
+
+Available arXiv categories:
+`)
+	sb.WriteString(fetcher.TaxonomyListing())
+	return sb.String()
+}
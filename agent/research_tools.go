@@ -0,0 +1,458 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/fulltext"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// maxListLimit caps how many rows a single list tool call returns, to keep tool
+// results within a reasonable share of the context window
+const maxListLimit = 100
+
+// maxSummaryLength is how much of an article abstract list_articles returns
+const maxSummaryLength = 600
+
+// ListSearchesInput defines the input for the list_searches tool
+type ListSearchesInput struct {
+	Limit  int64 `json:"limit,omitempty" jsonschema_description:"Maximum number of searches to return (default 20, max 100)"`
+	Offset int64 `json:"offset,omitempty" jsonschema_description:"Number of searches to skip, for paging"`
+}
+
+// GetSearchStatsInput defines the input for the get_search_stats tool
+type GetSearchStatsInput struct {
+	SearchID string `json:"search_id" jsonschema_description:"ID of the search, as returned by list_searches"`
+}
+
+// ListArticlesInput defines the input for the list_articles tool
+type ListArticlesInput struct {
+	SearchID string   `json:"search_id" jsonschema_description:"ID of the search whose articles to list"`
+	MinScore *float64 `json:"min_score,omitempty" jsonschema_description:"If set, only return scored articles with a relevance score at or above this value (0.0-1.0), best first"`
+	Limit    int64    `json:"limit,omitempty" jsonschema_description:"Maximum number of articles to return (default 20, max 100)"`
+	Offset   int64    `json:"offset,omitempty" jsonschema_description:"Number of articles to skip, for paging"`
+}
+
+// FindCandidateInput defines the input for the find_candidate tool
+type FindCandidateInput struct {
+	Name  string `json:"name" jsonschema_description:"Full or partial name of the candidate"`
+	Limit int64  `json:"limit,omitempty" jsonschema_description:"Maximum number of candidates to return (default 10, max 100)"`
+}
+
+// LinkCandidateInput defines the input for the link_candidate tool
+type LinkCandidateInput struct {
+	CandidateID    string   `json:"candidate_id" jsonschema_description:"ID of the candidate, as returned by find_candidate"`
+	SearchID       string   `json:"search_id" jsonschema_description:"ID of the search to link the candidate to"`
+	ArticleID      string   `json:"article_id,omitempty" jsonschema_description:"Optional ID of an article in that search which the candidate authored"`
+	RelevanceScore *float64 `json:"relevance_score,omitempty" jsonschema_description:"Optional relevance of the candidate to the search (0.0-1.0). An existing higher score is kept."`
+}
+
+// RecordRelevanceInput defines the input for the record_relevance tool
+type RecordRelevanceInput struct {
+	ArticleID  string   `json:"article_id" jsonschema_description:"ID of the article being judged"`
+	Score      float64  `json:"score" jsonschema_description:"Relevance from 0.0 (unrelated) to 1.0 (exactly the kind of work the search describes)"`
+	Rationale  string   `json:"rationale" jsonschema_description:"One or two sentences explaining the score"`
+	KeyAuthors []string `json:"key_authors,omitempty" jsonschema_description:"Authors of this paper worth following up on, copied exactly as written in the author list"`
+}
+
+var (
+	ListSearchesInputSchema    = GenerateSchema[ListSearchesInput]()
+	GetSearchStatsInputSchema  = GenerateSchema[GetSearchStatsInput]()
+	ListArticlesInputSchema    = GenerateSchema[ListArticlesInput]()
+	FindCandidateInputSchema   = GenerateSchema[FindCandidateInput]()
+	LinkCandidateInputSchema   = GenerateSchema[LinkCandidateInput]()
+	RecordRelevanceInputSchema = GenerateSchema[RecordRelevanceInput]()
+)
+
+// researchTools implements the database-backed tools. Tool functions take no
+// context, so the one the tools were created with is used for every query.
+type researchTools struct {
+	ctx      context.Context
+	queries  *database.Queries
+	fullText *fulltext.Index
+	linker   *candidates.Linker
+	model    string
+}
+
+// NewResearchTools returns tools that let the model browse searches, articles
+// and candidates and record its own judgements. Relevance verdicts recorded
+// through record_relevance are attributed to model.
+func NewResearchTools(ctx context.Context, queries *database.Queries, fullText *fulltext.Index, linker *candidates.Linker, model string) []ToolDefinition {
+	t := &researchTools{ctx: ctx, queries: queries, fullText: fullText, linker: linker, model: model}
+	return []ToolDefinition{
+		{
+			Name:        "list_searches",
+			Description: "List the recruiter's saved searches with their feed URL, last fetch time and article count, most recently fetched first.",
+			InputSchema: ListSearchesInputSchema,
+			Function:    t.listSearches,
+		},
+		{
+			Name:        "get_search_stats",
+			Description: "Get details and counts (articles, candidates) for one search.",
+			InputSchema: GetSearchStatsInputSchema,
+			Function:    t.getSearchStats,
+		},
+		{
+			Name:        "list_articles",
+			Description: "List articles fetched for a search, newest first. With min_score, list only scored articles at or above that relevance, best first, including the recorded rationale.",
+			InputSchema: ListArticlesInputSchema,
+			Function:    t.listArticles,
+		},
+		{
+			Name:        "find_candidate",
+			Description: "Find candidates (paper authors) by name. An exact match on the normalized name is listed first, followed by names with a word starting with each word given, so \"J Smi\" finds \"John Smith\".",
+			InputSchema: FindCandidateInputSchema,
+			Function:    t.findCandidate,
+		},
+		{
+			Name:        "link_candidate",
+			Description: "Link an existing candidate to a search, and optionally to an article in it that they authored. Safe to repeat.",
+			InputSchema: LinkCandidateInputSchema,
+			Function:    t.linkCandidate,
+		},
+		{
+			Name:        "record_relevance",
+			Description: "Record your relevance verdict for an article, replacing any earlier verdict.",
+			InputSchema: RecordRelevanceInputSchema,
+			Function:    t.recordRelevance,
+		},
+	}
+}
+
+func (t *researchTools) listSearches(input json.RawMessage) (string, error) {
+	listInput := ListSearchesInput{}
+	if err := json.Unmarshal(input, &listInput); err != nil {
+		return "", err
+	}
+
+	searches, err := t.queries.ListActiveSearches(t.ctx, database.ListActiveSearchesParams{
+		Limit:  clampLimit(listInput.Limit, 20),
+		Offset: max(listInput.Offset, 0),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	type searchView struct {
+		ID            string `json:"id"`
+		Description   string `json:"description"`
+		FeedURL       string `json:"feed_url"`
+		LastFetchDate string `json:"last_fetch_date,omitempty"`
+		ArticleCount  int64  `json:"article_count"`
+	}
+	out := make([]searchView, len(searches))
+	for i, s := range searches {
+		out[i] = searchView{
+			ID:            fmt.Sprint(s.ID),
+			Description:   s.Description,
+			FeedURL:       s.ArvixUrl,
+			LastFetchDate: formatTime(s.LastFetchDate),
+			ArticleCount:  s.ArticleCount,
+		}
+	}
+	return marshalResult(out)
+}
+
+func (t *researchTools) getSearchStats(input json.RawMessage) (string, error) {
+	statsInput := GetSearchStatsInput{}
+	if err := json.Unmarshal(input, &statsInput); err != nil {
+		return "", err
+	}
+
+	stats, err := t.queries.GetSearchWithStats(t.ctx, statsInput.SearchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("no search with ID %s", statsInput.SearchID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return marshalResult(struct {
+		ID              string `json:"id"`
+		Description     string `json:"description"`
+		FeedURL         string `json:"feed_url"`
+		ResultsPerFetch int64  `json:"results_per_fetch,omitempty"`
+		CreatedAt       string `json:"created_at"`
+		LastFetchDate   string `json:"last_fetch_date,omitempty"`
+		BackfilledTo    string `json:"backfilled_to,omitempty"`
+		ArticleCount    int64  `json:"article_count"`
+		CandidateCount  int64  `json:"candidate_count"`
+	}{
+		ID:              fmt.Sprint(stats.ID),
+		Description:     stats.Description,
+		FeedURL:         stats.ArvixUrl,
+		ResultsPerFetch: stats.ResultsPerFetch.Int64,
+		CreatedAt:       stats.CreatedAt.Format(time.RFC3339),
+		LastFetchDate:   formatTime(stats.LastFetchDate),
+		BackfilledTo:    formatTime(stats.BackfillCursor),
+		ArticleCount:    stats.ArticleCount,
+		CandidateCount:  stats.CandidateCount,
+	})
+}
+
+func (t *researchTools) listArticles(input json.RawMessage) (string, error) {
+	listInput := ListArticlesInput{}
+	if err := json.Unmarshal(input, &listInput); err != nil {
+		return "", err
+	}
+	if listInput.SearchID == "" {
+		return "", fmt.Errorf("search_id is required")
+	}
+
+	type articleView struct {
+		ID        string   `json:"id"`
+		URL       string   `json:"url"`
+		Title     string   `json:"title"`
+		Authors   string   `json:"authors"`
+		Summary   string   `json:"summary"`
+		FetchedAt string   `json:"fetched_at"`
+		Score     *float64 `json:"score,omitempty"`
+		Rationale string   `json:"rationale,omitempty"`
+	}
+
+	limit, offset := clampLimit(listInput.Limit, 20), max(listInput.Offset, 0)
+	var out []articleView
+	if listInput.MinScore != nil {
+		rows, err := t.queries.ListScoredArticlesBySearch(t.ctx, database.ListScoredArticlesBySearchParams{
+			SearchID: listInput.SearchID,
+			Score:    *listInput.MinScore,
+			Limit:    limit,
+			Offset:   offset,
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, row := range rows {
+			score := row.Score
+			out = append(out, articleView{
+				ID:        fmt.Sprint(row.ID),
+				URL:       row.ArticleUrl,
+				Title:     row.ArticleTitle,
+				Authors:   row.ArticleAuthors,
+				Summary:   truncate(row.ArticleSummary, maxSummaryLength),
+				FetchedAt: row.FetchedAt.Format(time.RFC3339),
+				Score:     &score,
+				Rationale: row.Rationale,
+			})
+		}
+	} else {
+		articles, err := t.queries.ListArticlesBySearch(t.ctx, database.ListArticlesBySearchParams{
+			SearchID: listInput.SearchID,
+			Limit:    limit,
+			Offset:   offset,
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, article := range articles {
+			out = append(out, articleView{
+				ID:        fmt.Sprint(article.ID),
+				URL:       article.ArticleUrl,
+				Title:     article.ArticleTitle,
+				Authors:   article.ArticleAuthors,
+				Summary:   truncate(article.ArticleSummary, maxSummaryLength),
+				FetchedAt: article.FetchedAt.Format(time.RFC3339),
+			})
+		}
+	}
+	if out == nil {
+		out = []articleView{}
+	}
+	return marshalResult(out)
+}
+
+func (t *researchTools) findCandidate(input json.RawMessage) (string, error) {
+	findInput := FindCandidateInput{}
+	if err := json.Unmarshal(input, &findInput); err != nil {
+		return "", err
+	}
+	normalized := candidates.NormalizeName(findInput.Name)
+	if normalized == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	limit := clampLimit(findInput.Limit, 10)
+
+	var found []database.Candidate
+	exact, err := t.queries.GetCandidateByNormalizedName(t.ctx, normalized)
+	if err == nil {
+		found = append(found, exact)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	partial, err := t.fullText.Candidates(t.ctx, fulltext.CandidateQuery{
+		Query:  fulltext.TermsQuery(findInput.Name, true),
+		Limit:  limit,
+		Offset: 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range partial {
+		if int64(len(found)) >= limit {
+			break
+		}
+		if len(found) > 0 && fmt.Sprint(candidate.ID) == fmt.Sprint(found[0].ID) {
+			continue
+		}
+		found = append(found, candidate)
+	}
+
+	type candidateView struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		GithubURL   string `json:"github_url,omitempty"`
+		LinkedinURL string `json:"linkedin_url,omitempty"`
+	}
+	out := make([]candidateView, len(found))
+	for i, candidate := range found {
+		out[i] = candidateView{
+			ID:          fmt.Sprint(candidate.ID),
+			Name:        candidate.Name,
+			GithubURL:   candidate.GithubUrl.String,
+			LinkedinURL: candidate.LinkedinUrl.String,
+		}
+	}
+	return marshalResult(out)
+}
+
+func (t *researchTools) linkCandidate(input json.RawMessage) (string, error) {
+	linkInput := LinkCandidateInput{}
+	if err := json.Unmarshal(input, &linkInput); err != nil {
+		return "", err
+	}
+
+	candidate, err := t.queries.GetCandidateByID(t.ctx, linkInput.CandidateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("no candidate with ID %s", linkInput.CandidateID)
+	}
+	if err != nil {
+		return "", err
+	}
+	search, err := t.queries.GetSearchByID(t.ctx, linkInput.SearchID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("no search with ID %s", linkInput.SearchID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var articleID interface{}
+	if linkInput.ArticleID != "" {
+		article, err := t.queries.GetArticleByID(t.ctx, linkInput.ArticleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("no article with ID %s", linkInput.ArticleID)
+		}
+		if err != nil {
+			return "", err
+		}
+		if fmt.Sprint(article.SearchID) != fmt.Sprint(search.ID) {
+			return "", fmt.Errorf("article %s does not belong to search %s", linkInput.ArticleID, linkInput.SearchID)
+		}
+		articleID = article.ID
+	}
+
+	relevance := sql.NullFloat64{}
+	if linkInput.RelevanceScore != nil {
+		if *linkInput.RelevanceScore < 0 || *linkInput.RelevanceScore > 1 {
+			return "", fmt.Errorf("relevance_score must be between 0.0 and 1.0")
+		}
+		relevance = sql.NullFloat64{Float64: *linkInput.RelevanceScore, Valid: true}
+	}
+
+	result, err := t.linker.LinkCandidate(t.ctx, candidate.ID, search.ID, articleID, relevance)
+	if err != nil {
+		return "", err
+	}
+	if result.SearchLinks == 0 && result.ArticleLinks == 0 {
+		return fmt.Sprintf("%s was already linked (a higher relevance score replaces a lower one)", candidate.Name), nil
+	}
+	return fmt.Sprintf("Linked %s (%d new search links, %d new article links)", candidate.Name, result.SearchLinks, result.ArticleLinks), nil
+}
+
+func (t *researchTools) recordRelevance(input json.RawMessage) (string, error) {
+	recordInput := RecordRelevanceInput{}
+	if err := json.Unmarshal(input, &recordInput); err != nil {
+		return "", err
+	}
+	if recordInput.Score < 0 || recordInput.Score > 1 {
+		return "", fmt.Errorf("score must be between 0.0 and 1.0")
+	}
+
+	article, err := t.queries.GetArticleByID(t.ctx, recordInput.ArticleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("no article with ID %s", recordInput.ArticleID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if recordInput.KeyAuthors == nil {
+		recordInput.KeyAuthors = []string{}
+	}
+	keyAuthors, err := json.Marshal(recordInput.KeyAuthors)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = t.queries.UpsertArticleRelevance(t.ctx, database.UpsertArticleRelevanceParams{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ArticleID:  article.ID,
+		SearchID:   article.SearchID,
+		Score:      recordInput.Score,
+		Rationale:  recordInput.Rationale,
+		KeyAuthors: string(keyAuthors),
+		Model:      t.model,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Recorded a score of %.2f for %q", recordInput.Score, article.ArticleTitle), nil
+}
+
+// clampLimit applies a default to unset limits and caps them at maxListLimit
+func clampLimit(limit, fallback int64) int64 {
+	if limit <= 0 {
+		return fallback
+	}
+	return min(limit, maxListLimit)
+}
+
+// formatTime formats a nullable timestamp as RFC 3339, or "" if it is unset
+func formatTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+// marshalResult encodes a tool result as JSON
+func marshalResult(v interface{}) (string, error) {
+	result, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
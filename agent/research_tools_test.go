@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/fulltext"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// newTestResearchTools returns the research tools over a migrated database
+func newTestResearchTools(t *testing.T) (*researchTools, *database.Queries) {
+	t.Helper()
+	db, queries := dbtest.Open(t)
+	return &researchTools{
+		ctx:      context.Background(),
+		queries:  queries,
+		fullText: fulltext.NewIndex(db),
+		linker:   candidates.NewLinker(db, queries),
+		model:    "claude-test",
+	}, queries
+}
+
+// call runs a tool function with input encoded as JSON
+func call(fn func(json.RawMessage) (string, error), input interface{}) (string, error) {
+	raw, _ := json.Marshal(input)
+	return fn(raw)
+}
+
+// articleTitles decodes a list_articles result into its titles, in order
+func articleTitles(t *testing.T, result string) []string {
+	t.Helper()
+	var articles []struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(result), &articles); err != nil {
+		t.Fatalf("decoding %s: %v", result, err)
+	}
+	var titles []string
+	for _, article := range articles {
+		titles = append(titles, article.Title)
+	}
+	return titles
+}
+
+// With min_score only scored articles at or above it are listed, best first;
+// without it every article is
+func TestListArticles(t *testing.T) {
+	tools, queries := newTestResearchTools(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	searchID := fmt.Sprint(search.ID)
+	scores := map[string]float64{"Weak": 0.3, "Strong": 0.9, "Good": 0.7}
+	for i, title := range []string{"Weak", "Strong", "Good", "Unscored"} {
+		article := dbtest.CreateArticle(t, queries, search.ID, fmt.Sprintf("https://arxiv.org/abs/%d", i), title, "Abstract.", "Ann Lee")
+		if score, ok := scores[title]; ok {
+			if _, err := call(tools.recordRelevance, RecordRelevanceInput{ArticleID: fmt.Sprint(article.ID), Score: score, Rationale: "because"}); err != nil {
+				t.Fatalf("recordRelevance: %v", err)
+			}
+		}
+	}
+
+	minScore := 0.5
+	result, err := call(tools.listArticles, ListArticlesInput{SearchID: searchID, MinScore: &minScore})
+	if err != nil {
+		t.Fatalf("listArticles: %v", err)
+	}
+	if got := fmt.Sprint(articleTitles(t, result)); got != "[Strong Good]" {
+		t.Errorf("min_score 0.5 listed %s, want [Strong Good]", got)
+	}
+	if !strings.Contains(result, `"rationale":"because"`) {
+		t.Errorf("scored articles come without their rationale: %s", result)
+	}
+
+	result, err = call(tools.listArticles, ListArticlesInput{SearchID: searchID})
+	if err != nil {
+		t.Fatalf("listArticles: %v", err)
+	}
+	if got := articleTitles(t, result); len(got) != 4 {
+		t.Errorf("without min_score listed %v, want all 4 articles", got)
+	}
+
+	if _, err := call(tools.listArticles, ListArticlesInput{}); err == nil {
+		t.Error("listing without a search_id succeeded")
+	}
+}
+
+// An exact name is listed first, followed by prefix matches, without repeats
+func TestFindCandidate(t *testing.T) {
+	tools, _ := newTestResearchTools(t)
+	for i, name := range []string{"John Smithson", "John Smith", "Jane Doe"} {
+		_, err := tools.queries.CreateCandidate(tools.ctx, database.CreateCandidateParams{
+			ID:             fmt.Sprintf("c%d", i),
+			Name:           name,
+			NormalizedName: candidates.NormalizeName(name),
+		})
+		if err != nil {
+			t.Fatalf("CreateCandidate: %v", err)
+		}
+	}
+
+	result, err := call(tools.findCandidate, FindCandidateInput{Name: "john smith"})
+	if err != nil {
+		t.Fatalf("findCandidate: %v", err)
+	}
+	var found []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(result), &found); err != nil {
+		t.Fatalf("decoding %s: %v", result, err)
+	}
+	if len(found) != 2 || found[0].Name != "John Smith" || found[1].Name != "John Smithson" {
+		t.Errorf("john smith found %s, want John Smith then John Smithson", result)
+	}
+
+	if _, err := call(tools.findCandidate, FindCandidateInput{Name: " . "}); err == nil {
+		t.Error("finding an empty name succeeded")
+	}
+}
+
+// A candidate is linked to a search and one of its articles, but not to an
+// article of another search or with a relevance outside 0 to 1
+func TestLinkCandidate(t *testing.T) {
+	tools, queries := newTestResearchTools(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	other := dbtest.CreateSearch(t, queries, "other", "http://rss.arxiv.org/rss/cs.CL")
+	article := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/1", "Title", "Abstract.", "Ann Lee")
+	elsewhere := dbtest.CreateArticle(t, queries, other.ID, "https://arxiv.org/abs/2", "Title", "Abstract.", "Ann Lee")
+	_, err := queries.CreateCandidate(tools.ctx, database.CreateCandidateParams{ID: "c1", Name: "Ann Lee", NormalizedName: "ann lee"})
+	if err != nil {
+		t.Fatalf("CreateCandidate: %v", err)
+	}
+
+	input := LinkCandidateInput{CandidateID: "c1", SearchID: fmt.Sprint(search.ID), ArticleID: fmt.Sprint(elsewhere.ID)}
+	if _, err := call(tools.linkCandidate, input); err == nil || !strings.Contains(err.Error(), "does not belong") {
+		t.Errorf("linking an article of another search: %v, want an error", err)
+	}
+	for _, score := range []float64{-0.1, 1.5} {
+		input := LinkCandidateInput{CandidateID: "c1", SearchID: fmt.Sprint(search.ID), RelevanceScore: &score}
+		if _, err := call(tools.linkCandidate, input); err == nil {
+			t.Errorf("linking with relevance %v succeeded", score)
+		}
+	}
+
+	score := 0.8
+	input = LinkCandidateInput{CandidateID: "c1", SearchID: fmt.Sprint(search.ID), ArticleID: fmt.Sprint(article.ID), RelevanceScore: &score}
+	result, err := call(tools.linkCandidate, input)
+	if err != nil {
+		t.Fatalf("linkCandidate: %v", err)
+	}
+	if result != "Linked Ann Lee (1 new search links, 1 new article links)" {
+		t.Errorf("first link: %q", result)
+	}
+	link, err := queries.GetCandidateSearchLink(tools.ctx, database.GetCandidateSearchLinkParams{CandidateID: "c1", SearchID: search.ID})
+	if err != nil {
+		t.Fatalf("GetCandidateSearchLink: %v", err)
+	}
+	if !link.RelevanceScore.Valid || link.RelevanceScore.Float64 != 0.8 {
+		t.Errorf("link relevance %v, want 0.8", link.RelevanceScore)
+	}
+	if result, err := call(tools.linkCandidate, input); err != nil || !strings.Contains(result, "already linked") {
+		t.Errorf("second link: %q, %v; want already linked", result, err)
+	}
+}
+
+// A second verdict on an article replaces the first, and scores outside 0 to
+// 1 are refused
+func TestRecordRelevance(t *testing.T) {
+	tools, queries := newTestResearchTools(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	article := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/1", "Title", "Abstract.", "Ann Lee, Bo Chen")
+	articleID := fmt.Sprint(article.ID)
+
+	for _, score := range []float64{-0.5, 1.01} {
+		if _, err := call(tools.recordRelevance, RecordRelevanceInput{ArticleID: articleID, Score: score}); err == nil {
+			t.Errorf("recording a score of %v succeeded", score)
+		}
+	}
+	if _, err := call(tools.recordRelevance, RecordRelevanceInput{ArticleID: "missing", Score: 0.5}); err == nil {
+		t.Error("recording a score for a missing article succeeded")
+	}
+
+	if _, err := call(tools.recordRelevance, RecordRelevanceInput{ArticleID: articleID, Score: 0.4, Rationale: "first"}); err != nil {
+		t.Fatalf("recordRelevance: %v", err)
+	}
+	input := RecordRelevanceInput{ArticleID: articleID, Score: 0.9, Rationale: "second", KeyAuthors: []string{"Bo Chen"}}
+	if _, err := call(tools.recordRelevance, input); err != nil {
+		t.Fatalf("recordRelevance again: %v", err)
+	}
+
+	relevance, err := queries.GetArticleRelevance(tools.ctx, article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleRelevance: %v", err)
+	}
+	if relevance.Score != 0.9 || relevance.Rationale != "second" || relevance.KeyAuthors != `["Bo Chen"]` || relevance.Model != "claude-test" {
+		t.Errorf("stored verdict %+v, want the second one by claude-test", relevance)
+	}
+	scored, err := queries.ListScoredArticlesBySearch(tools.ctx, database.ListScoredArticlesBySearchParams{SearchID: search.ID, Score: 0, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListScoredArticlesBySearch: %v", err)
+	}
+	if len(scored) != 1 {
+		t.Errorf("%d verdicts stored for the article, want 1", len(scored))
+	}
+}
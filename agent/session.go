@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Session kinds
+const (
+	SessionDiscovery = "discovery"
+	SessionAssistant = "assistant"
+)
+
+// startConversation is the fixed first message that lets Claude speak first.
+// Transcripts and replays leave it out.
+const startConversation = "Start the conversation"
+
+// omittedContent stands in for a message whose every block was left out when
+// saving it, since the API rejects a message with no content
+const omittedContent = "[web search results omitted]"
+
+// sessionTitleLength is the most bytes of the user's first message used as
+// the title of a session created without one
+const sessionTitleLength = 60
+
+// Session saves a conversation to SQLite after every turn, so a crashed or
+// closed conversation can be resumed where it stopped
+type Session struct {
+	ID    interface{}
+	Kind  string
+	Title string
+
+	db      *sql.DB
+	queries *database.Queries
+	// next is the position of the next message saved
+	next int64
+}
+
+// NewSession starts a session. An empty title is filled in from the user's
+// first message; searchID may be nil and set later with SetSearch.
+func NewSession(ctx context.Context, db *sql.DB, queries *database.Queries, kind, title string, searchID interface{}) (*Session, error) {
+	now := time.Now().UTC()
+	row, err := queries.CreateAgentSession(ctx, database.CreateAgentSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Kind:      kind,
+		Title:     title,
+		SearchID:  searchID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+	return &Session{ID: row.ID, Kind: row.Kind, Title: row.Title, db: db, queries: queries}, nil
+}
+
+// ResumeSession reopens a saved session and returns the conversation so far,
+// to be handed to Agent.SetSession
+func ResumeSession(ctx context.Context, db *sql.DB, queries *database.Queries, row database.AgentSession) (*Session, []anthropic.MessageParam, error) {
+	messages, err := LoadMessages(ctx, queries, row.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	session := &Session{ID: row.ID, Kind: row.Kind, Title: row.Title, db: db, queries: queries, next: int64(len(messages))}
+	return session, messages, nil
+}
+
+// LoadMessages reads a saved conversation in order
+func LoadMessages(ctx context.Context, queries *database.Queries, sessionID interface{}) ([]anthropic.MessageParam, error) {
+	rows, err := queries.ListAgentMessages(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("reading session messages: %w", err)
+	}
+	messages := make([]anthropic.MessageParam, 0, len(rows))
+	for _, row := range rows {
+		message := anthropic.MessageParam{Role: anthropic.MessageParamRole(row.Role)}
+		if err := json.Unmarshal([]byte(row.Content), &message.Content); err != nil {
+			return nil, fmt.Errorf("decoding message %d: %w", row.Position, err)
+		}
+		// Sessions saved before the placeholder was added can hold empty messages
+		if len(message.Content) == 0 {
+			message.Content = []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(omittedContent)}
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Record appends a message to the saved conversation. Content blocks the SDK
+// cannot turn back into request parameters, such as server-side web search
+// results, are sent as null and are left out. A message left with no blocks
+// is saved with a placeholder text block instead, so it can still be resumed.
+func (s *Session) Record(ctx context.Context, message anthropic.MessageParam) error {
+	var blocks []json.RawMessage
+	for _, block := range message.Content {
+		data, err := json.Marshal(block)
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+		if string(data) != "null" {
+			blocks = append(blocks, data)
+		}
+	}
+	if len(blocks) == 0 {
+		data, err := json.Marshal(anthropic.NewTextBlock(omittedContent))
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+		blocks = append(blocks, data)
+	}
+	content, err := json.Marshal(blocks)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.queries.WithTx(tx)
+
+	now := time.Now().UTC()
+	err = q.AddAgentMessage(ctx, database.AddAgentMessageParams{
+		SessionID: s.ID,
+		Position:  s.next,
+		CreatedAt: now,
+		Role:      string(message.Role),
+		Content:   string(content),
+	})
+	if err != nil {
+		return fmt.Errorf("saving message: %w", err)
+	}
+	title := s.Title
+	if text := MessageText(message); title == "" && message.Role == anthropic.MessageParamRoleUser && text != "" && text != startConversation {
+		title = truncate(strings.Join(strings.Fields(text), " "), sessionTitleLength)
+		err = q.SetAgentSessionTitle(ctx, database.SetAgentSessionTitleParams{Title: title, UpdatedAt: now, ID: s.ID})
+	} else {
+		err = q.TouchAgentSession(ctx, database.TouchAgentSessionParams{UpdatedAt: now, ID: s.ID})
+	}
+	if err != nil {
+		return fmt.Errorf("updating session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.next++
+	s.Title = title
+	return nil
+}
+
+// Finish marks the session as having reached its end, such as discovery
+// proposing categories
+func (s *Session) Finish(ctx context.Context) error {
+	now := time.Now().UTC()
+	err := s.queries.FinishAgentSession(ctx, database.FinishAgentSessionParams{
+		FinishedAt: sql.NullTime{Time: now, Valid: true},
+		UpdatedAt:  now,
+		ID:         s.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("finishing session: %w", err)
+	}
+	return nil
+}
+
+// SetSearch ties the session to a search, such as the one its category
+// discovery created
+func (s *Session) SetSearch(ctx context.Context, searchID interface{}) error {
+	err := s.queries.SetAgentSessionSearch(ctx, database.SetAgentSessionSearchParams{
+		SearchID:  searchID,
+		UpdatedAt: time.Now().UTC(),
+		ID:        s.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("linking session to search: %w", err)
+	}
+	return nil
+}
+
+// DeleteSession removes a session and its messages in one transaction and
+// returns how many messages were removed
+func DeleteSession(ctx context.Context, db *sql.DB, queries *database.Queries, id interface{}) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := queries.WithTx(tx)
+
+	messages, err := q.DeleteAgentMessages(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("removing session messages: %w", err)
+	}
+	if err := q.DeleteAgentSession(ctx, id); err != nil {
+		return 0, fmt.Errorf("removing session: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return messages, nil
+}
+
+// MessageText joins the text blocks of a message
+func MessageText(message anthropic.MessageParam) string {
+	var parts []string
+	for _, block := range message.Content {
+		if block.OfText != nil && strings.TrimSpace(block.OfText.Text) != "" {
+			parts = append(parts, strings.TrimSpace(block.OfText.Text))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// isStartMessage reports whether the message is the fixed opening that lets
+// Claude speak first
+func isStartMessage(message anthropic.MessageParam) bool {
+	return message.Role == anthropic.MessageParamRoleUser && MessageText(message) == startConversation
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// webSearchOnly is an assistant turn holding nothing but a server-side web
+// search, which the SDK cannot send back as request parameters
+const webSearchOnly = `{
+	"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-test",
+	"content": [
+		{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": {"query": "sparse attention"}},
+		{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": [
+			{"type": "web_search_result", "url": "https://example.com", "title": "Example", "encrypted_content": "abc", "page_age": null}
+		]}
+	],
+	"stop_reason": "end_turn", "usage": {"input_tokens": 1, "output_tokens": 1}
+}`
+
+func TestSessionKeepsMessagesResumable(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	session, err := NewSession(ctx, db, queries, SessionAssistant, "", nil)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+
+	var reply anthropic.Message
+	if err := json.Unmarshal([]byte(webSearchOnly), &reply); err != nil {
+		t.Fatalf("decoding the reply: %v", err)
+	}
+	conversation := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("Who works on sparse attention?")),
+		reply.ToParam(),
+		anthropic.NewUserMessage(anthropic.NewTextBlock("Go on.")),
+	}
+	for _, message := range conversation {
+		if err := session.Record(ctx, message); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if session.Title != "Who works on sparse attention?" {
+		t.Errorf("title = %q, want the first user message", session.Title)
+	}
+
+	row, err := queries.GetAgentSession(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetAgentSession: %v", err)
+	}
+	_, messages, err := ResumeSession(ctx, db, queries, row)
+	if err != nil {
+		t.Fatalf("ResumeSession: %v", err)
+	}
+	if len(messages) != len(conversation) {
+		t.Fatalf("resumed %d messages, want %d", len(messages), len(conversation))
+	}
+	// Every message must carry content for the API to accept the history
+	for i, message := range messages {
+		if len(message.Content) == 0 {
+			t.Errorf("message %d has no content", i)
+		}
+	}
+	if MessageText(messages[1]) != omittedContent {
+		t.Errorf("web search turn resumed as %q, want the placeholder", MessageText(messages[1]))
+	}
+	if messages[1].Role != anthropic.MessageParamRoleAssistant {
+		t.Errorf("web search turn resumed as a %s message", messages[1].Role)
+	}
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// sessionKindNames are the headings used for each kind of session
+var sessionKindNames = map[string]string{
+	SessionDiscovery: "Category discovery",
+	SessionAssistant: "Research assistant",
+}
+
+// WriteTranscript writes a saved conversation as Markdown. Tool calls and
+// their results are shown as code blocks under the message that made them.
+// searchDescription may be empty if the session has no search.
+func WriteTranscript(w io.Writer, session database.AgentSession, searchDescription string, messages []anthropic.MessageParam) error {
+	var b strings.Builder
+	kind := sessionKindNames[session.Kind]
+	if kind == "" {
+		kind = session.Kind
+	}
+	title := session.Title
+	if title == "" {
+		title = "Untitled"
+	}
+	fmt.Fprintf(&b, "# %s: %s\n\n", kind, title)
+	fmt.Fprintf(&b, "- Session: `%v`\n", session.ID)
+	if session.SearchID != nil {
+		fmt.Fprintf(&b, "- Search: %s (`%v`)\n", searchDescription, session.SearchID)
+	}
+	fmt.Fprintf(&b, "- Started: %s\n", session.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Last active: %s\n", session.UpdatedAt.UTC().Format(time.RFC3339))
+	if session.FinishedAt.Valid {
+		fmt.Fprintf(&b, "- Finished: %s\n", session.FinishedAt.Time.UTC().Format(time.RFC3339))
+	}
+
+	// Tool results only carry the ID of the call they answer
+	toolNames := map[string]string{}
+	for _, message := range messages {
+		if isStartMessage(message) {
+			continue
+		}
+		speaker := "You"
+		if message.Role == anthropic.MessageParamRoleAssistant {
+			speaker = "Claude"
+		}
+		var body []string
+		for _, block := range message.Content {
+			switch {
+			case block.OfText != nil && strings.TrimSpace(block.OfText.Text) != "":
+				body = append(body, strings.TrimSpace(block.OfText.Text))
+			case block.OfToolUse != nil:
+				toolNames[block.OfToolUse.ID] = block.OfToolUse.Name
+				input, err := json.MarshalIndent(block.OfToolUse.Input, "", "  ")
+				if err != nil {
+					return fmt.Errorf("encoding tool input: %w", err)
+				}
+				body = append(body, fmt.Sprintf("Called `%s`:\n\n%s", block.OfToolUse.Name, codeBlock("json", string(input))))
+			case block.OfToolResult != nil:
+				var parts []string
+				for _, content := range block.OfToolResult.Content {
+					if content.OfText != nil {
+						parts = append(parts, content.OfText.Text)
+					}
+				}
+				verb := "returned"
+				if block.OfToolResult.IsError.Value {
+					verb = "failed"
+				}
+				name := toolNames[block.OfToolResult.ToolUseID]
+				if name == "" {
+					name = "tool"
+				}
+				body = append(body, fmt.Sprintf("`%s` %s:\n\n%s", name, verb, codeBlock("", strings.Join(parts, "\n"))))
+			}
+		}
+		if len(body) == 0 {
+			continue
+		}
+		// Tool results are sent as the user's turn, but come from the tools
+		if message.Role == anthropic.MessageParamRoleUser && MessageText(message) == "" {
+			speaker = "Tools"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", speaker, strings.Join(body, "\n\n"))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// codeBlock fences text, using a fence longer than any run of backticks in it
+func codeBlock(language, text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + language + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Call is the usage of one completed model request
+type Call struct {
+	Model   string
+	Usage   anthropic.Usage
+	Latency time.Duration
+}
+
+// UsageRecorder is told about every model request an agent makes. Allow is
+// asked before each request and can refuse it, e.g. once a budget is spent;
+// Record is given the usage of each request that completes.
+type UsageRecorder interface {
+	Allow(ctx context.Context) error
+	Record(ctx context.Context, call Call) error
+}
+
+// SetUsageRecorder reports every model request the agent makes to recorder
+func (a *Agent) SetUsageRecorder(recorder UsageRecorder) {
+	a.usage = recorder
+}
+
+// mergeDeltaUsage copies the totals from a message_delta event into usage.
+// Message.Accumulate keeps only the output tokens, but the final delta also
+// carries the input, cache and web search counts of the whole turn, which
+// can grow after message_start as server-side tools run. Counts the delta
+// leaves out keep their message_start values.
+func mergeDeltaUsage(usage *anthropic.Usage, delta anthropic.MessageDeltaUsage) {
+	if delta.JSON.InputTokens.Valid() {
+		usage.InputTokens = delta.InputTokens
+	}
+	if delta.JSON.CacheCreationInputTokens.Valid() {
+		usage.CacheCreationInputTokens = delta.CacheCreationInputTokens
+	}
+	if delta.JSON.CacheReadInputTokens.Valid() {
+		usage.CacheReadInputTokens = delta.CacheReadInputTokens
+	}
+	if delta.JSON.ServerToolUse.Valid() {
+		usage.ServerToolUse.WebSearchRequests = delta.ServerToolUse.WebSearchRequests
+	}
+}
@@ -0,0 +1,274 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Workspace confines the file tools to a single directory tree. Paths given by
+// the model are relative to the root; absolute paths, ".." components and
+// symlinks that lead outside the root are all rejected.
+type Workspace struct {
+	root string
+}
+
+// NewWorkspace creates the root directory if needed and returns a workspace for it
+func NewWorkspace(root string) (*Workspace, error) {
+	if root == "" {
+		return nil, fmt.Errorf("workspace root must not be empty")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("creating workspace: %w", err)
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	// Compare against the real location so a symlinked root still works
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	return &Workspace{root: real}, nil
+}
+
+// Root returns the absolute path of the workspace
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Tools returns the read_file, list_files and edit_file tools bound to this workspace
+func (w *Workspace) Tools() []ToolDefinition {
+	return []ToolDefinition{
+		{
+			Name:        "read_file",
+			Description: "Read the contents of a file in the workspace. Use this when you want to see what's inside a file. Do not use this with directory names.",
+			InputSchema: ReadFileInputSchema,
+			Function:    w.ReadFile,
+		},
+		{
+			Name:        "list_files",
+			Description: "List files and directories in the workspace. If no path is provided, lists the whole workspace.",
+			InputSchema: ListFilesInputSchema,
+			Function:    w.ListFiles,
+		},
+		{
+			Name: "edit_file",
+			Description: `Make edits to a text file in the workspace.
+
+Replaces 'old_str' with 'new_str' in the given file. 'old_str' must appear in the file exactly once, and 'old_str' and 'new_str' MUST be different from each other.
+
+If the file specified with path doesn't exist and 'old_str' is empty, it will be created.
+`,
+			InputSchema: EditFileInputSchema,
+			Function:    w.EditFile,
+		},
+	}
+}
+
+// ReadFileInput defines the input for the read_file tool
+type ReadFileInput struct {
+	Path string `json:"path" jsonschema_description:"The path of a file, relative to the workspace root"`
+}
+
+var ReadFileInputSchema = GenerateSchema[ReadFileInput]()
+
+// ListFilesInput defines the input for the list_files tool
+type ListFilesInput struct {
+	Path string `json:"path,omitempty" jsonschema_description:"Optional path to list files from, relative to the workspace root. Defaults to the root if not provided."`
+}
+
+var ListFilesInputSchema = GenerateSchema[ListFilesInput]()
+
+// EditFileInput defines the input for the edit_file tool
+type EditFileInput struct {
+	Path   string `json:"path" jsonschema_description:"The path of the file, relative to the workspace root"`
+	OldStr string `json:"old_str" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
+	NewStr string `json:"new_str" jsonschema_description:"Text to replace old_str with"`
+}
+
+var EditFileInputSchema = GenerateSchema[EditFileInput]()
+
+// ReadFile implements the read_file tool
+func (w *Workspace) ReadFile(input json.RawMessage) (string, error) {
+	readFileInput := ReadFileInput{}
+	err := json.Unmarshal(input, &readFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := w.resolve(readFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", w.relativeError(err)
+	}
+	return string(content), nil
+}
+
+// ListFiles implements the list_files tool
+func (w *Workspace) ListFiles(input json.RawMessage) (string, error) {
+	listFilesInput := ListFilesInput{}
+	err := json.Unmarshal(input, &listFilesInput)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := w.resolve(listFilesInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	files := []string{}
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath != "." {
+			if entry.IsDir() {
+				files = append(files, relPath+"/")
+			} else {
+				files = append(files, relPath)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", w.relativeError(err)
+	}
+
+	result, err := json.Marshal(files)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// EditFile implements the edit_file tool. The edit is refused unless old_str
+// occurs exactly once, so the model cannot rewrite more than it looked at.
+func (w *Workspace) EditFile(input json.RawMessage) (string, error) {
+	editFileInput := EditFileInput{}
+	err := json.Unmarshal(input, &editFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	if editFileInput.Path == "" || editFileInput.OldStr == editFileInput.NewStr {
+		return "", fmt.Errorf("invalid input parameters")
+	}
+
+	path, err := w.resolve(editFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && editFileInput.OldStr == "" {
+			return w.createNewFile(path, editFileInput.NewStr)
+		}
+		return "", w.relativeError(err)
+	}
+	if editFileInput.OldStr == "" {
+		return "", fmt.Errorf("old_str must not be empty when editing an existing file")
+	}
+
+	oldContent := string(content)
+	switch matches := strings.Count(oldContent, editFileInput.OldStr); matches {
+	case 0:
+		return "", fmt.Errorf("old_str not found in file")
+	case 1:
+	default:
+		return "", fmt.Errorf("old_str matches %d times; include more surrounding text so it matches exactly once", matches)
+	}
+	newContent := strings.Replace(oldContent, editFileInput.OldStr, editFileInput.NewStr, 1)
+
+	err = os.WriteFile(path, []byte(newContent), 0644)
+	if err != nil {
+		return "", w.relativeError(err)
+	}
+	return "OK", nil
+}
+
+func (w *Workspace) createNewFile(path, content string) (string, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", w.relativeError(err))
+	}
+
+	// O_EXCL refuses to follow a symlink planted at the path since it was resolved
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", w.relativeError(err))
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", w.relativeError(err))
+	}
+
+	rel, _ := filepath.Rel(w.root, path)
+	return fmt.Sprintf("Successfully created file %s", rel), nil
+}
+
+// resolve maps a workspace-relative path to an absolute one, refusing anything
+// that would end up outside the root
+func (w *Workspace) resolve(rel string) (string, error) {
+	if filepath.IsAbs(rel) {
+		return "", fmt.Errorf("path %q must be relative to the workspace", rel)
+	}
+	path := filepath.Join(w.root, rel)
+	if !w.contains(path) {
+		return "", fmt.Errorf("path %q is outside the workspace", rel)
+	}
+
+	// A symlink inside the workspace could still point elsewhere, so check where
+	// the deepest existing part of the path really lives
+	existing, rest := path, ""
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if !w.contains(filepath.Join(real, rest)) {
+				return "", fmt.Errorf("path %q is outside the workspace", rel)
+			}
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", w.relativeError(err)
+		}
+		// A dangling symlink cannot be resolved, but writing through it would
+		// create its target wherever that is
+		if _, err := os.Lstat(existing); err == nil {
+			return "", fmt.Errorf("path %q goes through a broken symlink", rel)
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+}
+
+// contains reports whether path is the root or lies beneath it
+func (w *Workspace) contains(path string) bool {
+	rel, err := filepath.Rel(w.root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// relativeError strips the workspace root from file errors so the model only
+// ever sees workspace-relative paths
+func (w *Workspace) relativeError(err error) error {
+	return errors.New(strings.ReplaceAll(err.Error(), w.root+string(filepath.Separator), ""))
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// editFile runs the edit_file tool with the given path and strings
+func editFile(w *Workspace, path, oldStr, newStr string) (string, error) {
+	input, _ := json.Marshal(EditFileInput{Path: path, OldStr: oldStr, NewStr: newStr})
+	return w.EditFile(input)
+}
+
+func TestWorkspaceEditFile(t *testing.T) {
+	w, err := NewWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewWorkspace: %v", err)
+	}
+
+	if _, err := editFile(w, "notes/plan.md", "", "first draft"); err != nil {
+		t.Fatalf("creating a file: %v", err)
+	}
+	if _, err := editFile(w, "notes/plan.md", "first", "second"); err != nil {
+		t.Fatalf("editing the file: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(w.Root(), "notes", "plan.md"))
+	if err != nil || string(content) != "second draft" {
+		t.Errorf("file holds %q, %v; want %q", content, err, "second draft")
+	}
+
+	if _, err := editFile(w, "notes/plan.md", "", "again"); err == nil {
+		t.Error("empty old_str on an existing file succeeded")
+	}
+}
+
+func TestWorkspaceStaysInsideRoot(t *testing.T) {
+	outside := t.TempDir()
+	w, err := NewWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewWorkspace: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"to-dir":    outside,
+		"to-file":   filepath.Join(outside, "secret"),
+		"dangling":  filepath.Join(outside, "planted"),
+		"dangledir": filepath.Join(outside, "missing"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(w.Root(), name)); err != nil {
+			t.Skipf("cannot create symlinks here: %v", err)
+		}
+	}
+
+	tests := []string{
+		"/etc/passwd",
+		"../escape",
+		"notes/../../escape",
+		"to-dir/secret",
+		"to-dir/new",
+		"to-file",
+		"dangling",
+		"dangledir/new",
+	}
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			if _, err := editFile(w, path, "", "written"); err == nil {
+				t.Errorf("creating %s succeeded", path)
+			}
+			input, _ := json.Marshal(ReadFileInput{Path: path})
+			if content, err := w.ReadFile(input); err == nil {
+				t.Errorf("reading %s succeeded with %q", path, content)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("files were created outside the workspace: %v", entries)
+	}
+	if content, _ := os.ReadFile(filepath.Join(outside, "secret")); string(content) != "secret" {
+		t.Errorf("file outside the workspace was changed to %q", content)
+	}
+}
@@ -0,0 +1,137 @@
+package candidates
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jessewalker/reSearch/internal/names"
+)
+
+// Author is a single name parsed out of an arXiv author list
+type Author struct {
+	Name string
+	// Affiliation is any parenthesised text that followed the name, e.g. "MIT"
+	Affiliation string
+}
+
+var (
+	// footnotePattern matches trailing affiliation markers such as "Smith1,2" or "Smith*"
+	footnotePattern   = regexp.MustCompile(`[\d*†‡§¶]+$`)
+	etAlPattern       = regexp.MustCompile(`(?i)\s*\bet\s+al\.?$`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// ParseAuthors splits an arXiv author string into individual authors. It
+// understands comma, semicolon, "and" and "&" separators (including the Oxford
+// comma), keeps parenthesised affiliations together even when they contain
+// commas, and drops "et al." style placeholders.
+//
+//	ParseAuthors("Alice Smith (MIT, CSAIL), Bob Jones and Zoë Müller")
+//	// => [{Alice Smith MIT, CSAIL} {Bob Jones } {Zoë Müller }]
+func ParseAuthors(s string) []Author {
+	var authors []Author
+	for _, part := range splitTopLevel(whitespacePattern.ReplaceAllString(s, " ")) {
+		author := parseAuthor(part)
+		if author.Name == "" || isPlaceholder(author.Name) {
+			continue
+		}
+		authors = append(authors, author)
+	}
+	return authors
+}
+
+// NormalizeName reduces a name to the form used to deduplicate candidates:
+// NFC composed, lower case, periods treated as spaces and whitespace
+// collapsed. See names.Normalize.
+func NormalizeName(name string) string {
+	return names.Normalize(name)
+}
+
+// splitTopLevel splits on separators that are not inside parentheses or brackets
+func splitTopLevel(s string) []string {
+	var parts []string
+	var current strings.Builder
+	depth := 0
+	runes := []rune(s)
+
+	flush := func() {
+		parts = append(parts, current.String())
+		current.Reset()
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '(' || r == '[':
+			depth++
+		case (r == ')' || r == ']') && depth > 0:
+			depth--
+		case depth == 0 && (r == ',' || r == ';' || r == '&'):
+			flush()
+			continue
+		case depth == 0 && unicode.IsSpace(r) && hasWordAt(runes, i+1, "and"):
+			flush()
+			i += len("and")
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return parts
+}
+
+// hasWordAt reports whether word appears at position i as a whole word
+func hasWordAt(runes []rune, i int, word string) bool {
+	w := []rune(word)
+	if i+len(w) > len(runes) {
+		return false
+	}
+	if !strings.EqualFold(string(runes[i:i+len(w)]), word) {
+		return false
+	}
+	return i+len(w) == len(runes) || unicode.IsSpace(runes[i+len(w)])
+}
+
+// parseAuthor separates a single author entry into name and affiliation
+func parseAuthor(part string) Author {
+	var name, affiliation strings.Builder
+	depth := 0
+	for _, r := range part {
+		switch {
+		case r == '(' || r == '[':
+			if depth > 0 {
+				affiliation.WriteRune(r)
+			}
+			depth++
+		case (r == ')' || r == ']') && depth > 0:
+			depth--
+			if depth > 0 {
+				affiliation.WriteRune(r)
+			}
+		case depth > 0:
+			affiliation.WriteRune(r)
+		default:
+			name.WriteRune(r)
+		}
+	}
+
+	cleaned := strings.TrimSpace(whitespacePattern.ReplaceAllString(name.String(), " "))
+	cleaned = strings.TrimSpace(strings.TrimPrefix(cleaned, "and "))
+	cleaned = strings.TrimSpace(etAlPattern.ReplaceAllString(cleaned, ""))
+	cleaned = strings.TrimSpace(footnotePattern.ReplaceAllString(cleaned, ""))
+
+	return Author{
+		Name:        cleaned,
+		Affiliation: strings.TrimSpace(affiliation.String()),
+	}
+}
+
+// isPlaceholder reports whether a parsed "name" is really a collaboration marker
+func isPlaceholder(name string) bool {
+	switch NormalizeName(name) {
+	case "et al", "others":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package candidates
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAuthors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Author
+	}{
+		{
+			name: "commas",
+			in:   "Alice Smith, Bob Jones, Carol White",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}, {Name: "Carol White"}},
+		},
+		{
+			name: "and",
+			in:   "Alice Smith and Bob Jones",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}},
+		},
+		{
+			name: "oxford comma",
+			in:   "Alice Smith, Bob Jones, and Carol White",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}, {Name: "Carol White"}},
+		},
+		{
+			name: "semicolons and ampersands",
+			in:   "Alice Smith; Bob Jones & Carol White",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}, {Name: "Carol White"}},
+		},
+		{
+			name: "affiliations with commas",
+			in:   "Alice Smith (MIT, CSAIL), Bob Jones [Google Research] and Zoë Müller",
+			want: []Author{
+				{Name: "Alice Smith", Affiliation: "MIT, CSAIL"},
+				{Name: "Bob Jones", Affiliation: "Google Research"},
+				{Name: "Zoë Müller"},
+			},
+		},
+		{
+			name: "nested parentheses",
+			in:   "Alice Smith (Dept. of CS (AI Lab), MIT)",
+			want: []Author{{Name: "Alice Smith", Affiliation: "Dept. of CS (AI Lab), MIT"}},
+		},
+		{
+			name: "unicode names",
+			in:   "José Álvarez-Núñez, 李明, Øystein Ødegård",
+			want: []Author{{Name: "José Álvarez-Núñez"}, {Name: "李明"}, {Name: "Øystein Ødegård"}},
+		},
+		{
+			name: "names containing and",
+			in:   "Alexander Anderson and Sandra Andrews",
+			want: []Author{{Name: "Alexander Anderson"}, {Name: "Sandra Andrews"}},
+		},
+		{
+			name: "footnote markers",
+			in:   "Alice Smith1,2, Bob Jones*, Carol White†",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}, {Name: "Carol White"}},
+		},
+		{
+			name: "et al",
+			in:   "Alice Smith, Bob Jones et al.",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}},
+		},
+		{
+			name: "collaboration placeholders",
+			in:   "Alice Smith, others",
+			want: []Author{{Name: "Alice Smith"}},
+		},
+		{
+			name: "hard-wrapped whitespace",
+			in:   "Alice\n  Smith,\tBob   Jones",
+			want: []Author{{Name: "Alice Smith"}, {Name: "Bob Jones"}},
+		},
+		{
+			name: "empty",
+			in:   " , ,",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAuthors(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAuthors(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// Parsing is stable, so re-parsing the names it produced gives them back
+func TestParseAuthorsIdempotent(t *testing.T) {
+	in := "Alice Smith (MIT), Bob Jones, and Zoë Müller et al."
+	first := ParseAuthors(in)
+	var names string
+	for i, author := range first {
+		if i > 0 {
+			names += ", "
+		}
+		names += author.Name
+	}
+	second := ParseAuthors(names)
+	if len(first) != len(second) {
+		t.Fatalf("re-parsing gave %d authors, want %d", len(second), len(first))
+	}
+	for i := range first {
+		if first[i].Name != second[i].Name {
+			t.Errorf("author %d: %q re-parsed as %q", i, first[i].Name, second[i].Name)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"John Smith", "john smith"},
+		{"J.  Smith", "j smith"},
+		{"j. smith", "j smith"},
+		{"J.R.R. Tolkien", "j r r tolkien"},
+		{"  Zoë   MÜLLER ", "zoë müller"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.in); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
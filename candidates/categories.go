@@ -0,0 +1,36 @@
+package candidates
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// CopyArticleCategories adds the article's arXiv categories to the
+// candidate's, returning how many the article had. Categories the candidate
+// already has are kept, so copying again is harmless.
+func CopyArticleCategories(ctx context.Context, q *database.Queries, candidateID, articleID interface{}) (int, error) {
+	categories, err := q.ListArticleCategories(ctx, articleID)
+	if err != nil {
+		return 0, fmt.Errorf("reading article categories: %w", err)
+	}
+
+	now := time.Now()
+	for _, category := range categories {
+		_, err := q.AddCandidateCategory(ctx, database.AddCandidateCategoryParams{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			CandidateID:   candidateID,
+			ArxivCategory: category.ArxivCategory,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("adding category %s: %w", category.ArxivCategory, err)
+		}
+	}
+	return len(categories), nil
+}
@@ -0,0 +1,547 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// DefaultMinDuplicateScore is the score at or above which a pair of
+// candidates is proposed for merging. An identical name alone just reaches
+// it; a merely compatible name ("J. Smith" and "John Smith") also needs a
+// shared co-author or paper.
+const DefaultMinDuplicateScore = 0.6
+
+// Weights of the signals that make up a duplicate score, capped at 1.0
+const (
+	weightSameURL        = 1.0
+	weightSameName       = 0.6
+	weightCompatibleName = 0.3
+	weightSharedCoauthor = 0.2
+	maxCoauthorWeight    = 0.4
+	weightSharedPaper    = 0.4
+)
+
+// DuplicatePair is a proposal to merge two candidates. Keep is the one
+// judged more complete, which a merge keeps by default.
+type DuplicatePair struct {
+	Keep    database.Candidate
+	Drop    database.Candidate
+	Score   float64
+	Reasons []string
+}
+
+// MergeResult counts the rows a merge moved to the kept candidate. Rows the
+// kept candidate already had an equivalent of are merged or dropped instead.
+type MergeResult struct {
+	Candidate     database.Candidate
+	Articles      int64
+	Searches      int64
+	Categories    int64
+	Enrichments   int64
+	ProfileLinks  int64
+	StatusChanges int64
+	// Conflicts describes the duplicate's data that disagreed with the kept
+	// candidate's and was not kept: a different profile URL or enrichment
+	// match, or a pipeline stage overruled by a rejection
+	Conflicts []string
+}
+
+// Deduper finds candidates that are likely the same person and merges them
+type Deduper struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewDeduper creates a new duplicate finder
+func NewDeduper(db *sql.DB, queries *database.Queries) *Deduper {
+	return &Deduper{db: db, queries: queries}
+}
+
+// FindDuplicates scores every pair of candidates that share a profile URL or
+// have compatible names, and returns those scoring at least minScore, best
+// first. Pairs with different URLs of the same kind, or who are co-authors
+// of the same article, are never proposed: they are evidently two people.
+func (d *Deduper) FindDuplicates(ctx context.Context, minScore float64) ([]DuplicatePair, error) {
+	all, err := d.queries.ListAllCandidates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing candidates: %w", err)
+	}
+	refs, err := d.queries.ListCandidateArticleRefs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing candidate articles: %w", err)
+	}
+
+	// Index articles, papers (the same paper is one article per search) and co-authors
+	articles := map[string]map[string]bool{}
+	papers := map[string]map[string]bool{}
+	authors := map[string][]string{}
+	for _, ref := range refs {
+		candidateID, articleID := fmt.Sprint(ref.CandidateID), fmt.Sprint(ref.ArticleID)
+		addToSet(articles, candidateID, articleID)
+		addToSet(papers, candidateID, ref.ArticleUrl)
+		authors[articleID] = append(authors[articleID], candidateID)
+	}
+	coauthors := map[string]map[string]bool{}
+	for _, list := range authors {
+		for _, a := range list {
+			for _, b := range list {
+				if a != b {
+					addToSet(coauthors, a, b)
+				}
+			}
+		}
+	}
+
+	// Only candidates sharing a surname or a URL can be duplicates, which
+	// avoids comparing every pair
+	blocks := map[string][]int{}
+	for i, candidate := range all {
+		if parts := strings.Fields(candidate.NormalizedName); len(parts) > 0 {
+			blocks["name "+parts[len(parts)-1]] = append(blocks["name "+parts[len(parts)-1]], i)
+		}
+		if url := comparableURL(candidate.GithubUrl.String); url != "" {
+			blocks["github "+url] = append(blocks["github "+url], i)
+		}
+		if url := comparableURL(candidate.LinkedinUrl.String); url != "" {
+			blocks["linkedin "+url] = append(blocks["linkedin "+url], i)
+		}
+	}
+
+	seen := map[[2]int]bool{}
+	var pairs []DuplicatePair
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				key := [2]int{block[x], block[y]}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				a, b := all[block[x]], all[block[y]]
+				idA, idB := fmt.Sprint(a.ID), fmt.Sprint(b.ID)
+				if sharesAny(articles[idA], articles[idB]) {
+					continue
+				}
+				score, reasons, ok := scorePair(a, b)
+				if !ok {
+					continue
+				}
+				if shared := countShared(coauthors[idA], coauthors[idB]); shared > 0 {
+					score += min(float64(shared)*weightSharedCoauthor, maxCoauthorWeight)
+					reasons = append(reasons, fmt.Sprintf("%d shared co-authors", shared))
+				}
+				if shared := countShared(papers[idA], papers[idB]); shared > 0 {
+					score += weightSharedPaper
+					reasons = append(reasons, fmt.Sprintf("%d shared papers", shared))
+				}
+				score = min(score, 1.0)
+				if score < minScore {
+					continue
+				}
+
+				keep, drop := a, b
+				if completeness(b, len(papers[idB])) > completeness(a, len(papers[idA])) {
+					keep, drop = b, a
+				}
+				pairs = append(pairs, DuplicatePair{Keep: keep, Drop: drop, Score: score, Reasons: reasons})
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return fmt.Sprint(pairs[i].Keep.ID, pairs[i].Drop.ID) < fmt.Sprint(pairs[j].Keep.ID, pairs[j].Drop.ID)
+	})
+	return pairs, nil
+}
+
+// scorePair scores the names and URLs of two candidates. ok is false when
+// they cannot be the same person.
+func scorePair(a, b database.Candidate) (score float64, reasons []string, ok bool) {
+	for _, url := range []struct {
+		kind string
+		a, b sql.NullString
+	}{
+		{"GitHub", a.GithubUrl, b.GithubUrl},
+		{"LinkedIn", a.LinkedinUrl, b.LinkedinUrl},
+	} {
+		urlA, urlB := comparableURL(url.a.String), comparableURL(url.b.String)
+		switch {
+		case urlA == "" || urlB == "":
+		case urlA == urlB:
+			score += weightSameURL
+			reasons = append(reasons, "same "+url.kind+" URL")
+		default:
+			return 0, nil, false
+		}
+	}
+
+	switch {
+	case a.NormalizedName == b.NormalizedName:
+		score += weightSameName
+		reasons = append(reasons, "same name")
+	case compatibleNames(a.NormalizedName, b.NormalizedName):
+		score += weightCompatibleName
+		reasons = append(reasons, "compatible names")
+	case score == 0:
+		return 0, nil, false
+	}
+	return score, reasons, true
+}
+
+// compatibleNames reports whether two normalized names could be the same
+// person: the same surname, and given names that agree wherever both are
+// spelled out, e.g. "j smith", "john smith" and "john a smith"
+func compatibleNames(a, b string) bool {
+	partsA, partsB := strings.Fields(a), strings.Fields(b)
+	if len(partsA) < 2 || len(partsB) < 2 || partsA[len(partsA)-1] != partsB[len(partsB)-1] {
+		return false
+	}
+	first := func(x, y string) bool {
+		if len(x) == 1 || len(y) == 1 {
+			return x[0] == y[0]
+		}
+		return x == y
+	}
+	return first(partsA[0], partsB[0])
+}
+
+// completeness ranks which of two duplicates to keep: the one with more
+// profile links, then the fuller name, then more papers
+func completeness(c database.Candidate, papers int) int {
+	score := papers
+	if c.GithubUrl.String != "" {
+		score += 1000
+	}
+	if c.LinkedinUrl.String != "" {
+		score += 1000
+	}
+	for _, part := range strings.Fields(c.NormalizedName) {
+		if len(part) > 1 {
+			score += 100
+		}
+	}
+	return score
+}
+
+// Merge folds the drop candidate into the keep candidate in one transaction.
+// The kept candidate takes the fuller of the two names and any profile URL it
+// lacks. Articles, categories, enrichments, profile links and pipeline history
+// move over; where both were linked to the same search, the link keeps the
+// higher relevance, both sets of notes and the later pipeline stage, unless
+// either was rejected, in which case it stays rejected. A stage change is
+// recorded in the pipeline history as made by mergedBy. The drop candidate is
+// then deleted. Whatever of the duplicate's disagreed with the kept candidate
+// and was not kept is listed in the result's Conflicts.
+func (d *Deduper) Merge(ctx context.Context, keepID, dropID interface{}, mergedBy string) (*MergeResult, error) {
+	if fmt.Sprint(keepID) == fmt.Sprint(dropID) {
+		return nil, fmt.Errorf("cannot merge a candidate into itself")
+	}
+	if mergedBy == "" {
+		return nil, fmt.Errorf("the person making the merge must be recorded")
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := d.queries.WithTx(tx)
+
+	keep, err := qtx.GetCandidateByID(ctx, keepID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no candidate with ID %v", keepID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	drop, err := qtx.GetCandidateByID(ctx, dropID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no candidate with ID %v", dropID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	name := keep.Name
+	if fullerName(drop.Name, keep.Name) {
+		name = drop.Name
+	}
+	merged, err := qtx.SetCandidateFields(ctx, database.SetCandidateFieldsParams{
+		UpdatedAt:      now,
+		Name:           name,
+		NormalizedName: NormalizeName(name),
+		LinkedinUrl:    preferString(keep.LinkedinUrl, drop.LinkedinUrl),
+		GithubUrl:      preferString(keep.GithubUrl, drop.GithubUrl),
+		ID:             keep.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("updating candidate: %w", err)
+	}
+	result := &MergeResult{Candidate: merged}
+	for _, url := range []struct {
+		kind       string
+		keep, drop sql.NullString
+	}{
+		{"GitHub URL", keep.GithubUrl, drop.GithubUrl},
+		{"LinkedIn URL", keep.LinkedinUrl, drop.LinkedinUrl},
+	} {
+		if url.keep.String != "" && url.drop.String != "" && comparableURL(url.keep.String) != comparableURL(url.drop.String) {
+			result.Conflicts = append(result.Conflicts, fmt.Sprintf("%s %s was dropped for %s", url.kind, url.drop.String, url.keep.String))
+		}
+	}
+
+	conflicts, err := enrichmentConflicts(ctx, qtx, keep.ID, drop.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.Conflicts = append(result.Conflicts, conflicts...)
+
+	note := fmt.Sprintf("merged with duplicate %s (%v)", drop.Name, drop.ID)
+	conflicts, err = mergeSearchLinks(ctx, qtx, keep.ID, drop.ID, mergedBy, note, now)
+	if err != nil {
+		return nil, err
+	}
+	result.Conflicts = append(result.Conflicts, conflicts...)
+
+	moves := []struct {
+		what  string
+		count *int64
+		move  func(context.Context) (int64, error)
+		clear func(context.Context, interface{}) (int64, error)
+	}{
+		{"articles", &result.Articles, func(ctx context.Context) (int64, error) {
+			return qtx.MoveCandidateArticles(ctx, database.MoveCandidateArticlesParams{KeepID: keep.ID, UpdatedAt: now, DropID: drop.ID})
+		}, qtx.DeleteCandidateArticles},
+		{"searches", &result.Searches, func(ctx context.Context) (int64, error) {
+			return qtx.MoveCandidateSearches(ctx, database.MoveCandidateSearchesParams{KeepID: keep.ID, UpdatedAt: now, DropID: drop.ID})
+		}, qtx.DeleteCandidateSearches},
+		{"categories", &result.Categories, func(ctx context.Context) (int64, error) {
+			return qtx.MoveCandidateCategories(ctx, database.MoveCandidateCategoriesParams{KeepID: keep.ID, UpdatedAt: now, DropID: drop.ID})
+		}, qtx.DeleteCandidateCategories},
+		{"enrichments", &result.Enrichments, func(ctx context.Context) (int64, error) {
+			return qtx.MoveCandidateEnrichments(ctx, database.MoveCandidateEnrichmentsParams{KeepID: keep.ID, UpdatedAt: now, DropID: drop.ID})
+		}, qtx.DeleteCandidateEnrichments},
+		{"profile links", &result.ProfileLinks, func(ctx context.Context) (int64, error) {
+			return qtx.MoveCandidateProfileLinks(ctx, database.MoveCandidateProfileLinksParams{KeepID: keep.ID, UpdatedAt: now, DropID: drop.ID})
+		}, qtx.DeleteCandidateProfileLinks},
+	}
+	for _, m := range moves {
+		if *m.count, err = m.move(ctx); err != nil {
+			return nil, fmt.Errorf("moving %s: %w", m.what, err)
+		}
+		if _, err := m.clear(ctx, drop.ID); err != nil {
+			return nil, fmt.Errorf("removing duplicate %s: %w", m.what, err)
+		}
+	}
+	result.StatusChanges, err = qtx.MoveCandidateStatusChanges(ctx, database.MoveCandidateStatusChangesParams{KeepID: keep.ID, DropID: drop.ID})
+	if err != nil {
+		return nil, fmt.Errorf("moving pipeline history: %w", err)
+	}
+
+	if _, err := qtx.DeleteCandidate(ctx, drop.ID); err != nil {
+		return nil, fmt.Errorf("deleting duplicate: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// enrichmentConflicts lists the duplicate's enrichments that a merge drops
+// because the kept candidate already has one from the same source that
+// matched a different profile
+func enrichmentConflicts(ctx context.Context, q *database.Queries, keepID, dropID interface{}) ([]string, error) {
+	dropEnrichments, err := q.ListCandidateEnrichments(ctx, dropID)
+	if err != nil {
+		return nil, fmt.Errorf("listing enrichments: %w", err)
+	}
+	var conflicts []string
+	for _, dropped := range dropEnrichments {
+		kept, err := q.GetCandidateEnrichment(ctx, database.GetCandidateEnrichmentParams{
+			CandidateID: keepID,
+			Source:      dropped.Source,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up enrichment: %w", err)
+		}
+		if dropped.ProfileUrl.String == "" || comparableURL(dropped.ProfileUrl.String) == comparableURL(kept.ProfileUrl.String) {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s match %s (confidence %.2f) was dropped for %s (confidence %.2f)",
+			dropped.Source, dropped.ProfileUrl.String, dropped.Confidence, valueOrNone(kept.ProfileUrl.String), kept.Confidence))
+	}
+	return conflicts, nil
+}
+
+// mergeSearchLinks folds the drop candidate's link into the keep candidate's
+// for every search both are linked to, recording any stage change with note.
+// The remaining links are moved as they are. It returns the searches where a
+// rejection overruled the duplicate's or the kept candidate's progress.
+func mergeSearchLinks(ctx context.Context, q *database.Queries, keepID, dropID interface{}, mergedBy, note string, now time.Time) ([]string, error) {
+	dropLinks, err := q.ListCandidateSearchLinks(ctx, dropID)
+	if err != nil {
+		return nil, fmt.Errorf("listing search links: %w", err)
+	}
+	var conflicts []string
+	for _, dropLink := range dropLinks {
+		keepLink, err := q.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{
+			CandidateID: keepID,
+			SearchID:    dropLink.SearchID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("looking up search link: %w", err)
+		}
+
+		relevance := keepLink.RelevanceScore
+		if dropLink.RelevanceScore.Valid && (!relevance.Valid || dropLink.RelevanceScore.Float64 > relevance.Float64) {
+			relevance = dropLink.RelevanceScore
+		}
+		notes := keepLink.Notes
+		if dropLink.Notes.String != "" && dropLink.Notes.String != notes.String {
+			if notes.String == "" {
+				notes = dropLink.Notes
+			} else {
+				notes = sql.NullString{String: notes.String + "\n" + dropLink.Notes.String, Valid: true}
+			}
+		}
+		_, err = q.UpdateCandidateRelevance(ctx, database.UpdateCandidateRelevanceParams{
+			UpdatedAt:      now,
+			RelevanceScore: relevance,
+			Notes:          notes,
+			CandidateID:    keepID,
+			SearchID:       dropLink.SearchID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("merging search link: %w", err)
+		}
+
+		stage, overruled := mergedStage(keepLink.Status, dropLink.Status)
+		if overruled != "" {
+			conflicts = append(conflicts, fmt.Sprintf("search %v: the %s stage was overruled by a rejection", dropLink.SearchID, overruled))
+		}
+		if stage != keepLink.Status {
+			if _, err := setStage(ctx, q, keepID, dropLink.SearchID, keepLink.Status, stage, mergedBy, note, now); err != nil {
+				return nil, fmt.Errorf("merging pipeline stage: %w", err)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// mergedStage picks the stage of a link merged from two. A rejection is a
+// decision about the person, so it wins over any other stage, which is
+// returned as overruled; otherwise the stage further along the pipeline wins.
+func mergedStage(keep, drop string) (stage, overruled string) {
+	switch {
+	case keep == drop:
+		return keep, ""
+	case keep == StatusRejected:
+		if drop != StatusNew {
+			overruled = drop
+		}
+		return StatusRejected, overruled
+	case drop == StatusRejected:
+		if keep != StatusNew {
+			overruled = keep
+		}
+		return StatusRejected, overruled
+	case stageRank(drop) > stageRank(keep):
+		return drop, ""
+	}
+	return keep, ""
+}
+
+// stageRank orders the stages other than rejected by how far along the
+// pipeline they are
+func stageRank(stage string) int {
+	for i, s := range Stages {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// valueOrNone returns s, or "none" if it is empty
+func valueOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// fullerName reports whether a spells out more of the name than b, e.g.
+// "John Smith" over "J. Smith"
+func fullerName(a, b string) bool {
+	spelled := func(name string) (words, letters int) {
+		for _, part := range strings.Fields(NormalizeName(name)) {
+			if len(part) > 1 {
+				words++
+			}
+			letters += len(part)
+		}
+		return words, letters
+	}
+	wordsA, lettersA := spelled(a)
+	wordsB, lettersB := spelled(b)
+	if wordsA != wordsB {
+		return wordsA > wordsB
+	}
+	return lettersA > lettersB
+}
+
+// preferString returns the first non-empty value
+func preferString(values ...sql.NullString) sql.NullString {
+	for _, value := range values {
+		if value.String != "" {
+			return value
+		}
+	}
+	return sql.NullString{}
+}
+
+// comparableURL normalizes a profile URL for comparison
+func comparableURL(raw string) string {
+	raw = strings.ToLower(strings.TrimRight(strings.TrimSpace(raw), "/"))
+	raw = strings.TrimPrefix(strings.TrimPrefix(raw, "https://"), "http://")
+	return strings.TrimPrefix(raw, "www.")
+}
+
+func addToSet(sets map[string]map[string]bool, key, value string) {
+	if sets[key] == nil {
+		sets[key] = map[string]bool{}
+	}
+	sets[key][value] = true
+}
+
+func sharesAny(a, b map[string]bool) bool {
+	return countShared(a, b) > 0
+}
+
+func countShared(a, b map[string]bool) int {
+	shared := 0
+	for key := range a {
+		if b[key] {
+			shared++
+		}
+	}
+	return shared
+}
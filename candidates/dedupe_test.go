@@ -0,0 +1,124 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// The same paper fetched by two searches is stored once per search, and an
+// author spelled differently in each becomes two candidates whose shared
+// paper shows they are one person
+func TestFindDuplicatesSharedPaper(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	first := dbtest.CreateSearch(t, queries, "first", "http://rss.arxiv.org/rss/cs.LG")
+	second := dbtest.CreateSearch(t, queries, "second", "http://rss.arxiv.org/rss/cs.CL")
+	linker := NewLinker(db, queries)
+
+	url := "https://arxiv.org/abs/2401.01234"
+	for _, a := range []struct {
+		searchID interface{}
+		authors  string
+	}{
+		{first.ID, "J. Smith, Ann Lee"},
+		{second.ID, "John Smith, Ann Lee"},
+	} {
+		article := dbtest.CreateArticle(t, queries, a.searchID, url, "Sparse Attention", "Abstract.", a.authors)
+		if _, err := linker.LinkArticle(ctx, article, sql.NullFloat64{}); err != nil {
+			t.Fatalf("LinkArticle: %v", err)
+		}
+	}
+
+	pairs, err := NewDeduper(db, queries).FindDuplicates(ctx, DefaultMinDuplicateScore)
+	if err != nil {
+		t.Fatalf("FindDuplicates: %v", err)
+	}
+	if len(pairs) != 1 {
+		t.Fatalf("%d pairs proposed, want 1: %+v", len(pairs), pairs)
+	}
+	pair := pairs[0]
+	names := []string{pair.Keep.Name, pair.Drop.Name}
+	if !(names[0] == "John Smith" && names[1] == "J. Smith") && !(names[0] == "J. Smith" && names[1] == "John Smith") {
+		t.Errorf("proposed %q, want J. Smith and John Smith", names)
+	}
+	want := weightCompatibleName + weightSharedCoauthor + weightSharedPaper
+	if pair.Score < want-1e-9 || pair.Score > want+1e-9 {
+		t.Errorf("score = %v, want %v", pair.Score, want)
+	}
+	if reasons := strings.Join(pair.Reasons, "; "); !strings.Contains(reasons, "1 shared papers") {
+		t.Errorf("reasons = %q, want the shared paper", reasons)
+	}
+}
+
+// A rejected duplicate keeps the merged link rejected, the change is in the
+// pipeline history, and the duplicate's overruled stage and conflicting
+// GitHub match are reported rather than dropped silently
+func TestMergeRejectionWins(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	for _, statement := range []string{
+		`INSERT INTO candidates (id, created_at, updated_at, name, normalized_name) VALUES
+  ('keep', datetime('now'), datetime('now'), 'John Smith', 'john smith'),
+  ('drop', datetime('now'), datetime('now'), 'J. Smith', 'j smith')`,
+		`INSERT INTO candidate_searches (id, created_at, updated_at, candidate_id, search_id, status) VALUES
+  ('cs1', datetime('now'), datetime('now'), 'keep', ?1, 'contacted'),
+  ('cs2', datetime('now'), datetime('now'), 'drop', ?1, 'rejected')`,
+		`INSERT INTO candidate_enrichments (id, created_at, updated_at, candidate_id, source, profile_login, profile_url, confidence, evidence) VALUES
+  ('e1', datetime('now'), datetime('now'), 'keep', 'github', 'jsmith', 'https://github.com/jsmith', 0.9, '[]'),
+  ('e2', datetime('now'), datetime('now'), 'drop', 'github', 'johnsmith', 'https://github.com/johnsmith', 0.7, '[]')`,
+	} {
+		if _, err := db.ExecContext(ctx, statement, search.ID); err != nil {
+			t.Fatalf("inserting candidates: %v", err)
+		}
+	}
+
+	result, err := NewDeduper(db, queries).Merge(ctx, "keep", "drop", "tester")
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	link, err := queries.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{CandidateID: "keep", SearchID: search.ID})
+	if err != nil {
+		t.Fatalf("GetCandidateSearchLink: %v", err)
+	}
+	if link.Status != StatusRejected {
+		t.Errorf("merged link is %s, want rejected", link.Status)
+	}
+	history, err := queries.ListCandidateStatusChanges(ctx, database.ListCandidateStatusChangesParams{CandidateID: "keep", SearchID: search.ID})
+	if err != nil {
+		t.Fatalf("ListCandidateStatusChanges: %v", err)
+	}
+	if len(history) != 1 || history[0].FromStatus != StatusContacted || history[0].ToStatus != StatusRejected || history[0].ChangedBy != "tester" {
+		t.Errorf("history = %+v, want one contacted -> rejected change by tester", history)
+	}
+
+	conflicts := strings.Join(result.Conflicts, "\n")
+	for _, want := range []string{"contacted stage was overruled", "https://github.com/johnsmith"} {
+		if !strings.Contains(conflicts, want) {
+			t.Errorf("conflicts %q do not mention %q", conflicts, want)
+		}
+	}
+}
+
+func TestMergedStage(t *testing.T) {
+	for _, tc := range []struct {
+		keep, drop, stage, overruled string
+	}{
+		{StatusNew, StatusReviewing, StatusReviewing, ""},
+		{StatusContacted, StatusApproved, StatusContacted, ""},
+		{StatusReviewing, StatusRejected, StatusRejected, StatusReviewing},
+		{StatusRejected, StatusInterviewing, StatusRejected, StatusInterviewing},
+		{StatusNew, StatusRejected, StatusRejected, ""},
+	} {
+		stage, overruled := mergedStage(tc.keep, tc.drop)
+		if stage != tc.stage || overruled != tc.overruled {
+			t.Errorf("mergedStage(%s, %s) = %s, %q; want %s, %q", tc.keep, tc.drop, stage, overruled, tc.stage, tc.overruled)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Fields an import can read. By default each is read from the CSV column of
+// the same name, which is also what the export command writes.
+const (
+	FieldName        = "name"
+	FieldGithubURL   = "github_url"
+	FieldLinkedinURL = "linkedin_url"
+	FieldRelevance   = "relevance_score"
+	FieldNotes       = "notes"
+)
+
+// ImportFields lists every field an import can read
+var ImportFields = []string{FieldName, FieldGithubURL, FieldLinkedinURL, FieldRelevance, FieldNotes}
+
+// What an import does with each row
+const (
+	// ActionInsert creates a new candidate
+	ActionInsert = "insert"
+	// ActionMerge fills in an existing candidate's empty profile links
+	ActionMerge = "merge"
+	// ActionConflict leaves the database alone because the row contradicts
+	// what is stored, or matches more than one candidate
+	ActionConflict = "conflict"
+	// ActionSkip leaves the database alone because the row is unusable
+	ActionSkip = "skip"
+)
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	// Mapping maps fields to CSV column headers, overriding the default of
+	// the column named after the field. Headers are matched ignoring case.
+	Mapping map[string]string
+	// SearchID, if not nil, is the search every imported candidate is linked to
+	SearchID interface{}
+	// Relevance is the score given to new search links when the row has none
+	Relevance sql.NullFloat64
+	// DryRun reports what would happen without writing anything
+	DryRun bool
+}
+
+// ImportRow is the outcome for one CSV row
+type ImportRow struct {
+	// Line is the row's line number in the file, counting the header as line 1
+	Line        int
+	Name        string
+	Action      string
+	CandidateID interface{}
+	// MatchedBy names the field that matched an existing candidate
+	MatchedBy string
+	// Filled lists the existing candidate's columns the row filled in
+	Filled []string
+	// Linked is set when a new search link was created
+	Linked bool
+	// Reason explains a conflict or skip
+	Reason string
+}
+
+// ImportReport summarises an import
+type ImportReport struct {
+	DryRun    bool
+	Inserted  int
+	Merged    int
+	Conflicts int
+	Skipped   int
+	Linked    int
+	Rows      []ImportRow
+}
+
+// Importer adds candidates from spreadsheets, deduplicating them against
+// the candidates already stored
+type Importer struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewImporter creates a new candidate importer
+func NewImporter(db *sql.DB, queries *database.Queries) *Importer {
+	return &Importer{db: db, queries: queries}
+}
+
+// Import reads candidates from CSV. Each row is matched to an existing
+// candidate by GitHub URL, then LinkedIn URL, then normalized name; a match
+// has its empty profile links filled in, and a row with no match becomes a
+// new candidate. Rows that would overwrite a different stored URL, or that
+// match several candidates, are reported as conflicts and left out.
+//
+// The whole import runs in one transaction, so rows later in the file are
+// matched against those earlier in it. A dry run rolls the transaction back,
+// which makes its report exactly what a real import would do.
+func (im *Importer) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns, err := mapColumns(header, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := im.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := im.queries.WithTx(tx)
+
+	report := &ImportReport{DryRun: opts.DryRun}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row, err := im.importRow(ctx, qtx, line, value, opts)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch row.Action {
+		case ActionInsert:
+			report.Inserted++
+		case ActionMerge:
+			report.Merged++
+		case ActionConflict:
+			report.Conflicts++
+		case ActionSkip:
+			report.Skipped++
+		}
+		if row.Linked {
+			report.Linked++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importRow matches, inserts or merges a single row
+func (im *Importer) importRow(ctx context.Context, q *database.Queries, line int, value func(string) string, opts ImportOptions) (ImportRow, error) {
+	row := ImportRow{Line: line, Name: value(FieldName)}
+	if row.Name == "" {
+		row.Action, row.Reason = ActionSkip, "no name"
+		return row, nil
+	}
+	github, linkedin := cleanImportURL(value(FieldGithubURL)), cleanImportURL(value(FieldLinkedinURL))
+
+	relevance := opts.Relevance
+	if raw := value(FieldRelevance); raw != "" {
+		score, err := strconv.ParseFloat(raw, 64)
+		if err != nil || score < 0 || score > 1 {
+			row.Action, row.Reason = ActionSkip, fmt.Sprintf("relevance %q is not a number between 0 and 1", raw)
+			return row, nil
+		}
+		relevance = sql.NullFloat64{Float64: score, Valid: true}
+	}
+
+	candidate, matchedBy, reason, err := findImportMatch(ctx, q, row.Name, github, linkedin)
+	if err != nil {
+		return row, err
+	}
+	if reason != "" {
+		row.Action, row.Reason = ActionConflict, reason
+		return row, nil
+	}
+
+	now := time.Now()
+	if candidate == nil {
+		created, err := q.CreateCandidate(ctx, database.CreateCandidateParams{
+			ID:             uuid.New(),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			Name:           row.Name,
+			LinkedinUrl:    sql.NullString{String: linkedin, Valid: linkedin != ""},
+			GithubUrl:      sql.NullString{String: github, Valid: github != ""},
+			NormalizedName: NormalizeName(row.Name),
+		})
+		if err != nil {
+			return row, fmt.Errorf("creating candidate: %w", err)
+		}
+		candidate = &created
+		row.Action = ActionInsert
+	} else {
+		row.Action, row.MatchedBy = ActionMerge, matchedBy
+		update := database.UpdateCandidateParams{
+			UpdatedAt:   now,
+			Name:        candidate.Name,
+			LinkedinUrl: candidate.LinkedinUrl,
+			GithubUrl:   candidate.GithubUrl,
+			ID:          candidate.ID,
+		}
+		if github != "" && candidate.GithubUrl.String == "" {
+			update.GithubUrl = sql.NullString{String: github, Valid: true}
+			row.Filled = append(row.Filled, "github_url")
+		}
+		if linkedin != "" && candidate.LinkedinUrl.String == "" {
+			update.LinkedinUrl = sql.NullString{String: linkedin, Valid: true}
+			row.Filled = append(row.Filled, "linkedin_url")
+		}
+		if len(row.Filled) > 0 {
+			if _, err := q.UpdateCandidate(ctx, update); err != nil {
+				return row, fmt.Errorf("updating candidate: %w", err)
+			}
+		}
+	}
+	row.CandidateID = candidate.ID
+
+	if opts.SearchID != nil {
+		// An existing link keeps its score, notes and stage
+		_, err := q.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{
+			CandidateID: candidate.ID,
+			SearchID:    opts.SearchID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			notes := value(FieldNotes)
+			_, err = q.LinkCandidateToSearch(ctx, database.LinkCandidateToSearchParams{
+				ID:             uuid.New(),
+				CreatedAt:      now,
+				UpdatedAt:      now,
+				CandidateID:    candidate.ID,
+				SearchID:       opts.SearchID,
+				RelevanceScore: relevance,
+				Notes:          sql.NullString{String: notes, Valid: notes != ""},
+			})
+			if err != nil {
+				return row, fmt.Errorf("linking candidate to search: %w", err)
+			}
+			row.Linked = true
+		} else if err != nil {
+			return row, fmt.Errorf("looking up search link: %w", err)
+		}
+	}
+	return row, nil
+}
+
+// findImportMatch returns the existing candidate the row describes, nil if
+// there is none, or a reason the row conflicts with what is stored
+func findImportMatch(ctx context.Context, q *database.Queries, name, github, linkedin string) (*database.Candidate, string, string, error) {
+	var matches []database.Candidate
+	var matchedBy string
+	for _, key := range []struct {
+		field, value string
+		list         func(context.Context, string) ([]database.Candidate, error)
+	}{
+		{FieldGithubURL, github, q.ListCandidatesByGithubURL},
+		{FieldLinkedinURL, linkedin, q.ListCandidatesByLinkedinURL},
+	} {
+		if key.value == "" {
+			continue
+		}
+		found, err := key.list(ctx, key.value)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("looking up %s: %w", key.field, err)
+		}
+		for _, candidate := range found {
+			if !containsCandidate(matches, candidate.ID) {
+				matches = append(matches, candidate)
+			}
+		}
+		if len(found) > 0 && matchedBy == "" {
+			matchedBy = key.field
+		}
+	}
+
+	// A name only identifies someone when neither URL does
+	if len(matches) == 0 {
+		found, err := q.ListCandidatesByNormalizedName(ctx, NormalizeName(name))
+		if err != nil {
+			return nil, "", "", fmt.Errorf("looking up name: %w", err)
+		}
+		matches, matchedBy = found, FieldName
+	}
+
+	if len(matches) == 0 {
+		return nil, "", "", nil
+	}
+	if len(matches) > 1 {
+		names := make([]string, len(matches))
+		for i, candidate := range matches {
+			names[i] = fmt.Sprintf("%s (%v)", candidate.Name, candidate.ID)
+		}
+		sort.Strings(names)
+		return nil, "", fmt.Sprintf("matches %d candidates by %s: %s", len(matches), matchedBy, strings.Join(names, ", ")), nil
+	}
+
+	candidate := matches[0]
+	if github != "" && candidate.GithubUrl.String != "" && !sameURL(github, candidate.GithubUrl.String) {
+		return nil, "", fmt.Sprintf("%s (%v) already has GitHub %s", candidate.Name, candidate.ID, candidate.GithubUrl.String), nil
+	}
+	if linkedin != "" && candidate.LinkedinUrl.String != "" && !sameURL(linkedin, candidate.LinkedinUrl.String) {
+		return nil, "", fmt.Sprintf("%s (%v) already has LinkedIn %s", candidate.Name, candidate.ID, candidate.LinkedinUrl.String), nil
+	}
+	return &candidate, matchedBy, "", nil
+}
+
+// mapColumns finds the index of each field's column in the header. Only the
+// name column is required, and only explicitly mapped columns must exist.
+func mapColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field := range mapping {
+		if !containsString(ImportFields, field) {
+			return nil, fmt.Errorf("unknown field %q in column mapping (expected one of %s)", field, strings.Join(ImportFields, ", "))
+		}
+	}
+
+	columns := map[string]int{}
+	for _, field := range ImportFields {
+		want, explicit := mapping[field]
+		if !explicit {
+			want = field
+		}
+		for i, column := range header {
+			// Spreadsheets often save a byte order mark before the first header
+			column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+			if strings.EqualFold(column, strings.TrimSpace(want)) {
+				columns[field] = i
+				break
+			}
+		}
+		if _, ok := columns[field]; !ok && (explicit || field == FieldName) {
+			return nil, fmt.Errorf("no %q column for %s in the header", want, field)
+		}
+	}
+	return columns, nil
+}
+
+// cleanImportURL trims a spreadsheet URL and adds the scheme people often leave off
+func cleanImportURL(raw string) string {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if raw == "" || strings.Contains(raw, "://") {
+		return raw
+	}
+	return "https://" + raw
+}
+
+// sameURL compares URLs the way the lookup queries do
+func sameURL(a, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+func containsCandidate(list []database.Candidate, id interface{}) bool {
+	for _, candidate := range list {
+		if fmt.Sprint(candidate.ID) == fmt.Sprint(id) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
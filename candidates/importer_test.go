@@ -0,0 +1,207 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// createCandidate stores a candidate with the given profile links, either of
+// which may be empty
+func createCandidate(t *testing.T, queries *database.Queries, id, name, github, linkedin string) {
+	t.Helper()
+	_, err := queries.CreateCandidate(context.Background(), database.CreateCandidateParams{
+		ID:             id,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		Name:           name,
+		GithubUrl:      sql.NullString{String: github, Valid: github != ""},
+		LinkedinUrl:    sql.NullString{String: linkedin, Valid: linkedin != ""},
+		NormalizedName: NormalizeName(name),
+	})
+	if err != nil {
+		t.Fatalf("CreateCandidate: %v", err)
+	}
+}
+
+// importCSV is a spreadsheet export, saved with a byte order mark, whose rows
+// in order match an existing candidate by GitHub URL, by LinkedIn URL and by
+// name, add a new candidate, repeat that new candidate, conflict over a
+// stored URL, and cannot be used at all
+const importCSV = "\ufeffName,GitHub,LinkedIn,Score,Comment\n" +
+	"Annie Lee,github.com/annlee/,linkedin.com/in/annlee,0.9,met at NeurIPS\n" +
+	"B. Chen,,https://LinkedIn.com/in/bochen,,\n" +
+	"carl  ng,,,,\n" +
+	"Dee Park,github.com/deepark,,0.5,\n" +
+	"Dee  Park,,,,\n" +
+	"Ann Lee,github.com/someone-else,,,\n" +
+	",github.com/nobody,,,\n" +
+	"Eve Stone,,,2,\n"
+
+// importMapping reads importCSV's headers
+var importMapping = map[string]string{
+	FieldName:        "name",
+	FieldGithubURL:   "github",
+	FieldLinkedinURL: "LINKEDIN",
+	FieldRelevance:   "score",
+	FieldNotes:       "comment",
+}
+
+// seedImport stores the candidates importCSV is matched against
+func seedImport(t *testing.T, queries *database.Queries) {
+	t.Helper()
+	createCandidate(t, queries, "ann", "Ann Lee", "https://github.com/annlee", "")
+	createCandidate(t, queries, "bo", "Bo Chen", "", "https://linkedin.com/in/bochen")
+	createCandidate(t, queries, "carl", "Carl Ng", "", "")
+}
+
+// importSummary lists each row's line, action and what it matched or why not
+func importSummary(report *ImportReport) string {
+	var rows []string
+	for _, row := range report.Rows {
+		summary := fmt.Sprintf("%d %s", row.Line, row.Action)
+		if row.MatchedBy != "" {
+			summary += " by " + row.MatchedBy
+		}
+		if len(row.Filled) > 0 {
+			summary += " filling " + strings.Join(row.Filled, "+")
+		}
+		rows = append(rows, summary)
+	}
+	return strings.Join(rows, "; ")
+}
+
+const wantImportSummary = "2 merge by github_url filling linkedin_url; 3 merge by linkedin_url; 4 merge by name; 5 insert; 6 merge by name; 7 conflict; 8 skip; 9 skip"
+
+// A dry run reports exactly what the import would do and writes nothing
+func TestImportDryRun(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	seedImport(t, queries)
+
+	report, err := NewImporter(db, queries).Import(ctx, strings.NewReader(importCSV), ImportOptions{Mapping: importMapping, DryRun: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if got := importSummary(report); got != wantImportSummary {
+		t.Errorf("dry run reported\n%s\nwant\n%s", got, wantImportSummary)
+	}
+	if !report.DryRun || report.Inserted != 1 || report.Merged != 4 || report.Conflicts != 1 || report.Skipped != 2 {
+		t.Errorf("dry run counted %+v", report)
+	}
+	if reason := report.Rows[5].Reason; !strings.Contains(reason, "already has GitHub https://github.com/annlee") {
+		t.Errorf("conflict reason %q, want the stored GitHub URL", reason)
+	}
+
+	all, err := queries.ListAllCandidates(ctx)
+	if err != nil {
+		t.Fatalf("ListAllCandidates: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("%d candidates after a dry run, want the 3 seeded", len(all))
+	}
+	ann, err := queries.GetCandidateByID(ctx, "ann")
+	if err != nil {
+		t.Fatalf("GetCandidateByID: %v", err)
+	}
+	if ann.LinkedinUrl.Valid {
+		t.Errorf("dry run filled in LinkedIn %q", ann.LinkedinUrl.String)
+	}
+}
+
+// A real import writes what the dry run reports, fills in only empty profile
+// links and links each candidate to the search once
+func TestImportApply(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	seedImport(t, queries)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+
+	opts := ImportOptions{
+		Mapping:   importMapping,
+		SearchID:  search.ID,
+		Relevance: sql.NullFloat64{Float64: 0.1, Valid: true},
+	}
+	report, err := NewImporter(db, queries).Import(ctx, strings.NewReader(importCSV), opts)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if got := importSummary(report); got != wantImportSummary {
+		t.Errorf("import reported\n%s\nwant\n%s", got, wantImportSummary)
+	}
+	if report.Linked != 4 {
+		t.Errorf("%d search links created, want 4", report.Linked)
+	}
+
+	ann, err := queries.GetCandidateByID(ctx, "ann")
+	if err != nil {
+		t.Fatalf("GetCandidateByID: %v", err)
+	}
+	if ann.Name != "Ann Lee" || ann.GithubUrl.String != "https://github.com/annlee" || ann.LinkedinUrl.String != "https://linkedin.com/in/annlee" {
+		t.Errorf("merged candidate %+v, want the stored name and GitHub with the LinkedIn filled in", ann)
+	}
+	dee, err := queries.ListCandidatesByNormalizedName(ctx, "dee park")
+	if err != nil {
+		t.Fatalf("ListCandidatesByNormalizedName: %v", err)
+	}
+	if len(dee) != 1 || dee[0].GithubUrl.String != "https://github.com/deepark" {
+		t.Fatalf("imported %+v, want one Dee Park with a GitHub URL", dee)
+	}
+
+	for _, want := range []struct {
+		candidateID interface{}
+		relevance   float64
+		notes       string
+	}{
+		{"ann", 0.9, "met at NeurIPS"},
+		{"bo", 0.1, ""},
+		{dee[0].ID, 0.5, ""},
+	} {
+		link, err := queries.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{CandidateID: want.candidateID, SearchID: search.ID})
+		if err != nil {
+			t.Fatalf("GetCandidateSearchLink(%v): %v", want.candidateID, err)
+		}
+		if link.RelevanceScore.Float64 != want.relevance || link.Notes.String != want.notes {
+			t.Errorf("%v linked with relevance %v and notes %q, want %v and %q", want.candidateID, link.RelevanceScore, link.Notes.String, want.relevance, want.notes)
+		}
+	}
+}
+
+// Columns are found by the mapping or the field names, and a header missing a
+// column the import needs is refused before anything is read
+func TestImportColumns(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	importer := NewImporter(db, queries)
+
+	// The default mapping reads the columns the export writes, in any order and case
+	report, err := importer.Import(ctx, strings.NewReader("GitHub_URL,NAME\ngithub.com/ann,Ann Lee\n"), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Inserted != 1 {
+		t.Errorf("default mapping inserted %d, want 1", report.Inserted)
+	}
+
+	for _, tt := range []struct {
+		csv     string
+		mapping map[string]string
+		want    string
+	}{
+		{"full name\nAnn\n", nil, `no "name" column`},
+		{"name\nAnn\n", map[string]string{FieldGithubURL: "gh"}, `no "gh" column`},
+		{"name\nAnn\n", map[string]string{"email": "email"}, `unknown field "email"`},
+		{"", nil, "empty"},
+	} {
+		_, err := importer.Import(ctx, strings.NewReader(tt.csv), ImportOptions{Mapping: tt.mapping})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("importing %q with %v: %v, want an error containing %q", tt.csv, tt.mapping, err, tt.want)
+		}
+	}
+}
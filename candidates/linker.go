@@ -0,0 +1,330 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// DefaultMinRelevance is the article score at or above which its authors become candidates
+const DefaultMinRelevance = 0.5
+
+// Linker turns article author lists into candidates linked to the article and its search
+type Linker struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// LinkResult summarises the candidates touched while linking articles
+type LinkResult struct {
+	Articles int
+	// Created counts candidates that did not exist before
+	Created int
+	// Matched counts authors that resolved to an existing candidate
+	Matched int
+	// ArticleLinks and SearchLinks count newly inserted link rows
+	ArticleLinks int
+	SearchLinks  int
+}
+
+// NewLinker creates a new candidate linker
+func NewLinker(db *sql.DB, queries *database.Queries) *Linker {
+	return &Linker{db: db, queries: queries}
+}
+
+// LinkArticle creates or reuses a candidate for every author of the article and
+// links each one to the article and to the article's search. The relevance
+// score, if valid, is recorded on the candidate's search link, keeping the
+// highest score seen. Linking is idempotent: running it again on the same
+// article creates no new rows.
+func (l *Linker) LinkArticle(ctx context.Context, article database.Article, relevance sql.NullFloat64) (*LinkResult, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &LinkResult{Articles: 1}
+	qtx := l.queries.WithTx(tx)
+	authors := ParseAuthors(article.ArticleAuthors)
+	for _, author := range authors {
+		candidate, created, err := l.findOrCreateCandidate(ctx, qtx, author, article, coauthorNames(authors, author))
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Matched++
+		}
+
+		linked, err := l.linkToArticle(ctx, qtx, candidate.ID, article.ID)
+		if err != nil {
+			return nil, err
+		}
+		if linked {
+			result.ArticleLinks++
+		}
+
+		linked, err = l.linkToSearch(ctx, qtx, candidate.ID, article.SearchID, relevance)
+		if err != nil {
+			return nil, err
+		}
+		if linked {
+			result.SearchLinks++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LinkCandidate links an existing candidate to a search and, if articleID is
+// not nil, to one of that search's articles. Like LinkArticle it is idempotent
+// and only ever raises the recorded relevance.
+func (l *Linker) LinkCandidate(ctx context.Context, candidateID, searchID, articleID interface{}, relevance sql.NullFloat64) (*LinkResult, error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &LinkResult{Matched: 1}
+	qtx := l.queries.WithTx(tx)
+	if articleID != nil {
+		result.Articles = 1
+		linked, err := l.linkToArticle(ctx, qtx, candidateID, articleID)
+		if err != nil {
+			return nil, err
+		}
+		if linked {
+			result.ArticleLinks++
+		}
+	}
+
+	linked, err := l.linkToSearch(ctx, qtx, candidateID, searchID, relevance)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		result.SearchLinks++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LinkSearch links the authors of every article in the search whose relevance
+// verdict is at least minScore
+func (l *Linker) LinkSearch(ctx context.Context, search database.Search, minScore float64) (*LinkResult, error) {
+	const pageSize = 100
+
+	total := &LinkResult{}
+	for offset := int64(0); ; offset += pageSize {
+		articles, err := l.queries.ListScoredArticlesBySearch(ctx, database.ListScoredArticlesBySearchParams{
+			SearchID: search.ID,
+			Score:    minScore,
+			Limit:    pageSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return total, fmt.Errorf("listing scored articles: %w", err)
+		}
+
+		for _, row := range articles {
+			article := database.Article{
+				ID:             row.ID,
+				FetchedAt:      row.FetchedAt,
+				ArticleUrl:     row.ArticleUrl,
+				ArticleTitle:   row.ArticleTitle,
+				ArticleSummary: row.ArticleSummary,
+				ArticleAuthors: row.ArticleAuthors,
+				SearchID:       row.SearchID,
+			}
+			result, err := l.LinkArticle(ctx, article, sql.NullFloat64{Float64: row.Score, Valid: true})
+			if err != nil {
+				return total, fmt.Errorf("linking authors of %q: %w", row.ArticleTitle, err)
+			}
+			total.add(result)
+		}
+
+		if len(articles) < pageSize {
+			return total, nil
+		}
+	}
+}
+
+// findOrCreateCandidate resolves an author to an existing candidate with the
+// same normalized name, creating one if none is plausibly the same person. A
+// name alone is not enough, since many people publish as "Wei Zhang", so the
+// namesake must already be linked to the article's search, have written the
+// same paper in another search or have written with one of the article's
+// other authors. Otherwise a new candidate is created and FindDuplicates
+// proposes the pair for a person to merge.
+func (l *Linker) findOrCreateCandidate(ctx context.Context, q *database.Queries, author Author, article database.Article, coauthors map[string]bool) (database.Candidate, bool, error) {
+	normalized := NormalizeName(author.Name)
+	namesakes, err := q.ListCandidatesByNormalizedName(ctx, normalized)
+	if err != nil {
+		return database.Candidate{}, false, fmt.Errorf("looking up candidate %q: %w", author.Name, err)
+	}
+
+	for _, candidate := range namesakes {
+		_, err := q.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{
+			CandidateID: candidate.ID,
+			SearchID:    article.SearchID,
+		})
+		if err == nil {
+			return candidate, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Candidate{}, false, fmt.Errorf("looking up search link of %q: %w", author.Name, err)
+		}
+	}
+
+	for _, candidate := range namesakes {
+		authored, err := q.CheckCandidateAuthoredPaper(ctx, database.CheckCandidateAuthoredPaperParams{
+			CandidateID: candidate.ID,
+			ArticleUrl:  article.ArticleUrl,
+		})
+		if err != nil {
+			return database.Candidate{}, false, fmt.Errorf("checking papers of %q: %w", author.Name, err)
+		}
+		if authored != 0 {
+			return candidate, false, nil
+		}
+	}
+
+	if len(coauthors) > 0 {
+		for _, candidate := range namesakes {
+			known, err := q.ListCandidateCoauthorNames(ctx, candidate.ID)
+			if err != nil {
+				return database.Candidate{}, false, fmt.Errorf("listing co-authors of %q: %w", author.Name, err)
+			}
+			for _, name := range known {
+				if coauthors[name] {
+					return candidate, false, nil
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	candidate, err := q.CreateCandidate(ctx, database.CreateCandidateParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Name:           author.Name,
+		LinkedinUrl:    sql.NullString{},
+		GithubUrl:      sql.NullString{},
+		NormalizedName: normalized,
+	})
+	if err != nil {
+		return database.Candidate{}, false, fmt.Errorf("creating candidate %q: %w", author.Name, err)
+	}
+	return candidate, true, nil
+}
+
+// coauthorNames returns the normalized names of the article's authors other
+// than author
+func coauthorNames(authors []Author, author Author) map[string]bool {
+	self := NormalizeName(author.Name)
+	names := make(map[string]bool, len(authors))
+	for _, other := range authors {
+		if name := NormalizeName(other.Name); name != self {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// linkToArticle records that the candidate authored the article, if not
+// already recorded, and gives the candidate the article's categories
+func (l *Linker) linkToArticle(ctx context.Context, q *database.Queries, candidateID, articleID interface{}) (bool, error) {
+	exists, err := q.CheckArticleCandidateLinkExists(ctx, database.CheckArticleCandidateLinkExistsParams{
+		CandidateID: candidateID,
+		ArticleID:   articleID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("checking article link: %w", err)
+	}
+	if exists != 0 {
+		return false, nil
+	}
+
+	now := time.Now()
+	_, err = q.LinkArticleToCandidate(ctx, database.LinkArticleToCandidateParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CandidateID: candidateID,
+		ArticleID:   articleID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("linking candidate to article: %w", err)
+	}
+	if _, err := CopyArticleCategories(ctx, q, candidateID, articleID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// linkToSearch links the candidate to the search, or raises the existing
+// link's relevance score if this article scored higher
+func (l *Linker) linkToSearch(ctx context.Context, q *database.Queries, candidateID, searchID interface{}, relevance sql.NullFloat64) (bool, error) {
+	now := time.Now()
+	link, err := q.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{
+		CandidateID: candidateID,
+		SearchID:    searchID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = q.LinkCandidateToSearch(ctx, database.LinkCandidateToSearchParams{
+			ID:             uuid.New(),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			CandidateID:    candidateID,
+			SearchID:       searchID,
+			RelevanceScore: relevance,
+			Notes:          sql.NullString{},
+		})
+		if err != nil {
+			return false, fmt.Errorf("linking candidate to search: %w", err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("looking up search link: %w", err)
+	}
+
+	if relevance.Valid && (!link.RelevanceScore.Valid || relevance.Float64 > link.RelevanceScore.Float64) {
+		_, err = q.UpdateCandidateRelevance(ctx, database.UpdateCandidateRelevanceParams{
+			UpdatedAt:      now,
+			RelevanceScore: relevance,
+			Notes:          link.Notes,
+			CandidateID:    candidateID,
+			SearchID:       searchID,
+		})
+		if err != nil {
+			return false, fmt.Errorf("updating candidate relevance: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// add accumulates another result into r
+func (r *LinkResult) add(other *LinkResult) {
+	r.Articles += other.Articles
+	r.Created += other.Created
+	r.Matched += other.Matched
+	r.ArticleLinks += other.ArticleLinks
+	r.SearchLinks += other.SearchLinks
+}
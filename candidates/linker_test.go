@@ -0,0 +1,127 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+func TestLinkArticleIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	article := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.01234",
+		"Sparse Attention", "Abstract.", "Alice Smith (MIT), Bob Jones and Zoë Müller")
+	linker := NewLinker(db, queries)
+
+	first, err := linker.LinkArticle(ctx, article, sql.NullFloat64{Float64: 0.6, Valid: true})
+	if err != nil {
+		t.Fatalf("first LinkArticle: %v", err)
+	}
+	want := LinkResult{Articles: 1, Created: 3, ArticleLinks: 3, SearchLinks: 3}
+	if *first != want {
+		t.Errorf("first run = %+v, want %+v", *first, want)
+	}
+
+	second, err := linker.LinkArticle(ctx, article, sql.NullFloat64{Float64: 0.9, Valid: true})
+	if err != nil {
+		t.Fatalf("second LinkArticle: %v", err)
+	}
+	want = LinkResult{Articles: 1, Matched: 3}
+	if *second != want {
+		t.Errorf("second run = %+v, want %+v", *second, want)
+	}
+
+	all, err := queries.ListAllCandidates(ctx)
+	if err != nil {
+		t.Fatalf("ListAllCandidates: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("%d candidates after linking twice, want 3", len(all))
+	}
+	authors, err := queries.GetCandidatesByArticle(ctx, article.ID)
+	if err != nil {
+		t.Fatalf("GetCandidatesByArticle: %v", err)
+	}
+	if len(authors) != 3 {
+		t.Errorf("article has %d author links, want 3", len(authors))
+	}
+
+	// The higher score of the second run is kept on the search link
+	for _, candidate := range all {
+		link, err := queries.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{CandidateID: candidate.ID, SearchID: search.ID})
+		if err != nil {
+			t.Fatalf("GetCandidateSearchLink: %v", err)
+		}
+		if link.RelevanceScore.Float64 != 0.9 {
+			t.Errorf("%s: relevance %v, want 0.9", candidate.Name, link.RelevanceScore)
+		}
+	}
+
+	// A lower score never lowers it again
+	if _, err := linker.LinkArticle(ctx, article, sql.NullFloat64{Float64: 0.1, Valid: true}); err != nil {
+		t.Fatalf("third LinkArticle: %v", err)
+	}
+	link, err := queries.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{CandidateID: all[0].ID, SearchID: search.ID})
+	if err != nil {
+		t.Fatalf("GetCandidateSearchLink: %v", err)
+	}
+	if link.RelevanceScore.Float64 != 0.9 {
+		t.Errorf("relevance lowered to %v", link.RelevanceScore)
+	}
+}
+
+// An author is matched by normalized name within a search, and across searches
+// only when a co-author is shared too. A namesake with neither becomes a new
+// candidate that the duplicates queue proposes merging.
+func TestLinkArticleMatchesExistingCandidates(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	first := dbtest.CreateSearch(t, queries, "first", "http://rss.arxiv.org/rss/cs.LG")
+	second := dbtest.CreateSearch(t, queries, "second", "http://rss.arxiv.org/rss/cs.CL")
+	third := dbtest.CreateSearch(t, queries, "third", "http://rss.arxiv.org/rss/q-bio.BM")
+	linker := NewLinker(db, queries)
+
+	articles := []struct {
+		searchID interface{}
+		url      string
+		authors  string
+	}{
+		{first.ID, "https://arxiv.org/abs/2401.00001", "Alice Smith, Bob Jones"},
+		{first.ID, "https://arxiv.org/abs/2401.00002", "alice  smith and Carol White"},
+		{second.ID, "https://arxiv.org/abs/2401.00003", "Alice Smith, Bob Jones"},
+		{third.ID, "https://arxiv.org/abs/2401.00004", "Alice Smith"},
+	}
+	total := &LinkResult{}
+	for _, a := range articles {
+		article := dbtest.CreateArticle(t, queries, a.searchID, a.url, "Title", "Abstract.", a.authors)
+		result, err := linker.LinkArticle(ctx, article, sql.NullFloat64{})
+		if err != nil {
+			t.Fatalf("LinkArticle %s: %v", a.url, err)
+		}
+		total.add(result)
+	}
+
+	want := LinkResult{Articles: 4, Created: 4, Matched: 3, ArticleLinks: 7, SearchLinks: 6}
+	if *total != want {
+		t.Errorf("linked %+v, want %+v", *total, want)
+	}
+
+	namesakes, err := queries.ListCandidatesByNormalizedName(ctx, "alice smith")
+	if err != nil {
+		t.Fatalf("ListCandidatesByNormalizedName: %v", err)
+	}
+	if len(namesakes) != 2 {
+		t.Fatalf("%d candidates named Alice Smith, want 2", len(namesakes))
+	}
+	pairs, err := NewDeduper(db, queries).FindDuplicates(ctx, DefaultMinDuplicateScore)
+	if err != nil {
+		t.Fatalf("FindDuplicates: %v", err)
+	}
+	if len(pairs) != 1 || pairs[0].Keep.NormalizedName != "alice smith" || pairs[0].Drop.NormalizedName != "alice smith" {
+		t.Errorf("duplicates %+v, want the two Alice Smiths", pairs)
+	}
+}
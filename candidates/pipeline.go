@@ -0,0 +1,168 @@
+package candidates
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Recruiting pipeline stages recorded in candidate_searches.status
+const (
+	StatusNew          = "new"
+	StatusReviewing    = "reviewing"
+	StatusShortlisted  = "shortlisted"
+	StatusApproved     = "approved"
+	StatusContacted    = "contacted"
+	StatusResponded    = "responded"
+	StatusInterviewing = "interviewing"
+	StatusRejected     = "rejected"
+	StatusHired        = "hired"
+)
+
+// Stages lists every pipeline stage in the order a candidate moves through them
+var Stages = []string{
+	StatusNew,
+	StatusReviewing,
+	StatusShortlisted,
+	StatusApproved,
+	StatusContacted,
+	StatusResponded,
+	StatusInterviewing,
+	StatusRejected,
+	StatusHired,
+}
+
+// transitions lists the stages each stage may move to. The review decisions
+// (shortlist, approve, reject) can be made straight from new or reviewing and
+// undone back to new until the candidate is contacted. A candidate can be
+// rejected at any point before being hired and reconsidered after a rejection.
+var transitions = map[string][]string{
+	StatusNew:          {StatusReviewing, StatusShortlisted, StatusApproved, StatusRejected},
+	StatusReviewing:    {StatusShortlisted, StatusApproved, StatusContacted, StatusRejected, StatusNew},
+	StatusShortlisted:  {StatusApproved, StatusContacted, StatusRejected, StatusNew},
+	StatusApproved:     {StatusContacted, StatusShortlisted, StatusRejected, StatusNew},
+	StatusContacted:    {StatusResponded, StatusRejected},
+	StatusResponded:    {StatusInterviewing, StatusRejected},
+	StatusInterviewing: {StatusHired, StatusRejected},
+	StatusRejected:     {StatusReviewing, StatusNew},
+	StatusHired:        {},
+}
+
+// ErrInvalidTransition is returned when a candidate may not move between two stages
+var ErrInvalidTransition = errors.New("invalid pipeline transition")
+
+// ValidStage reports whether stage is one of Stages
+func ValidStage(stage string) bool {
+	_, ok := transitions[stage]
+	return ok
+}
+
+// NextStages returns the stages a candidate at stage may move to
+func NextStages(stage string) []string {
+	return transitions[stage]
+}
+
+// CanTransition reports whether a candidate may move from one stage to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipeline moves candidates between stages and keeps their history
+type Pipeline struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewPipeline creates a new pipeline
+func NewPipeline(db *sql.DB, queries *database.Queries) *Pipeline {
+	return &Pipeline{db: db, queries: queries}
+}
+
+// Move changes the candidate's stage within the search and records who made
+// the change, and why, in the same transaction. Moves not allowed from the
+// current stage fail with ErrInvalidTransition.
+func (p *Pipeline) Move(ctx context.Context, candidateID, searchID interface{}, to, changedBy, note string) (database.CandidateStatusChange, error) {
+	if !ValidStage(to) {
+		return database.CandidateStatusChange{}, fmt.Errorf("unknown stage %q (expected one of %s)", to, strings.Join(Stages, ", "))
+	}
+	if changedBy == "" {
+		return database.CandidateStatusChange{}, fmt.Errorf("the person making the change must be recorded")
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.CandidateStatusChange{}, err
+	}
+	defer tx.Rollback()
+	qtx := p.queries.WithTx(tx)
+
+	link, err := qtx.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{
+		CandidateID: candidateID,
+		SearchID:    searchID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.CandidateStatusChange{}, fmt.Errorf("candidate %v is not linked to search %v", candidateID, searchID)
+	}
+	if err != nil {
+		return database.CandidateStatusChange{}, err
+	}
+	if !CanTransition(link.Status, to) {
+		allowed := "none"
+		if next := NextStages(link.Status); len(next) > 0 {
+			allowed = strings.Join(next, ", ")
+		}
+		return database.CandidateStatusChange{}, fmt.Errorf("%w: %s to %s (allowed: %s)", ErrInvalidTransition, link.Status, to, allowed)
+	}
+
+	change, err := setStage(ctx, qtx, candidateID, searchID, link.Status, to, changedBy, note, time.Now())
+	if err != nil {
+		return database.CandidateStatusChange{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.CandidateStatusChange{}, err
+	}
+	return change, nil
+}
+
+// setStage moves the candidate's link to a search from one stage to another
+// and records the change in its history. It does not check the transition, so
+// callers other than Move must have their own reason for the change.
+func setStage(ctx context.Context, q *database.Queries, candidateID, searchID interface{}, from, to, changedBy, note string, now time.Time) (database.CandidateStatusChange, error) {
+	_, err := q.SetCandidateSearchStatus(ctx, database.SetCandidateSearchStatusParams{
+		UpdatedAt:   now,
+		Status:      to,
+		CandidateID: candidateID,
+		SearchID:    searchID,
+	})
+	if err != nil {
+		return database.CandidateStatusChange{}, fmt.Errorf("updating stage: %w", err)
+	}
+
+	change, err := q.CreateCandidateStatusChange(ctx, database.CreateCandidateStatusChangeParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		CandidateID: candidateID,
+		SearchID:    searchID,
+		FromStatus:  from,
+		ToStatus:    to,
+		ChangedBy:   changedBy,
+		Note:        sql.NullString{String: note, Valid: note != ""},
+	})
+	if err != nil {
+		return database.CandidateStatusChange{}, fmt.Errorf("recording stage change: %w", err)
+	}
+	return change, nil
+}
@@ -0,0 +1,176 @@
+package candidates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// Every stage has an entry in transitions, every move leads to a known stage,
+// and hiring ends the pipeline
+func TestTransitions(t *testing.T) {
+	if len(transitions) != len(Stages) {
+		t.Errorf("%d stages have transitions, want all %d", len(transitions), len(Stages))
+	}
+	for _, from := range Stages {
+		for _, to := range NextStages(from) {
+			if !ValidStage(to) {
+				t.Errorf("%s moves to unknown stage %q", from, to)
+			}
+			if to == from {
+				t.Errorf("%s moves to itself", from)
+			}
+		}
+	}
+	if next := NextStages(StatusHired); len(next) != 0 {
+		t.Errorf("hired candidates can move to %v", next)
+	}
+	if ValidStage("archived") {
+		t.Error(`"archived" is a valid stage`)
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusNew, StatusShortlisted, true},
+		{StatusShortlisted, StatusNew, true},
+		{StatusApproved, StatusContacted, true},
+		{StatusInterviewing, StatusHired, true},
+		{StatusRejected, StatusReviewing, true},
+		{StatusNew, StatusContacted, false},
+		{StatusContacted, StatusNew, false},
+		{StatusNew, StatusHired, false},
+		{StatusHired, StatusRejected, false},
+		{StatusNew, StatusNew, false},
+		{"archived", StatusNew, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+// newPipelineLink links a new candidate to a new search and returns their IDs
+func newPipelineLink(t *testing.T, queries *database.Queries) (candidateID, searchID interface{}) {
+	t.Helper()
+	ctx := context.Background()
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	candidate, err := queries.CreateCandidate(ctx, database.CreateCandidateParams{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		Name:           "Ann Lee",
+		NormalizedName: "ann lee",
+	})
+	if err != nil {
+		t.Fatalf("CreateCandidate: %v", err)
+	}
+	_, err = queries.LinkCandidateToSearch(ctx, database.LinkCandidateToSearchParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		CandidateID: candidate.ID,
+		SearchID:    search.ID,
+	})
+	if err != nil {
+		t.Fatalf("LinkCandidateToSearch: %v", err)
+	}
+	return candidate.ID, search.ID
+}
+
+// stage returns the candidate's current stage in the search
+func stage(t *testing.T, queries *database.Queries, candidateID, searchID interface{}) string {
+	t.Helper()
+	link, err := queries.GetCandidateSearchLink(context.Background(), database.GetCandidateSearchLinkParams{CandidateID: candidateID, SearchID: searchID})
+	if err != nil {
+		t.Fatalf("GetCandidateSearchLink: %v", err)
+	}
+	return link.Status
+}
+
+// history lists the candidate's stage changes, oldest first, as from>to by whom
+func history(t *testing.T, queries *database.Queries, candidateID, searchID interface{}) string {
+	t.Helper()
+	changes, err := queries.ListCandidateStatusChanges(context.Background(), database.ListCandidateStatusChangesParams{CandidateID: candidateID, SearchID: searchID})
+	if err != nil {
+		t.Fatalf("ListCandidateStatusChanges: %v", err)
+	}
+	var out []string
+	for i := len(changes) - 1; i >= 0; i-- {
+		out = append(out, fmt.Sprintf("%s>%s by %s", changes[i].FromStatus, changes[i].ToStatus, changes[i].ChangedBy))
+	}
+	return strings.Join(out, ", ")
+}
+
+// Allowed moves change the stage and add to the history; refused ones change
+// neither
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	candidateID, searchID := newPipelineLink(t, queries)
+	pipeline := NewPipeline(db, queries)
+
+	change, err := pipeline.Move(ctx, candidateID, searchID, StatusShortlisted, "ana", "strong papers")
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if change.FromStatus != StatusNew || change.ToStatus != StatusShortlisted || change.Note.String != "strong papers" {
+		t.Errorf("recorded %+v, want new to shortlisted with the note", change)
+	}
+
+	_, err = pipeline.Move(ctx, candidateID, searchID, StatusHired, "ana", "")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("shortlisted to hired: %v, want ErrInvalidTransition", err)
+	}
+	if _, err := pipeline.Move(ctx, candidateID, searchID, "archived", "ana", ""); err == nil {
+		t.Error("moving to an unknown stage succeeded")
+	}
+	if _, err := pipeline.Move(ctx, candidateID, searchID, StatusApproved, "", ""); err == nil {
+		t.Error("moving without saying who made the change succeeded")
+	}
+	if _, err := pipeline.Move(ctx, uuid.New(), searchID, StatusApproved, "ana", ""); err == nil {
+		t.Error("moving an unlinked candidate succeeded")
+	}
+
+	if _, err := pipeline.Move(ctx, candidateID, searchID, StatusContacted, "bo", ""); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if got := stage(t, queries, candidateID, searchID); got != StatusContacted {
+		t.Errorf("stage %q, want contacted", got)
+	}
+	if got, want := history(t, queries, candidateID, searchID), "new>shortlisted by ana, shortlisted>contacted by bo"; got != want {
+		t.Errorf("history %q, want %q", got, want)
+	}
+}
+
+// The stage and its history change in one transaction, so a history row that
+// cannot be written leaves the stage as it was
+func TestMoveRollsBackWithoutHistory(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	candidateID, searchID := newPipelineLink(t, queries)
+	_, err := db.ExecContext(ctx, `CREATE TRIGGER refuse_history BEFORE INSERT ON candidate_status_changes BEGIN SELECT RAISE(ABORT, 'history is read-only'); END`)
+	if err != nil {
+		t.Fatalf("creating trigger: %v", err)
+	}
+
+	_, err = NewPipeline(db, queries).Move(ctx, candidateID, searchID, StatusShortlisted, "ana", "")
+	if err == nil || !strings.Contains(err.Error(), "history is read-only") {
+		t.Fatalf("Move: %v, want the history insert to fail", err)
+	}
+	if got := stage(t, queries, candidateID, searchID); got != StatusNew {
+		t.Errorf("stage %q after the failed move, want new", got)
+	}
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/config"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/enrich"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/fulltext"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/scorer"
+	"github.com/jessewalker/reSearch/similar"
+)
+
+// app bundles the dependencies shared by the non-interactive subcommands
+type app struct {
+	cfg      config.Config
+	db       *sql.DB
+	queries  *database.Queries
+	fetcher  *fetcher.Fetcher
+	sizer    *fetcher.Sizer
+	scorer   *scorer.Scorer
+	linker   *candidates.Linker
+	pipeline *candidates.Pipeline
+	enricher *enrich.Enricher
+	profiles *enrich.WebDiscoverer
+	index    *similar.Index
+	fullText *fulltext.Index
+	client   anthropic.Client
+	ledger   *costs.Ledger
+}
+
+// usage describes every subcommand; running with no arguments opens the menu
+const usage = `Usage: reSearch [global flags] [command] [flags]
+
+With no command, reSearch opens the interactive menu.
+
+Commands:
+  search create --description TEXT (--url URL | --categories cs.LG,cs.PL) [--results-per-fetch N]
+                [--budget USD]
+  search list [--limit N] [--offset N]
+  search show <id>
+  search budget <id> --monthly USD | --clear
+  search delete <id> --yes [--remove-orphans]
+  search articles QUERY [--search <id>] [--since DATE] [--until DATE] [--limit N] [--offset N]
+  fetch <id> | --all [--older] [--pages N] [--score]
+  daemon [--interval D] [--concurrency N] [--host-delay D] [--score=BOOL] [--once]
+  candidates list --search <id> | --status STAGE | --category CAT [--limit N] [--offset N]
+  candidates move <candidate-id> --search <id> --to STAGE [--note TEXT] [--by NAME]
+  candidates history <candidate-id> --search <id>
+  candidates duplicates [--min-score F] [--limit N]
+  candidates merge <keep-id> <duplicate-id> --yes [--by NAME]
+  enrich [--search <id>] [--limit N] [--email-domain DOMAIN] [--force]
+  discover --candidate <id> | [--search <id>] [--limit N] [--force]
+  export --search <id> [--format csv|ndjson|vcard] [--min-relevance F] [--status STAGE]
+         [--category CAT] [--output FILE]
+  import candidates <file.csv> [--map FIELD=COLUMN,...] [--search <id>] [--relevance F] [--apply]
+  articles list [--search <id>] [--limit N] [--offset N]
+  articles show <article-id>
+  articles similar <article-id> | --text TEXT | --candidate <id> [--search <id>] [--limit N]
+  articles categorize [--limit N]
+  graph central [--search <id>] [--by degree|betweenness] [--limit N]
+  graph collaborators <candidate-id> [--search <id>] [--limit N]
+  graph export [--search <id>] [--format dot|gexf] [--output FILE]
+  sessions list [--search <id>] [--kind discovery|assistant] [--limit N] [--offset N]
+  sessions resume <session-id>
+  sessions delete <session-id> --yes
+  sessions export <session-id> [--output FILE]
+  costs [--by search|day|model|purpose] [--search <id>] [--since DATE] [--until DATE]
+  migrate up | down | status
+
+Every command except migrate, export, graph export, sessions resume and
+sessions export accepts --json for machine-readable output.
+Search IDs may be abbreviated to any unique prefix, and candidate IDs to a
+prefix unique within the search (or among all candidates, for merge, similar and
+graph). Articles may also be given by arXiv ID, and sessions by a unique ID
+prefix. Pipeline stages are new, reviewing, shortlisted, approved, contacted,
+responded, interviewing, rejected and hired.
+
+Global flags (before the command) override ~/.config/research/config.toml,
+.env and RESEARCH_* environment variables:
+  --config PATH            config file to read
+  --env-file PATH          dotenv file to load (default .env)
+  --db PATH                SQLite database (default research.db)
+  --workspace DIR          directory the assistant's file tools are confined to
+                           (workspace_root in the config file; none by default)
+  --user NAME              name recorded on pipeline moves (default: login name)
+  --model NAME             Anthropic model
+  --max-tokens N           maximum tokens per model response
+  --web-search=BOOL        allow the model to search the web
+  --web-search-max-uses N  maximum web searches per model response
+  --web-search-domains D,D restrict web search to these domains
+  --results-per-fetch N    page size for newly created searches
+  --backfill-pages N       arXiv API pages per backfill
+  --page-delay DURATION    pause between arXiv API pages, e.g. 3s
+`
+
+// runCommand dispatches a subcommand
+func runCommand(ctx context.Context, a *app, args []string) error {
+	switch args[0] {
+	case "search":
+		if len(args) < 2 {
+			return fmt.Errorf("search requires a subcommand (create, list, show, budget, delete, articles)")
+		}
+		switch args[1] {
+		case "create":
+			return a.searchCreate(ctx, args[2:])
+		case "list":
+			return a.searchList(ctx, args[2:])
+		case "show":
+			return a.searchShow(ctx, args[2:])
+		case "budget":
+			return a.searchBudget(ctx, args[2:])
+		case "delete":
+			return a.searchDelete(ctx, args[2:])
+		case "articles":
+			return a.searchArticles(ctx, args[2:])
+		}
+		return fmt.Errorf("unknown search subcommand %q", args[1])
+	case "fetch":
+		return a.fetch(ctx, args[1:])
+	case "daemon":
+		return a.daemon(ctx, args[1:])
+	case "candidates":
+		if len(args) < 2 {
+			return fmt.Errorf("candidates requires a subcommand (list, move, history, duplicates, merge)")
+		}
+		switch args[1] {
+		case "list":
+			return a.candidatesList(ctx, args[2:])
+		case "move":
+			return a.candidatesMove(ctx, args[2:])
+		case "history":
+			return a.candidatesHistory(ctx, args[2:])
+		case "duplicates":
+			return a.candidatesDuplicates(ctx, args[2:])
+		case "merge":
+			return a.candidatesMerge(ctx, args[2:])
+		}
+		return fmt.Errorf("unknown candidates subcommand %q", args[1])
+	case "enrich":
+		return a.enrich(ctx, args[1:])
+	case "discover":
+		return a.discover(ctx, args[1:])
+	case "export":
+		return a.export(ctx, args[1:])
+	case "import":
+		if len(args) < 2 || args[1] != "candidates" {
+			return fmt.Errorf("import requires a subcommand (candidates)")
+		}
+		return a.importCandidates(ctx, args[2:])
+	case "articles":
+		if len(args) < 2 {
+			return fmt.Errorf("articles requires a subcommand (list, show, similar, categorize)")
+		}
+		switch args[1] {
+		case "list":
+			return a.articlesList(ctx, args[2:])
+		case "show":
+			return a.articlesShow(ctx, args[2:])
+		case "similar":
+			return a.articlesSimilar(ctx, args[2:])
+		case "categorize":
+			return a.articlesCategorize(ctx, args[2:])
+		}
+		return fmt.Errorf("unknown articles subcommand %q", args[1])
+	case "graph":
+		if len(args) < 2 {
+			return fmt.Errorf("graph requires a subcommand (central, collaborators, export)")
+		}
+		switch args[1] {
+		case "central":
+			return a.graphCentral(ctx, args[2:])
+		case "collaborators":
+			return a.graphCollaborators(ctx, args[2:])
+		case "export":
+			return a.graphExport(ctx, args[2:])
+		}
+		return fmt.Errorf("unknown graph subcommand %q", args[1])
+	case "sessions":
+		if len(args) < 2 {
+			return fmt.Errorf("sessions requires a subcommand (list, resume, delete, export)")
+		}
+		switch args[1] {
+		case "list":
+			return a.sessionsList(ctx, args[2:])
+		case "resume":
+			return a.sessionsResume(ctx, args[2:])
+		case "delete":
+			return a.sessionsDelete(ctx, args[2:])
+		case "export":
+			return a.sessionsExport(ctx, args[2:])
+		}
+		return fmt.Errorf("unknown sessions subcommand %q", args[1])
+	case "costs":
+		return a.costs(ctx, args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+// resolveSearch finds a search by full ID or by a unique ID prefix
+func (a *app) resolveSearch(ctx context.Context, id string) (database.Search, error) {
+	search, err := a.queries.GetSearchByID(ctx, id)
+	if err == nil {
+		return search, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Search{}, fmt.Errorf("looking up search %s: %w", id, err)
+	}
+
+	searches, err := a.queries.ListAllSearches(ctx)
+	if err != nil {
+		return database.Search{}, fmt.Errorf("listing searches: %w", err)
+	}
+	var matches []database.Search
+	for _, s := range searches {
+		if strings.HasPrefix(fmt.Sprint(s.ID), id) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return database.Search{}, fmt.Errorf("no search with ID %s", id)
+	case 1:
+		return matches[0], nil
+	}
+	return database.Search{}, fmt.Errorf("search ID prefix %s is ambiguous (%d matches)", id, len(matches))
+}
+
+// resolveArticle finds an article by full ID, arXiv ID or abstract URL, or a
+// unique ID prefix
+func (a *app) resolveArticle(ctx context.Context, id string) (database.Article, error) {
+	article, err := a.queries.GetArticleByID(ctx, id)
+	if err == nil {
+		return article, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Article{}, fmt.Errorf("looking up article %s: %w", id, err)
+	}
+
+	if arxivID := fetcher.ExtractArxivID(id); arxivID != "" {
+		article, err := a.queries.GetArticleByURL(ctx, fetcher.AbstractURL(arxivID))
+		if err == nil {
+			return article, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return database.Article{}, fmt.Errorf("looking up article %s: %w", id, err)
+		}
+	}
+
+	matches, err := a.queries.ListArticlesByIDPrefix(ctx, sql.NullString{String: id, Valid: true})
+	if err != nil {
+		return database.Article{}, fmt.Errorf("looking up article %s: %w", id, err)
+	}
+	switch len(matches) {
+	case 0:
+		return database.Article{}, fmt.Errorf("no article with ID %s", id)
+	case 1:
+		return matches[0], nil
+	}
+	return database.Article{}, fmt.Errorf("article ID prefix %s is ambiguous", id)
+}
+
+// resolveSession finds a saved conversation by full ID or a unique ID prefix
+func (a *app) resolveSession(ctx context.Context, id string) (database.AgentSession, error) {
+	session, err := a.queries.GetAgentSession(ctx, id)
+	if err == nil {
+		return session, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.AgentSession{}, fmt.Errorf("looking up session %s: %w", id, err)
+	}
+
+	matches, err := a.queries.ListAgentSessionsByIDPrefix(ctx, sql.NullString{String: id, Valid: true})
+	if err != nil {
+		return database.AgentSession{}, fmt.Errorf("looking up session %s: %w", id, err)
+	}
+	switch len(matches) {
+	case 0:
+		return database.AgentSession{}, fmt.Errorf("no session with ID %s", id)
+	case 1:
+		return matches[0], nil
+	}
+	return database.AgentSession{}, fmt.Errorf("session ID prefix %s is ambiguous", id)
+}
+
+// resolveAnyCandidate finds a candidate by full ID or by an ID prefix unique
+// among all candidates
+func (a *app) resolveAnyCandidate(ctx context.Context, id string) (database.Candidate, error) {
+	candidate, err := a.queries.GetCandidateByID(ctx, id)
+	if err == nil {
+		return candidate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.Candidate{}, fmt.Errorf("looking up candidate %s: %w", id, err)
+	}
+
+	all, err := a.queries.ListAllCandidates(ctx)
+	if err != nil {
+		return database.Candidate{}, fmt.Errorf("listing candidates: %w", err)
+	}
+	var matches []database.Candidate
+	for _, c := range all {
+		if strings.HasPrefix(fmt.Sprint(c.ID), id) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return database.Candidate{}, fmt.Errorf("no candidate with ID %s", id)
+	case 1:
+		return matches[0], nil
+	}
+	return database.Candidate{}, fmt.Errorf("candidate ID prefix %s is ambiguous (%d matches)", id, len(matches))
+}
+
+// resolveCandidate finds one of the search's candidates by full ID or by a
+// unique ID prefix
+func (a *app) resolveCandidate(ctx context.Context, search database.Search, id string) (database.ListCandidatesBySearchRow, error) {
+	total, err := a.queries.CountCandidatesBySearch(ctx, search.ID)
+	if err != nil {
+		return database.ListCandidatesBySearchRow{}, fmt.Errorf("counting candidates: %w", err)
+	}
+	rows, err := a.queries.ListCandidatesBySearch(ctx, database.ListCandidatesBySearchParams{
+		SearchID: search.ID,
+		Limit:    total,
+	})
+	if err != nil {
+		return database.ListCandidatesBySearchRow{}, fmt.Errorf("listing candidates: %w", err)
+	}
+	var matches []database.ListCandidatesBySearchRow
+	for _, row := range rows {
+		if fmt.Sprint(row.ID) == id {
+			return row, nil
+		}
+		if strings.HasPrefix(fmt.Sprint(row.ID), id) {
+			matches = append(matches, row)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return database.ListCandidatesBySearchRow{}, fmt.Errorf("no candidate with ID %s in search %v", id, search.ID)
+	case 1:
+		return matches[0], nil
+	}
+	return database.ListCandidatesBySearchRow{}, fmt.Errorf("candidate ID prefix %s is ambiguous (%d matches)", id, len(matches))
+}
+
+// parseFlags parses flags that may appear before or after positional
+// arguments, returning the positional arguments in order
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", fs.Name(), err)
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// formatNullTime formats a nullable timestamp, or "Never" if it is unset
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return "Never"
+	}
+	return t.Time.Format("2006-01-02 15:04")
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullFloatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/similar"
+)
+
+// articleJSON is the --json representation of an article
+type articleJSON struct {
+	ID         interface{} `json:"id"`
+	SearchID   interface{} `json:"search_id"`
+	URL        string      `json:"url"`
+	Title      string      `json:"title"`
+	Authors    string      `json:"authors"`
+	Summary    string      `json:"summary"`
+	Categories []string    `json:"categories"`
+	FetchedAt  time.Time   `json:"fetched_at"`
+	// Similar is only set by articles show
+	Similar []similarArticleJSON `json:"similar,omitempty"`
+}
+
+// similarArticleJSON is the --json representation of a "more like this" match
+type similarArticleJSON struct {
+	ID       interface{} `json:"id"`
+	SearchID interface{} `json:"search_id"`
+	URL      string      `json:"url"`
+	Title    string      `json:"title"`
+	Authors  string      `json:"authors"`
+	Score    float64     `json:"score"`
+}
+
+func (a *app) articlesList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("articles list", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only list articles of this search")
+	limit := fs.Int64("limit", 20, "maximum number of articles")
+	offset := fs.Int64("offset", 0, "number of articles to skip (requires --search)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var articles []database.Article
+	var err error
+	if *searchID != "" {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		articles, err = a.queries.ListArticlesBySearch(ctx, database.ListArticlesBySearchParams{
+			SearchID: search.ID,
+			Limit:    *limit,
+			Offset:   *offset,
+		})
+		if err != nil {
+			return fmt.Errorf("listing articles: %w", err)
+		}
+	} else {
+		articles, err = a.queries.ListRecentArticles(ctx, *limit)
+		if err != nil {
+			return fmt.Errorf("listing articles: %w", err)
+		}
+	}
+
+	if *asJSON {
+		out := make([]articleJSON, len(articles))
+		for i, article := range articles {
+			categories, err := a.queries.ListArticleCategories(ctx, article.ID)
+			if err != nil {
+				return fmt.Errorf("reading article categories: %w", err)
+			}
+			out[i] = articleJSON{
+				ID:         article.ID,
+				SearchID:   article.SearchID,
+				URL:        article.ArticleUrl,
+				Title:      article.ArticleTitle,
+				Authors:    article.ArticleAuthors,
+				Summary:    article.ArticleSummary,
+				Categories: []string{},
+				FetchedAt:  article.FetchedAt,
+			}
+			for _, category := range categories {
+				out[i].Categories = append(out[i].Categories, category.ArxivCategory)
+			}
+		}
+		return printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FETCHED\tURL\tTITLE")
+	for _, article := range articles {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", article.FetchedAt.Format("2006-01-02"), article.ArticleUrl, article.ArticleTitle)
+	}
+	return w.Flush()
+}
+
+// articlesShow prints an article with the papers most like it
+func (a *app) articlesShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("articles show", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("articles show requires an article ID")
+	}
+
+	article, err := a.resolveArticle(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	search, err := a.queries.GetSearchByID(ctx, article.SearchID)
+	if err != nil {
+		return fmt.Errorf("retrieving search: %w", err)
+	}
+	categories, err := a.queries.ListArticleCategories(ctx, article.ID)
+	if err != nil {
+		return fmt.Errorf("reading article categories: %w", err)
+	}
+	model, err := a.index.Load(ctx)
+	if err != nil {
+		return err
+	}
+	similarArticles, err := a.similarArticles(ctx, model.SimilarToArticles([]string{fmt.Sprint(article.ID)}, similar.Options{Limit: 5}))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out := articleJSON{
+			ID:         article.ID,
+			SearchID:   article.SearchID,
+			URL:        article.ArticleUrl,
+			Title:      article.ArticleTitle,
+			Authors:    article.ArticleAuthors,
+			Summary:    article.ArticleSummary,
+			Categories: []string{},
+			FetchedAt:  article.FetchedAt,
+			Similar:    similarArticles,
+		}
+		for _, category := range categories {
+			out.Categories = append(out.Categories, category.ArxivCategory)
+		}
+		return printJSON(out)
+	}
+
+	var names []string
+	for _, category := range categories {
+		names = append(names, category.ArxivCategory)
+	}
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+	fmt.Printf("ID:          %v\n", article.ID)
+	fmt.Printf("Title:       %s\n", article.ArticleTitle)
+	fmt.Printf("URL:         %s\n", article.ArticleUrl)
+	fmt.Printf("Authors:     %s\n", article.ArticleAuthors)
+	fmt.Printf("Search:      %s\n", search.Description)
+	fmt.Printf("Fetched:     %s\n", article.FetchedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Categories:  %s\n", strings.Join(names, ", "))
+	fmt.Printf("\n%s\n", article.ArticleSummary)
+	if len(similarArticles) > 0 {
+		fmt.Println("\nSimilar articles:")
+		for _, match := range similarArticles {
+			fmt.Printf("  %.2f  %s\n        %s\n", match.Score, match.Title, match.URL)
+		}
+	}
+	return nil
+}
+
+// articlesSimilar lists the stored articles most like an article, a
+// candidate's papers or a free-text description
+func (a *app) articlesSimilar(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("articles similar", flag.ContinueOnError)
+	text := fs.String("text", "", "describe the papers wanted instead of naming an article")
+	candidateID := fs.String("candidate", "", "find papers like this candidate's instead of naming an article")
+	searchID := fs.String("search", "", "only list articles of this search")
+	limit := fs.Int("limit", similar.DefaultLimit, "maximum number of articles")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	given := len(positional)
+	if *text != "" {
+		given++
+	}
+	if *candidateID != "" {
+		given++
+	}
+	if given != 1 {
+		return fmt.Errorf("articles similar requires exactly one of an article ID, --text or --candidate")
+	}
+
+	opts := similar.Options{Limit: *limit}
+	if *searchID != "" {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		opts.SearchID = fmt.Sprint(search.ID)
+	}
+	model, err := a.index.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var matches []similar.Match
+	switch {
+	case *text != "":
+		matches = model.SimilarToText(*text, opts)
+	case *candidateID != "":
+		candidate, err := a.resolveAnyCandidate(ctx, *candidateID)
+		if err != nil {
+			return err
+		}
+		papers, err := a.queries.GetCandidateDiscoveryArticles(ctx, candidate.ID)
+		if err != nil {
+			return fmt.Errorf("retrieving papers: %w", err)
+		}
+		if len(papers) == 0 {
+			return fmt.Errorf("%s has no papers to compare with", candidate.Name)
+		}
+		ids := make([]string, len(papers))
+		for i, paper := range papers {
+			ids[i] = fmt.Sprint(paper.ID)
+		}
+		matches = model.SimilarToArticles(ids, opts)
+	default:
+		article, err := a.resolveArticle(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+		matches = model.SimilarToArticles([]string{fmt.Sprint(article.ID)}, opts)
+	}
+
+	out, err := a.similarArticles(ctx, matches)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(out)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tID\tURL\tTITLE")
+	for _, match := range out {
+		fmt.Fprintf(w, "%.2f\t%v\t%s\t%s\n", match.Score, match.ID, match.URL, match.Title)
+	}
+	return w.Flush()
+}
+
+// similarArticles looks up the articles behind similarity matches
+func (a *app) similarArticles(ctx context.Context, matches []similar.Match) ([]similarArticleJSON, error) {
+	out := []similarArticleJSON{}
+	for _, match := range matches {
+		article, err := a.queries.GetArticleByID(ctx, match.ArticleID)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving article %s: %w", match.ArticleID, err)
+		}
+		out = append(out, similarArticleJSON{
+			ID:       article.ID,
+			SearchID: article.SearchID,
+			URL:      article.ArticleUrl,
+			Title:    article.ArticleTitle,
+			Authors:  article.ArticleAuthors,
+			Score:    match.Score,
+		})
+	}
+	return out, nil
+}
+
+func (a *app) articlesCategorize(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("articles categorize", flag.ContinueOnError)
+	limit := fs.Int64("limit", 1000, "maximum number of articles to look up")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	articles, err := a.queries.ListUncategorizedArticles(ctx, *limit)
+	if err != nil {
+		return fmt.Errorf("listing articles: %w", err)
+	}
+	result, err := a.fetcher.FetchCategories(ctx, articles, a.cfg.Fetch.PageDelay)
+	if err != nil && (result == nil || len(result.Categorized) == 0) {
+		return err
+	}
+
+	// Pass the new categories on to the articles' authors
+	authors := map[string]bool{}
+	for _, article := range result.Categorized {
+		linked, copyErr := a.queries.GetCandidatesByArticle(ctx, article.ID)
+		if copyErr != nil {
+			return fmt.Errorf("listing candidates: %w", copyErr)
+		}
+		for _, candidate := range linked {
+			if _, copyErr := candidates.CopyArticleCategories(ctx, a.queries, candidate.ID, article.ID); copyErr != nil {
+				return copyErr
+			}
+			authors[fmt.Sprint(candidate.ID)] = true
+		}
+	}
+
+	if *asJSON {
+		if printErr := printJSON(map[string]interface{}{
+			"articles":    len(articles),
+			"categorized": len(result.Categorized),
+			"missing":     result.Missing,
+			"candidates":  len(authors),
+		}); printErr != nil {
+			return printErr
+		}
+		return err
+	}
+	fmt.Printf("%d of %d articles categorized (%d not found on arXiv), categories copied to %d authors\n",
+		len(result.Categorized), len(articles), result.Missing, len(authors))
+	return err
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// candidateJSON is the --json representation of a candidate within a search
+type candidateJSON struct {
+	ID             interface{} `json:"id"`
+	Name           string      `json:"name"`
+	LinkedinURL    *string     `json:"linkedin_url"`
+	GithubURL      *string     `json:"github_url"`
+	RelevanceScore *float64    `json:"relevance_score"`
+	Notes          *string     `json:"notes"`
+	Status         string      `json:"status"`
+	CreatedAt      time.Time   `json:"created_at"`
+	// StatusUpdatedAt, SearchID and Search are only set when listing a stage across searches
+	StatusUpdatedAt *time.Time  `json:"status_updated_at,omitempty"`
+	SearchID        interface{} `json:"search_id,omitempty"`
+	Search          string      `json:"search,omitempty"`
+}
+
+func (a *app) candidatesList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("candidates list", flag.ContinueOnError)
+	searchID := fs.String("search", "", "search ID (required unless --status or --category is given)")
+	status := fs.String("status", "", "only list candidates at this pipeline stage")
+	category := fs.String("category", "", "list candidates with this arXiv category across all searches, e.g. cs.LG")
+	limit := fs.Int64("limit", 50, "maximum number of candidates")
+	offset := fs.Int64("offset", 0, "number of candidates to skip")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *status != "" && !candidates.ValidStage(*status) {
+		return fmt.Errorf("unknown stage %q (expected one of %s)", *status, strings.Join(candidates.Stages, ", "))
+	}
+	if *category != "" {
+		if *searchID != "" || *status != "" {
+			return fmt.Errorf("--category cannot be combined with --search or --status")
+		}
+		return a.candidatesInCategory(ctx, *category, *limit, *offset, *asJSON)
+	}
+	if *searchID == "" {
+		if *status == "" {
+			return fmt.Errorf("--search, --status or --category is required")
+		}
+		return a.candidatesAtStage(ctx, *status, *limit, *offset, *asJSON)
+	}
+
+	search, err := a.resolveSearch(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	var rows []database.ListCandidatesBySearchRow
+	if *status == "" {
+		rows, err = a.queries.ListCandidatesBySearch(ctx, database.ListCandidatesBySearchParams{
+			SearchID: search.ID,
+			Limit:    *limit,
+			Offset:   *offset,
+		})
+	} else {
+		var staged []database.ListCandidatesBySearchAndStatusRow
+		staged, err = a.queries.ListCandidatesBySearchAndStatus(ctx, database.ListCandidatesBySearchAndStatusParams{
+			SearchID: search.ID,
+			Status:   *status,
+			Limit:    *limit,
+			Offset:   *offset,
+		})
+		for _, row := range staged {
+			rows = append(rows, database.ListCandidatesBySearchRow(row))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("listing candidates: %w", err)
+	}
+
+	if *asJSON {
+		out := make([]candidateJSON, len(rows))
+		for i, c := range rows {
+			out[i] = candidateJSON{
+				ID:             c.ID,
+				Name:           c.Name,
+				LinkedinURL:    nullStringPtr(c.LinkedinUrl),
+				GithubURL:      nullStringPtr(c.GithubUrl),
+				RelevanceScore: nullFloatPtr(c.RelevanceScore),
+				Notes:          nullStringPtr(c.Notes),
+				Status:         c.Status,
+				CreatedAt:      c.CreatedAt,
+			}
+		}
+		return printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tRELEVANCE\tSTATUS\tGITHUB\tLINKEDIN")
+	for _, c := range rows {
+		relevance := "-"
+		if c.RelevanceScore.Valid {
+			relevance = fmt.Sprintf("%.2f", c.RelevanceScore.Float64)
+		}
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.Name, relevance, c.Status, c.GithubUrl.String, c.LinkedinUrl.String)
+	}
+	return w.Flush()
+}
+
+// candidatesAtStage lists every candidate at a pipeline stage across all
+// searches, those that have waited longest first
+func (a *app) candidatesAtStage(ctx context.Context, status string, limit, offset int64, asJSON bool) error {
+	rows, err := a.queries.ListCandidatesByStatus(ctx, database.ListCandidatesByStatusParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return fmt.Errorf("listing candidates: %w", err)
+	}
+
+	if asJSON {
+		out := make([]candidateJSON, len(rows))
+		for i, c := range rows {
+			out[i] = candidateJSON{
+				ID:              c.ID,
+				Name:            c.Name,
+				LinkedinURL:     nullStringPtr(c.LinkedinUrl),
+				GithubURL:       nullStringPtr(c.GithubUrl),
+				RelevanceScore:  nullFloatPtr(c.RelevanceScore),
+				Status:          c.Status,
+				StatusUpdatedAt: &c.StatusUpdatedAt,
+				SearchID:        c.SearchID,
+				Search:          c.SearchDescription,
+				CreatedAt:       c.CreatedAt,
+			}
+		}
+		return printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSEARCH\tRELEVANCE\tSINCE")
+	for _, c := range rows {
+		relevance := "-"
+		if c.RelevanceScore.Valid {
+			relevance = fmt.Sprintf("%.2f", c.RelevanceScore.Float64)
+		}
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%s\n", c.ID, c.Name, c.SearchDescription, relevance, c.StatusUpdatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// candidatesInCategory lists every candidate with an arXiv category, most
+// recently discovered first
+func (a *app) candidatesInCategory(ctx context.Context, category string, limit, offset int64, asJSON bool) error {
+	rows, err := a.queries.GetCandidatesByCategory(ctx, database.GetCandidatesByCategoryParams{
+		ArxivCategory: category,
+		Limit:         limit,
+		Offset:        offset,
+	})
+	if err != nil {
+		return fmt.Errorf("listing candidates: %w", err)
+	}
+
+	if asJSON {
+		out := make([]candidateJSON, len(rows))
+		for i, c := range rows {
+			out[i] = candidateJSON{
+				ID:          c.ID,
+				Name:        c.Name,
+				LinkedinURL: nullStringPtr(c.LinkedinUrl),
+				GithubURL:   nullStringPtr(c.GithubUrl),
+				CreatedAt:   c.CreatedAt,
+			}
+		}
+		return printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tGITHUB\tLINKEDIN\tFOUND")
+	for _, c := range rows {
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%s\n", c.ID, c.Name, c.GithubUrl.String, c.LinkedinUrl.String, c.CreatedAt.Format("2006-01-02"))
+	}
+	return w.Flush()
+}
+
+// statusChangeJSON is the --json representation of a pipeline move
+type statusChangeJSON struct {
+	CandidateID interface{} `json:"candidate_id"`
+	SearchID    interface{} `json:"search_id"`
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	ChangedBy   string      `json:"changed_by"`
+	Note        *string     `json:"note"`
+	At          time.Time   `json:"at"`
+}
+
+func toStatusChangeJSON(change database.CandidateStatusChange) statusChangeJSON {
+	return statusChangeJSON{
+		CandidateID: change.CandidateID,
+		SearchID:    change.SearchID,
+		From:        change.FromStatus,
+		To:          change.ToStatus,
+		ChangedBy:   change.ChangedBy,
+		Note:        nullStringPtr(change.Note),
+		At:          change.CreatedAt,
+	}
+}
+
+func (a *app) candidatesMove(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("candidates move", flag.ContinueOnError)
+	searchID := fs.String("search", "", "search ID (required)")
+	to := fs.String("to", "", "stage to move the candidate to (required)")
+	note := fs.String("note", "", "reason for the move")
+	by := fs.String("by", a.cfg.User, "who is making the change")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("candidates move requires a candidate ID")
+	}
+	if *searchID == "" || *to == "" {
+		return fmt.Errorf("--search and --to are required")
+	}
+
+	search, err := a.resolveSearch(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	candidate, err := a.resolveCandidate(ctx, search, positional[0])
+	if err != nil {
+		return err
+	}
+	change, err := a.pipeline.Move(ctx, candidate.ID, search.ID, *to, *by, *note)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(toStatusChangeJSON(change))
+	}
+	fmt.Printf("Moved %s from %s to %s\n", candidate.Name, change.FromStatus, change.ToStatus)
+	return nil
+}
+
+func (a *app) candidatesHistory(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("candidates history", flag.ContinueOnError)
+	searchID := fs.String("search", "", "search ID (required)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("candidates history requires a candidate ID")
+	}
+	if *searchID == "" {
+		return fmt.Errorf("--search is required")
+	}
+
+	search, err := a.resolveSearch(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	candidate, err := a.resolveCandidate(ctx, search, positional[0])
+	if err != nil {
+		return err
+	}
+	changes, err := a.queries.ListCandidateStatusChanges(ctx, database.ListCandidateStatusChangesParams{
+		CandidateID: candidate.ID,
+		SearchID:    search.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+
+	if *asJSON {
+		out := make([]statusChangeJSON, len(changes))
+		for i, change := range changes {
+			out[i] = toStatusChangeJSON(change)
+		}
+		return printJSON(out)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("%s has not been moved since being found.\n", candidate.Name)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AT\tFROM\tTO\tBY\tNOTE")
+	for _, change := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", change.CreatedAt.Format("2006-01-02 15:04"),
+			change.FromStatus, change.ToStatus, change.ChangedBy, change.Note.String)
+	}
+	return w.Flush()
+}
+
+// duplicateJSON is the --json representation of a merge proposal
+type duplicateJSON struct {
+	KeepID   interface{} `json:"keep_id"`
+	KeepName string      `json:"keep_name"`
+	DropID   interface{} `json:"duplicate_id"`
+	DropName string      `json:"duplicate_name"`
+	Score    float64     `json:"score"`
+	Reasons  []string    `json:"reasons"`
+}
+
+func (a *app) candidatesDuplicates(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("candidates duplicates", flag.ContinueOnError)
+	minScore := fs.Float64("min-score", candidates.DefaultMinDuplicateScore, "only propose pairs scoring at least this")
+	limit := fs.Int("limit", 50, "maximum number of proposals")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	pairs, err := candidates.NewDeduper(a.db, a.queries).FindDuplicates(ctx, *minScore)
+	if err != nil {
+		return err
+	}
+	if len(pairs) > *limit {
+		pairs = pairs[:*limit]
+	}
+
+	if *asJSON {
+		out := make([]duplicateJSON, len(pairs))
+		for i, pair := range pairs {
+			out[i] = duplicateJSON{
+				KeepID:   pair.Keep.ID,
+				KeepName: pair.Keep.Name,
+				DropID:   pair.Drop.ID,
+				DropName: pair.Drop.Name,
+				Score:    pair.Score,
+				Reasons:  pair.Reasons,
+			}
+		}
+		return printJSON(out)
+	}
+
+	if len(pairs) == 0 {
+		fmt.Println("No likely duplicates found.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tKEEP\tDUPLICATE\tREASONS")
+	for _, pair := range pairs {
+		fmt.Fprintf(w, "%.2f\t%s (%v)\t%s (%v)\t%s\n", pair.Score, pair.Keep.Name, pair.Keep.ID,
+			pair.Drop.Name, pair.Drop.ID, strings.Join(pair.Reasons, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println("\nMerge a pair with: reSearch candidates merge <keep-id> <duplicate-id> --yes")
+	return nil
+}
+
+func (a *app) candidatesMerge(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("candidates merge", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "confirm the merge")
+	by := fs.String("by", a.cfg.User, "who is making the merge, recorded on any stage change")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("candidates merge requires the ID to keep and the duplicate's ID")
+	}
+	if !*yes {
+		return fmt.Errorf("refusing to merge without --yes")
+	}
+
+	keep, err := a.resolveAnyCandidate(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	drop, err := a.resolveAnyCandidate(ctx, positional[1])
+	if err != nil {
+		return err
+	}
+	result, err := candidates.NewDeduper(a.db, a.queries).Merge(ctx, keep.ID, drop.ID, *by)
+	if err != nil {
+		return fmt.Errorf("merging candidates (no changes were made): %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{
+			"id":             result.Candidate.ID,
+			"name":           result.Candidate.Name,
+			"merged_id":      drop.ID,
+			"articles":       result.Articles,
+			"searches":       result.Searches,
+			"categories":     result.Categories,
+			"enrichments":    result.Enrichments,
+			"profile_links":  result.ProfileLinks,
+			"status_changes": result.StatusChanges,
+			"conflicts":      result.Conflicts,
+		})
+	}
+	fmt.Printf("Merged %s (%v) into %s (%v): moved %d articles, %d search links, %d categories, %d enrichments, %d profile links and %d pipeline changes\n",
+		drop.Name, drop.ID, result.Candidate.Name, result.Candidate.ID, result.Articles, result.Searches,
+		result.Categories, result.Enrichments, result.ProfileLinks, result.StatusChanges)
+	if len(result.Conflicts) > 0 {
+		fmt.Println("Not kept from the duplicate:")
+		for _, conflict := range result.Conflicts {
+			fmt.Printf("  %s\n", conflict)
+		}
+	}
+	return nil
+}
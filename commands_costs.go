@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jessewalker/reSearch/costs"
+)
+
+// costLineJSON is the --json representation of a line of the costs report
+type costLineJSON struct {
+	Group                    string   `json:"group"`
+	Description              string   `json:"description,omitempty"`
+	Calls                    int64    `json:"calls"`
+	InputTokens              int64    `json:"input_tokens"`
+	OutputTokens             int64    `json:"output_tokens"`
+	CacheCreationInputTokens int64    `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64    `json:"cache_read_input_tokens"`
+	WebSearchRequests        int64    `json:"web_search_requests"`
+	AvgLatencyMs             int64    `json:"avg_latency_ms"`
+	CostUSD                  float64  `json:"cost_usd"`
+	UnpricedModels           []string `json:"unpriced_models,omitempty"`
+}
+
+// costs reports what model calls cost, grouped by search, day, model or purpose
+func (a *app) costs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("costs", flag.ContinueOnError)
+	by := fs.String("by", costs.BySearch, "group by search, day, model or purpose")
+	searchID := fs.String("search", "", "only count calls made for this search")
+	since := fs.String("since", "", "only count calls made on or after this date (YYYY-MM-DD, UTC)")
+	until := fs.String("until", "", "only count calls made on or before this date (YYYY-MM-DD, UTC)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("costs takes no arguments")
+	}
+
+	opts := costs.ReportOptions{By: *by}
+	if *searchID != "" {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		opts.SearchID = search.ID
+	}
+	if *since != "" {
+		day, err := time.Parse("2006-01-02", *since)
+		if err != nil {
+			return fmt.Errorf("invalid --since date %q (expected YYYY-MM-DD)", *since)
+		}
+		opts.Since = day
+	}
+	if *until != "" {
+		day, err := time.Parse("2006-01-02", *until)
+		if err != nil {
+			return fmt.Errorf("invalid --until date %q (expected YYYY-MM-DD)", *until)
+		}
+		opts.Until = day.AddDate(0, 0, 1)
+	}
+
+	lines, total, err := a.ledger.Report(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out := make([]costLineJSON, len(lines))
+		for i, line := range lines {
+			out[i] = toCostLineJSON(line)
+		}
+		return printJSON(map[string]interface{}{
+			"by":    *by,
+			"lines": out,
+			"total": toCostLineJSON(total),
+		})
+	}
+
+	if len(lines) == 0 {
+		fmt.Println("No LLM calls recorded.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if *by == costs.BySearch {
+		fmt.Fprint(w, "SEARCH\tDESCRIPTION\t")
+	} else {
+		fmt.Fprintf(w, "%s\t", strings.ToUpper(*by))
+	}
+	fmt.Fprintln(w, "CALLS\tINPUT\tOUTPUT\tCACHE WRITE\tCACHE READ\tWEB SEARCHES\tAVG LATENCY\tCOST")
+	printCostLine := func(group, description string, line costs.Line) {
+		fmt.Fprintf(w, "%s\t", group)
+		if *by == costs.BySearch {
+			fmt.Fprintf(w, "%s\t", description)
+		}
+		cost := costs.FormatUSD(line.Cost)
+		if len(line.Unpriced) > 0 {
+			cost += "*"
+		}
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", line.Calls, line.Input, line.Output,
+			line.CacheWrite, line.CacheRead, line.WebSearches, averageLatency(line.Tokens), cost)
+	}
+	for _, line := range lines {
+		group := line.Key
+		if group == "" {
+			group = "(none)"
+		}
+		printCostLine(group, line.Label, line)
+	}
+	printCostLine("TOTAL", "", total)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(total.Unpriced) > 0 {
+		fmt.Printf("\n* No price for %s, so its tokens are not counted. Add prices under [costs.prices] in the config file.\n",
+			strings.Join(total.Unpriced, ", "))
+	}
+	return nil
+}
+
+// toCostLineJSON converts a line of the costs report into its JSON representation
+func toCostLineJSON(line costs.Line) costLineJSON {
+	return costLineJSON{
+		Group:                    line.Key,
+		Description:              line.Label,
+		Calls:                    line.Calls,
+		InputTokens:              line.Input,
+		OutputTokens:             line.Output,
+		CacheCreationInputTokens: line.CacheWrite,
+		CacheReadInputTokens:     line.CacheRead,
+		WebSearchRequests:        line.WebSearches,
+		AvgLatencyMs:             averageLatency(line.Tokens).Milliseconds(),
+		CostUSD:                  line.Cost,
+		UnpricedModels:           line.Unpriced,
+	}
+}
+
+// averageLatency is the mean time a call took, to the millisecond
+func averageLatency(t costs.Tokens) time.Duration {
+	if t.Calls == 0 {
+		return 0
+	}
+	return (t.Latency / time.Duration(t.Calls)).Round(time.Millisecond)
+}
+
+// setBudgetJSON adds a search's spend this month to its JSON representation
+func setBudgetJSON(out *searchJSON, budget costs.Budget) {
+	exceeded := budget.Exceeded()
+	out.SpentThisMonth = &budget.Spent
+	out.BudgetExceeded = &exceeded
+}
+
+// formatBudget describes a search's spend this month against its budget
+func formatBudget(budget costs.Budget) string {
+	var text string
+	switch {
+	case !budget.Set:
+		text = costs.FormatUSD(budget.Spent) + " this month (no budget)"
+	case budget.Exceeded():
+		text = fmt.Sprintf("%s of %s this month, LLM work paused", costs.FormatUSD(budget.Spent), costs.FormatUSD(budget.Limit))
+	default:
+		text = fmt.Sprintf("%s of %s this month", costs.FormatUSD(budget.Spent), costs.FormatUSD(budget.Limit))
+	}
+	if len(budget.Unpriced) > 0 {
+		text += fmt.Sprintf(", not counting %s (no price)", strings.Join(budget.Unpriced, ", "))
+	}
+	return text
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// enrichJSON is the --json representation of a GitHub match
+type enrichJSON struct {
+	CandidateID interface{} `json:"candidate_id"`
+	Name        string      `json:"name"`
+	Login       string      `json:"github_login,omitempty"`
+	ProfileURL  string      `json:"github_url,omitempty"`
+	Confidence  float64     `json:"confidence"`
+	Evidence    []string    `json:"evidence"`
+	Applied     bool        `json:"applied"`
+}
+
+func (a *app) enrich(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("enrich", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only enrich candidates of this search")
+	limit := fs.Int64("limit", 20, "maximum number of candidates to look up")
+	emailDomain := fs.String("email-domain", "", "institution domain to prefer, e.g. mit.edu")
+	force := fs.Bool("force", false, "look up candidates again even if already enriched (requires --search)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("enrich takes no arguments")
+	}
+	if *force && *searchID == "" {
+		return fmt.Errorf("--force requires --search")
+	}
+
+	var list []database.Candidate
+	if *searchID == "" {
+		list, err = a.queries.ListUnenrichedCandidates(ctx, *limit)
+		if err != nil {
+			return fmt.Errorf("listing candidates: %w", err)
+		}
+	} else {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		if list, err = a.candidatesToEnrich(ctx, search, *limit, *force); err != nil {
+			return fmt.Errorf("listing candidates: %w", err)
+		}
+	}
+
+	// A rate-limited run still reports, and keeps, the lookups that finished
+	result, enrichErr := a.enricher.EnrichCandidates(ctx, list, *emailDomain)
+
+	if *asJSON {
+		out := make([]enrichJSON, len(result.Matches))
+		for i, m := range result.Matches {
+			out[i] = enrichJSON{
+				CandidateID: m.CandidateID,
+				Name:        m.Name,
+				Login:       m.Login,
+				ProfileURL:  m.ProfileURL,
+				Confidence:  m.Confidence,
+				Evidence:    m.Evidence,
+				Applied:     m.Applied,
+			}
+		}
+		if err := printJSON(out); err != nil {
+			return err
+		}
+		return enrichErr
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CANDIDATE\tGITHUB\tCONFIDENCE\tAPPLIED")
+	for _, m := range result.Matches {
+		login := m.Login
+		if login == "" {
+			login = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.2f\t%t\n", m.Name, login, m.Confidence, m.Applied)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d candidates looked up, %d with a possible match, %d github_url set\n",
+		result.Candidates, result.Matched, result.Applied)
+	return enrichErr
+}
+
+// candidatesToEnrich picks a search's candidates for enrichment: those never
+// looked up, or with force every candidate, most relevant first
+func (a *app) candidatesToEnrich(ctx context.Context, search database.Search, limit int64, force bool) ([]database.Candidate, error) {
+	if !force {
+		return a.queries.ListUnenrichedCandidatesBySearch(ctx, database.ListUnenrichedCandidatesBySearchParams{
+			SearchID: search.ID,
+			Limit:    limit,
+		})
+	}
+
+	rows, err := a.queries.ListCandidatesBySearch(ctx, database.ListCandidatesBySearchParams{
+		SearchID: search.ID,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]database.Candidate, len(rows))
+	for i, row := range rows {
+		list[i] = database.Candidate{
+			ID:             row.ID,
+			CreatedAt:      row.CreatedAt,
+			UpdatedAt:      row.UpdatedAt,
+			Name:           row.Name,
+			LinkedinUrl:    row.LinkedinUrl,
+			GithubUrl:      row.GithubUrl,
+			NormalizedName: row.NormalizedName,
+		}
+	}
+	return list, nil
+}
+
+// discoverJSON is the --json representation of a web search for profiles
+type discoverJSON struct {
+	CandidateID interface{}       `json:"candidate_id"`
+	Name        string            `json:"name"`
+	Searches    int               `json:"searches"`
+	Links       []profileLinkJSON `json:"links"`
+	Applied     []string          `json:"applied"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// profileLinkJSON is the --json representation of a discovered profile link
+type profileLinkJSON struct {
+	Kind        string `json:"kind"`
+	URL         string `json:"url"`
+	SourceURL   string `json:"source_url,omitempty"`
+	SourceTitle string `json:"source_title,omitempty"`
+	CitedText   string `json:"cited_text,omitempty"`
+	Backed      bool   `json:"backed"`
+}
+
+func (a *app) discover(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	candidateID := fs.String("candidate", "", "look up this candidate only")
+	searchID := fs.String("search", "", "only look up candidates of this search")
+	limit := fs.Int64("limit", 5, "maximum number of candidates to look up")
+	force := fs.Bool("force", false, "look up candidates again even if already searched for (requires --search)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("discover takes no arguments")
+	}
+	if *force && *searchID == "" {
+		return fmt.Errorf("--force requires --search")
+	}
+
+	// Lookups made for a search count against its monthly budget
+	var list []database.Candidate
+	var forSearch interface{}
+	switch {
+	case *candidateID != "":
+		candidate, err := a.queries.GetCandidateByID(ctx, *candidateID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no candidate with ID %s", *candidateID)
+		}
+		if err != nil {
+			return fmt.Errorf("reading candidate: %w", err)
+		}
+		list = []database.Candidate{candidate}
+	case *searchID == "":
+		if list, err = a.queries.ListUndiscoveredCandidates(ctx, *limit); err != nil {
+			return fmt.Errorf("listing candidates: %w", err)
+		}
+	default:
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		forSearch = search.ID
+		if *force {
+			list, err = a.candidatesToEnrich(ctx, search, *limit, true)
+		} else {
+			list, err = a.queries.ListUndiscoveredCandidatesBySearch(ctx, database.ListUndiscoveredCandidatesBySearchParams{
+				SearchID: search.ID,
+				Limit:    *limit,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("listing candidates: %w", err)
+		}
+	}
+
+	if len(list) == 0 && !*asJSON {
+		fmt.Println("No candidates to look up.")
+		return nil
+	}
+
+	// Each candidate is independent, so one failure does not stop the rest,
+	// unless the search's monthly budget runs out
+	var out []discoverJSON
+	failed := false
+	for _, candidate := range list {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		view := discoverJSON{CandidateID: candidate.ID, Name: candidate.Name, Links: []profileLinkJSON{}, Applied: []string{}}
+		discovery, err := a.profiles.Discover(ctx, candidate, forSearch)
+		if err != nil {
+			failed = true
+			view.Error = err.Error()
+		} else {
+			view.Searches = discovery.Searches
+			view.Applied = append(view.Applied, discovery.Applied...)
+			for _, link := range discovery.Links {
+				view.Links = append(view.Links, profileLinkJSON{
+					Kind:        link.Kind,
+					URL:         link.URL,
+					SourceURL:   link.SourceURL,
+					SourceTitle: link.SourceTitle,
+					CitedText:   link.CitedText,
+					Backed:      link.Backed,
+				})
+			}
+		}
+		out = append(out, view)
+		if errors.Is(err, costs.ErrBudgetExceeded) {
+			// Every remaining lookup would be refused the same way
+			if !*asJSON {
+				fmt.Printf("%s: error: %v\n", candidate.Name, err)
+			}
+			break
+		}
+
+		if *asJSON {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("%s: error: %v\n", candidate.Name, err)
+			continue
+		}
+		fmt.Printf("%s (%d searches)\n", candidate.Name, discovery.Searches)
+		if len(discovery.Links) == 0 {
+			fmt.Println("  no profiles found")
+		}
+		for _, link := range discovery.Links {
+			if !link.Backed {
+				fmt.Printf("  %-8s %s (not backed by a search result, not saved)\n", link.Kind, link.URL)
+				continue
+			}
+			fmt.Printf("  %-8s %s\n           source: %s\n", link.Kind, link.URL, link.SourceURL)
+		}
+		if len(discovery.Applied) > 0 {
+			fmt.Printf("  set %s\n", strings.Join(discovery.Applied, ", "))
+		}
+	}
+
+	if *asJSON {
+		if out == nil {
+			out = []discoverJSON{}
+		}
+		if err := printJSON(out); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errors.New("one or more candidates could not be looked up")
+	}
+	return nil
+}
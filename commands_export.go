@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/export"
+)
+
+func (a *app) export(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	searchID := fs.String("search", "", "search ID (required)")
+	format := fs.String("format", export.FormatCSV, "output format: "+strings.Join(export.Formats, ", "))
+	minRelevance := fs.Float64("min-relevance", 0, "only export candidates scored at least this")
+	status := fs.String("status", "", "only export candidates at this pipeline stage")
+	category := fs.String("category", "", "only export candidates with this arXiv category")
+	output := fs.String("output", "", "file to write (default stdout)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("export takes no arguments")
+	}
+	if *searchID == "" {
+		return fmt.Errorf("--search is required")
+	}
+	if *status != "" && !candidates.ValidStage(*status) {
+		return fmt.Errorf("unknown stage %q (expected one of %s)", *status, strings.Join(candidates.Stages, ", "))
+	}
+	// Check the format before reading anything or creating the output file
+	if err := export.Write(io.Discard, *format, nil); err != nil {
+		return err
+	}
+
+	search, err := a.resolveSearch(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	list, err := export.Load(ctx, a.queries, search, export.Filter{
+		MinRelevance: *minRelevance,
+		Status:       *status,
+		Category:     *category,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return export.Write(os.Stdout, *format, list)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(file, *format, list); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", *output, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", *output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d candidates to %s\n", len(list), *output)
+	return nil
+}
+
+// importRowJSON is the --json representation of one imported row
+type importRowJSON struct {
+	Line        int         `json:"line"`
+	Name        string      `json:"name"`
+	Action      string      `json:"action"`
+	CandidateID interface{} `json:"candidate_id,omitempty"`
+	MatchedBy   string      `json:"matched_by,omitempty"`
+	Filled      []string    `json:"filled,omitempty"`
+	Linked      bool        `json:"linked"`
+	Reason      string      `json:"reason,omitempty"`
+}
+
+func (a *app) importCandidates(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import candidates", flag.ContinueOnError)
+	mapping := fs.String("map", "", "comma-separated FIELD=COLUMN pairs, e.g. name=Full Name,github_url=GitHub")
+	searchID := fs.String("search", "", "link every imported candidate to this search")
+	relevance := fs.Float64("relevance", -1, "relevance score for rows without one (requires --search)")
+	apply := fs.Bool("apply", false, "write the changes (without it, only report what would happen)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("import candidates requires a CSV file")
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["relevance"] && *searchID == "" {
+		return fmt.Errorf("--relevance requires --search")
+	}
+	if set["relevance"] && (*relevance < 0 || *relevance > 1) {
+		return fmt.Errorf("--relevance must be between 0 and 1")
+	}
+
+	opts := candidates.ImportOptions{Mapping: map[string]string{}, DryRun: !*apply}
+	for _, pair := range strings.Split(*mapping, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("--map entry %q is not FIELD=COLUMN", pair)
+		}
+		opts.Mapping[strings.TrimSpace(field)] = strings.TrimSpace(column)
+	}
+	if *searchID != "" {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		opts.SearchID = search.ID
+	}
+	if set["relevance"] {
+		opts.Relevance = sql.NullFloat64{Float64: *relevance, Valid: true}
+	}
+
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	report, err := candidates.NewImporter(a.db, a.queries).Import(ctx, file, opts)
+	if err != nil {
+		return fmt.Errorf("importing %s (no changes were made): %w", positional[0], err)
+	}
+
+	if *asJSON {
+		rows := make([]importRowJSON, len(report.Rows))
+		for i, row := range report.Rows {
+			rows[i] = importRowJSON{
+				Line:        row.Line,
+				Name:        row.Name,
+				Action:      row.Action,
+				CandidateID: row.CandidateID,
+				MatchedBy:   row.MatchedBy,
+				Filled:      row.Filled,
+				Linked:      row.Linked,
+				Reason:      row.Reason,
+			}
+		}
+		return printJSON(map[string]interface{}{
+			"dry_run":   report.DryRun,
+			"inserted":  report.Inserted,
+			"merged":    report.Merged,
+			"conflicts": report.Conflicts,
+			"skipped":   report.Skipped,
+			"linked":    report.Linked,
+			"rows":      rows,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tACTION\tNAME\tDETAILS")
+	for _, row := range report.Rows {
+		var details []string
+		if row.MatchedBy != "" {
+			details = append(details, fmt.Sprintf("matched %v by %s", row.CandidateID, row.MatchedBy))
+		}
+		if len(row.Filled) > 0 {
+			details = append(details, "fills "+strings.Join(row.Filled, ", "))
+		}
+		if row.Linked {
+			details = append(details, "links to search")
+		}
+		if row.Reason != "" {
+			details = append(details, row.Reason)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", row.Line, row.Action, row.Name, strings.Join(details, "; "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if report.DryRun {
+		fmt.Printf("\nDry run: would insert %d, merge %d and link %d candidates; %d conflicts and %d skipped rows would be left out.\n",
+			report.Inserted, report.Merged, report.Linked, report.Conflicts, report.Skipped)
+		fmt.Println("Nothing was written. Re-run with --apply to import.")
+		return nil
+	}
+	fmt.Printf("\nInserted %d, merged %d and linked %d candidates; left out %d conflicts and %d skipped rows.\n",
+		report.Inserted, report.Merged, report.Linked, report.Conflicts, report.Skipped)
+	return nil
+}
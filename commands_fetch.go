@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/daemon"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// fetchSummary is the per-search outcome of the fetch command
+type fetchSummary struct {
+	SearchID   interface{} `json:"search_id"`
+	Seen       int         `json:"seen"`
+	Created    int         `json:"created"`
+	Skipped    int         `json:"skipped"`
+	Scored     int         `json:"scored,omitempty"`
+	Candidates int         `json:"new_candidates,omitempty"`
+	SizeChange string      `json:"results_per_fetch_change,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+func (a *app) fetch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	all := fs.Bool("all", false, "fetch every search")
+	older := fs.Bool("older", false, "backfill older papers through the arXiv API instead of reading the RSS feed")
+	pages := fs.Int("pages", a.cfg.Fetch.BackfillPages, "API pages to fetch with --older")
+	score := fs.Bool("score", false, "score new articles and extract candidates after fetching")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var searches []database.Search
+	switch {
+	case *all && len(positional) == 0:
+		searches, err = a.queries.ListAllSearches(ctx)
+		if err != nil {
+			return fmt.Errorf("listing searches: %w", err)
+		}
+	case !*all && len(positional) == 1:
+		search, err := a.resolveSearch(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+		searches = []database.Search{search}
+	default:
+		return fmt.Errorf("fetch requires either a search ID or --all")
+	}
+
+	var summaries []fetchSummary
+	failed := false
+	for _, search := range searches {
+		summary := fetchSummary{SearchID: search.ID}
+		var backfill *fetcher.BackfillResult
+		if *older {
+			opts := a.cfg.BackfillOptions()
+			opts.MaxPages = *pages
+			result, err := a.fetcher.FetchOlder(ctx, search, opts)
+			backfill = result
+			if result != nil {
+				summary.Seen, summary.Created, summary.Skipped = result.Seen, len(result.Created), result.Skipped
+			}
+			if err != nil {
+				summary.Error = err.Error()
+			}
+		} else {
+			result, err := a.fetcher.FetchNew(ctx, search)
+			if result != nil {
+				summary.Seen, summary.Created, summary.Skipped = result.Seen, len(result.Created), result.Skipped
+			}
+			if err != nil {
+				summary.Error = err.Error()
+			}
+		}
+
+		if *score && summary.Error == "" {
+			scored, err := a.scorer.ScoreSearch(ctx, search, 1000)
+			if scored != nil {
+				summary.Scored = scored.Scored
+			}
+			if err == nil {
+				linked, linkErr := a.linker.LinkSearch(ctx, search, candidates.DefaultMinRelevance)
+				summary.Candidates = linked.Created
+				err = linkErr
+			}
+			if err != nil {
+				summary.Error = err.Error()
+			}
+		}
+
+		if backfill != nil && summary.Error == "" {
+			adjustment, err := a.sizer.AdjustBackfill(ctx, search, backfill, candidates.DefaultMinRelevance)
+			if err != nil {
+				summary.Error = err.Error()
+			} else if adjustment != nil {
+				summary.SizeChange = fmt.Sprintf("%d -> %d (%s)", adjustment.PreviousSize, adjustment.NewSize, adjustment.Reason)
+			}
+		}
+
+		failed = failed || summary.Error != ""
+		summaries = append(summaries, summary)
+		if !*asJSON {
+			fmt.Printf("%v: %d entries, %d new, %d already seen", search.ID, summary.Seen, summary.Created, summary.Skipped)
+			if *score {
+				fmt.Printf(", %d scored, %d new candidates", summary.Scored, summary.Candidates)
+			}
+			if summary.SizeChange != "" {
+				fmt.Printf(", results per fetch %s", summary.SizeChange)
+			}
+			if summary.Error != "" {
+				fmt.Printf(" (error: %s)", summary.Error)
+			}
+			fmt.Println()
+		}
+	}
+
+	if *asJSON {
+		if err := printJSON(summaries); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return errors.New("one or more searches failed to fetch")
+	}
+	return nil
+}
+
+func (a *app) daemon(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	interval := fs.Duration("interval", a.cfg.Daemon.Interval, "time between refresh cycles; searches not fetched for this long are refreshed")
+	concurrency := fs.Int("concurrency", a.cfg.Daemon.Concurrency, "searches refreshed at the same time")
+	hostDelay := fs.Duration("host-delay", a.cfg.Daemon.HostDelay, "minimum pause between requests to the same host")
+	score := fs.Bool("score", a.cfg.Daemon.Score, "score new articles and extract candidates")
+	once := fs.Bool("once", false, "run a single cycle and exit")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("daemon takes no arguments")
+	}
+	if *interval <= 0 || *concurrency < 1 || *hostDelay < 0 {
+		return fmt.Errorf("--interval and --concurrency must be positive and --host-delay must not be negative")
+	}
+
+	// SQLite allows a single writer; sharing one connection makes concurrent
+	// workers queue for it instead of failing with "database is locked"
+	a.db.SetMaxOpenConns(1)
+	a.fetcher.SetHostRateLimit(*hostDelay)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	refresher := daemon.NewDaemon(a.queries, a.fetcher, a.scorer, a.linker, daemon.Options{
+		Interval:    *interval,
+		Concurrency: *concurrency,
+		Score:       *score,
+	}, log.New(os.Stderr, "", log.LstdFlags))
+
+	if !*once {
+		return refresher.Run(ctx)
+	}
+	summary, err := refresher.RunOnce(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d searches (%d failed, %d over budget), %d new articles, %d scored, %d new candidates\n",
+		summary.Searches, summary.Failed, summary.Paused, summary.NewArticles, summary.Scored, summary.NewCandidates)
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jessewalker/reSearch/graph"
+)
+
+// graphNodeJSON is the --json representation of a candidate in the co-authorship graph
+type graphNodeJSON struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Papers           int     `json:"papers"`
+	Degree           int     `json:"degree"`
+	DegreeCentrality float64 `json:"degree_centrality"`
+	Betweenness      float64 `json:"betweenness"`
+	Component        int     `json:"component"`
+}
+
+// collaboratorJSON is the --json representation of a co-author
+type collaboratorJSON struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Shared int      `json:"shared_papers"`
+	Papers []string `json:"papers"`
+}
+
+func toGraphNodeJSON(node *graph.Node) graphNodeJSON {
+	return graphNodeJSON{
+		ID:               node.ID,
+		Name:             node.Name,
+		Papers:           node.Papers,
+		Degree:           node.Degree,
+		DegreeCentrality: node.DegreeCentrality,
+		Betweenness:      node.Betweenness,
+		Component:        node.Component,
+	}
+}
+
+// loadGraph builds the co-authorship graph of one search, or of every search
+// when searchID is empty
+func (a *app) loadGraph(ctx context.Context, searchID string) (*graph.Graph, error) {
+	if searchID == "" {
+		return graph.Load(ctx, a.queries, nil)
+	}
+	search, err := a.resolveSearch(ctx, searchID)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Load(ctx, a.queries, search.ID)
+}
+
+// graphCentral lists the most central candidates of the co-authorship graph
+func (a *app) graphCentral(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("graph central", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only use the papers of this search")
+	by := fs.String("by", string(graph.ByDegree), "rank by degree or betweenness")
+	limit := fs.Int("limit", 20, "maximum number of candidates (0 for all)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	g, err := a.loadGraph(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	nodes, err := g.Central(graph.Ranking(*by), *limit)
+	if err != nil {
+		return err
+	}
+	largest := 0
+	if len(g.Components) > 0 {
+		largest = g.Components[0]
+	}
+
+	if *asJSON {
+		out := []graphNodeJSON{}
+		for _, node := range nodes {
+			out = append(out, toGraphNodeJSON(node))
+		}
+		return printJSON(map[string]interface{}{
+			"candidates":        len(g.Nodes),
+			"collaborations":    len(g.Edges),
+			"components":        len(g.Components),
+			"largest_component": largest,
+			"central":           out,
+		})
+	}
+
+	fmt.Printf("%d candidates, %d collaborations, %d components (largest has %d candidates)\n\n",
+		len(g.Nodes), len(g.Edges), len(g.Components), largest)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tCO-AUTHORS\tBETWEENNESS\tPAPERS\tCOMPONENT")
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.3f\t%d\t%d\n", node.ID, node.Name, node.Degree, node.Betweenness, node.Papers, node.Component)
+	}
+	return w.Flush()
+}
+
+// graphCollaborators lists a candidate's co-authors, strongest first
+func (a *app) graphCollaborators(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("graph collaborators", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only use the papers of this search")
+	limit := fs.Int("limit", 10, "maximum number of collaborators (0 for all)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("graph collaborators requires a candidate ID")
+	}
+
+	candidate, err := a.resolveAnyCandidate(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	g, err := a.loadGraph(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	collaborators := g.Collaborators(fmt.Sprint(candidate.ID))
+	if *limit > 0 && len(collaborators) > *limit {
+		collaborators = collaborators[:*limit]
+	}
+
+	if *asJSON {
+		out := []collaboratorJSON{}
+		for _, c := range collaborators {
+			out = append(out, collaboratorJSON{ID: c.Node.ID, Name: c.Node.Name, Shared: len(c.Papers), Papers: c.Papers})
+		}
+		return printJSON(out)
+	}
+
+	if len(collaborators) == 0 {
+		fmt.Printf("%s has no co-authors among the stored papers\n", candidate.Name)
+		return nil
+	}
+	fmt.Printf("Co-authors of %s:\n", candidate.Name)
+	for _, c := range collaborators {
+		noun := "papers"
+		if len(c.Papers) == 1 {
+			noun = "paper"
+		}
+		fmt.Printf("\n%s (%s), %d shared %s\n", c.Node.Name, c.Node.ID, len(c.Papers), noun)
+		for _, title := range c.Papers {
+			fmt.Printf("  - %s\n", title)
+		}
+	}
+	return nil
+}
+
+// graphExport writes the co-authorship graph for GraphViz or Gephi
+func (a *app) graphExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("graph export", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only use the papers of this search")
+	format := fs.String("format", graph.FormatDOT, "output format: "+strings.Join(graph.Formats, ", "))
+	output := fs.String("output", "", "file to write (default stdout)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("graph export takes no arguments")
+	}
+	// Check the format before reading anything or creating the output file
+	if err := graph.Write(io.Discard, *format, &graph.Graph{}); err != nil {
+		return err
+	}
+
+	g, err := a.loadGraph(ctx, *searchID)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return graph.Write(os.Stdout, *format, g)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := graph.Write(file, *format, g); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", *output, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", *output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d candidates and %d collaborations to %s\n", len(g.Nodes), len(g.Edges), *output)
+	return nil
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/fulltext"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// searchJSON is the --json representation of a search
+type searchJSON struct {
+	ID              interface{} `json:"id"`
+	Description     string      `json:"description"`
+	FeedURL         string      `json:"feed_url"`
+	ResultsPerFetch int64       `json:"results_per_fetch"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+	LastFetchDate   *time.Time  `json:"last_fetch_date"`
+	BackfillCursor  *time.Time  `json:"backfill_cursor"`
+	MonthlyBudget   *float64    `json:"monthly_budget"`
+	ArticleCount    *int64      `json:"article_count,omitempty"`
+	CandidateCount  *int64      `json:"candidate_count,omitempty"`
+	// SpentThisMonth and BudgetExceeded are only set by search show and search budget
+	SpentThisMonth *float64 `json:"spent_this_month,omitempty"`
+	BudgetExceeded *bool    `json:"budget_exceeded,omitempty"`
+	// Pipeline counts the search's candidates at each stage
+	Pipeline map[string]int64 `json:"pipeline,omitempty"`
+	// CategoryCoverage counts the search's candidates in each arXiv category
+	CategoryCoverage map[string]int64 `json:"category_coverage,omitempty"`
+	// FetchSizeHistory lists the most recent results_per_fetch changes, newest first
+	FetchSizeHistory []fetchAdjustmentJSON `json:"fetch_size_history,omitempty"`
+}
+
+// fetchAdjustmentJSON is the --json representation of a results_per_fetch change
+type fetchAdjustmentJSON struct {
+	At           time.Time `json:"at"`
+	PreviousSize int64     `json:"previous_size"`
+	NewSize      int64     `json:"new_size"`
+	Fetched      int64     `json:"fetched"`
+	NewArticles  int64     `json:"new_articles"`
+	Duplicates   int64     `json:"duplicates"`
+	Scored       int64     `json:"scored"`
+	Relevant     int64     `json:"relevant"`
+	Reason       string    `json:"reason"`
+}
+
+// articleMatchJSON is the --json representation of a full-text search result
+type articleMatchJSON struct {
+	ID        interface{} `json:"id"`
+	SearchID  interface{} `json:"search_id"`
+	URL       string      `json:"url"`
+	Title     string      `json:"title"`
+	Authors   string      `json:"authors"`
+	Snippet   string      `json:"snippet"`
+	Score     float64     `json:"score"`
+	FetchedAt time.Time   `json:"fetched_at"`
+}
+
+func (a *app) searchCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search create", flag.ContinueOnError)
+	description := fs.String("description", "", "what the search is looking for")
+	feedURL := fs.String("url", "", "arXiv RSS feed URL, e.g. http://rss.arxiv.org/rss/cs.LG+cs.PL")
+	categories := fs.String("categories", "", "comma-separated arXiv categories to build the feed URL from")
+	resultsPerFetch := fs.Int64("results-per-fetch", a.cfg.Fetch.ResultsPerFetch, "papers per backfill page (10-1999)")
+	budget := fs.Float64("budget", 0, "monthly LLM budget in US dollars (default none)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	var monthlyBudget sql.NullFloat64
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "budget" {
+			monthlyBudget = sql.NullFloat64{Float64: *budget, Valid: true}
+		}
+	})
+
+	if strings.TrimSpace(*description) == "" {
+		return fmt.Errorf("--description is required")
+	}
+	if (*feedURL == "") == (*categories == "") {
+		return fmt.Errorf("exactly one of --url or --categories is required")
+	}
+	if *categories != "" {
+		url, err := fetcher.FeedURL(strings.Split(*categories, ","))
+		if err != nil {
+			return err
+		}
+		*feedURL = url
+	}
+	if *resultsPerFetch < 10 || *resultsPerFetch >= 2000 {
+		return fmt.Errorf("--results-per-fetch must be between 10 and 1999")
+	}
+	if *budget < 0 {
+		return fmt.Errorf("--budget must not be negative")
+	}
+
+	now := time.Now()
+	search, err := a.queries.CreateSearch(ctx, database.CreateSearchParams{
+		ID:              uuid.New(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Description:     strings.TrimSpace(*description),
+		ArvixUrl:        *feedURL,
+		ResultsPerFetch: sql.NullInt64{Int64: *resultsPerFetch, Valid: true},
+		LastFetchDate:   sql.NullTime{Valid: false},
+		MonthlyBudget:   monthlyBudget,
+	})
+	if err != nil {
+		return fmt.Errorf("creating search: %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(toSearchJSON(search))
+	}
+	fmt.Printf("Search created successfully with ID: %v\n", search.ID)
+	return nil
+}
+
+func (a *app) searchList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search list", flag.ContinueOnError)
+	limit := fs.Int64("limit", 50, "maximum number of searches")
+	offset := fs.Int64("offset", 0, "number of searches to skip")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	searches, err := a.queries.ListActiveSearches(ctx, database.ListActiveSearchesParams{
+		Limit:  *limit,
+		Offset: *offset,
+	})
+	if err != nil {
+		return fmt.Errorf("listing searches: %w", err)
+	}
+
+	if *asJSON {
+		out := make([]searchJSON, len(searches))
+		for i, s := range searches {
+			out[i] = toSearchJSON(database.Search{
+				ID:              s.ID,
+				CreatedAt:       s.CreatedAt,
+				UpdatedAt:       s.UpdatedAt,
+				Description:     s.Description,
+				ArvixUrl:        s.ArvixUrl,
+				ResultsPerFetch: s.ResultsPerFetch,
+				LastFetchDate:   s.LastFetchDate,
+				BackfillCursor:  s.BackfillCursor,
+				MonthlyBudget:   s.MonthlyBudget,
+			})
+			out[i].ArticleCount = &s.ArticleCount
+		}
+		return printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDESCRIPTION\tFEED URL\tLAST FETCH\tARTICLES")
+	for _, s := range searches {
+		fmt.Fprintf(w, "%v\t%s\t%s\t%s\t%d\n", s.ID, s.Description, s.ArvixUrl, formatNullTime(s.LastFetchDate), s.ArticleCount)
+	}
+	return w.Flush()
+}
+
+func (a *app) searchShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search show", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("search show requires a search ID")
+	}
+
+	search, err := a.resolveSearch(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	stats, err := a.queries.GetSearchWithStats(ctx, search.ID)
+	if err != nil {
+		return fmt.Errorf("retrieving search details: %w", err)
+	}
+	adjustments, err := a.queries.ListSearchFetchAdjustments(ctx, database.ListSearchFetchAdjustmentsParams{
+		SearchID: search.ID,
+		Limit:    5,
+	})
+	if err != nil {
+		return fmt.Errorf("retrieving fetch size history: %w", err)
+	}
+	stageCounts, err := a.queries.CountCandidatesByStatus(ctx, search.ID)
+	if err != nil {
+		return fmt.Errorf("counting candidates by stage: %w", err)
+	}
+	pipeline := map[string]int64{}
+	for _, count := range stageCounts {
+		pipeline[count.Status] = count.CandidateCount
+	}
+	coverage, err := a.queries.GetSearchCoverageByCategory(ctx, search.ID)
+	if err != nil {
+		return fmt.Errorf("counting candidates by category: %w", err)
+	}
+	budget, err := a.ledger.Budget(ctx, search.ID)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out := toSearchJSON(search)
+		out.ArticleCount = &stats.ArticleCount
+		out.CandidateCount = &stats.CandidateCount
+		setBudgetJSON(&out, budget)
+		out.Pipeline = pipeline
+		if len(coverage) > 0 {
+			out.CategoryCoverage = map[string]int64{}
+			for _, count := range coverage {
+				out.CategoryCoverage[count.ArxivCategory] = count.CandidateCount
+			}
+		}
+		out.FetchSizeHistory = make([]fetchAdjustmentJSON, len(adjustments))
+		for i, adjustment := range adjustments {
+			out.FetchSizeHistory[i] = fetchAdjustmentJSON{
+				At:           adjustment.CreatedAt,
+				PreviousSize: adjustment.PreviousSize,
+				NewSize:      adjustment.NewSize,
+				Fetched:      adjustment.Fetched,
+				NewArticles:  adjustment.NewArticles,
+				Duplicates:   adjustment.Duplicates,
+				Scored:       adjustment.Scored,
+				Relevant:     adjustment.Relevant,
+				Reason:       adjustment.Reason,
+			}
+		}
+		return printJSON(out)
+	}
+
+	fmt.Printf("ID:              %v\n", search.ID)
+	fmt.Printf("Description:     %s\n", search.Description)
+	fmt.Printf("arXiv URL:       %s\n", search.ArvixUrl)
+	fmt.Printf("Created:         %s\n", search.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Last Updated:    %s\n", search.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Results/Fetch:   %d\n", defaultIfNullInt64(search.ResultsPerFetch, 50))
+	fmt.Printf("Last Fetch:      %s\n", formatNullTime(search.LastFetchDate))
+	fmt.Printf("Backfilled To:   %s\n", formatNullTime(search.BackfillCursor))
+	fmt.Printf("LLM Spend:       %s\n", formatBudget(budget))
+	fmt.Printf("Article Count:   %d\n", stats.ArticleCount)
+	fmt.Printf("Candidate Count: %d\n", stats.CandidateCount)
+	if len(pipeline) > 0 {
+		var stages []string
+		for _, stage := range candidates.Stages {
+			if pipeline[stage] > 0 {
+				stages = append(stages, fmt.Sprintf("%s %d", stage, pipeline[stage]))
+			}
+		}
+		fmt.Printf("Pipeline:        %s\n", strings.Join(stages, ", "))
+	}
+	if len(coverage) > 0 {
+		var categories []string
+		for _, count := range coverage {
+			categories = append(categories, fmt.Sprintf("%s %d", count.ArxivCategory, count.CandidateCount))
+		}
+		fmt.Printf("Categories:      %s\n", strings.Join(categories, ", "))
+	}
+	if len(adjustments) > 0 {
+		fmt.Println("Recent fetch size changes:")
+		for _, adjustment := range adjustments {
+			fmt.Printf("  %s  %d -> %d  %s\n", adjustment.CreatedAt.Format("2006-01-02 15:04"),
+				adjustment.PreviousSize, adjustment.NewSize, adjustment.Reason)
+		}
+	}
+	return nil
+}
+
+// searchBudget sets or removes the search's monthly LLM budget
+func (a *app) searchBudget(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search budget", flag.ContinueOnError)
+	monthly := fs.Float64("monthly", 0, "monthly LLM budget in US dollars")
+	clearBudget := fs.Bool("clear", false, "remove the budget")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("search budget requires a search ID")
+	}
+	var monthlyBudget sql.NullFloat64
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "monthly" {
+			monthlyBudget = sql.NullFloat64{Float64: *monthly, Valid: true}
+		}
+	})
+	if monthlyBudget.Valid == *clearBudget {
+		return fmt.Errorf("exactly one of --monthly or --clear is required")
+	}
+	if *monthly < 0 {
+		return fmt.Errorf("--monthly must not be negative")
+	}
+
+	search, err := a.resolveSearch(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	search, err = a.queries.SetSearchMonthlyBudget(ctx, database.SetSearchMonthlyBudgetParams{
+		UpdatedAt:     time.Now(),
+		MonthlyBudget: monthlyBudget,
+		ID:            search.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("saving budget: %w", err)
+	}
+	budget, err := a.ledger.Budget(ctx, search.ID)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out := toSearchJSON(search)
+		setBudgetJSON(&out, budget)
+		return printJSON(out)
+	}
+	fmt.Printf("LLM spend for %v: %s\n", search.ID, formatBudget(budget))
+	return nil
+}
+
+// searchArticles ranks the stored articles against a full-text query
+func (a *app) searchArticles(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search articles", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only match articles of this search")
+	since := fs.String("since", "", "only match articles fetched on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only match articles fetched on or before this date (YYYY-MM-DD)")
+	limit := fs.Int64("limit", 20, "maximum number of articles")
+	offset := fs.Int64("offset", 0, "number of articles to skip")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("search articles requires a query")
+	}
+
+	params := fulltext.ArticleQuery{
+		Query:  strings.Join(positional, " "),
+		Limit:  *limit,
+		Offset: *offset,
+	}
+	if *asJSON {
+		params.MarkStart, params.MarkEnd = "**", "**"
+	} else {
+		params.MarkStart, params.MarkEnd = highlightMarks()
+	}
+	if *searchID != "" {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		params.SearchID = search.ID
+	}
+	if *since != "" {
+		day, err := time.Parse("2006-01-02", *since)
+		if err != nil {
+			return fmt.Errorf("invalid --since date %q (expected YYYY-MM-DD)", *since)
+		}
+		params.FetchedAfter = sql.NullTime{Time: day, Valid: true}
+	}
+	if *until != "" {
+		day, err := time.Parse("2006-01-02", *until)
+		if err != nil {
+			return fmt.Errorf("invalid --until date %q (expected YYYY-MM-DD)", *until)
+		}
+		params.FetchedBefore = sql.NullTime{Time: day.AddDate(0, 0, 1), Valid: true}
+	}
+
+	rows, err := findArticles(ctx, a.fullText, params)
+	if err != nil {
+		return fmt.Errorf("searching articles: %w", err)
+	}
+
+	if *asJSON {
+		out := make([]articleMatchJSON, len(rows))
+		for i, row := range rows {
+			out[i] = articleMatchJSON{
+				ID:        row.ID,
+				SearchID:  row.SearchID,
+				URL:       row.ArticleUrl,
+				Title:     row.ArticleTitle,
+				Authors:   row.ArticleAuthors,
+				Snippet:   row.Snippet,
+				Score:     -row.Rank,
+				FetchedAt: row.FetchedAt,
+			}
+		}
+		return printJSON(out)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No matching articles.")
+		return nil
+	}
+	printArticleMatches(rows)
+	return nil
+}
+
+func (a *app) searchDelete(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search delete", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "confirm the deletion")
+	removeOrphans := fs.Bool("remove-orphans", false, "also remove the search's candidates left with no other links")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("search delete requires a search ID")
+	}
+	if !*yes {
+		return fmt.Errorf("refusing to delete without --yes")
+	}
+
+	search, err := a.resolveSearch(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	result, err := database.DeleteSearchCascade(ctx, a.db, search.ID, *removeOrphans)
+	if err != nil {
+		return fmt.Errorf("deleting search (no changes were made): %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{
+			"id":                   search.ID,
+			"articles":             result.Articles,
+			"article_relevance":    result.ArticleRelevance,
+			"article_categories":   result.ArticleCategories,
+			"article_terms":        result.ArticleTerms,
+			"agent_sessions":       result.AgentSessions,
+			"agent_messages":       result.AgentMessages,
+			"llm_calls_detached":   result.LLMCalls,
+			"candidate_articles":   result.CandidateArticles,
+			"candidate_searches":   result.CandidateSearches,
+			"candidate_history":    result.StatusChanges,
+			"candidate_categories": result.CandidateCategories,
+			"candidate_enrichment": result.CandidateEnrichment,
+			"candidate_links":      result.CandidateLinks,
+			"candidates":           result.Candidates,
+		})
+	}
+	fmt.Printf("Deleted search %v (%d articles, %d candidate associations, %d orphaned candidates)\n",
+		search.ID, result.Articles, result.CandidateSearches, result.Candidates)
+	return nil
+}
+
+// toSearchJSON converts a search row into its JSON representation
+func toSearchJSON(search database.Search) searchJSON {
+	return searchJSON{
+		ID:              search.ID,
+		Description:     search.Description,
+		FeedURL:         search.ArvixUrl,
+		ResultsPerFetch: defaultIfNullInt64(search.ResultsPerFetch, 50),
+		CreatedAt:       search.CreatedAt,
+		UpdatedAt:       search.UpdatedAt,
+		LastFetchDate:   nullTimePtr(search.LastFetchDate),
+		BackfillCursor:  nullTimePtr(search.BackfillCursor),
+		MonthlyBudget:   nullFloatPtr(search.MonthlyBudget),
+	}
+}
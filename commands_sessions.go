@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// sessionJSON is the --json representation of a saved conversation
+type sessionJSON struct {
+	ID           interface{} `json:"id"`
+	Kind         string      `json:"kind"`
+	Title        string      `json:"title"`
+	SearchID     interface{} `json:"search_id"`
+	Search       string      `json:"search,omitempty"`
+	MessageCount int64       `json:"message_count"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	FinishedAt   *time.Time  `json:"finished_at"`
+}
+
+func (a *app) sessionsList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sessions list", flag.ContinueOnError)
+	searchID := fs.String("search", "", "only list the conversations of this search")
+	kind := fs.String("kind", "", "only list "+agent.SessionDiscovery+" or "+agent.SessionAssistant+" conversations")
+	limit := fs.Int64("limit", 50, "maximum number of conversations")
+	offset := fs.Int64("offset", 0, "number of conversations to skip")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	params := database.ListAgentSessionsParams{Limit: *limit, Offset: *offset}
+	if *searchID != "" {
+		search, err := a.resolveSearch(ctx, *searchID)
+		if err != nil {
+			return err
+		}
+		params.SearchID = search.ID
+	}
+	switch *kind {
+	case "":
+	case agent.SessionDiscovery, agent.SessionAssistant:
+		params.Kind = *kind
+	default:
+		return fmt.Errorf("unknown kind %q (expected %s or %s)", *kind, agent.SessionDiscovery, agent.SessionAssistant)
+	}
+	rows, err := a.queries.ListAgentSessions(ctx, params)
+	if err != nil {
+		return fmt.Errorf("listing conversations: %w", err)
+	}
+
+	if *asJSON {
+		out := make([]sessionJSON, len(rows))
+		for i, row := range rows {
+			out[i] = sessionJSON{
+				ID:           row.ID,
+				Kind:         row.Kind,
+				Title:        row.Title,
+				SearchID:     row.SearchID,
+				Search:       row.SearchDescription,
+				MessageCount: row.MessageCount,
+				CreatedAt:    row.CreatedAt,
+				UpdatedAt:    row.UpdatedAt,
+				FinishedAt:   nullTimePtr(row.FinishedAt),
+			}
+		}
+		return printJSON(out)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tUPDATED\tMESSAGES\tFINISHED\tSEARCH\tTITLE")
+	for _, row := range rows {
+		finished := "no"
+		if row.FinishedAt.Valid {
+			finished = "yes"
+		}
+		search := row.SearchDescription
+		if search == "" {
+			search = "-"
+		}
+		fmt.Fprintf(w, "%v\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			row.ID, row.Kind, row.UpdatedAt.Format("2006-01-02 15:04"), row.MessageCount, finished, search, row.Title)
+	}
+	return w.Flush()
+}
+
+// sessionsResume continues a saved conversation on the terminal. A category
+// discovery that ends with confirmed categories creates its search.
+func (a *app) sessionsResume(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sessions resume", flag.ContinueOnError)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("sessions resume requires a session ID")
+	}
+
+	row, err := a.resolveSession(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if row.Kind == agent.SessionDiscovery && row.SearchID != nil {
+		return fmt.Errorf("this conversation already created search %v", row.SearchID)
+	}
+	session, history, err := agent.ResumeSession(ctx, a.db, a.queries, row)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	switch row.Kind {
+	case agent.SessionDiscovery:
+		if feedURL := discoverCategories(ctx, a.client, a.cfg.AgentOptions(), a.ledger, session, history, row.Title, scanner); feedURL != "" {
+			saveNewSearch(ctx, a.cfg, a.queries, a.ledger, row.Title, feedURL, session)
+		}
+		return nil
+	case agent.SessionAssistant:
+		fmt.Println("Chat with the assistant (type 'exit' to stop).")
+		return runAssistant(ctx, a.cfg, a.queries, a.fullText, a.linker, a.client, a.ledger, session, history, scanner)
+	}
+	return fmt.Errorf("cannot resume a %q conversation", row.Kind)
+}
+
+func (a *app) sessionsDelete(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sessions delete", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "confirm the deletion")
+	asJSON := fs.Bool("json", false, "print JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("sessions delete requires a session ID")
+	}
+	if !*yes {
+		return fmt.Errorf("refusing to delete without --yes")
+	}
+
+	row, err := a.resolveSession(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	messages, err := agent.DeleteSession(ctx, a.db, a.queries, row.ID)
+	if err != nil {
+		return fmt.Errorf("deleting conversation (no changes were made): %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{
+			"id":       row.ID,
+			"messages": messages,
+		})
+	}
+	fmt.Printf("Deleted conversation %v (%d messages)\n", row.ID, messages)
+	return nil
+}
+
+// sessionsExport writes a saved conversation as a Markdown transcript
+func (a *app) sessionsExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sessions export", flag.ContinueOnError)
+	output := fs.String("output", "", "file to write (default stdout)")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("sessions export requires a session ID")
+	}
+
+	row, err := a.resolveSession(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	messages, err := agent.LoadMessages(ctx, a.queries, row.ID)
+	if err != nil {
+		return err
+	}
+	var searchDescription string
+	if row.SearchID != nil {
+		search, err := a.queries.GetSearchByID(ctx, row.SearchID)
+		if err != nil {
+			return fmt.Errorf("retrieving search: %w", err)
+		}
+		searchDescription = search.Description
+	}
+
+	if *output == "" {
+		return agent.WriteTranscript(os.Stdout, row, searchDescription, messages)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := agent.WriteTranscript(file, row, searchDescription, messages); err != nil {
+		file.Close()
+		return fmt.Errorf("writing %s: %w", *output, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", *output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d messages to %s\n", len(messages), *output)
+	return nil
+}
@@ -0,0 +1,446 @@
+// Package config gathers reSearch's settings from, in increasing order of
+// precedence: built-in defaults, ~/.config/research/config.toml, a .env file,
+// environment variables, and command-line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/enrich"
+	"github.com/jessewalker/reSearch/fetcher"
+)
+
+// Config holds every user-tunable setting
+type Config struct {
+	// DBPath is the SQLite database file
+	DBPath string `toml:"db_path"`
+	// APIKey is the Anthropic API key. It is only read from the environment
+	// (or .env), never from the config file, so the file can be shared safely.
+	APIKey string `toml:"-"`
+	// WorkspaceRoot is the only directory the assistant's file tools may touch.
+	// File tools are disabled when it is empty.
+	WorkspaceRoot string `toml:"workspace_root"`
+	// User is recorded as the author of candidate pipeline changes. It
+	// defaults to the login name of whoever runs reSearch.
+	User string `toml:"user"`
+
+	Anthropic AnthropicConfig `toml:"anthropic"`
+	WebSearch WebSearchConfig `toml:"web_search"`
+	Fetch     FetchConfig     `toml:"fetch"`
+	Daemon    DaemonConfig    `toml:"daemon"`
+	GitHub    GitHubConfig    `toml:"github"`
+	Costs     CostsConfig     `toml:"costs"`
+}
+
+// AnthropicConfig controls the model requests
+type AnthropicConfig struct {
+	Model     string `toml:"model"`
+	MaxTokens int64  `toml:"max_tokens"`
+}
+
+// WebSearchConfig controls Anthropic's server-side web search tool
+type WebSearchConfig struct {
+	Enabled bool  `toml:"enabled"`
+	MaxUses int64 `toml:"max_uses"`
+	// AllowedDomains, if not empty, restricts every web search to these domains
+	AllowedDomains []string `toml:"allowed_domains"`
+}
+
+// FetchConfig holds the defaults used when creating and fetching searches
+type FetchConfig struct {
+	// ResultsPerFetch is the page size given to newly created searches
+	ResultsPerFetch int64 `toml:"results_per_fetch"`
+	// BackfillPages is how many arXiv API pages a backfill requests by default
+	BackfillPages int `toml:"backfill_pages"`
+	// PageDelay is the pause between arXiv API pages, written as e.g. "3s"
+	PageDelay time.Duration `toml:"page_delay"`
+}
+
+// DaemonConfig controls the background refresh started by "reSearch daemon"
+type DaemonConfig struct {
+	// Interval is the time between refresh cycles, and how long a search may go
+	// without a fetch before it is refreshed
+	Interval time.Duration `toml:"interval"`
+	// Concurrency is the number of searches refreshed at the same time
+	Concurrency int `toml:"concurrency"`
+	// HostDelay is the minimum pause between requests to the same host
+	HostDelay time.Duration `toml:"host_delay"`
+	// Score enables relevance scoring and candidate extraction after fetching
+	Score bool `toml:"score"`
+}
+
+// GitHubConfig controls candidate enrichment from GitHub profiles
+type GitHubConfig struct {
+	// BaseURL is the GitHub REST API endpoint, e.g. a local stand-in for testing
+	BaseURL string `toml:"base_url"`
+	// Token is a GitHub access token. Like APIKey it is only read from the
+	// environment, never from the config file.
+	Token string `toml:"-"`
+	// CacheTTL is how long GitHub responses are reused before being fetched again
+	CacheTTL time.Duration `toml:"cache_ttl"`
+	// MinConfidence is the match confidence needed to set a candidate's github_url
+	MinConfidence float64 `toml:"min_confidence"`
+}
+
+// CostsConfig prices model usage for the costs report and search budgets
+type CostsConfig struct {
+	// Prices maps model names, or prefixes such as "claude-sonnet-4", to their
+	// price in US dollars per million tokens. Entries from the config file are
+	// added to the built-in list prices, replacing any of the same name.
+	Prices map[string]costs.Price `toml:"prices"`
+	// WebSearch is the price of 1,000 web searches in US dollars
+	WebSearch float64 `toml:"web_search"`
+}
+
+// Default returns the settings used when nothing overrides them
+func Default() Config {
+	return Config{
+		DBPath: "research.db",
+		User:   defaultUser(),
+		Anthropic: AnthropicConfig{
+			Model:     agent.DefaultModel,
+			MaxTokens: agent.DefaultMaxTokens,
+		},
+		WebSearch: WebSearchConfig{
+			Enabled: true,
+			MaxUses: agent.DefaultWebSearchMaxUses,
+		},
+		Fetch: FetchConfig{
+			ResultsPerFetch: 50,
+			BackfillPages:   1,
+			PageDelay:       fetcher.DefaultPageDelay,
+		},
+		Daemon: DaemonConfig{
+			Interval:    time.Hour,
+			Concurrency: 2,
+			HostDelay:   fetcher.DefaultPageDelay,
+			Score:       true,
+		},
+		GitHub: GitHubConfig{
+			BaseURL:       enrich.DefaultGitHubBaseURL,
+			CacheTTL:      enrich.DefaultCacheTTL,
+			MinConfidence: enrich.DefaultMinConfidence,
+		},
+		Costs: CostsConfig{
+			Prices:    costs.DefaultModelPrices(),
+			WebSearch: costs.DefaultWebSearchPrice,
+		},
+	}
+}
+
+// Environment variables read by Load
+const (
+	EnvConfigPath        = "RESEARCH_CONFIG"
+	EnvDBPath            = "RESEARCH_DB_PATH"
+	EnvWorkspaceRoot     = "RESEARCH_WORKSPACE_ROOT"
+	EnvUser              = "RESEARCH_USER"
+	EnvAPIKey            = "ANTHROPIC_API_KEY"
+	EnvModel             = "RESEARCH_MODEL"
+	EnvMaxTokens         = "RESEARCH_MAX_TOKENS"
+	EnvWebSearch         = "RESEARCH_WEB_SEARCH"
+	EnvWebSearchMaxUses  = "RESEARCH_WEB_SEARCH_MAX_USES"
+	EnvWebSearchDomains  = "RESEARCH_WEB_SEARCH_ALLOWED_DOMAINS"
+	EnvResultsPerFetch   = "RESEARCH_RESULTS_PER_FETCH"
+	EnvBackfillPages     = "RESEARCH_BACKFILL_PAGES"
+	EnvPageDelay         = "RESEARCH_PAGE_DELAY"
+	EnvDaemonInterval    = "RESEARCH_DAEMON_INTERVAL"
+	EnvDaemonConcurrency = "RESEARCH_DAEMON_CONCURRENCY"
+	EnvDaemonHostDelay   = "RESEARCH_DAEMON_HOST_DELAY"
+	EnvDaemonScore       = "RESEARCH_DAEMON_SCORE"
+	EnvGitHubToken       = "GITHUB_TOKEN"
+	EnvGitHubBaseURL     = "RESEARCH_GITHUB_BASE_URL"
+	EnvGitHubCacheTTL    = "RESEARCH_GITHUB_CACHE_TTL"
+	EnvGitHubConfidence  = "RESEARCH_GITHUB_MIN_CONFIDENCE"
+)
+
+// Load builds the configuration and returns it together with the arguments
+// left over after the global flags, i.e. the subcommand and its own flags.
+// Global flags must therefore come before the subcommand:
+//
+//	reSearch --db other.db search list
+func Load(args []string) (Config, []string, error) {
+	flags := flag.NewFlagSet("reSearch", flag.ContinueOnError)
+	// The caller prints its own usage, which covers these flags and the subcommands
+	flags.Usage = func() {}
+	flags.SetOutput(io.Discard)
+	envFile := flags.String("env-file", ".env", "dotenv file to load (a missing file is ignored)")
+	configPath := flags.String("config", "", "config file (default ~/.config/research/config.toml)")
+	dbPath := flags.String("db", "", "SQLite database path")
+	workspaceRoot := flags.String("workspace", "", "directory the assistant's file tools are confined to")
+	userName := flags.String("user", "", "name recorded on candidate pipeline changes")
+	model := flags.String("model", "", "Anthropic model")
+	maxTokens := flags.Int64("max-tokens", 0, "maximum tokens per model response")
+	webSearch := flags.Bool("web-search", false, "allow the model to search the web")
+	webSearchMaxUses := flags.Int64("web-search-max-uses", 0, "maximum web searches per model response")
+	webSearchDomains := flags.String("web-search-domains", "", "comma-separated domains web search is restricted to")
+	resultsPerFetch := flags.Int64("results-per-fetch", 0, "page size for newly created searches")
+	backfillPages := flags.Int("backfill-pages", 0, "arXiv API pages per backfill")
+	pageDelay := flags.Duration("page-delay", 0, "pause between arXiv API pages")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// godotenv never overrides variables that are already set, which gives the
+	// real environment precedence over .env
+	if err := godotenv.Load(*envFile); err != nil && !(errors.Is(err, fs.ErrNotExist) && !set["env-file"]) {
+		return Config{}, nil, fmt.Errorf("loading %s: %w", *envFile, err)
+	}
+
+	cfg := Default()
+
+	// An explicitly requested config file must exist; the default one is optional
+	path, explicit := *configPath, set["config"]
+	if !explicit {
+		path, explicit = os.LookupEnv(EnvConfigPath)
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		if _, err := toml.DecodeFile(path, &cfg); err != nil {
+			if explicit || !errors.Is(err, fs.ErrNotExist) {
+				return Config{}, nil, fmt.Errorf("reading config %s: %w", path, err)
+			}
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, nil, err
+	}
+
+	if set["db"] {
+		cfg.DBPath = *dbPath
+	}
+	if set["workspace"] {
+		cfg.WorkspaceRoot = *workspaceRoot
+	}
+	if set["user"] {
+		cfg.User = *userName
+	}
+	if set["model"] {
+		cfg.Anthropic.Model = *model
+	}
+	if set["max-tokens"] {
+		cfg.Anthropic.MaxTokens = *maxTokens
+	}
+	if set["web-search"] {
+		cfg.WebSearch.Enabled = *webSearch
+	}
+	if set["web-search-max-uses"] {
+		cfg.WebSearch.MaxUses = *webSearchMaxUses
+	}
+	if set["web-search-domains"] {
+		cfg.WebSearch.AllowedDomains = splitList(*webSearchDomains)
+	}
+	if set["results-per-fetch"] {
+		cfg.Fetch.ResultsPerFetch = *resultsPerFetch
+	}
+	if set["backfill-pages"] {
+		cfg.Fetch.BackfillPages = *backfillPages
+	}
+	if set["page-delay"] {
+		cfg.Fetch.PageDelay = *pageDelay
+	}
+
+	if err := cfg.validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, flags.Args(), nil
+}
+
+// AgentOptions returns the settings every agent should be created with
+func (c Config) AgentOptions() agent.Options {
+	return agent.Options{
+		Model:            c.Anthropic.Model,
+		MaxTokens:        c.Anthropic.MaxTokens,
+		WebSearch:        c.WebSearch.Enabled,
+		WebSearchMaxUses: c.WebSearch.MaxUses,
+
+		WebSearchAllowedDomains: c.WebSearch.AllowedDomains,
+	}
+}
+
+// Prices returns the price table model usage is costed with
+func (c Config) Prices() costs.Prices {
+	return costs.Prices{Models: c.Costs.Prices, WebSearch: c.Costs.WebSearch}
+}
+
+// BackfillOptions returns the default options for backfilling a search
+func (c Config) BackfillOptions() fetcher.BackfillOptions {
+	return fetcher.BackfillOptions{
+		MaxPages:  c.Fetch.BackfillPages,
+		PageDelay: c.Fetch.PageDelay,
+	}
+}
+
+// applyEnv overrides cfg with any settings present in the environment
+func applyEnv(cfg *Config) error {
+	cfg.APIKey = os.Getenv(EnvAPIKey)
+	cfg.GitHub.Token = os.Getenv(EnvGitHubToken)
+	if v, ok := os.LookupEnv(EnvDBPath); ok {
+		cfg.DBPath = v
+	}
+	if v, ok := os.LookupEnv(EnvWorkspaceRoot); ok {
+		cfg.WorkspaceRoot = v
+	}
+	if v, ok := os.LookupEnv(EnvUser); ok {
+		cfg.User = v
+	}
+	if v, ok := os.LookupEnv(EnvModel); ok {
+		cfg.Anthropic.Model = v
+	}
+
+	var err error
+	if v, ok := os.LookupEnv(EnvMaxTokens); ok {
+		if cfg.Anthropic.MaxTokens, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return fmt.Errorf("%s: %w", EnvMaxTokens, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvWebSearch); ok {
+		if cfg.WebSearch.Enabled, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvWebSearch, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvWebSearchMaxUses); ok {
+		if cfg.WebSearch.MaxUses, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return fmt.Errorf("%s: %w", EnvWebSearchMaxUses, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvWebSearchDomains); ok {
+		cfg.WebSearch.AllowedDomains = splitList(v)
+	}
+	if v, ok := os.LookupEnv(EnvResultsPerFetch); ok {
+		if cfg.Fetch.ResultsPerFetch, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return fmt.Errorf("%s: %w", EnvResultsPerFetch, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvBackfillPages); ok {
+		if cfg.Fetch.BackfillPages, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvBackfillPages, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvPageDelay); ok {
+		if cfg.Fetch.PageDelay, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvPageDelay, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvDaemonInterval); ok {
+		if cfg.Daemon.Interval, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvDaemonInterval, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvDaemonConcurrency); ok {
+		if cfg.Daemon.Concurrency, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvDaemonConcurrency, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvDaemonHostDelay); ok {
+		if cfg.Daemon.HostDelay, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvDaemonHostDelay, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvDaemonScore); ok {
+		if cfg.Daemon.Score, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvDaemonScore, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvGitHubBaseURL); ok {
+		cfg.GitHub.BaseURL = v
+	}
+	if v, ok := os.LookupEnv(EnvGitHubCacheTTL); ok {
+		if cfg.GitHub.CacheTTL, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("%s: %w", EnvGitHubCacheTTL, err)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvGitHubConfidence); ok {
+		if cfg.GitHub.MinConfidence, err = strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%s: %w", EnvGitHubConfidence, err)
+		}
+	}
+	return nil
+}
+
+// validate rejects settings that would fail later in less obvious ways
+func (c Config) validate() error {
+	switch {
+	case c.DBPath == "":
+		return fmt.Errorf("database path must not be empty")
+	case c.User == "":
+		return fmt.Errorf("user must not be empty")
+	case c.Anthropic.Model == "":
+		return fmt.Errorf("model must not be empty")
+	case c.Anthropic.MaxTokens < 1:
+		return fmt.Errorf("max tokens must be positive")
+	case c.WebSearch.MaxUses < 1:
+		return fmt.Errorf("web search max uses must be positive")
+	case c.Fetch.ResultsPerFetch < 10 || c.Fetch.ResultsPerFetch >= 2000:
+		return fmt.Errorf("results per fetch must be between 10 and 1999")
+	case c.Fetch.BackfillPages < 1:
+		return fmt.Errorf("backfill pages must be positive")
+	case c.Fetch.PageDelay < 0:
+		return fmt.Errorf("page delay must not be negative")
+	case c.Daemon.Interval <= 0:
+		return fmt.Errorf("daemon interval must be positive")
+	case c.Daemon.Concurrency < 1:
+		return fmt.Errorf("daemon concurrency must be positive")
+	case c.Daemon.HostDelay < 0:
+		return fmt.Errorf("daemon host delay must not be negative")
+	case c.GitHub.BaseURL == "":
+		return fmt.Errorf("GitHub base URL must not be empty")
+	case c.GitHub.MinConfidence < 0 || c.GitHub.MinConfidence > 1:
+		return fmt.Errorf("GitHub min confidence must be between 0 and 1")
+	case c.Costs.WebSearch < 0:
+		return fmt.Errorf("web search price must not be negative")
+	}
+	for model, price := range c.Costs.Prices {
+		if price.Input < 0 || price.Output < 0 || price.CacheWrite < 0 || price.CacheRead < 0 {
+			return fmt.Errorf("prices for %s must not be negative", model)
+		}
+	}
+	return nil
+}
+
+// splitList parses a comma-separated environment value, dropping empty items
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// defaultUser is the current login name, or "" if it cannot be determined
+func defaultUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// defaultConfigPath is ~/.config/research/config.toml, or "" if there is no home directory
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "research", "config.toml")
+}
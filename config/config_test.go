@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate clears every variable Load reads for the rest of the test, and
+// points the default config file into an empty home directory. Variables a
+// .env file sets are removed again when the test ends.
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{
+		EnvConfigPath, EnvDBPath, EnvWorkspaceRoot, EnvUser, EnvAPIKey, EnvModel,
+		EnvMaxTokens, EnvWebSearch, EnvWebSearchMaxUses, EnvWebSearchDomains,
+		EnvResultsPerFetch, EnvBackfillPages, EnvPageDelay, EnvDaemonInterval,
+		EnvDaemonConcurrency, EnvDaemonHostDelay, EnvDaemonScore, EnvGitHubToken,
+		EnvGitHubBaseURL, EnvGitHubCacheTTL, EnvGitHubConfidence,
+	} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+// writeFile writes content to name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	isolate(t)
+	t.Setenv(EnvUser, "tester")
+	// The default .env is optional, but one named on the command line must exist
+	if _, _, err := Load([]string{"--env-file", filepath.Join(t.TempDir(), "missing.env")}); err == nil {
+		t.Error("Load with a missing, explicitly given .env succeeded")
+	}
+
+	cfg, args, err := Load([]string{"search", "list"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := Default()
+	if cfg.DBPath != want.DBPath || cfg.Anthropic != want.Anthropic || cfg.Fetch != want.Fetch || cfg.Daemon != want.Daemon || cfg.User != "tester" {
+		t.Errorf("Load without settings = %+v, want the defaults", cfg)
+	}
+	if strings.Join(args, " ") != "search list" {
+		t.Errorf("leftover arguments %q, want the subcommand", args)
+	}
+}
+
+// Each source overrides the ones before it: defaults, the config file, .env,
+// the environment and flags
+func TestLoadPrecedence(t *testing.T) {
+	isolate(t)
+	dir := t.TempDir()
+	configPath := writeFile(t, dir, "config.toml", `
+db_path = "toml.db"
+user = "toml-user"
+
+[anthropic]
+model = "toml-model"
+max_tokens = 100
+
+[fetch]
+results_per_fetch = 20
+
+[costs.prices."claude-test"]
+input = 1
+output = 2
+`)
+	envPath := writeFile(t, dir, ".env", `
+RESEARCH_MODEL=dotenv-model
+RESEARCH_MAX_TOKENS=200
+RESEARCH_USER=dotenv-user
+RESEARCH_DB_PATH=dotenv.db
+ANTHROPIC_API_KEY=dotenv-key
+`)
+	t.Setenv(EnvMaxTokens, "300")
+	t.Setenv(EnvDBPath, "env.db")
+
+	cfg, args, err := Load([]string{"--config", configPath, "--env-file", envPath, "--db", "flag.db", "candidates", "--json"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, check := range []struct {
+		setting   string
+		got, want interface{}
+	}{
+		{"db path (flag over environment)", cfg.DBPath, "flag.db"},
+		{"max tokens (environment over .env)", cfg.Anthropic.MaxTokens, int64(300)},
+		{"model (.env over config file)", cfg.Anthropic.Model, "dotenv-model"},
+		{"user (.env over config file)", cfg.User, "dotenv-user"},
+		{"API key (.env)", cfg.APIKey, "dotenv-key"},
+		{"results per fetch (config file over default)", cfg.Fetch.ResultsPerFetch, int64(20)},
+		{"backfill pages (default)", cfg.Fetch.BackfillPages, Default().Fetch.BackfillPages},
+	} {
+		if check.got != check.want {
+			t.Errorf("%s = %v, want %v", check.setting, check.got, check.want)
+		}
+	}
+	if _, ok := cfg.Costs.Prices["claude-test"]; !ok {
+		t.Error("the config file's price was not added")
+	}
+	if _, ok := cfg.Costs.Prices["claude-sonnet-4"]; !ok {
+		t.Error("the config file's prices replaced the built-in ones")
+	}
+	if strings.Join(args, " ") != "candidates --json" {
+		t.Errorf("leftover arguments %q, want the subcommand and its flags", args)
+	}
+
+	// An explicitly named config file must exist
+	if _, _, err := Load([]string{"--config", filepath.Join(dir, "missing.toml")}); err == nil {
+		t.Error("Load with a missing, explicitly given config file succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	isolate(t)
+	t.Setenv(EnvUser, "tester")
+	tests := []struct {
+		args []string
+		want string
+	}{
+		// Pipeline changes must say who made them, so an empty default user,
+		// as when the login name cannot be found, is refused
+		{[]string{"--user", ""}, "user must not be empty"},
+		{[]string{"--db", ""}, "database path must not be empty"},
+		{[]string{"--max-tokens", "-1"}, "max tokens must be positive"},
+		{[]string{"--results-per-fetch", "5"}, "results per fetch must be between 10 and 1999"},
+		{[]string{"--page-delay", "-1s"}, "page delay must not be negative"},
+	}
+	for _, tt := range tests {
+		if _, _, err := Load(tt.args); err == nil || err.Error() != tt.want {
+			t.Errorf("Load(%q) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+
+	t.Setenv(EnvUser, "")
+	if _, _, err := Load(nil); err == nil || err.Error() != "user must not be empty" {
+		t.Errorf("Load with an empty %s = %v, want the empty user error", EnvUser, err)
+	}
+	t.Setenv(EnvUser, "tester")
+	t.Setenv(EnvMaxTokens, "lots")
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), EnvMaxTokens) {
+		t.Errorf("Load with %s=lots = %v, want an error naming the variable", EnvMaxTokens, err)
+	}
+}
@@ -0,0 +1,195 @@
+// Package costs keeps the books on model requests: every call an agent makes
+// is recorded with its model, token counts, server-side web searches, latency
+// and the search or article it was for. Calls are priced with a configurable
+// table when a report is run, and a search with a monthly budget has its LLM
+// work refused once the month's calls have cost that much.
+package costs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Purposes recorded with each call
+const (
+	PurposeScoring           = "scoring"
+	PurposeProfileDiscovery  = "profile_discovery"
+	PurposeCategoryDiscovery = "category_discovery"
+	PurposeAssistant         = "assistant"
+)
+
+// ErrBudgetExceeded is wrapped by the error returned when a search has spent
+// its monthly budget
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+// Tokens totals the usage of one or more calls
+type Tokens struct {
+	Calls       int64
+	Input       int64
+	Output      int64
+	CacheWrite  int64
+	CacheRead   int64
+	WebSearches int64
+	Latency     time.Duration
+}
+
+func (t *Tokens) add(other Tokens) {
+	t.Calls += other.Calls
+	t.Input += other.Input
+	t.Output += other.Output
+	t.CacheWrite += other.CacheWrite
+	t.CacheRead += other.CacheRead
+	t.WebSearches += other.WebSearches
+	t.Latency += other.Latency
+}
+
+// Ledger records model calls in SQLite and checks them against search budgets
+type Ledger struct {
+	queries *database.Queries
+	prices  Prices
+}
+
+// NewLedger creates a new ledger that prices calls with prices
+func NewLedger(queries *database.Queries, prices Prices) *Ledger {
+	return &Ledger{queries: queries, prices: prices}
+}
+
+// Target says what a model call was made for. SearchID, ArticleID and
+// SessionID may be nil; a call with a search counts against its budget.
+type Target struct {
+	Purpose   string
+	SearchID  interface{}
+	ArticleID interface{}
+	// SessionID is the saved conversation the call was made in
+	SessionID interface{}
+}
+
+// For returns a recorder for calls made toward target, to hand to
+// agent.Agent.SetUsageRecorder
+func (l *Ledger) For(target Target) *Recorder {
+	return &Recorder{ledger: l, target: target}
+}
+
+// Recorder records an agent's calls against one target
+type Recorder struct {
+	ledger *Ledger
+	target Target
+}
+
+// Allow refuses the call once the target's search has spent its monthly budget
+func (r *Recorder) Allow(ctx context.Context) error {
+	if r.target.SearchID == nil {
+		return nil
+	}
+	return r.ledger.CheckBudget(ctx, r.target.SearchID)
+}
+
+// Record saves a completed call
+func (r *Recorder) Record(ctx context.Context, call agent.Call) error {
+	return r.ledger.queries.AddLLMCall(ctx, database.AddLLMCallParams{
+		ID:                       uuid.New(),
+		CreatedAt:                time.Now().UTC(),
+		Purpose:                  r.target.Purpose,
+		Model:                    call.Model,
+		InputTokens:              call.Usage.InputTokens,
+		OutputTokens:             call.Usage.OutputTokens,
+		CacheCreationInputTokens: call.Usage.CacheCreationInputTokens,
+		CacheReadInputTokens:     call.Usage.CacheReadInputTokens,
+		WebSearchRequests:        call.Usage.ServerToolUse.WebSearchRequests,
+		LatencyMs:                call.Latency.Milliseconds(),
+		SearchID:                 r.target.SearchID,
+		ArticleID:                r.target.ArticleID,
+		SessionID:                r.target.SessionID,
+	})
+}
+
+// AttachSession puts the calls made in a conversation before its search
+// existed, such as category discovery's, against the search it created
+func (l *Ledger) AttachSession(ctx context.Context, sessionID, searchID interface{}) error {
+	_, err := l.queries.AttachLLMCallsBySessionID(ctx, database.AttachLLMCallsBySessionIDParams{
+		SearchID:  searchID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("attaching the conversation's calls to search %v: %w", searchID, err)
+	}
+	return nil
+}
+
+// Budget is a search's spend this month against its monthly budget
+type Budget struct {
+	// Limit is the monthly budget in US dollars, meaningful only when Set
+	Limit float64
+	Set   bool
+	// Spent is what the search's calls have cost since the start of the
+	// month, in UTC
+	Spent float64
+	// Unpriced lists the models used this month that have no price; their
+	// tokens are not counted in Spent
+	Unpriced []string
+}
+
+// Exceeded reports whether the search's LLM work is paused
+func (b Budget) Exceeded() bool {
+	return b.Set && b.Spent >= b.Limit
+}
+
+// MonthStart is the start of t's calendar month in UTC, when budgets reset
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Budget works out a search's spend this month against its budget
+func (l *Ledger) Budget(ctx context.Context, searchID interface{}) (Budget, error) {
+	search, err := l.queries.GetSearchByID(ctx, searchID)
+	if err != nil {
+		return Budget{}, fmt.Errorf("retrieving search %v: %w", searchID, err)
+	}
+	rows, err := l.queries.SumLLMCallsBySearchSince(ctx, database.SumLLMCallsBySearchSinceParams{
+		SearchID: searchID,
+		Since:    MonthStart(time.Now()),
+	})
+	if err != nil {
+		return Budget{}, fmt.Errorf("totalling this month's calls: %w", err)
+	}
+
+	budget := Budget{Limit: search.MonthlyBudget.Float64, Set: search.MonthlyBudget.Valid}
+	for _, row := range rows {
+		cost, priced := l.prices.Cost(row.Model, Tokens{
+			Input:       row.InputTokens,
+			Output:      row.OutputTokens,
+			CacheWrite:  row.CacheCreationInputTokens,
+			CacheRead:   row.CacheReadInputTokens,
+			WebSearches: row.WebSearchRequests,
+		})
+		budget.Spent += cost
+		if !priced {
+			budget.Unpriced = append(budget.Unpriced, row.Model)
+		}
+	}
+	sort.Strings(budget.Unpriced)
+	return budget, nil
+}
+
+// CheckBudget returns an error wrapping ErrBudgetExceeded if the search has
+// spent its monthly budget
+func (l *Ledger) CheckBudget(ctx context.Context, searchID interface{}) error {
+	budget, err := l.Budget(ctx, searchID)
+	if err != nil {
+		return err
+	}
+	if budget.Exceeded() {
+		return fmt.Errorf("%w: search %v has spent %s of %s; its LLM work resumes next month or once the budget is raised",
+			ErrBudgetExceeded, searchID, FormatUSD(budget.Spent), FormatUSD(budget.Limit))
+	}
+	return nil
+}
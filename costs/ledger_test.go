@@ -0,0 +1,104 @@
+package costs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// dollarCall costs $1 with testPrices: 40k output tokens at $25 per million
+var dollarCall = agent.Call{Model: "claude-opus-4-5", Usage: anthropic.Usage{OutputTokens: 40000}}
+
+// Calls against a search add up to its spend, and its work is refused once
+// the spend reaches the monthly budget
+func TestCheckBudget(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	ledger := NewLedger(queries, testPrices)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	_, err := queries.SetSearchMonthlyBudget(ctx, database.SetSearchMonthlyBudgetParams{
+		UpdatedAt:     time.Now(),
+		MonthlyBudget: sql.NullFloat64{Float64: 2, Valid: true},
+		ID:            search.ID,
+	})
+	if err != nil {
+		t.Fatalf("SetSearchMonthlyBudget: %v", err)
+	}
+
+	recorder := ledger.For(Target{Purpose: PurposeScoring, SearchID: search.ID})
+	for i := 0; i < 2; i++ {
+		if err := recorder.Allow(ctx); err != nil {
+			t.Fatalf("call %d refused: %v", i+1, err)
+		}
+		if err := recorder.Record(ctx, dollarCall); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	// A call to an unpriced model is flagged rather than counted
+	if err := recorder.Record(ctx, agent.Call{Model: "gpt-4o", Usage: anthropic.Usage{OutputTokens: 1e6}}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	budget, err := ledger.Budget(ctx, search.ID)
+	if err != nil {
+		t.Fatalf("Budget: %v", err)
+	}
+	if budget.Spent != 2 || len(budget.Unpriced) != 1 || budget.Unpriced[0] != "gpt-4o" || !budget.Exceeded() {
+		t.Errorf("budget %+v, want $2 spent of $2 with gpt-4o unpriced", budget)
+	}
+	if err := recorder.Allow(ctx); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Allow after the budget is spent = %v, want ErrBudgetExceeded", err)
+	}
+
+	// Calls for no search are never refused
+	if err := ledger.For(Target{Purpose: PurposeAssistant}).Allow(ctx); err != nil {
+		t.Errorf("Allow without a search = %v", err)
+	}
+}
+
+// Category discovery's calls, recorded before its search exists, count
+// toward the search once the conversation is attached to it
+func TestAttachSession(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	ledger := NewLedger(queries, testPrices)
+	sessionID, otherSessionID := uuid.New(), uuid.New()
+
+	for _, target := range []Target{
+		{Purpose: PurposeCategoryDiscovery, SessionID: sessionID},
+		{Purpose: PurposeCategoryDiscovery, SessionID: sessionID},
+		{Purpose: PurposeCategoryDiscovery, SessionID: otherSessionID},
+	} {
+		if err := ledger.For(target).Record(ctx, dollarCall); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	if err := ledger.AttachSession(ctx, sessionID, search.ID); err != nil {
+		t.Fatalf("AttachSession: %v", err)
+	}
+
+	calls, err := queries.ListLLMCalls(ctx, database.ListLLMCallsParams{SearchID: search.ID})
+	if err != nil {
+		t.Fatalf("ListLLMCalls: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("%d calls attached to the search, want the session's 2", len(calls))
+	}
+	budget, err := ledger.Budget(ctx, search.ID)
+	if err != nil {
+		t.Fatalf("Budget: %v", err)
+	}
+	if budget.Spent != 2 {
+		t.Errorf("search spent %v, want the $2 of its discovery", budget.Spent)
+	}
+}
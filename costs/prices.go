@@ -0,0 +1,80 @@
+package costs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Price is what a model charges, in US dollars per million tokens
+type Price struct {
+	Input  float64 `toml:"input"`
+	Output float64 `toml:"output"`
+	// CacheWrite is the price of writing to the prompt cache with the default
+	// five-minute lifetime
+	CacheWrite float64 `toml:"cache_write"`
+	CacheRead  float64 `toml:"cache_read"`
+}
+
+// DefaultWebSearchPrice is the price of 1,000 server-side web searches
+const DefaultWebSearchPrice = 10.0
+
+// DefaultModelPrices are Anthropic's list prices. Keys are model name
+// prefixes, so "claude-sonnet-4" also prices "claude-sonnet-4-20250514".
+func DefaultModelPrices() map[string]Price {
+	return map[string]Price{
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+		"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+		"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+	}
+}
+
+// Prices is the price table costs are worked out with
+type Prices struct {
+	// Models maps model names, or prefixes of them, to their price. The
+	// longest matching key wins.
+	Models map[string]Price
+	// WebSearch is the price of 1,000 web searches
+	WebSearch float64
+}
+
+// Lookup finds a model's price, reporting false if no key matches
+func (p Prices) Lookup(model string) (Price, bool) {
+	var best string
+	found := false
+	for key := range p.Models {
+		if strings.HasPrefix(model, key) && (!found || len(key) > len(best)) {
+			best, found = key, true
+		}
+	}
+	return p.Models[best], found
+}
+
+// Cost prices a model's usage in US dollars, reporting false if the model
+// has no price. Web searches are priced even then, and included in the cost.
+func (p Prices) Cost(model string, t Tokens) (float64, bool) {
+	cost := float64(t.WebSearches) * p.WebSearch / 1000
+	price, ok := p.Lookup(model)
+	if !ok {
+		return cost, false
+	}
+	cost += (float64(t.Input)*price.Input +
+		float64(t.Output)*price.Output +
+		float64(t.CacheWrite)*price.CacheWrite +
+		float64(t.CacheRead)*price.CacheRead) / 1e6
+	return cost, true
+}
+
+// FormatUSD formats dollars, with more places for the small sums single calls
+// cost
+func FormatUSD(v float64) string {
+	if v != 0 && v < 1 {
+		return fmt.Sprintf("$%.4f", v)
+	}
+	return fmt.Sprintf("$%.2f", v)
+}
@@ -0,0 +1,89 @@
+package costs
+
+import (
+	"math"
+	"testing"
+)
+
+// testPrices has overlapping prefixes so the longest must win
+var testPrices = Prices{
+	Models: map[string]Price{
+		"claude-opus-4":   {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"claude-opus-4-5": {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+		"claude":          {Input: 100, Output: 100},
+	},
+	WebSearch: 10,
+}
+
+func TestPricesLookup(t *testing.T) {
+	tests := []struct {
+		model     string
+		wantInput float64
+		wantFound bool
+	}{
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-opus-4-20250514", 15, true},
+		{"claude-opus-4", 15, true},
+		{"claude-haiku-4-5", 100, true},
+		{"gpt-4o", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		price, found := testPrices.Lookup(tt.model)
+		if found != tt.wantFound || price.Input != tt.wantInput {
+			t.Errorf("Lookup(%q) = %v, %v; want input %v, %v", tt.model, price, found, tt.wantInput, tt.wantFound)
+		}
+	}
+}
+
+func TestPricesCost(t *testing.T) {
+	tests := []struct {
+		model      string
+		tokens     Tokens
+		want       float64
+		wantPriced bool
+	}{
+		// 1M input at $5, 200k output at $25, 400k cache writes at $6.25 and
+		// 1M cache reads at $0.50
+		{"claude-opus-4-5", Tokens{Input: 1e6, Output: 2e5, CacheWrite: 4e5, CacheRead: 1e6}, 5 + 5 + 2.5 + 0.5, true},
+		// Web searches cost $10 per thousand on top of the tokens
+		{"claude-opus-4", Tokens{Output: 1e5, WebSearches: 3}, 7.5 + 0.03, true},
+		// An unpriced model still pays for its web searches
+		{"gpt-4o", Tokens{Input: 1e6, WebSearches: 100}, 1, false},
+		{"claude-opus-4", Tokens{}, 0, true},
+	}
+	for _, tt := range tests {
+		got, priced := testPrices.Cost(tt.model, tt.tokens)
+		if math.Abs(got-tt.want) > 1e-9 || priced != tt.wantPriced {
+			t.Errorf("Cost(%q, %+v) = %v, %v; want %v, %v", tt.model, tt.tokens, got, priced, tt.want, tt.wantPriced)
+		}
+	}
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	tests := []struct {
+		budget Budget
+		want   bool
+	}{
+		{Budget{Limit: 5, Set: true, Spent: 4.99}, false},
+		{Budget{Limit: 5, Set: true, Spent: 5}, true},
+		{Budget{Limit: 5, Set: true, Spent: 7}, true},
+		// A budget of zero pauses the search outright
+		{Budget{Limit: 0, Set: true}, true},
+		// No budget never pauses it
+		{Budget{Spent: 1000}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.budget.Exceeded(); got != tt.want {
+			t.Errorf("%+v Exceeded() = %v, want %v", tt.budget, got, tt.want)
+		}
+	}
+}
+
+func TestFormatUSD(t *testing.T) {
+	for v, want := range map[float64]string{0: "$0.00", 0.00123: "$0.0012", 1: "$1.00", 12.345: "$12.35"} {
+		if got := FormatUSD(v); got != want {
+			t.Errorf("FormatUSD(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
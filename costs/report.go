@@ -0,0 +1,147 @@
+package costs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Groupings of the costs report
+const (
+	BySearch  = "search"
+	ByDay     = "day"
+	ByModel   = "model"
+	ByPurpose = "purpose"
+)
+
+// Groupings lists every supported grouping
+var Groupings = []string{BySearch, ByDay, ByModel, ByPurpose}
+
+// ReportOptions selects the calls a report covers. Zero values match
+// everything.
+type ReportOptions struct {
+	// By is the grouping, BySearch when empty
+	By       string
+	SearchID interface{}
+	// Since is inclusive and Until exclusive
+	Since time.Time
+	Until time.Time
+}
+
+// Line is one row of a costs report
+type Line struct {
+	// Key is the search ID, the day (YYYY-MM-DD, UTC), the model or the
+	// purpose. It is empty for calls made for no search, or for a search
+	// since deleted.
+	Key string
+	// Label is the search's description when grouping by search
+	Label string
+	Tokens
+	// Cost is in US dollars
+	Cost float64
+	// Unpriced lists the models that have no price; their tokens are left
+	// out of Cost
+	Unpriced []string
+}
+
+// Report groups the selected calls into priced lines and returns them with
+// their total. Days run oldest first; other groupings cost most first.
+func (l *Ledger) Report(ctx context.Context, opts ReportOptions) ([]Line, Line, error) {
+	by := opts.By
+	if by == "" {
+		by = BySearch
+	}
+	var key func(database.ListLLMCallsRow) string
+	switch by {
+	case BySearch:
+		key = func(row database.ListLLMCallsRow) string {
+			if row.SearchID == nil {
+				return ""
+			}
+			return fmt.Sprint(row.SearchID)
+		}
+	case ByDay:
+		key = func(row database.ListLLMCallsRow) string { return row.CreatedAt.UTC().Format("2006-01-02") }
+	case ByModel:
+		key = func(row database.ListLLMCallsRow) string { return row.Model }
+	case ByPurpose:
+		key = func(row database.ListLLMCallsRow) string { return row.Purpose }
+	default:
+		return nil, Line{}, fmt.Errorf("unknown grouping %q (expected one of %s)", by, strings.Join(Groupings, ", "))
+	}
+
+	params := database.ListLLMCallsParams{SearchID: opts.SearchID}
+	if !opts.Since.IsZero() {
+		params.Since = opts.Since.UTC()
+	}
+	if !opts.Until.IsZero() {
+		params.Until = opts.Until.UTC()
+	}
+	rows, err := l.queries.ListLLMCalls(ctx, params)
+	if err != nil {
+		return nil, Line{}, fmt.Errorf("listing LLM calls: %w", err)
+	}
+
+	// Tokens are totalled per model first, since each model has its own price
+	var keys []string
+	labels := map[string]string{}
+	byModel := map[string]map[string]Tokens{}
+	totalByModel := map[string]Tokens{}
+	for _, row := range rows {
+		k := key(row)
+		if _, ok := byModel[k]; !ok {
+			keys = append(keys, k)
+			byModel[k] = map[string]Tokens{}
+		}
+		if by == BySearch {
+			labels[k] = row.SearchDescription
+		}
+		tokens := Tokens{
+			Calls:       1,
+			Input:       row.InputTokens,
+			Output:      row.OutputTokens,
+			CacheWrite:  row.CacheCreationInputTokens,
+			CacheRead:   row.CacheReadInputTokens,
+			WebSearches: row.WebSearchRequests,
+			Latency:     time.Duration(row.LatencyMs) * time.Millisecond,
+		}
+		t := byModel[k][row.Model]
+		t.add(tokens)
+		byModel[k][row.Model] = t
+		t = totalByModel[row.Model]
+		t.add(tokens)
+		totalByModel[row.Model] = t
+	}
+
+	lines := make([]Line, len(keys))
+	for i, k := range keys {
+		lines[i] = l.price(byModel[k])
+		lines[i].Key, lines[i].Label = k, labels[k]
+	}
+	sort.SliceStable(lines, func(x, y int) bool {
+		if by != ByDay && lines[x].Cost != lines[y].Cost {
+			return lines[x].Cost > lines[y].Cost
+		}
+		return lines[x].Key < lines[y].Key
+	})
+	return lines, l.price(totalByModel), nil
+}
+
+// price totals and prices per-model usage into a line
+func (l *Ledger) price(byModel map[string]Tokens) Line {
+	var line Line
+	for model, tokens := range byModel {
+		cost, priced := l.prices.Cost(model, tokens)
+		line.Cost += cost
+		line.Tokens.add(tokens)
+		if !priced {
+			line.Unpriced = append(line.Unpriced, model)
+		}
+	}
+	sort.Strings(line.Unpriced)
+	return line
+}
@@ -0,0 +1,251 @@
+// Package daemon keeps searches fresh in the background: on every tick it picks
+// up the searches that have not been fetched recently, fetches their feeds,
+// scores the new articles and extracts candidates. Scoring is skipped for
+// searches that have spent their monthly budget, and a search whose feed keeps
+// failing is retried less and less often.
+package daemon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/scorer"
+)
+
+// Options controls how often and how aggressively the daemon refreshes searches
+type Options struct {
+	// Interval is the time between refresh cycles. A search counts as stale
+	// once it has not been fetched for this long.
+	Interval time.Duration
+	// Concurrency is the number of searches refreshed at the same time
+	Concurrency int
+	// BatchSize caps the number of stale searches picked up per cycle
+	BatchSize int64
+	// Score enables relevance scoring and candidate extraction after fetching
+	Score bool
+	// ScoreLimit caps the number of articles scored per search per cycle
+	ScoreLimit int64
+	// MaxBackoff caps how long a search whose fetches keep failing is left
+	// out. It is never shorter than Interval.
+	MaxBackoff time.Duration
+}
+
+// Summary totals the work done in one refresh cycle. Paused counts the searches
+// whose scoring stopped at their monthly budget.
+type Summary struct {
+	Searches      int
+	Failed        int
+	Paused        int
+	NewArticles   int
+	Scored        int
+	NewCandidates int
+	Duration      time.Duration
+}
+
+// Daemon periodically refreshes stale searches
+type Daemon struct {
+	queries *database.Queries
+	fetcher *fetcher.Fetcher
+	scorer  *scorer.Scorer
+	linker  *candidates.Linker
+	opts    Options
+	logger  *log.Logger
+}
+
+// NewDaemon creates a new daemon. The fetcher should have a host rate limit
+// set (see fetcher.SetHostRateLimit) when Concurrency is above one.
+func NewDaemon(queries *database.Queries, feedFetcher *fetcher.Fetcher, articleScorer *scorer.Scorer, candidateLinker *candidates.Linker, opts Options, logger *log.Logger) *Daemon {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.ScoreLimit <= 0 {
+		opts.ScoreLimit = 100
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 24 * time.Hour
+	}
+	if opts.MaxBackoff < opts.Interval {
+		opts.MaxBackoff = opts.Interval
+	}
+	return &Daemon{
+		queries: queries,
+		fetcher: feedFetcher,
+		scorer:  articleScorer,
+		linker:  candidateLinker,
+		opts:    opts,
+		logger:  logger,
+	}
+}
+
+// Run refreshes stale searches immediately and then once per interval until
+// ctx is cancelled. Cancelling ctx aborts in-flight work; Run waits for it to
+// unwind and returns nil.
+func (d *Daemon) Run(ctx context.Context) error {
+	d.logger.Printf("daemon started: interval %s, concurrency %d, scoring %t", d.opts.Interval, d.opts.Concurrency, d.opts.Score)
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		summary, err := d.RunOnce(ctx)
+		if ctx.Err() != nil {
+			d.logger.Printf("daemon stopping: %v", ctx.Err())
+			return nil
+		}
+		if err != nil {
+			d.logger.Printf("cycle failed: %v", err)
+		} else {
+			d.logger.Printf("cycle done in %s: %d searches (%d failed, %d over budget), %d new articles, %d scored, %d new candidates",
+				summary.Duration.Round(time.Millisecond), summary.Searches, summary.Failed, summary.Paused,
+				summary.NewArticles, summary.Scored, summary.NewCandidates)
+		}
+
+		select {
+		case <-ctx.Done():
+			d.logger.Printf("daemon stopping: %v", ctx.Err())
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce refreshes every search that has not been fetched within the
+// interval, except those backing off after failed fetches. Searches that have
+// been failing are taken last, so they cannot fill the batch ahead of healthy
+// ones.
+func (d *Daemon) RunOnce(ctx context.Context) (Summary, error) {
+	start := time.Now()
+	var summary Summary
+
+	searches, err := d.queries.GetSearchesWithoutRecentFetches(ctx, database.GetSearchesWithoutRecentFetchesParams{
+		LastFetchDate:  sql.NullTime{Time: start.Add(-d.opts.Interval), Valid: true},
+		NextFetchAfter: sql.NullTime{Time: start, Valid: true},
+		Limit:          d.opts.BatchSize,
+	})
+	if err != nil {
+		return summary, fmt.Errorf("finding stale searches: %w", err)
+	}
+	summary.Searches = len(searches)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, d.opts.Concurrency)
+	for _, search := range searches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(search database.Search) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := d.refresh(ctx, search)
+			// Work interrupted by shutdown is not a failure worth reporting
+			if err != nil && ctx.Err() == nil {
+				d.logger.Printf("search %v (%s): %v", search.ID, search.Description, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				summary.Failed++
+			}
+			summary.Paused += result.Paused
+			summary.NewArticles += result.NewArticles
+			summary.Scored += result.Scored
+			summary.NewCandidates += result.NewCandidates
+		}(search)
+	}
+	wg.Wait()
+
+	summary.Duration = time.Since(start)
+	return summary, nil
+}
+
+// refresh fetches one search and, if enabled, scores it and extracts candidates
+func (d *Daemon) refresh(ctx context.Context, search database.Search) (Summary, error) {
+	var summary Summary
+
+	fetched, err := d.fetcher.FetchNew(ctx, search)
+	if fetched != nil {
+		summary.NewArticles = len(fetched.Created)
+	}
+	if err != nil && ctx.Err() == nil {
+		if backOffErr := d.backOff(ctx, search); backOffErr != nil {
+			d.logger.Printf("search %v (%s): recording failed fetch: %v", search.ID, search.Description, backOffErr)
+		}
+	}
+	if err != nil || !d.opts.Score {
+		return summary, err
+	}
+
+	scored, err := d.scorer.ScoreSearch(ctx, search, d.opts.ScoreLimit)
+	if scored != nil {
+		summary.Scored = scored.Scored
+	}
+	if errors.Is(err, costs.ErrBudgetExceeded) {
+		// Candidates are still extracted from what was scored before the budget ran out
+		d.logger.Printf("search %v (%s): scoring paused: %v", search.ID, search.Description, err)
+		summary.Paused = 1
+		err = nil
+	}
+	if err != nil {
+		return summary, err
+	}
+
+	linked, err := d.linker.LinkSearch(ctx, search, candidates.DefaultMinRelevance)
+	if linked != nil {
+		summary.NewCandidates = linked.Created
+	}
+	return summary, err
+}
+
+// backOff leaves a search whose fetch failed out of the next cycles. The
+// fetcher clears the backoff on its next successful fetch.
+func (d *Daemon) backOff(ctx context.Context, search database.Search) error {
+	failures := search.FetchFailures + 1
+	now := time.Now()
+	next := now.Add(backoffDelay(d.opts.Interval, d.opts.MaxBackoff, failures))
+	_, err := d.queries.RecordSearchFetchFailure(ctx, database.RecordSearchFetchFailureParams{
+		UpdatedAt:      now,
+		NextFetchAfter: sql.NullTime{Time: next, Valid: true},
+		ID:             search.ID,
+	})
+	if err == nil {
+		d.logger.Printf("search %v (%s): %d failed fetches in a row, next try after %s", search.ID, search.Description, failures, next.Format(time.RFC3339))
+	}
+	return err
+}
+
+// backoffDelay is how long a search is left out after its nth failed fetch in
+// a row: one interval, doubling with every further failure, up to limit
+func backoffDelay(interval, limit time.Duration, failures int64) time.Duration {
+	delay := interval
+	for i := int64(1); i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
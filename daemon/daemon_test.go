@@ -0,0 +1,202 @@
+package daemon
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// feedServer serves the fetcher's recorded feed at /rss/cs.LG and fails at
+// /rss/broken until broken is cleared. A request to /rss/slow is reported on
+// slow and then hangs until the client gives up.
+type feedServer struct {
+	*httptest.Server
+	broken atomic.Bool
+	slow   chan struct{}
+}
+
+func newFeedServer(t *testing.T) *feedServer {
+	t.Helper()
+	s := &feedServer{slow: make(chan struct{}, 1)}
+	s.broken.Store(true)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss/cs.LG":
+			http.ServeFile(w, r, "../fetcher/testdata/rss.xml")
+		case "/rss/broken":
+			if s.broken.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			http.ServeFile(w, r, "../fetcher/testdata/rss.xml")
+		case "/rss/slow":
+			s.slow <- struct{}{}
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestDaemon(queries *database.Queries, server *feedServer, opts Options) *Daemon {
+	return NewDaemon(queries, fetcher.NewFetcher(queries, server.Client()), nil, nil, opts, log.New(io.Discard, "", 0))
+}
+
+// runOnce runs a cycle and checks how many searches it refreshed and failed
+func runOnce(t *testing.T, d *Daemon, searches, failed int) Summary {
+	t.Helper()
+	summary, err := d.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if summary.Searches != searches || summary.Failed != failed {
+		t.Fatalf("cycle refreshed %d searches with %d failed, want %d with %d failed", summary.Searches, summary.Failed, searches, failed)
+	}
+	return summary
+}
+
+// expireBackoff makes a backing-off search due again
+func expireBackoff(t *testing.T, queries *database.Queries, id interface{}) {
+	t.Helper()
+	_, err := queries.RecordSearchFetchFailure(context.Background(), database.RecordSearchFetchFailureParams{
+		UpdatedAt:      time.Now(),
+		NextFetchAfter: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		ID:             id,
+	})
+	if err != nil {
+		t.Fatalf("RecordSearchFetchFailure: %v", err)
+	}
+}
+
+// A failing search is left out of the following cycles, so the searches
+// behind it in a full batch are still refreshed, and comes back less and less
+// often until a fetch succeeds
+func TestRunOnceBacksOffFailingSearch(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	server := newFeedServer(t)
+	d := newTestDaemon(queries, server, Options{Interval: time.Hour, BatchSize: 1, MaxBackoff: 4 * time.Hour})
+
+	broken := dbtest.CreateSearch(t, queries, "broken", server.URL+"/rss/broken")
+	healthy := dbtest.CreateSearch(t, queries, "healthy", server.URL+"/rss/cs.LG")
+	// Never fetched, the broken search would otherwise be first in every batch
+	_, err := queries.UpdateSearchLastFetchDate(ctx, database.UpdateSearchLastFetchDateParams{
+		UpdatedAt:     time.Now(),
+		LastFetchDate: sql.NullTime{Time: time.Now().Add(-2 * time.Hour), Valid: true},
+		ID:            healthy.ID,
+	})
+	if err != nil {
+		t.Fatalf("UpdateSearchLastFetchDate: %v", err)
+	}
+
+	runOnce(t, d, 1, 1)
+	search, err := queries.GetSearchByID(ctx, broken.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	if search.FetchFailures != 1 || !search.NextFetchAfter.Valid {
+		t.Fatalf("after a failed fetch: %d failures, next fetch after %v; want 1 and a time", search.FetchFailures, search.NextFetchAfter)
+	}
+
+	if summary := runOnce(t, d, 1, 0); summary.NewArticles != 2 {
+		t.Errorf("healthy search fetched %d new articles, want 2", summary.NewArticles)
+	}
+	runOnce(t, d, 0, 0)
+
+	// Once due again, the failing search still goes after one that is not failing
+	expireBackoff(t, queries, broken.ID)
+	dbtest.CreateSearch(t, queries, "new", server.URL+"/rss/cs.LG")
+	runOnce(t, d, 1, 0)
+	start := time.Now()
+	runOnce(t, d, 1, 1)
+	search, err = queries.GetSearchByID(ctx, broken.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	if search.FetchFailures != 3 {
+		t.Errorf("%d failures, want 3", search.FetchFailures)
+	}
+	if wait := search.NextFetchAfter.Time.Sub(start); wait < 4*time.Hour || wait > 4*time.Hour+time.Minute {
+		t.Errorf("third failure backs off for %s, want the 4h cap", wait)
+	}
+
+	// A successful fetch ends the backoff
+	server.broken.Store(false)
+	expireBackoff(t, queries, broken.ID)
+	runOnce(t, d, 1, 0)
+	search, err = queries.GetSearchByID(ctx, broken.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	if search.FetchFailures != 0 || search.NextFetchAfter.Valid {
+		t.Errorf("after a successful fetch: %d failures, next fetch after %v; want 0 and none", search.FetchFailures, search.NextFetchAfter)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		interval, limit time.Duration
+		failures        int64
+		want            time.Duration
+	}{
+		{time.Hour, 24 * time.Hour, 1, time.Hour},
+		{time.Hour, 24 * time.Hour, 2, 2 * time.Hour},
+		{time.Hour, 24 * time.Hour, 3, 4 * time.Hour},
+		{time.Hour, 24 * time.Hour, 100, 24 * time.Hour},
+		{time.Hour, 90 * time.Minute, 2, 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(tt.interval, tt.limit, tt.failures); got != tt.want {
+			t.Errorf("backoffDelay(%s, %s, %d) = %s, want %s", tt.interval, tt.limit, tt.failures, got, tt.want)
+		}
+	}
+}
+
+// Cancelling Run aborts a fetch in flight, Run returns nil, and the
+// interrupted search is not counted as failing
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, queries := dbtest.Open(t)
+	server := newFeedServer(t)
+	d := newTestDaemon(queries, server, Options{Interval: time.Hour})
+	slow := dbtest.CreateSearch(t, queries, "slow", server.URL+"/rss/slow")
+
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+
+	select {
+	case <-server.slow:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the slow feed was never requested")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	search, err := queries.GetSearchByID(context.Background(), slow.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	if search.FetchFailures != 0 || search.NextFetchAfter.Valid {
+		t.Errorf("interrupted search has %d failures, next fetch after %v; want none", search.FetchFailures, search.NextFetchAfter)
+	}
+}
@@ -0,0 +1,336 @@
+// Package enrich fills in candidate profile links. The Enricher matches
+// authors against GitHub users: an account is taken to belong to an author
+// when its profile name matches and, ideally, when it owns a repository
+// mentioning one of the author's papers. The WebDiscoverer has Claude look for
+// profiles with web search and keeps only the URLs a search result backs.
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// SourceGitHub is the candidate_enrichments.source of GitHub matches
+const SourceGitHub = "github"
+
+// DefaultMinConfidence is the confidence at or above which a match is written
+// to the candidate's github_url. A name match alone never reaches it.
+const DefaultMinConfidence = 0.6
+
+// Weights of the signals that make up a match's confidence, capped at 1.0
+const (
+	weightExactName   = 0.4
+	weightPartialName = 0.2
+	weightEmailDomain = 0.2
+	weightArxivRepo   = 0.4
+	weightTitleRepo   = 0.25
+)
+
+// Lookup limits per candidate, to stay well within GitHub's search rate limit
+const (
+	maxPapers   = 3
+	maxUsers    = 5
+	maxRepos    = 10
+	maxProfiles = 10
+	// titleWords is how many leading title words are searched for as a phrase
+	titleWords = 8
+)
+
+// Match is the best GitHub account found for a candidate
+type Match struct {
+	CandidateID interface{}
+	Name        string
+	// Login and ProfileURL are empty when no account was plausible at all
+	Login      string
+	ProfileURL string
+	Confidence float64
+	// Evidence lists the signals behind the confidence
+	Evidence []string
+	// Applied is set when the match was written to the candidate's github_url
+	Applied bool
+}
+
+// Result summarises an enrichment run
+type Result struct {
+	Candidates int
+	Matched    int
+	Applied    int
+	Matches    []Match
+}
+
+// Enricher looks candidates up on GitHub and records the best match
+type Enricher struct {
+	db            *sql.DB
+	queries       *database.Queries
+	github        *GitHubClient
+	minConfidence float64
+}
+
+// NewEnricher creates a new enricher. Matches below minConfidence are recorded
+// but not applied to the candidate.
+func NewEnricher(db *sql.DB, queries *database.Queries, github *GitHubClient, minConfidence float64) *Enricher {
+	return &Enricher{db: db, queries: queries, github: github, minConfidence: minConfidence}
+}
+
+// EnrichCandidates enriches each candidate in turn. It stops early, returning
+// what was done so far along with the error, if GitHub's rate limit runs out.
+func (e *Enricher) EnrichCandidates(ctx context.Context, list []database.Candidate, emailDomain string) (*Result, error) {
+	result := &Result{}
+	for _, candidate := range list {
+		match, err := e.EnrichCandidate(ctx, candidate, emailDomain)
+		if err != nil {
+			return result, fmt.Errorf("enriching %s: %w", candidate.Name, err)
+		}
+		result.Candidates++
+		if match.Login != "" {
+			result.Matched++
+		}
+		if match.Applied {
+			result.Applied++
+		}
+		result.Matches = append(result.Matches, *match)
+	}
+	return result, nil
+}
+
+// EnrichCandidate finds the most likely GitHub account for the candidate and
+// stores it with its confidence. emailDomain, e.g. "mit.edu", is an optional
+// hint: arXiv does not publish author emails, so it has to come from the user.
+// The match replaces the candidate's github_url only if that is empty or was
+// set by an earlier enrichment, so hand-entered links are never overwritten.
+func (e *Enricher) EnrichCandidate(ctx context.Context, candidate database.Candidate, emailDomain string) (*Match, error) {
+	match, err := e.findMatch(ctx, candidate, strings.ToLower(strings.TrimPrefix(emailDomain, "@")))
+	if err != nil {
+		return nil, err
+	}
+
+	evidence, err := json.Marshal(match.Evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := e.queries.WithTx(tx)
+
+	previous, err := qtx.GetCandidateEnrichment(ctx, database.GetCandidateEnrichmentParams{
+		CandidateID: candidate.ID,
+		Source:      SourceGitHub,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("reading previous enrichment: %w", err)
+	}
+
+	now := time.Now()
+	_, err = qtx.UpsertCandidateEnrichment(ctx, database.UpsertCandidateEnrichmentParams{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		CandidateID:  candidate.ID,
+		Source:       SourceGitHub,
+		ProfileLogin: sql.NullString{String: match.Login, Valid: match.Login != ""},
+		ProfileUrl:   sql.NullString{String: match.ProfileURL, Valid: match.ProfileURL != ""},
+		Confidence:   match.Confidence,
+		Evidence:     string(evidence),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recording enrichment: %w", err)
+	}
+
+	current := candidate.GithubUrl.String
+	ownedByEnrichment := !candidate.GithubUrl.Valid || current == "" ||
+		(previous.ProfileUrl.Valid && current == previous.ProfileUrl.String)
+	if match.Login != "" && match.Confidence >= e.minConfidence && ownedByEnrichment {
+		_, err = qtx.UpdateCandidate(ctx, database.UpdateCandidateParams{
+			UpdatedAt:   now,
+			Name:        candidate.Name,
+			LinkedinUrl: candidate.LinkedinUrl,
+			GithubUrl:   sql.NullString{String: match.ProfileURL, Valid: true},
+			ID:          candidate.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("updating candidate: %w", err)
+		}
+		match.Applied = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// profileEvidence accumulates the signals found for one GitHub login
+type profileEvidence struct {
+	login    string
+	found    int // order of discovery, to break ties in favour of GitHub's ranking
+	arxiv    []string
+	titles   []string
+	byDomain bool
+}
+
+// findMatch gathers GitHub logins from a user search and from repositories
+// mentioning the candidate's papers, then scores each one
+func (e *Enricher) findMatch(ctx context.Context, candidate database.Candidate, emailDomain string) (*Match, error) {
+	match := &Match{CandidateID: candidate.ID, Name: candidate.Name, Evidence: []string{}}
+	logins := map[string]*profileEvidence{}
+	add := func(login string) *profileEvidence {
+		key := strings.ToLower(login)
+		if logins[key] == nil {
+			logins[key] = &profileEvidence{login: login, found: len(logins)}
+		}
+		return logins[key]
+	}
+
+	query := fmt.Sprintf("%q in:name type:user", candidate.Name)
+	users, err := e.github.SearchUsers(ctx, query, maxUsers)
+	if err != nil {
+		return nil, fmt.Errorf("searching users: %w", err)
+	}
+	for _, user := range users {
+		add(user.Login)
+	}
+	if emailDomain != "" {
+		users, err := e.github.SearchUsers(ctx, fmt.Sprintf("%s %s in:email type:user", query, emailDomain), maxUsers)
+		if err != nil {
+			return nil, fmt.Errorf("searching users by email domain: %w", err)
+		}
+		for _, user := range users {
+			add(user.Login).byDomain = true
+		}
+	}
+
+	articles, err := e.queries.GetArticlesByCandidate(ctx, candidate.ID)
+	if err != nil {
+		return nil, fmt.Errorf("reading candidate's articles: %w", err)
+	}
+	for i, article := range articles {
+		if i == maxPapers {
+			break
+		}
+		if arxivID := fetcher.ExtractArxivID(article.ArticleUrl); arxivID != "" {
+			repos, err := e.github.SearchRepos(ctx, fmt.Sprintf("%q in:readme,description", arxivID), maxRepos)
+			if err != nil {
+				return nil, fmt.Errorf("searching repositories for %s: %w", arxivID, err)
+			}
+			for _, repo := range repos {
+				if repo.Owner.Type == "User" {
+					evidence := add(repo.Owner.Login)
+					evidence.arxiv = append(evidence.arxiv, fmt.Sprintf("%s mentions arXiv:%s", repo.FullName, arxivID))
+				}
+			}
+		}
+		if phrase := titlePhrase(article.ArticleTitle); phrase != "" {
+			repos, err := e.github.SearchRepos(ctx, fmt.Sprintf("%q in:name,description,readme", phrase), maxRepos)
+			if err != nil {
+				return nil, fmt.Errorf("searching repositories for %q: %w", phrase, err)
+			}
+			for _, repo := range repos {
+				if repo.Owner.Type == "User" {
+					evidence := add(repo.Owner.Login)
+					evidence.titles = append(evidence.titles, fmt.Sprintf("%s mentions %q", repo.FullName, article.ArticleTitle))
+				}
+			}
+		}
+	}
+
+	ranked := make([]*profileEvidence, 0, len(logins))
+	for _, evidence := range logins {
+		ranked = append(ranked, evidence)
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].found < ranked[j].found })
+	if len(ranked) > maxProfiles {
+		ranked = ranked[:maxProfiles]
+	}
+
+	wantName := candidates.NormalizeName(candidate.Name)
+	for _, evidence := range ranked {
+		user, err := e.github.GetUser(ctx, evidence.login)
+		if err != nil {
+			return nil, fmt.Errorf("reading profile %s: %w", evidence.login, err)
+		}
+		if user == nil {
+			continue
+		}
+
+		confidence, reasons := 0.0, []string{}
+		switch gotName := candidates.NormalizeName(user.Name); {
+		case gotName == wantName:
+			confidence += weightExactName
+			reasons = append(reasons, fmt.Sprintf("profile name %q matches", user.Name))
+		case partialNameMatch(wantName, gotName):
+			confidence += weightPartialName
+			reasons = append(reasons, fmt.Sprintf("profile name %q partly matches", user.Name))
+		}
+		if emailDomain != "" && (evidence.byDomain || mentionsDomain(user, emailDomain)) {
+			confidence += weightEmailDomain
+			reasons = append(reasons, fmt.Sprintf("profile is associated with %s", emailDomain))
+		}
+		if len(evidence.arxiv) > 0 {
+			confidence += weightArxivRepo
+			reasons = append(reasons, evidence.arxiv...)
+		}
+		if len(evidence.titles) > 0 {
+			confidence += weightTitleRepo
+			reasons = append(reasons, evidence.titles...)
+		}
+		confidence = min(confidence, 1.0)
+
+		if confidence > match.Confidence {
+			match.Login = user.Login
+			match.ProfileURL = user.HTMLURL
+			match.Confidence = confidence
+			match.Evidence = reasons
+		}
+	}
+	return match, nil
+}
+
+// titlePhrase returns the first few words of a title as a search phrase, or ""
+// if the title is too short to be distinctive
+func titlePhrase(title string) string {
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return r == ' ' || r == ':' || r == '"' || r == '\t' || r == '\n'
+	})
+	if len(words) < 3 {
+		return ""
+	}
+	return strings.Join(words[:min(len(words), titleWords)], " ")
+}
+
+// partialNameMatch reports whether two normalized names share a surname and a
+// first initial, e.g. "j smith" and "jane smith"
+func partialNameMatch(want, got string) bool {
+	wantParts, gotParts := strings.Fields(want), strings.Fields(got)
+	if len(wantParts) < 2 || len(gotParts) < 2 {
+		return false
+	}
+	return wantParts[len(wantParts)-1] == gotParts[len(gotParts)-1] &&
+		wantParts[0][0] == gotParts[0][0]
+}
+
+// mentionsDomain reports whether the profile's public email or website is on the domain
+func mentionsDomain(user *GitHubUser, domain string) bool {
+	for _, field := range []string{user.Email, user.Blog} {
+		field = strings.ToLower(field)
+		if strings.HasSuffix(field, "@"+domain) || strings.Contains(field, "."+domain) || strings.Contains(field, "//"+domain) {
+			return true
+		}
+	}
+	return false
+}
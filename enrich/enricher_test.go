@@ -0,0 +1,148 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// newEnricher links one paper by Alice Smith and returns an enricher backed by
+// the fake, along with her candidate record
+func newEnricher(t *testing.T, fake *fakeGitHub) (*Enricher, *database.Queries, database.Candidate) {
+	t.Helper()
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	article := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.01234",
+		"Sparse Attention for Long Documents", "Abstract.", "Alice Smith")
+	if _, err := candidates.NewLinker(db, queries).LinkArticle(ctx, article, sql.NullFloat64{}); err != nil {
+		t.Fatalf("LinkArticle: %v", err)
+	}
+	list, err := queries.ListAllCandidates(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListAllCandidates = %d, %v; want one candidate", len(list), err)
+	}
+
+	github := newGitHubClientWith(t, fake, queries, "")
+	return NewEnricher(db, queries, github, DefaultMinConfidence), queries, list[0]
+}
+
+// aliceOnGitHub has two accounts named Alice Smith, one of which owns the
+// paper's code, and a user search hit whose account has since been deleted
+func aliceOnGitHub() *fakeGitHub {
+	repo := GitHubRepo{FullName: "asmith/sparse-attention", HTMLURL: "https://github.com/asmith/sparse-attention"}
+	repo.Owner.Login, repo.Owner.Type = "asmith", "User"
+	org := GitHubRepo{FullName: "lab/papers"}
+	org.Owner.Login, org.Owner.Type = "lab", "Organization"
+
+	return &fakeGitHub{
+		users: map[string]GitHubUser{
+			"alice":  {Login: "alice", Name: "Alice Smith", HTMLURL: "https://github.com/alice"},
+			"asmith": {Login: "asmith", Name: "Alice Smith", HTMLURL: "https://github.com/asmith"},
+		},
+		userHits: map[string][]string{`"Alice Smith"`: {"alice", "deleted", "asmith"}},
+		repoHits: map[string][]GitHubRepo{`"2401.01234"`: {repo, org}},
+	}
+}
+
+func TestEnrichCandidate(t *testing.T) {
+	ctx := context.Background()
+	fake := aliceOnGitHub()
+	enricher, queries, candidate := newEnricher(t, fake)
+
+	match, err := enricher.EnrichCandidate(ctx, candidate, "")
+	if err != nil {
+		t.Fatalf("EnrichCandidate: %v", err)
+	}
+	// The paper's repository breaks the tie between the two namesakes, and
+	// the organisation owning the other repository is never considered
+	if match.Login != "asmith" || match.Confidence != weightExactName+weightArxivRepo || !match.Applied {
+		t.Errorf("match = %+v, want asmith at %v, applied", match, weightExactName+weightArxivRepo)
+	}
+	if len(match.Evidence) != 2 {
+		t.Errorf("evidence = %q, want the name and the repository", match.Evidence)
+	}
+
+	updated, err := queries.GetCandidateByID(ctx, candidate.ID)
+	if err != nil {
+		t.Fatalf("GetCandidateByID: %v", err)
+	}
+	if updated.GithubUrl.String != "https://github.com/asmith" {
+		t.Errorf("github_url = %v, want the match", updated.GithubUrl)
+	}
+	stored, err := queries.GetCandidateEnrichment(ctx, database.GetCandidateEnrichmentParams{CandidateID: candidate.ID, Source: SourceGitHub})
+	if err != nil {
+		t.Fatalf("GetCandidateEnrichment: %v", err)
+	}
+	if stored.ProfileLogin.String != "asmith" || stored.Confidence != match.Confidence {
+		t.Errorf("stored enrichment = %+v", stored)
+	}
+
+	// Enriching again is served entirely from the cache
+	before := fake.Requests()
+	if _, err := enricher.EnrichCandidate(ctx, updated, ""); err != nil {
+		t.Fatalf("second EnrichCandidate: %v", err)
+	}
+	if fake.Requests() != before {
+		t.Errorf("second run made %d requests, want 0", fake.Requests()-before)
+	}
+}
+
+func TestEnrichCandidateKeepsHandEnteredLink(t *testing.T) {
+	ctx := context.Background()
+	enricher, queries, candidate := newEnricher(t, aliceOnGitHub())
+	candidate.GithubUrl = sql.NullString{String: "https://github.com/the-real-alice", Valid: true}
+	if _, err := queries.UpdateCandidate(ctx, database.UpdateCandidateParams{
+		UpdatedAt: time.Now(),
+		Name:      candidate.Name,
+		GithubUrl: candidate.GithubUrl,
+		ID:        candidate.ID,
+	}); err != nil {
+		t.Fatalf("UpdateCandidate: %v", err)
+	}
+
+	match, err := enricher.EnrichCandidate(ctx, candidate, "")
+	if err != nil {
+		t.Fatalf("EnrichCandidate: %v", err)
+	}
+	if match.Login != "asmith" || match.Applied {
+		t.Errorf("match = %+v, want asmith, not applied", match)
+	}
+	updated, err := queries.GetCandidateByID(ctx, candidate.ID)
+	if err != nil {
+		t.Fatalf("GetCandidateByID: %v", err)
+	}
+	if updated.GithubUrl != candidate.GithubUrl {
+		t.Errorf("github_url = %v, want the hand-entered link kept", updated.GithubUrl)
+	}
+}
+
+func TestEnrichCandidatesStopsWhenRateLimited(t *testing.T) {
+	ctx := context.Background()
+	fake := aliceOnGitHub()
+	enricher, queries, candidate := newEnricher(t, fake)
+	fake.limitNext(http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+	})
+
+	result, err := enricher.EnrichCandidates(ctx, []database.Candidate{candidate}, "")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("EnrichCandidates error = %v, want ErrRateLimited", err)
+	}
+	if result.Candidates != 0 {
+		t.Errorf("result = %+v, want nothing enriched", result)
+	}
+	// Nothing is recorded, so the candidate is picked up again next time
+	if _, err := queries.GetCandidateEnrichment(ctx, database.GetCandidateEnrichmentParams{CandidateID: candidate.ID, Source: SourceGitHub}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("an enrichment was recorded (lookup error %v)", err)
+	}
+}
@@ -0,0 +1,238 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// DefaultGitHubBaseURL is the public GitHub REST API
+const DefaultGitHubBaseURL = "https://api.github.com"
+
+// DefaultCacheTTL is how long a cached GitHub response is reused
+const DefaultCacheTTL = 7 * 24 * time.Hour
+
+// maxRateLimitWait is the longest the client sleeps for an exhausted rate
+// limit to reset before giving up with ErrRateLimited
+const maxRateLimitWait = 90 * time.Second
+
+// userAgent identifies reSearch to GitHub, which rejects requests without one
+const userAgent = "reSearch/0.1 (https://github.com/jessewalker/reSearch)"
+
+// ErrRateLimited is returned when GitHub's rate limit is exhausted and does not
+// reset soon enough to wait for
+var ErrRateLimited = errors.New("GitHub rate limit exceeded")
+
+// GitHubUser is the subset of a GitHub user profile used for matching
+type GitHubUser struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Company string `json:"company"`
+	Blog    string `json:"blog"`
+	Email   string `json:"email"`
+}
+
+// GitHubRepo is the subset of a GitHub repository used for matching
+type GitHubRepo struct {
+	FullName    string `json:"full_name"`
+	HTMLURL     string `json:"html_url"`
+	Description string `json:"description"`
+	Owner       struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"owner"`
+}
+
+// GitHubClient is a small GitHub REST API client. Every successful (or
+// definitively negative) response is stored in the http_cache table, so the
+// same lookup is served from the database until the cache TTL runs out.
+type GitHubClient struct {
+	queries  *database.Queries
+	client   *http.Client
+	baseURL  string
+	token    string
+	cacheTTL time.Duration
+}
+
+// NewGitHubClient creates a GitHub client. baseURL defaults to the public API
+// and may point at a local stand-in; token is optional but raises the rate
+// limit considerably. A cacheTTL of zero or less disables cache reads.
+func NewGitHubClient(queries *database.Queries, client *http.Client, baseURL, token string, cacheTTL time.Duration) *GitHubClient {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if baseURL == "" {
+		baseURL = DefaultGitHubBaseURL
+	}
+	return &GitHubClient{
+		queries:  queries,
+		client:   client,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		token:    token,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// SearchUsers runs a user search and returns up to limit logins, best first
+func (c *GitHubClient) SearchUsers(ctx context.Context, query string, limit int) ([]GitHubUser, error) {
+	var result struct {
+		Items []GitHubUser `json:"items"`
+	}
+	params := url.Values{"q": {query}, "per_page": {strconv.Itoa(limit)}}
+	if _, err := c.get(ctx, "/search/users", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// SearchRepos runs a repository search and returns up to limit repositories, best first
+func (c *GitHubClient) SearchRepos(ctx context.Context, query string, limit int) ([]GitHubRepo, error) {
+	var result struct {
+		Items []GitHubRepo `json:"items"`
+	}
+	params := url.Values{"q": {query}, "per_page": {strconv.Itoa(limit)}}
+	if _, err := c.get(ctx, "/search/repositories", params, &result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// GetUser fetches a full user profile. It returns nil, nil if the login does not exist.
+func (c *GitHubClient) GetUser(ctx context.Context, login string) (*GitHubUser, error) {
+	var user GitHubUser
+	found, err := c.get(ctx, "/users/"+url.PathEscape(login), nil, &user)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// get fetches path from the API, or from the cache when a fresh copy exists,
+// and decodes the JSON body into v. It reports false for a 404.
+func (c *GitHubClient) get(ctx context.Context, path string, params url.Values, v interface{}) (bool, error) {
+	requestURL := c.baseURL + path
+	if len(params) > 0 {
+		requestURL += "?" + params.Encode()
+	}
+
+	status, body, err := c.cached(ctx, requestURL)
+	if err != nil {
+		return false, err
+	}
+	if status == 0 {
+		if status, body, err = c.fetch(ctx, requestURL); err != nil {
+			return false, err
+		}
+	}
+
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		return false, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// cached returns a stored response for requestURL, or a zero status if there
+// is no fresh one
+func (c *GitHubClient) cached(ctx context.Context, requestURL string) (int, string, error) {
+	if c.cacheTTL <= 0 {
+		return 0, "", nil
+	}
+	entry, err := c.queries.GetHTTPCacheEntry(ctx, requestURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("reading GitHub cache: %w", err)
+	}
+	if time.Since(entry.FetchedAt) > c.cacheTTL {
+		return 0, "", nil
+	}
+	return int(entry.StatusCode), entry.Body, nil
+}
+
+// fetch requests requestURL from GitHub, waiting out a short rate-limit reset
+// once, and caches 200 and 404 responses
+func (c *GitHubClient) fetch(ctx context.Context, requestURL string) (int, string, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return 0, "", err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		req.Header.Set("User-Agent", userAgent)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return 0, "", err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return 0, "", err
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusNotFound:
+			err := c.queries.PutHTTPCacheEntry(ctx, database.PutHTTPCacheEntryParams{
+				CacheKey:   requestURL,
+				FetchedAt:  time.Now(),
+				StatusCode: int64(resp.StatusCode),
+				Body:       string(body),
+			})
+			if err != nil {
+				return 0, "", fmt.Errorf("writing GitHub cache: %w", err)
+			}
+			return resp.StatusCode, string(body), nil
+		case http.StatusForbidden, http.StatusTooManyRequests:
+			wait, limited := rateLimitWait(resp.Header)
+			if !limited {
+				break
+			}
+			if attempt > 0 || wait > maxRateLimitWait {
+				return 0, "", fmt.Errorf("%w (resets in %s)", ErrRateLimited, wait.Round(time.Second))
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return 0, "", ctx.Err()
+			}
+		}
+		return 0, "", fmt.Errorf("GitHub returned %s for %s", resp.Status, requestURL)
+	}
+}
+
+// rateLimitWait reads GitHub's rate-limit headers and reports how long to wait
+// before retrying, and whether the response was a rate-limit rejection at all
+func rateLimitWait(header http.Header) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return maxRateLimitWait + time.Second, true
+	}
+	return max(time.Until(time.Unix(reset, 0)), 0) + time.Second, true
+}
@@ -0,0 +1,238 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// fakeGitHub is a stand-in for the GitHub REST API. Users and repositories
+// are matched by substring of the search query; limited makes the next
+// requests fail with the given rate-limit headers.
+type fakeGitHub struct {
+	users map[string]GitHubUser
+	// userHits lists the logins each user search query returns
+	userHits map[string][]string
+	// repoHits lists the repositories each repository search query returns
+	repoHits map[string][]GitHubRepo
+
+	mu       sync.Mutex
+	requests []*http.Request
+	limited  []http.Header
+}
+
+func (g *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	g.requests = append(g.requests, r)
+	var limit http.Header
+	if len(g.limited) > 0 {
+		limit, g.limited = g.limited[0], g.limited[1:]
+	}
+	g.mu.Unlock()
+
+	if limit != nil {
+		for key, values := range limit {
+			w.Header()[key] = values
+		}
+		status := http.StatusForbidden
+		if limit.Get("Retry-After") != "" {
+			status = http.StatusTooManyRequests
+		}
+		http.Error(w, `{"message": "API rate limit exceeded"}`, status)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	switch {
+	case r.URL.Path == "/search/users":
+		var items []GitHubUser
+		for pattern, logins := range g.userHits {
+			if strings.Contains(query, pattern) {
+				for _, login := range logins {
+					items = append(items, GitHubUser{Login: login})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case r.URL.Path == "/search/repositories":
+		var items []GitHubRepo
+		for pattern, repos := range g.repoHits {
+			if strings.Contains(query, pattern) {
+				items = append(items, repos...)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case strings.HasPrefix(r.URL.Path, "/users/"):
+		user, ok := g.users[strings.TrimPrefix(r.URL.Path, "/users/")]
+		if !ok {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(user)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Requests returns the number of requests served so far
+func (g *fakeGitHub) Requests() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.requests)
+}
+
+// limitNext makes the next requests fail with a rate limit, one per header set
+func (g *fakeGitHub) limitNext(headers ...http.Header) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limited = append(g.limited, headers...)
+}
+
+// newGitHubClient starts the fake and returns a client for it that caches in
+// a scratch database
+func newGitHubClient(t *testing.T, fake *fakeGitHub, token string) *GitHubClient {
+	t.Helper()
+	_, queries := dbtest.Open(t)
+	return newGitHubClientWith(t, fake, queries, token)
+}
+
+// newGitHubClientWith starts the fake and returns a client for it that caches
+// through queries
+func newGitHubClientWith(t *testing.T, fake *fakeGitHub, queries *database.Queries, token string) *GitHubClient {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return NewGitHubClient(queries, server.Client(), server.URL+"/", token, time.Hour)
+}
+
+func TestGitHubClientGetUser(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeGitHub{users: map[string]GitHubUser{
+		"alice": {Login: "alice", Name: "Alice Smith", HTMLURL: "https://github.com/alice"},
+	}}
+	client := newGitHubClient(t, fake, "secret")
+
+	user, err := client.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user == nil || user.Name != "Alice Smith" {
+		t.Fatalf("GetUser = %+v, want Alice Smith", user)
+	}
+	request := fake.requests[0]
+	if got := request.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the token", got)
+	}
+	if request.Header.Get("User-Agent") == "" {
+		t.Error("request has no User-Agent, which GitHub rejects")
+	}
+
+	// A missing user is not an error
+	user, err = client.GetUser(ctx, "nobody")
+	if err != nil || user != nil {
+		t.Fatalf("GetUser of a missing login = %+v, %v; want nil, nil", user, err)
+	}
+
+	// Both answers, including the 404, are served from the cache from now on
+	before := fake.Requests()
+	for _, login := range []string{"alice", "nobody"} {
+		if _, err := client.GetUser(ctx, login); err != nil {
+			t.Fatalf("cached GetUser %s: %v", login, err)
+		}
+	}
+	if fake.Requests() != before {
+		t.Errorf("cached lookups made %d requests, want 0", fake.Requests()-before)
+	}
+}
+
+func TestGitHubClientRateLimit(t *testing.T) {
+	ctx := context.Background()
+	exhausted := http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+	}
+	retryNow := http.Header{"Retry-After": {"0"}}
+
+	tests := []struct {
+		name     string
+		limits   []http.Header
+		wantErr  bool
+		requests int
+	}{
+		{"long reset gives up at once", []http.Header{exhausted}, true, 1},
+		{"short wait is retried once", []http.Header{retryNow}, false, 2},
+		{"still limited after the retry", []http.Header{retryNow, retryNow}, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeGitHub{users: map[string]GitHubUser{"alice": {Login: "alice"}}}
+			client := newGitHubClient(t, fake, "")
+			fake.limitNext(tt.limits...)
+
+			user, err := client.GetUser(ctx, "alice")
+			if tt.wantErr {
+				if !errors.Is(err, ErrRateLimited) {
+					t.Errorf("GetUser error = %v, want ErrRateLimited", err)
+				}
+			} else if err != nil || user == nil {
+				t.Errorf("GetUser = %+v, %v; want alice", user, err)
+			}
+			if fake.Requests() != tt.requests {
+				t.Errorf("made %d requests, want %d", fake.Requests(), tt.requests)
+			}
+		})
+	}
+
+	// A 403 that is not a rate limit is reported as it is, and not retried
+	fake := &fakeGitHub{}
+	client := newGitHubClient(t, fake, "")
+	fake.limitNext(http.Header{"X-Ratelimit-Remaining": {"12"}})
+	_, err := client.GetUser(ctx, "alice")
+	if err == nil || errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "403") {
+		t.Errorf("GetUser error = %v, want a plain 403", err)
+	}
+	if fake.Requests() != 1 {
+		t.Errorf("made %d requests, want 1", fake.Requests())
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		limited bool
+		atLeast time.Duration
+		atMost  time.Duration
+	}{
+		{"retry after", http.Header{"Retry-After": {"30"}}, true, 30 * time.Second, 30 * time.Second},
+		{"not exhausted", http.Header{"X-Ratelimit-Remaining": {"5"}}, false, 0, 0},
+		{"no headers", http.Header{}, false, 0, 0},
+		{"reset soon", http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(10*time.Second).Unix(), 10)},
+		}, true, 9 * time.Second, 12 * time.Second},
+		{"reset passed", http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)},
+		}, true, time.Second, time.Second},
+		{"unreadable reset", http.Header{"X-Ratelimit-Remaining": {"0"}}, true, maxRateLimitWait + time.Second, maxRateLimitWait + time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, limited := rateLimitWait(tt.header)
+			if limited != tt.limited || wait < tt.atLeast || wait > tt.atMost {
+				t.Errorf("rateLimitWait = %v, %v; want %v-%v, %v", wait, limited, tt.atLeast, tt.atMost, tt.limited)
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// SourceWebSearch is the candidate_enrichments.source recording that a
+// candidate's profiles were looked for with web search
+const SourceWebSearch = "web_search"
+
+// Kinds of profile link the web search looks for
+const (
+	KindLinkedIn = "linkedin"
+	KindGitHub   = "github"
+	KindHomepage = "homepage"
+)
+
+var (
+	// profileLinePattern matches the "LinkedIn: <url>" lines the model is asked to reply with
+	profileLinePattern = regexp.MustCompile(`(?i)^[\s*_#-]*(linkedin|github|homepage)[\s*_]*:(.*)$`)
+	urlPattern         = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+)
+
+// ProfileLink is a profile URL the model reported, with the search result behind it
+type ProfileLink struct {
+	Kind string
+	URL  string
+	// SourceURL and SourceTitle identify the search result backing the URL
+	SourceURL   string
+	SourceTitle string
+	// CitedText is the passage the model quoted, if it cited the result
+	CitedText string
+	// Backed is false when the URL matched no search result or citation. Such
+	// links are reported but never stored.
+	Backed bool
+}
+
+// WebDiscovery is the outcome of a web search for one candidate's profiles
+type WebDiscovery struct {
+	CandidateID interface{}
+	Name        string
+	Model       string
+	// Searches is the number of web searches the model ran
+	Searches int
+	Links    []ProfileLink
+	// Applied lists the candidate columns (linkedin_url, github_url) that were filled in
+	Applied []string
+}
+
+// WebDiscoverer asks Claude to find a candidate's LinkedIn, GitHub and
+// homepage with the web search tool, and keeps only the URLs backed by a
+// search result
+type WebDiscoverer struct {
+	db      *sql.DB
+	queries *database.Queries
+	client  anthropic.Client
+	options agent.Options
+	ledger  *costs.Ledger
+}
+
+// NewWebDiscoverer creates a new profile discoverer whose agents record their
+// calls in ledger. Web search is always enabled for its agents;
+// opts.WebSearchAllowedDomains restricts where it looks.
+func NewWebDiscoverer(db *sql.DB, queries *database.Queries, client anthropic.Client, opts agent.Options, ledger *costs.Ledger) *WebDiscoverer {
+	opts.WebSearch = true
+	return &WebDiscoverer{db: db, queries: queries, client: client, options: opts, ledger: ledger}
+}
+
+// Discover runs the web search for the candidate and stores every backed link
+// with its citation. linkedin_url and github_url are filled in only when empty.
+// searchID, which may be nil, is the search the lookup is made for; its calls
+// count against that search's monthly budget.
+func (d *WebDiscoverer) Discover(ctx context.Context, candidate database.Candidate, searchID interface{}) (*WebDiscovery, error) {
+	articles, err := d.queries.GetArticlesByCandidate(ctx, candidate.ID)
+	if err != nil {
+		return nil, fmt.Errorf("reading candidate's articles: %w", err)
+	}
+
+	a := agent.NewAgent(d.client, nil, discoverySystemPrompt, nil, d.options)
+	a.SetUsageRecorder(d.ledger.For(costs.Target{Purpose: costs.PurposeProfileDiscovery, SearchID: searchID}))
+	// Discovery is non-interactive, so the streamed output is discarded
+	go agent.NewConsoleClient(io.Discard).Run(a)
+	defer a.Close()
+
+	message, err := a.Complete(ctx, discoveryPrompt(candidate, articles))
+	if err != nil {
+		return nil, fmt.Errorf("searching for %s: %w", candidate.Name, err)
+	}
+
+	discovery := &WebDiscovery{
+		CandidateID: candidate.ID,
+		Name:        candidate.Name,
+		Model:       string(message.Model),
+		Links:       ParseProfileLinks(message),
+	}
+	for _, block := range message.Content {
+		if block.Type == "server_tool_use" {
+			discovery.Searches++
+		}
+	}
+
+	if err := d.save(ctx, candidate, discovery); err != nil {
+		return nil, err
+	}
+	return discovery, nil
+}
+
+// save stores the backed links, records the attempt and fills in empty
+// candidate columns, all in one transaction
+func (d *WebDiscoverer) save(ctx context.Context, candidate database.Candidate, discovery *WebDiscovery) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := d.queries.WithTx(tx)
+
+	now := time.Now()
+	evidence := []string{}
+	linkedin, github := candidate.LinkedinUrl, candidate.GithubUrl
+	for _, link := range discovery.Links {
+		if !link.Backed {
+			continue
+		}
+		_, err := qtx.UpsertCandidateProfileLink(ctx, database.UpsertCandidateProfileLinkParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			CandidateID: candidate.ID,
+			Kind:        link.Kind,
+			Url:         link.URL,
+			SourceUrl:   link.SourceURL,
+			SourceTitle: link.SourceTitle,
+			CitedText:   link.CitedText,
+			Model:       discovery.Model,
+		})
+		if err != nil {
+			return fmt.Errorf("saving %s link: %w", link.Kind, err)
+		}
+		evidence = append(evidence, fmt.Sprintf("%s %s (source: %s)", link.Kind, link.URL, link.SourceURL))
+
+		switch {
+		case link.Kind == KindLinkedIn && linkedin.String == "":
+			linkedin = sql.NullString{String: link.URL, Valid: true}
+			discovery.Applied = append(discovery.Applied, "linkedin_url")
+		case link.Kind == KindGitHub && github.String == "":
+			github = sql.NullString{String: link.URL, Valid: true}
+			discovery.Applied = append(discovery.Applied, "github_url")
+		}
+	}
+
+	if len(discovery.Applied) > 0 {
+		_, err := qtx.UpdateCandidate(ctx, database.UpdateCandidateParams{
+			UpdatedAt:   now,
+			Name:        candidate.Name,
+			LinkedinUrl: linkedin,
+			GithubUrl:   github,
+			ID:          candidate.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("updating candidate: %w", err)
+		}
+	}
+
+	// The attempt is recorded even when nothing was found, so the candidate
+	// is not searched for again unless asked
+	encoded, err := json.Marshal(evidence)
+	if err != nil {
+		return err
+	}
+	confidence := 0.0
+	if len(evidence) > 0 {
+		confidence = 1.0
+	}
+	_, err = qtx.UpsertCandidateEnrichment(ctx, database.UpsertCandidateEnrichmentParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CandidateID: candidate.ID,
+		Source:      SourceWebSearch,
+		Confidence:  confidence,
+		Evidence:    string(encoded),
+	})
+	if err != nil {
+		return fmt.Errorf("recording web search: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ParseProfileLinks reads the "Kind: URL" lines of the model's reply and pairs
+// each URL with the search result backing it: preferably a citation on the
+// same line, otherwise a web_search_tool_result entry with the same URL.
+func ParseProfileLinks(message *anthropic.Message) []ProfileLink {
+	results := map[string]anthropic.WebSearchResultBlock{}
+	for _, block := range message.Content {
+		if block.Type != "web_search_tool_result" {
+			continue
+		}
+		entries := block.Content.OfWebSearchResultBlockArray
+		if len(entries) == 0 {
+			entries = block.Content.AsWebSearchResultBlockArray()
+		}
+		for _, entry := range entries {
+			results[normalizeURL(entry.URL)] = entry
+		}
+	}
+
+	// The reply arrives as several text blocks, split wherever a citation
+	// starts or ends, so join them while remembering where each one landed
+	type span struct {
+		start, end int
+		citations  []anthropic.TextCitationUnion
+	}
+	var text strings.Builder
+	var spans []span
+	for _, block := range message.Content {
+		if block.Type != "text" {
+			continue
+		}
+		start := text.Len()
+		text.WriteString(block.Text)
+		spans = append(spans, span{start: start, end: text.Len(), citations: block.Citations})
+	}
+
+	var links []ProfileLink
+	seen := map[string]bool{}
+	offset := 0
+	for _, line := range strings.SplitAfter(text.String(), "\n") {
+		lineStart, lineEnd := offset, offset+len(line)
+		offset = lineEnd
+
+		match := profileLinePattern.FindStringSubmatch(strings.TrimRight(line, "\n"))
+		if match == nil {
+			continue
+		}
+		raw := urlPattern.FindString(match[2])
+		if raw == "" {
+			continue
+		}
+		link := ProfileLink{Kind: strings.ToLower(match[1]), URL: cleanProfileURL(strings.ToLower(match[1]), raw)}
+		if link.URL == "" || seen[link.Kind+" "+link.URL] {
+			continue
+		}
+		seen[link.Kind+" "+link.URL] = true
+
+		var lineCitations []anthropic.TextCitationUnion
+		for _, s := range spans {
+			if s.start < lineEnd && s.end > lineStart {
+				for _, citation := range s.citations {
+					if citation.Type == "web_search_result_location" {
+						lineCitations = append(lineCitations, citation)
+					}
+				}
+			}
+		}
+		for _, citation := range lineCitations {
+			if normalizeURL(citation.URL) == normalizeURL(link.URL) {
+				link.SourceURL, link.SourceTitle, link.CitedText, link.Backed = citation.URL, citation.Title, citation.CitedText, true
+				break
+			}
+		}
+		if !link.Backed {
+			if result, ok := results[normalizeURL(link.URL)]; ok {
+				link.SourceURL, link.SourceTitle, link.Backed = result.URL, result.Title, true
+			} else if len(lineCitations) > 0 {
+				// A page about the person (e.g. a lab roster) linking to the profile
+				citation := lineCitations[0]
+				link.SourceURL, link.SourceTitle, link.CitedText, link.Backed = citation.URL, citation.Title, citation.CitedText, true
+			}
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// cleanProfileURL trims punctuation the model may have run into the URL and
+// rejects URLs that are not the kind of profile they claim to be. GitHub
+// repository links are reduced to their owner's profile.
+func cleanProfileURL(kind, raw string) string {
+	raw = strings.TrimRight(raw, ".,;:!?*_`")
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	switch kind {
+	case KindLinkedIn:
+		if !strings.HasSuffix(host, "linkedin.com") || !strings.HasPrefix(parsed.Path, "/in/") {
+			return ""
+		}
+	case KindGitHub:
+		owner := strings.Split(strings.Trim(parsed.Path, "/"), "/")[0]
+		if host != "github.com" || owner == "" {
+			return ""
+		}
+		return "https://github.com/" + owner
+	}
+	return raw
+}
+
+// normalizeURL makes URLs comparable across scheme, "www." and trailing slashes
+func normalizeURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	return host + strings.TrimRight(parsed.EscapedPath(), "/")
+}
+
+// discoverySystemPrompt fixes the reply format ParseProfileLinks reads
+const discoverySystemPrompt = `You find the public professional profiles of AI researchers for a recruiter. Use web search to look for the researcher's LinkedIn profile, GitHub profile and personal or academic homepage.
+
+Only report a URL you found in a search result, and cite the result that shows it belongs to this researcher. Several people can share a name, so use the papers and affiliation you are given to tell them apart. If you are not confident, say "none" rather than guessing.
+
+Finish with exactly these three lines:
+LinkedIn: <url or none>
+GitHub: <url or none>
+Homepage: <url or none>`
+
+// discoveryPrompt describes the candidate by name and their most recent papers
+func discoveryPrompt(candidate database.Candidate, articles []database.GetArticlesByCandidateRow) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Researcher: %s\n", candidate.Name)
+	if len(articles) > 0 {
+		sb.WriteString("\nRecent papers:\n")
+		for i, article := range articles {
+			if i == maxPapers {
+				break
+			}
+			fmt.Fprintf(&sb, "- %s", article.ArticleTitle)
+			if arxivID := fetcher.ExtractArxivID(article.ArticleUrl); arxivID != "" {
+				fmt.Fprintf(&sb, " (arXiv:%s)", arxivID)
+			}
+			fmt.Fprintf(&sb, ", with %s\n", article.ArticleAuthors)
+		}
+	}
+	return sb.String()
+}
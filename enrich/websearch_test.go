@@ -0,0 +1,175 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/internal/anthropictest"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// newWebDiscoverer links one paper by Alice Smith and returns a discoverer
+// backed by a fake Messages API giving the replies, along with her candidate
+// record
+func newWebDiscoverer(t *testing.T, replies ...anthropictest.Reply) (*WebDiscoverer, *database.Queries, *anthropictest.Server, database.Candidate) {
+	t.Helper()
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	article := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.01234",
+		"Sparse Attention for Long Documents", "Abstract.", "Alice Smith")
+	if _, err := candidates.NewLinker(db, queries).LinkArticle(ctx, article, sql.NullFloat64{}); err != nil {
+		t.Fatalf("LinkArticle: %v", err)
+	}
+	list, err := queries.ListAllCandidates(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("ListAllCandidates = %d, %v; want one candidate", len(list), err)
+	}
+
+	server := anthropictest.NewServer(t, replies...)
+	ledger := costs.NewLedger(queries, costs.Prices{Models: costs.DefaultModelPrices()})
+	return NewWebDiscoverer(db, queries, server.Client(), agent.DefaultOptions(), ledger), queries, server, list[0]
+}
+
+// aliceOnTheWeb is a reply that searched once and found Alice's LinkedIn
+// profile among the results, her GitHub account on a cited lab roster, and a
+// homepage nothing backs. The reply's text is split where the citation
+// starts and ends, as the API splits it.
+var aliceOnTheWeb = anthropictest.Reply{
+	Blocks: []string{
+		`{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": {"query": "Alice Smith sparse attention"}}`,
+		`{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": [
+			{"type": "web_search_result", "url": "https://linkedin.com/in/alice-smith", "title": "Alice Smith - LinkedIn", "encrypted_content": "abc"},
+			{"type": "web_search_result", "url": "https://lab.example.edu/people", "title": "Lab members", "encrypted_content": "def"}
+		]}`,
+		`{"type": "text", "text": "Found her.\nLinkedIn: https://www.linkedin.com/in/alice-smith/\nGitHub: "}`,
+		`{"type": "text", "text": "https://github.com/asmith/sparse-attention", "citations": [
+			{"type": "web_search_result_location", "url": "https://lab.example.edu/people", "title": "Lab members", "cited_text": "Alice Smith (github.com/asmith)", "encrypted_index": "ghi"}
+		]}`,
+		`{"type": "text", "text": "\nHomepage: https://alice.example.com\n"}`,
+	},
+}
+
+func TestDiscover(t *testing.T) {
+	ctx := context.Background()
+	discoverer, queries, server, candidate := newWebDiscoverer(t, aliceOnTheWeb)
+
+	// A GitHub link entered by hand is kept
+	candidate, err := queries.UpdateCandidate(ctx, database.UpdateCandidateParams{
+		UpdatedAt: time.Now(),
+		Name:      candidate.Name,
+		GithubUrl: sql.NullString{String: "https://github.com/alice", Valid: true},
+		ID:        candidate.ID,
+	})
+	if err != nil {
+		t.Fatalf("UpdateCandidate: %v", err)
+	}
+
+	discovery, err := discoverer.Discover(ctx, candidate, nil)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if discovery.Searches != 1 || discovery.Model != anthropictest.Model {
+		t.Errorf("discovery ran %d searches with %q, want 1 with %q", discovery.Searches, discovery.Model, anthropictest.Model)
+	}
+	want := []ProfileLink{
+		// Backed by a search result with the same URL
+		{Kind: KindLinkedIn, URL: "https://www.linkedin.com/in/alice-smith/",
+			SourceURL: "https://linkedin.com/in/alice-smith", SourceTitle: "Alice Smith - LinkedIn", Backed: true},
+		// Backed by the page cited on its line, and reduced to the owner's profile
+		{Kind: KindGitHub, URL: "https://github.com/asmith",
+			SourceURL: "https://lab.example.edu/people", SourceTitle: "Lab members", CitedText: "Alice Smith (github.com/asmith)", Backed: true},
+		{Kind: KindHomepage, URL: "https://alice.example.com"},
+	}
+	if len(discovery.Links) != len(want) {
+		t.Fatalf("links = %+v, want %+v", discovery.Links, want)
+	}
+	for i := range want {
+		if discovery.Links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, discovery.Links[i], want[i])
+		}
+	}
+	if strings.Join(discovery.Applied, ",") != "linkedin_url" {
+		t.Errorf("applied = %q, want only linkedin_url", discovery.Applied)
+	}
+
+	// Only the backed links are stored, with their sources
+	stored, err := queries.ListCandidateProfileLinks(ctx, candidate.ID)
+	if err != nil {
+		t.Fatalf("ListCandidateProfileLinks: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("stored %d links, want 2: %+v", len(stored), stored)
+	}
+	if stored[0].Kind != KindGitHub || stored[0].Url != "https://github.com/asmith" || stored[0].SourceUrl != "https://lab.example.edu/people" ||
+		stored[0].CitedText != "Alice Smith (github.com/asmith)" || stored[0].Model != anthropictest.Model {
+		t.Errorf("stored GitHub link = %+v", stored[0])
+	}
+	if stored[1].Kind != KindLinkedIn || stored[1].Url != "https://www.linkedin.com/in/alice-smith/" || stored[1].SourceTitle != "Alice Smith - LinkedIn" {
+		t.Errorf("stored LinkedIn link = %+v", stored[1])
+	}
+
+	updated, err := queries.GetCandidateByID(ctx, candidate.ID)
+	if err != nil {
+		t.Fatalf("GetCandidateByID: %v", err)
+	}
+	if updated.LinkedinUrl.String != "https://www.linkedin.com/in/alice-smith/" || updated.GithubUrl.String != "https://github.com/alice" {
+		t.Errorf("candidate links = %v, %v; want the found LinkedIn and the hand-entered GitHub", updated.LinkedinUrl, updated.GithubUrl)
+	}
+
+	enrichment, err := queries.GetCandidateEnrichment(ctx, database.GetCandidateEnrichmentParams{CandidateID: candidate.ID, Source: SourceWebSearch})
+	if err != nil {
+		t.Fatalf("GetCandidateEnrichment: %v", err)
+	}
+	var evidence []string
+	if err := json.Unmarshal([]byte(enrichment.Evidence), &evidence); err != nil {
+		t.Fatalf("evidence %q: %v", enrichment.Evidence, err)
+	}
+	if len(evidence) != 2 || enrichment.Confidence != 1 {
+		t.Errorf("enrichment = %v at %v, want two pieces of evidence at 1", evidence, enrichment.Confidence)
+	}
+
+	// The request offers web search and describes the candidate by their papers
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(requests))
+	}
+	body := string(requests[0])
+	for _, want := range []string{"web_search", "Alice Smith", "Sparse Attention for Long Documents", "arXiv:2401.01234"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("request does not mention %q", want)
+		}
+	}
+}
+
+// A search that turns up nothing is still recorded, so the candidate is not
+// searched for again
+func TestDiscoverFindsNothing(t *testing.T) {
+	ctx := context.Background()
+	discoverer, queries, _, candidate := newWebDiscoverer(t, anthropictest.Reply{
+		Text: "LinkedIn: none\nGitHub: none\nHomepage: none\n",
+	})
+
+	discovery, err := discoverer.Discover(ctx, candidate, nil)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(discovery.Links) != 0 || len(discovery.Applied) != 0 {
+		t.Errorf("discovery = %+v, want no links", discovery)
+	}
+	enrichment, err := queries.GetCandidateEnrichment(ctx, database.GetCandidateEnrichmentParams{CandidateID: candidate.ID, Source: SourceWebSearch})
+	if err != nil {
+		t.Fatalf("GetCandidateEnrichment: %v", err)
+	}
+	if enrichment.Confidence != 0 || enrichment.Evidence != "[]" {
+		t.Errorf("enrichment = %+v, want an empty attempt", enrichment)
+	}
+}
@@ -0,0 +1,252 @@
+// Package export writes a search's candidates in formats that can be handed
+// to people who do not use reSearch: CSV for spreadsheets, newline-delimited
+// JSON for scripts and vCard for address books. Output is sorted and carries
+// no export timestamps, so two exports of unchanged data are byte-identical
+// and can be diffed.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Supported output formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatVCard  = "vcard"
+)
+
+// Formats lists every supported output format
+var Formats = []string{FormatCSV, FormatNDJSON, FormatVCard}
+
+// Filter narrows the exported candidates. Zero values match everything.
+type Filter struct {
+	// MinRelevance excludes candidates scored below it, and unscored ones when above 0
+	MinRelevance float64
+	// Status keeps only candidates at this pipeline stage
+	Status string
+	// Category keeps only candidates with this arXiv category, e.g. "cs.LG"
+	Category string
+}
+
+// Paper is an article that surfaced a candidate for the search
+type Paper struct {
+	Title        string    `json:"title"`
+	URL          string    `json:"url"`
+	DiscoveredAt time.Time `json:"discovered_at"`
+}
+
+// Candidate is one exported row: the candidate, their link to the search,
+// and the papers and categories behind it
+type Candidate struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Relevance   *float64 `json:"relevance_score"`
+	Status      string   `json:"status"`
+	GithubURL   string   `json:"github_url,omitempty"`
+	LinkedinURL string   `json:"linkedin_url,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Categories  []string `json:"categories"`
+	Papers      []Paper  `json:"papers"`
+}
+
+// Load reads the search's candidates that pass the filter, most relevant
+// first, with ties broken by name and then ID
+func Load(ctx context.Context, queries *database.Queries, search database.Search, filter Filter) ([]Candidate, error) {
+	total, err := queries.CountCandidatesBySearch(ctx, search.ID)
+	if err != nil {
+		return nil, fmt.Errorf("counting candidates: %w", err)
+	}
+	rows, err := queries.ListCandidatesBySearch(ctx, database.ListCandidatesBySearchParams{
+		SearchID: search.ID,
+		Limit:    total,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing candidates: %w", err)
+	}
+
+	var list []Candidate
+	for _, row := range rows {
+		if filter.Status != "" && row.Status != filter.Status {
+			continue
+		}
+		if filter.MinRelevance > 0 && (!row.RelevanceScore.Valid || row.RelevanceScore.Float64 < filter.MinRelevance) {
+			continue
+		}
+
+		categories, err := loadCategories(ctx, queries, row.ID)
+		if err != nil {
+			return nil, fmt.Errorf("reading categories of %s: %w", row.Name, err)
+		}
+		if filter.Category != "" && !contains(categories, filter.Category) {
+			continue
+		}
+		papers, err := loadPapers(ctx, queries, row.ID, search.ID)
+		if err != nil {
+			return nil, fmt.Errorf("reading papers of %s: %w", row.Name, err)
+		}
+
+		candidate := Candidate{
+			ID:          fmt.Sprint(row.ID),
+			Name:        row.Name,
+			Status:      row.Status,
+			GithubURL:   row.GithubUrl.String,
+			LinkedinURL: row.LinkedinUrl.String,
+			Notes:       row.Notes.String,
+			Categories:  categories,
+			Papers:      papers,
+		}
+		if row.RelevanceScore.Valid {
+			relevance := row.RelevanceScore.Float64
+			candidate.Relevance = &relevance
+		}
+		list = append(list, candidate)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		ri, rj := relevanceOf(list[i]), relevanceOf(list[j])
+		if ri != rj {
+			return ri > rj
+		}
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list, nil
+}
+
+// loadCategories returns the candidate's arXiv categories, sorted
+func loadCategories(ctx context.Context, queries *database.Queries, candidateID interface{}) ([]string, error) {
+	rows, err := queries.GetCandidateWithCategories(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	categories := []string{}
+	for _, row := range rows {
+		if row.ArxivCategory.Valid && !contains(categories, row.ArxivCategory.String) {
+			categories = append(categories, row.ArxivCategory.String)
+		}
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+// loadPapers returns the articles of this search that surfaced the candidate,
+// most recently discovered first
+func loadPapers(ctx context.Context, queries *database.Queries, candidateID, searchID interface{}) ([]Paper, error) {
+	rows, err := queries.GetCandidateDiscoveryArticles(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	papers := []Paper{}
+	for _, row := range rows {
+		if fmt.Sprint(row.SearchID) != fmt.Sprint(searchID) {
+			continue
+		}
+		papers = append(papers, Paper{Title: row.ArticleTitle, URL: row.ArticleUrl, DiscoveredAt: row.DiscoveryDate.UTC()})
+	}
+	sort.SliceStable(papers, func(i, j int) bool {
+		if !papers[i].DiscoveredAt.Equal(papers[j].DiscoveredAt) {
+			return papers[i].DiscoveredAt.After(papers[j].DiscoveredAt)
+		}
+		return papers[i].URL < papers[j].URL
+	})
+	return papers, nil
+}
+
+// Write encodes the candidates in the given format
+func Write(w io.Writer, format string, list []Candidate) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, list)
+	case FormatNDJSON:
+		return WriteNDJSON(w, list)
+	case FormatVCard:
+		return WriteVCard(w, list)
+	}
+	return fmt.Errorf("unknown export format %q (expected one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// csvHeader names the CSV columns. Multi-valued columns are joined with "; ".
+var csvHeader = []string{"id", "name", "relevance_score", "status", "github_url", "linkedin_url", "notes", "categories", "paper_titles", "paper_urls"}
+
+// WriteCSV writes one row per candidate under a header row. Cells that a
+// spreadsheet would run as a formula are escaped, see csvCell.
+func WriteCSV(w io.Writer, list []Candidate) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, c := range list {
+		relevance := ""
+		if c.Relevance != nil {
+			relevance = strconv.FormatFloat(*c.Relevance, 'f', 2, 64)
+		}
+		titles := make([]string, len(c.Papers))
+		urls := make([]string, len(c.Papers))
+		for i, paper := range c.Papers {
+			titles[i], urls[i] = paper.Title, paper.URL
+		}
+		record := []string{
+			c.ID, c.Name, relevance, c.Status, c.GithubURL, c.LinkedinURL, c.Notes,
+			strings.Join(c.Categories, "; "), strings.Join(titles, "; "), strings.Join(urls, "; "),
+		}
+		for i := range record {
+			record[i] = csvCell(record[i])
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// csvCell neutralises text a spreadsheet would evaluate, such as a paper
+// title or note starting with "=", by prefixing it with an apostrophe, which
+// spreadsheets show as plain text
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// WriteNDJSON writes one JSON object per line
+func WriteNDJSON(w io.Writer, list []Candidate) error {
+	encoder := json.NewEncoder(w)
+	for _, c := range list {
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relevanceOf returns the candidate's score, with unscored candidates last
+func relevanceOf(c Candidate) float64 {
+	if c.Relevance == nil {
+		return -1
+	}
+	return *c.Relevance
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
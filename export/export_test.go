@@ -0,0 +1,152 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	relevance := 0.75
+	list := []Candidate{{
+		ID:        "1",
+		Name:      "=HYPERLINK(\"http://evil.example\",\"Alice\")",
+		Relevance: &relevance,
+		Status:    "new",
+		Notes:     "@SUM(A1:A9)",
+		Papers: []Paper{
+			{Title: "+1 for sparse attention", URL: "https://arxiv.org/abs/2401.01234"},
+			{Title: "-cmd|' /C calc'!A0", URL: "https://arxiv.org/abs/2401.05678"},
+		},
+	}, {
+		ID:     "2",
+		Name:   "Bob Jones",
+		Status: "new",
+		Notes:  "Met at NeurIPS; score = high",
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, list); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV back: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("%d records, want a header and 2 rows", len(records))
+	}
+
+	want := map[string]string{
+		"name":            "'=HYPERLINK(\"http://evil.example\",\"Alice\")",
+		"relevance_score": "0.75",
+		"notes":           "'@SUM(A1:A9)",
+		"paper_titles":    "'+1 for sparse attention; -cmd|' /C calc'!A0",
+		"paper_urls":      "https://arxiv.org/abs/2401.01234; https://arxiv.org/abs/2401.05678",
+	}
+	for i, column := range records[0] {
+		if expected, ok := want[column]; ok && records[1][i] != expected {
+			t.Errorf("%s = %q, want %q", column, records[1][i], expected)
+		}
+	}
+
+	// Cells that only contain formula characters later on are left alone
+	if records[2][1] != "Bob Jones" || records[2][6] != "Met at NeurIPS; score = high" {
+		t.Errorf("plain row changed: %q", records[2])
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Alice", "Alice"},
+		{"=1+1", "'=1+1"},
+		{"+44 20 7946 0000", "'+44 20 7946 0000"},
+		{"-2", "'-2"},
+		{"@handle", "'@handle"},
+		{"\t=1", "'\t=1"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := csvCell(tt.in); got != tt.want {
+			t.Errorf("csvCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteVCard(t *testing.T) {
+	relevance := 0.5
+	list := []Candidate{{
+		ID:          "1",
+		Name:        `Alice "Al" Smith, Jr.; \ PhD`,
+		Relevance:   &relevance,
+		Status:      "shortlisted",
+		GithubURL:   "https://github.com/asmith",
+		LinkedinURL: "https://www.linkedin.com/in/alice-smith",
+		Notes:       "Met at NeurIPS.\r\nFollow up; maybe",
+		Categories:  []string{"cs.LG", "cs,CL"},
+		Papers: []Paper{
+			{Title: "Sparse Attention for Long Documents: Scaling Transformers to Millions of Tokens", URL: "https://arxiv.org/abs/2401.01234"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteVCard(&buf, list); err != nil {
+		t.Fatalf("WriteVCard: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "\r\n") {
+		t.Fatalf("output does not end with CRLF: %q", out)
+	}
+
+	// Every physical line fits the limit and continuation lines start with a
+	// space, so unfolding gives back the logical lines
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > vCardLineLimit {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if strings.HasPrefix(line, " ") && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	want := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"UID:1",
+		`FN:Alice "Al" Smith\, Jr.\; \\ PhD`,
+		`N:PhD;Alice "Al" Smith\, Jr.\; \\;;;`,
+		"URL;TYPE=github:https://github.com/asmith",
+		"URL;TYPE=linkedin:https://www.linkedin.com/in/alice-smith",
+		`CATEGORIES:cs.LG,cs\,CL`,
+		`NOTE:Relevance: 0.50\nStage: shortlisted\nNotes: Met at NeurIPS.\nFollow up\; maybe\nPaper: Sparse Attention for Long Documents: Scaling Transformers to Millions of Tokens (https://arxiv.org/abs/2401.01234)`,
+		"END:VCARD",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unfolded vCard =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if strings.Count(out, "\r\n ") < 2 {
+		t.Errorf("the note was not folded:\n%s", out)
+	}
+}
+
+func TestFoldVCardLine(t *testing.T) {
+	short := strings.Repeat("a", vCardLineLimit)
+	if got := foldVCardLine(short); got != short+"\r\n" {
+		t.Errorf("a line at the limit was folded: %q", got)
+	}
+
+	// The 75th octet falls inside "é", so the first line breaks before it
+	line := "NOTE:" + strings.Repeat("a", vCardLineLimit-6) + "é" + strings.Repeat("b", vCardLineLimit)
+	want := "NOTE:" + strings.Repeat("a", vCardLineLimit-6) + "\r\n" +
+		" é" + strings.Repeat("b", vCardLineLimit-3) + "\r\n" +
+		" bbb\r\n"
+	if got := foldVCardLine(line); got != want {
+		t.Errorf("foldVCardLine =\n%q\nwant\n%q", got, want)
+	}
+}
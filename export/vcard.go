@@ -0,0 +1,102 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// vCardLineLimit is the longest line RFC 6350 allows before folding, in octets
+const vCardLineLimit = 75
+
+// WriteVCard writes one vCard 3.0 per candidate. Version 3.0 is used rather
+// than 4.0 because it is the newest version every common address book imports.
+// Profile links become URL properties, arXiv categories become CATEGORIES and
+// the relevance, stage, notes and papers go in NOTE.
+func WriteVCard(w io.Writer, list []Candidate) error {
+	for _, c := range list {
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:3.0",
+			"UID:" + escapeVCard(c.ID),
+			"FN:" + escapeVCard(c.Name),
+			"N:" + structuredName(c.Name),
+		}
+		if c.GithubURL != "" {
+			lines = append(lines, "URL;TYPE=github:"+c.GithubURL)
+		}
+		if c.LinkedinURL != "" {
+			lines = append(lines, "URL;TYPE=linkedin:"+c.LinkedinURL)
+		}
+		if len(c.Categories) > 0 {
+			escaped := make([]string, len(c.Categories))
+			for i, category := range c.Categories {
+				escaped[i] = escapeVCard(category)
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(escaped, ","))
+		}
+		lines = append(lines, "NOTE:"+escapeVCard(vCardNote(c)), "END:VCARD")
+
+		for _, line := range lines {
+			if _, err := io.WriteString(w, foldVCardLine(line)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// vCardNote summarises the candidate's standing in the search
+func vCardNote(c Candidate) string {
+	var sb strings.Builder
+	if c.Relevance != nil {
+		fmt.Fprintf(&sb, "Relevance: %.2f\n", *c.Relevance)
+	}
+	fmt.Fprintf(&sb, "Stage: %s\n", c.Status)
+	if c.Notes != "" {
+		fmt.Fprintf(&sb, "Notes: %s\n", c.Notes)
+	}
+	for _, paper := range c.Papers {
+		fmt.Fprintf(&sb, "Paper: %s (%s)\n", paper.Title, paper.URL)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// structuredName splits a full name into the N property's family and given
+// names, treating the last word as the family name
+func structuredName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return ";;;;"
+	}
+	family := parts[len(parts)-1]
+	given := strings.Join(parts[:len(parts)-1], " ")
+	return escapeVCard(family) + ";" + escapeVCard(given) + ";;;"
+}
+
+// escapeVCard escapes a text value as RFC 6350 section 3.4 requires
+func escapeVCard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldVCardLine ends the line with CRLF, first breaking it into continuation
+// lines of at most vCardLineLimit octets without splitting a UTF-8 character
+func foldVCardLine(line string) string {
+	var sb strings.Builder
+	limit := vCardLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = vCardLineLimit - 1
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+	return sb.String()
+}
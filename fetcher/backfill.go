@@ -0,0 +1,259 @@
+package fetcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// DefaultAPIBaseURL is the arXiv export API endpoint used for backfilling
+const DefaultAPIBaseURL = "http://export.arxiv.org/api/query"
+
+// arxivDateFormat is the minute-resolution format used by submittedDate ranges
+const arxivDateFormat = "200601021504"
+
+// DefaultPageDelay is the pause between API pages; arXiv asks clients to wait 3 seconds
+const DefaultPageDelay = 3 * time.Second
+
+// BackfillOptions controls how far a single backfill run goes
+type BackfillOptions struct {
+	// MaxPages is the number of API pages to request in this run (default 1)
+	MaxPages int
+	// PageDelay is the pause between consecutive pages (default DefaultPageDelay)
+	PageDelay time.Duration
+}
+
+// BackfillResult summarises a backfill run
+type BackfillResult struct {
+	Pages   int
+	Seen    int
+	Skipped int
+	Created []database.Article
+	// Cursor is the oldest submission date reached so far
+	Cursor sql.NullTime
+	// Exhausted is set when the API returned no older papers
+	Exhausted bool
+}
+
+// SetAPIBaseURL overrides the export API endpoint, e.g. to point at a local stand-in
+func (f *Fetcher) SetAPIBaseURL(baseURL string) {
+	f.apiBaseURL = baseURL
+}
+
+// FetchOlder walks backwards in time through the export API for the search's
+// categories. Each page holds results_per_fetch papers; the oldest submission
+// date seen is persisted as the search's backfill cursor after every page, so
+// an interrupted run picks up where it stopped.
+func (f *Fetcher) FetchOlder(ctx context.Context, search database.Search, opts BackfillOptions) (*BackfillResult, error) {
+	categories := CategoriesFromFeedURL(search.ArvixUrl)
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("no arXiv categories found in %s", search.ArvixUrl)
+	}
+
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = 1
+	}
+	if opts.PageDelay <= 0 {
+		opts.PageDelay = DefaultPageDelay
+	}
+
+	pageSize := int64(50)
+	if search.ResultsPerFetch.Valid {
+		pageSize = search.ResultsPerFetch.Int64
+	}
+
+	result := &BackfillResult{Cursor: search.BackfillCursor}
+	for page := 0; page < opts.MaxPages; page++ {
+		if page > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(opts.PageDelay):
+			}
+		}
+
+		feed, err := f.getFeed(ctx, f.queryURL(categories, result.Cursor, pageSize))
+		if err != nil {
+			return result, err
+		}
+		result.Pages++
+
+		if len(feed.Entries) == 0 {
+			result.Exhausted = true
+			break
+		}
+
+		stored, err := f.storeEntries(ctx, search.ID, feed.Entries)
+		result.Seen += stored.Seen
+		result.Skipped += stored.Skipped
+		result.Created = append(result.Created, stored.Created...)
+		if err != nil {
+			return result, err
+		}
+
+		cursor := nextCursor(result.Cursor, feed.Entries)
+		_, err = f.queries.UpdateSearchBackfillCursor(ctx, database.UpdateSearchBackfillCursorParams{
+			UpdatedAt:      time.Now(),
+			BackfillCursor: cursor,
+			ID:             search.ID,
+		})
+		if err != nil {
+			return result, fmt.Errorf("updating backfill cursor: %w", err)
+		}
+		result.Cursor = cursor
+
+		if int64(len(feed.Entries)) < pageSize {
+			result.Exhausted = true
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// queryURL builds an export API request for papers in the given categories
+// submitted at or before the cursor, newest first
+func (f *Fetcher) queryURL(categories []string, cursor sql.NullTime, pageSize int64) string {
+	terms := make([]string, len(categories))
+	for i, category := range categories {
+		terms[i] = "cat:" + category
+	}
+	query := "(" + strings.Join(terms, " OR ") + ")"
+	if cursor.Valid {
+		query += fmt.Sprintf(" AND submittedDate:[199101010000 TO %s]", cursor.Time.UTC().Format(arxivDateFormat))
+	}
+
+	params := url.Values{}
+	params.Set("search_query", query)
+	params.Set("sortBy", "submittedDate")
+	params.Set("sortOrder", "descending")
+	params.Set("start", "0")
+	params.Set("max_results", strconv.FormatInt(pageSize, 10))
+	return f.apiBaseURL + "?" + params.Encode()
+}
+
+// nextCursor returns the oldest submission date in the page. The date range
+// upper bound is inclusive, so if a full page shares the current cursor's
+// minute the cursor is stepped back a minute to guarantee progress.
+func nextCursor(current sql.NullTime, entries []Entry) sql.NullTime {
+	var oldest time.Time
+	for _, entry := range entries {
+		if entry.Published.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || entry.Published.Before(oldest) {
+			oldest = entry.Published
+		}
+	}
+	if oldest.IsZero() {
+		if !current.Valid {
+			return current
+		}
+		oldest = current.Time
+	}
+
+	oldest = oldest.UTC().Truncate(time.Minute)
+	if current.Valid && !oldest.Before(current.Time) {
+		oldest = current.Time.UTC().Truncate(time.Minute).Add(-time.Minute)
+	}
+	return sql.NullTime{Time: oldest, Valid: true}
+}
+
+// CategoriesFromFeedURL extracts the arXiv categories from a feed URL such as
+// "http://rss.arxiv.org/rss/cs.LG+cs.PL"
+func CategoriesFromFeedURL(feedURL string) []string {
+	parsed, err := url.Parse(feedURL)
+	if err != nil {
+		return nil
+	}
+	last := path.Base(parsed.Path)
+	if last == "." || last == "/" {
+		return nil
+	}
+
+	var categories []string
+	for _, category := range strings.Split(last, "+") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// maxIDsPerRequest is how many papers are looked up per export API request
+const maxIDsPerRequest = 100
+
+// CategorizeResult summarises a FetchCategories run
+type CategorizeResult struct {
+	// Categorized holds the articles whose categories were stored
+	Categorized []database.Article
+	// Missing counts articles without an arXiv ID or unknown to the API
+	Missing int
+}
+
+// FetchCategories looks the articles up in the export API by arXiv ID and
+// stores their categories. It is meant for articles stored before categories
+// were recorded; newly fetched articles get theirs from the feed.
+func (f *Fetcher) FetchCategories(ctx context.Context, articles []database.Article, pageDelay time.Duration) (*CategorizeResult, error) {
+	if pageDelay <= 0 {
+		pageDelay = DefaultPageDelay
+	}
+
+	result := &CategorizeResult{}
+	byID := map[string][]database.Article{}
+	var ids []string
+	for _, article := range articles {
+		id := ExtractArxivID(article.ArticleUrl)
+		if id == "" {
+			result.Missing++
+			continue
+		}
+		if byID[id] == nil {
+			ids = append(ids, id)
+		}
+		byID[id] = append(byID[id], article)
+	}
+
+	for start := 0; start < len(ids); start += maxIDsPerRequest {
+		if start > 0 {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(pageDelay):
+			}
+		}
+		batch := ids[start:min(start+maxIDsPerRequest, len(ids))]
+
+		params := url.Values{}
+		params.Set("id_list", strings.Join(batch, ","))
+		params.Set("max_results", strconv.Itoa(len(batch)))
+		feed, err := f.getFeed(ctx, f.apiBaseURL+"?"+params.Encode())
+		if err != nil {
+			return result, err
+		}
+
+		found := map[string]bool{}
+		for _, entry := range feed.Entries {
+			for _, article := range byID[entry.ArxivID] {
+				if err := f.storeCategories(ctx, article.ID, entry); err != nil {
+					return result, fmt.Errorf("storing categories of %s: %w", entry.ArxivID, err)
+				}
+				result.Categorized = append(result.Categorized, article)
+			}
+			found[entry.ArxivID] = true
+		}
+		for _, id := range batch {
+			if !found[id] {
+				result.Missing += len(byID[id])
+			}
+		}
+	}
+	return result, nil
+}
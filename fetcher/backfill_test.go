@@ -0,0 +1,288 @@
+package fetcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+func TestNextCursor(t *testing.T) {
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(2024, 1, 3, hour, minute, second, 0, time.UTC)
+	}
+	valid := func(t time.Time) sql.NullTime { return sql.NullTime{Time: t, Valid: true} }
+
+	tests := []struct {
+		name    string
+		current sql.NullTime
+		dates   []time.Time
+		want    sql.NullTime
+	}{
+		{
+			name:  "first page takes the oldest date, truncated to the minute",
+			dates: []time.Time{at(10, 5, 0), at(9, 30, 45), at(10, 1, 0)},
+			want:  valid(at(9, 30, 0)),
+		},
+		{
+			name:    "older page moves the cursor back",
+			current: valid(at(10, 0, 0)),
+			dates:   []time.Time{at(10, 0, 0), at(8, 15, 20)},
+			want:    valid(at(8, 15, 0)),
+		},
+		{
+			name:    "page stuck on the cursor's minute steps back a minute",
+			current: valid(at(10, 0, 0)),
+			dates:   []time.Time{at(10, 0, 10), at(10, 0, 50)},
+			want:    valid(at(9, 59, 0)),
+		},
+		{
+			name:    "undated entries fall back to the current cursor",
+			current: valid(at(10, 0, 0)),
+			dates:   []time.Time{{}},
+			want:    valid(at(9, 59, 0)),
+		},
+		{
+			name:  "undated entries and no cursor leave it unset",
+			dates: []time.Time{{}},
+		},
+		{
+			name:  "other zones are converted to UTC",
+			dates: []time.Time{time.Date(2024, 1, 3, 5, 0, 0, 0, time.FixedZone("EST", -5*3600))},
+			want:  valid(at(10, 0, 0)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []Entry
+			for _, date := range tt.dates {
+				entries = append(entries, Entry{Published: date})
+			}
+			got := nextCursor(tt.current, entries)
+			if got.Valid != tt.want.Valid || !got.Time.Equal(tt.want.Time) {
+				t.Errorf("nextCursor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// upperBoundPattern pulls the inclusive upper bound out of a submittedDate range
+var upperBoundPattern = regexp.MustCompile(`submittedDate:\[\d{12} TO (\d{12})\]`)
+
+// fakeExportAPI answers export API queries from a fixed set of papers the way
+// arXiv does: newest first, limited to max_results, and with the submittedDate
+// upper bound inclusive to the minute
+type fakeExportAPI struct {
+	papers []Entry
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (api *fakeExportAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("search_query")
+	api.mu.Lock()
+	api.queries = append(api.queries, query)
+	api.mu.Unlock()
+
+	if r.URL.Query().Get("sortBy") != "submittedDate" || r.URL.Query().Get("sortOrder") != "descending" {
+		http.Error(w, "results must be sorted newest first", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("max_results"))
+	if err != nil {
+		http.Error(w, "bad max_results", http.StatusBadRequest)
+		return
+	}
+
+	var page []Entry
+	for _, paper := range api.papers {
+		if !strings.Contains(query, "cat:"+paper.PrimaryCategory) {
+			continue
+		}
+		if match := upperBoundPattern.FindStringSubmatch(query); match != nil {
+			bound, _ := time.Parse(arxivDateFormat, match[1])
+			if paper.Published.Truncate(time.Minute).After(bound) {
+				continue
+			}
+		}
+		if len(page) < limit {
+			page = append(page, paper)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml")
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?><feed xmlns="http://www.w3.org/2005/Atom">`)
+	for _, paper := range page {
+		fmt.Fprintf(w, `<entry><id>http://arxiv.org/abs/%sv1</id><published>%s</published><title>%s</title><summary>Abstract.</summary><author><name>Ada Author</name></author><category term=%q/></entry>`,
+			paper.ArxivID, paper.Published.Format(time.RFC3339), paper.Title, paper.PrimaryCategory)
+	}
+	fmt.Fprintln(w, `</feed>`)
+}
+
+// Queries returns the search_query of every request so far
+func (api *fakeExportAPI) Queries() []string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return append([]string(nil), api.queries...)
+}
+
+// newBackfill serves five cs.LG papers a minute apart, plus one in another
+// category, and returns a fetcher pointed at them with a search that pages
+// two papers at a time
+func newBackfill(t *testing.T) (*Fetcher, *database.Queries, *fakeExportAPI, database.Search) {
+	t.Helper()
+	api := &fakeExportAPI{}
+	for i := 0; i < 5; i++ {
+		api.papers = append(api.papers, Entry{
+			ArxivID:         fmt.Sprintf("2401.0000%d", i+1),
+			Title:           fmt.Sprintf("Paper %d", i+1),
+			PrimaryCategory: "cs.LG",
+			Published:       time.Date(2024, 1, 3, 10, 5-i, 0, 0, time.UTC),
+		})
+	}
+	api.papers = append(api.papers, Entry{ArxivID: "2401.09999", Title: "Elsewhere", PrimaryCategory: "math.CO", Published: time.Date(2024, 1, 3, 10, 3, 0, 0, time.UTC)})
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	_, queries := dbtest.Open(t)
+	now := time.Now()
+	search, err := queries.CreateSearch(context.Background(), database.CreateSearchParams{
+		ID:              uuid.New(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Description:     "backfill",
+		ArvixUrl:        "http://rss.arxiv.org/rss/cs.LG",
+		ResultsPerFetch: sql.NullInt64{Int64: 2, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("creating search: %v", err)
+	}
+
+	f := NewFetcher(queries, server.Client())
+	f.SetAPIBaseURL(server.URL + "/api/query")
+	return f, queries, api, search
+}
+
+func TestFetchOlderWalksBackToTheEnd(t *testing.T) {
+	ctx := context.Background()
+	f, queries, api, search := newBackfill(t)
+
+	result, err := f.FetchOlder(ctx, search, BackfillOptions{MaxPages: 10, PageDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("FetchOlder: %v", err)
+	}
+
+	// Each page after the first repeats the paper at the cursor, since the
+	// range is inclusive. The fifth page holds only that paper, so it comes
+	// back short, ending the walk, and the cursor steps back past its minute.
+	if result.Pages != 5 || len(result.Created) != 5 || result.Skipped != 4 || !result.Exhausted {
+		t.Errorf("pages %d, created %d, skipped %d, exhausted %v; want 5, 5, 4, true",
+			result.Pages, len(result.Created), result.Skipped, result.Exhausted)
+	}
+	wantCursor := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+	if !result.Cursor.Valid || !result.Cursor.Time.Equal(wantCursor) {
+		t.Errorf("cursor = %v, want %v", result.Cursor, wantCursor)
+	}
+
+	queriesSent := api.Queries()
+	if strings.Contains(queriesSent[0], "submittedDate") {
+		t.Errorf("first query %q has a date range, want none", queriesSent[0])
+	}
+	if want := "(cat:cs.LG) AND submittedDate:[199101010000 TO 202401031004]"; queriesSent[1] != want {
+		t.Errorf("second query = %q, want %q", queriesSent[1], want)
+	}
+
+	stored, err := queries.GetSearchByID(ctx, search.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	if !stored.BackfillCursor.Valid || !stored.BackfillCursor.Time.Equal(wantCursor) {
+		t.Errorf("stored cursor = %v, want %v", stored.BackfillCursor, wantCursor)
+	}
+}
+
+func TestFetchOlderResumesFromStoredCursor(t *testing.T) {
+	ctx := context.Background()
+	f, queries, api, search := newBackfill(t)
+
+	first, err := f.FetchOlder(ctx, search, BackfillOptions{MaxPages: 2, PageDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("first FetchOlder: %v", err)
+	}
+	if first.Pages != 2 || len(first.Created) != 3 || first.Exhausted {
+		t.Fatalf("first run: pages %d, created %d, exhausted %v; want 2, 3, false", first.Pages, len(first.Created), first.Exhausted)
+	}
+
+	// A later run starts from the cursor saved on the search
+	search, err = queries.GetSearchByID(ctx, search.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	second, err := f.FetchOlder(ctx, search, BackfillOptions{MaxPages: 10, PageDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("second FetchOlder: %v", err)
+	}
+	if len(second.Created) != 2 || !second.Exhausted {
+		t.Errorf("second run: created %d, exhausted %v; want 2, true", len(second.Created), second.Exhausted)
+	}
+	if got := api.Queries()[2]; !strings.HasSuffix(got, "TO 202401031003]") {
+		t.Errorf("resumed query = %q, want it to continue from 10:03", got)
+	}
+}
+
+func TestFetchOlderEmptyPage(t *testing.T) {
+	ctx := context.Background()
+	f, _, _, search := newBackfill(t)
+	search.BackfillCursor = sql.NullTime{Time: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	result, err := f.FetchOlder(ctx, search, BackfillOptions{MaxPages: 3, PageDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("FetchOlder: %v", err)
+	}
+	if result.Pages != 1 || result.Seen != 0 || !result.Exhausted {
+		t.Errorf("pages %d, seen %d, exhausted %v; want 1, 0, true", result.Pages, result.Seen, result.Exhausted)
+	}
+	if !result.Cursor.Time.Equal(search.BackfillCursor.Time) {
+		t.Errorf("cursor moved to %v on an empty page", result.Cursor.Time)
+	}
+}
+
+func TestFetchOlderNeedsCategories(t *testing.T) {
+	f, _, _, search := newBackfill(t)
+	search.ArvixUrl = "http://rss.arxiv.org"
+	if _, err := f.FetchOlder(context.Background(), search, BackfillOptions{}); err == nil {
+		t.Error("FetchOlder succeeded for a feed URL without categories")
+	}
+}
+
+func TestCategoriesFromFeedURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"http://rss.arxiv.org/rss/cs.LG+cs.PL", []string{"cs.LG", "cs.PL"}},
+		{"https://rss.arxiv.org/atom/math.CO", []string{"math.CO"}},
+		{"http://rss.arxiv.org", nil},
+		{"::not a url", nil},
+	}
+	for _, tt := range tests {
+		got := CategoriesFromFeedURL(tt.url)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("CategoriesFromFeedURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package fetcher
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Entry is a single paper parsed out of an arXiv RSS or Atom feed
+type Entry struct {
+	// ArxivID is the versionless arXiv identifier, e.g. "2401.10241"
+	ArxivID string
+	// URL is the canonical abstract page for the paper
+	URL     string
+	Title   string
+	Summary string
+	// Authors is the author list as arXiv presents it, e.g. "Alice Smith, Bob Jones"
+	Authors         string
+	PrimaryCategory string
+	Categories      []string
+	Published       time.Time
+	// AnnounceType is "new", "cross", "replace", etc. (RSS feeds only)
+	AnnounceType string
+}
+
+// rssFeed mirrors the RSS 2.0 documents served by rss.arxiv.org
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Items   []struct {
+		Title        string   `xml:"title"`
+		Link         string   `xml:"link"`
+		Description  string   `xml:"description"`
+		GUID         string   `xml:"guid"`
+		Categories   []string `xml:"category"`
+		PubDate      string   `xml:"pubDate"`
+		AnnounceType string   `xml:"announce_type"`
+		Creator      string   `xml:"creator"`
+	} `xml:"channel>item"`
+}
+
+// atomFeed mirrors the Atom documents served by rss.arxiv.org/atom and export.arxiv.org/api
+type atomFeed struct {
+	XMLName      xml.Name `xml:"feed"`
+	TotalResults int      `xml:"totalResults"`
+	Entries      []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Published string `xml:"published"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		PrimaryCategory struct {
+			Term string `xml:"term,attr"`
+		} `xml:"primary_category"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+		AnnounceType string `xml:"announce_type"`
+	} `xml:"entry"`
+}
+
+// Feed is the parsed result of an arXiv RSS or Atom document
+type Feed struct {
+	Entries []Entry
+	// TotalResults is only reported by the export API and is zero otherwise
+	TotalResults int
+}
+
+var (
+	arxivIDPattern     = regexp.MustCompile(`(\d{4}\.\d{4,5}|[a-z\-]+(?:\.[A-Z]{2})?/\d{7})(v\d+)?`)
+	rssAbstractPattern = regexp.MustCompile(`(?s)^arXiv:\S+\s+Announce Type:\s*\S+\s*Abstract:\s*`)
+	whitespacePattern  = regexp.MustCompile(`\s+`)
+)
+
+// ParseFeed reads an arXiv RSS or Atom document and returns its entries.
+// The format is detected from the root element, so recorded fixtures of
+// either kind can be fed in directly.
+func ParseFeed(r io.Reader) (*Feed, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading feed: %w", err)
+	}
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parsing feed: %w", err)
+	}
+
+	switch root.XMLName.Local {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed root element %q", root.XMLName.Local)
+	}
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var doc rssFeed
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing RSS feed: %w", err)
+	}
+
+	feed := &Feed{}
+	for _, item := range doc.Items {
+		id := ExtractArxivID(item.GUID)
+		if id == "" {
+			id = ExtractArxivID(item.Link)
+		}
+		if id == "" {
+			continue
+		}
+
+		entry := Entry{
+			ArxivID:      id,
+			URL:          AbstractURL(id),
+			Title:        cleanText(item.Title),
+			Summary:      cleanText(rssAbstractPattern.ReplaceAllString(strings.TrimSpace(item.Description), "")),
+			Authors:      cleanText(item.Creator),
+			Categories:   item.Categories,
+			AnnounceType: strings.TrimSpace(item.AnnounceType),
+		}
+		if len(entry.Categories) > 0 {
+			entry.PrimaryCategory = entry.Categories[0]
+		}
+		if t, err := time.Parse(time.RFC1123Z, strings.TrimSpace(item.PubDate)); err == nil {
+			entry.Published = t
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var doc atomFeed
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing Atom feed: %w", err)
+	}
+
+	feed := &Feed{TotalResults: doc.TotalResults}
+	for _, e := range doc.Entries {
+		id := ExtractArxivID(e.ID)
+		if id == "" {
+			continue
+		}
+
+		entry := Entry{
+			ArxivID:         id,
+			URL:             AbstractURL(id),
+			Title:           cleanText(e.Title),
+			Summary:         cleanText(rssAbstractPattern.ReplaceAllString(strings.TrimSpace(e.Summary), "")),
+			PrimaryCategory: e.PrimaryCategory.Term,
+			AnnounceType:    strings.TrimSpace(e.AnnounceType),
+		}
+		var names []string
+		for _, author := range e.Authors {
+			if name := cleanText(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+		entry.Authors = strings.Join(names, ", ")
+		for _, category := range e.Categories {
+			entry.Categories = append(entry.Categories, category.Term)
+		}
+		if entry.PrimaryCategory == "" && len(entry.Categories) > 0 {
+			entry.PrimaryCategory = entry.Categories[0]
+		}
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(e.Published)); err == nil {
+			entry.Published = t
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, nil
+}
+
+// EntryCategories returns the entry's categories without duplicates, primary
+// category first
+func EntryCategories(entry Entry) []string {
+	var categories []string
+	seen := map[string]bool{}
+	for _, category := range append([]string{entry.PrimaryCategory}, entry.Categories...) {
+		category = strings.TrimSpace(category)
+		if category != "" && !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// ExtractArxivID pulls a versionless arXiv identifier out of a URL, GUID or
+// "arXiv:" reference. It returns an empty string if none is found.
+func ExtractArxivID(s string) string {
+	match := arxivIDPattern.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// AbstractURL returns the canonical abstract page for an arXiv identifier.
+// Both the RSS and API fetchers store articles under this URL so that
+// CheckSearchArticleExists deduplicates across the two sources.
+func AbstractURL(arxivID string) string {
+	return "https://arxiv.org/abs/" + arxivID
+}
+
+// cleanText collapses the hard-wrapped whitespace arXiv puts in titles and abstracts
+func cleanText(s string) string {
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
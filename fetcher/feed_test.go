@@ -0,0 +1,169 @@
+package fetcher
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    []Entry
+		total   int
+	}{
+		{
+			name:    "rss",
+			fixture: "testdata/rss.xml",
+			want: []Entry{
+				{
+					ArxivID:         "2401.01234",
+					URL:             "https://arxiv.org/abs/2401.01234",
+					Title:           "Sparse Attention for Long Documents",
+					Summary:         "We study sparse attention over long documents.",
+					Authors:         "Alice Smith, Bob Jones",
+					PrimaryCategory: "cs.LG",
+					Categories:      []string{"cs.LG", "cs.CL"},
+					Published:       time.Date(2024, 1, 8, 5, 0, 0, 0, time.UTC),
+					AnnounceType:    "new",
+				},
+				{
+					ArxivID:         "2312.09876",
+					URL:             "https://arxiv.org/abs/2312.09876",
+					Title:           "Robust Optimisation Revisited",
+					Summary:         "A revised look at robust optimisation.",
+					Authors:         "Zoë Müller",
+					PrimaryCategory: "math.OC",
+					Categories:      []string{"math.OC"},
+					Published:       time.Date(2024, 1, 8, 5, 0, 0, 0, time.UTC),
+					AnnounceType:    "replace",
+				},
+			},
+		},
+		{
+			name:    "atom",
+			fixture: "testdata/atom.xml",
+			total:   2,
+			want: []Entry{
+				{
+					ArxivID:         "2401.01234",
+					URL:             "https://arxiv.org/abs/2401.01234",
+					Title:           "Sparse Attention for Long Documents",
+					Summary:         "We study sparse attention over long documents.",
+					Authors:         "Alice Smith, Bob Jones",
+					PrimaryCategory: "cs.LG",
+					Categories:      []string{"cs.CL", "cs.LG"},
+					Published:       time.Date(2024, 1, 3, 18, 59, 12, 0, time.UTC),
+				},
+				{
+					ArxivID:         "math/0601001",
+					URL:             "https://arxiv.org/abs/math/0601001",
+					Title:           "An Old-Style Identifier",
+					Summary:         "Abstract text.",
+					Authors:         "Carol White",
+					PrimaryCategory: "math.CO",
+					Categories:      []string{"math.CO"},
+					Published:       time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open(tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			feed, err := ParseFeed(file)
+			if err != nil {
+				t.Fatalf("ParseFeed: %v", err)
+			}
+			if feed.TotalResults != tt.total {
+				t.Errorf("TotalResults = %d, want %d", feed.TotalResults, tt.total)
+			}
+			if len(feed.Entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(feed.Entries), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := feed.Entries[i]
+				if !got.Published.Equal(want.Published) {
+					t.Errorf("entry %d: Published = %v, want %v", i, got.Published, want.Published)
+				}
+				got.Published, want.Published = time.Time{}, time.Time{}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("entry %d:\n got %+v\nwant %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"not xml", "this is not a feed", "parsing feed"},
+		{"unknown root", `<html><body/></html>`, `unsupported feed root element "html"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFeed(strings.NewReader(tt.doc))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseFeed error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeedSkipsEntriesWithoutID(t *testing.T) {
+	doc := `<rss><channel>
+		<item><title>No identifier</title><link>https://example.com/post</link></item>
+		<item><title>Has one</title><guid>oai:arXiv.org:2401.00001v1</guid></item>
+	</channel></rss>`
+	feed, err := ParseFeed(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ParseFeed: %v", err)
+	}
+	if len(feed.Entries) != 1 || feed.Entries[0].ArxivID != "2401.00001" {
+		t.Errorf("got %+v, want only 2401.00001", feed.Entries)
+	}
+}
+
+func TestExtractArxivID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://arxiv.org/abs/2401.01234", "2401.01234"},
+		{"https://arxiv.org/abs/2401.01234v3", "2401.01234"},
+		{"http://arxiv.org/pdf/1706.03762v7.pdf", "1706.03762"},
+		{"oai:arXiv.org:2312.0987v2", "2312.0987"},
+		{"arXiv:2401.12345", "2401.12345"},
+		{"http://arxiv.org/abs/math/0601001v1", "math/0601001"},
+		{"hep-th/9901001", "hep-th/9901001"},
+		{"math.GT/0309136", "math.GT/0309136"},
+		{"https://example.com/paper", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractArxivID(tt.in); got != tt.want {
+			t.Errorf("ExtractArxivID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEntryCategories(t *testing.T) {
+	entry := Entry{PrimaryCategory: "cs.LG", Categories: []string{"cs.CL", "cs.LG", " stat.ML ", ""}}
+	want := []string{"cs.LG", "cs.CL", "stat.ML"}
+	if got := EntryCategories(entry); !reflect.DeepEqual(got, want) {
+		t.Errorf("EntryCategories = %v, want %v", got, want)
+	}
+}
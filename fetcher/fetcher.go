@@ -0,0 +1,150 @@
+package fetcher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// userAgent identifies reSearch to arXiv, as requested by their API terms of use
+const userAgent = "reSearch/0.1 (https://github.com/jessewalker/reSearch)"
+
+// Fetcher downloads arXiv feeds for a search and stores any unseen papers as articles
+type Fetcher struct {
+	queries    *database.Queries
+	client     *http.Client
+	apiBaseURL string
+}
+
+// Result summarises the outcome of a single fetch
+type Result struct {
+	// Seen is the number of entries present in the feed
+	Seen int
+	// Skipped is the number of entries that were already stored
+	Skipped int
+	// Created holds the articles that were newly stored
+	Created []database.Article
+}
+
+// NewFetcher creates a new fetcher. If client is nil, a default client with a
+// 30 second timeout is used.
+func NewFetcher(queries *database.Queries, client *http.Client) *Fetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Fetcher{queries: queries, client: client, apiBaseURL: DefaultAPIBaseURL}
+}
+
+// FetchNew downloads the search's RSS/Atom feed, stores every paper that has not
+// been seen before and records the fetch time on the search
+func (f *Fetcher) FetchNew(ctx context.Context, search database.Search) (*Result, error) {
+	feed, err := f.getFeed(ctx, search.ArvixUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := f.storeEntries(ctx, search.ID, feed.Entries)
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	_, err = f.queries.UpdateSearchLastFetchDate(ctx, database.UpdateSearchLastFetchDateParams{
+		UpdatedAt:     now,
+		LastFetchDate: sql.NullTime{Time: now, Valid: true},
+		ID:            search.ID,
+	})
+	if err != nil {
+		return result, fmt.Errorf("updating last fetch date: %w", err)
+	}
+	return result, nil
+}
+
+// getFeed downloads and parses the feed at the given URL
+func (f *Fetcher) getFeed(ctx context.Context, feedURL string) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building request for %s: %w", feedURL, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", feedURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", feedURL, resp.Status)
+	}
+
+	feed, err := ParseFeed(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", feedURL, err)
+	}
+	return feed, nil
+}
+
+// storeEntries creates an article for every entry whose URL is not already
+// stored for the search
+func (f *Fetcher) storeEntries(ctx context.Context, searchID interface{}, entries []Entry) (*Result, error) {
+	result := &Result{Seen: len(entries)}
+
+	for _, entry := range entries {
+		exists, err := f.queries.CheckSearchArticleExists(ctx, database.CheckSearchArticleExistsParams{
+			ArticleUrl: entry.URL,
+			SearchID:   searchID,
+		})
+		if err != nil {
+			return result, fmt.Errorf("checking article %s: %w", entry.ArxivID, err)
+		}
+		if exists != 0 {
+			result.Skipped++
+			continue
+		}
+
+		article, err := f.queries.CreateArticle(ctx, database.CreateArticleParams{
+			ID:             uuid.New(),
+			FetchedAt:      time.Now(),
+			ArticleUrl:     entry.URL,
+			ArticleTitle:   entry.Title,
+			ArticleSummary: entry.Summary,
+			ArticleAuthors: entry.Authors,
+			SearchID:       searchID,
+		})
+		if err != nil {
+			return result, fmt.Errorf("creating article %s: %w", entry.ArxivID, err)
+		}
+		if err := f.storeCategories(ctx, article.ID, entry); err != nil {
+			return result, fmt.Errorf("storing categories of %s: %w", entry.ArxivID, err)
+		}
+		result.Created = append(result.Created, article)
+	}
+
+	return result, nil
+}
+
+// storeCategories records the entry's primary and cross-listed categories
+// against the article
+func (f *Fetcher) storeCategories(ctx context.Context, articleID interface{}, entry Entry) error {
+	now := time.Now()
+	for _, category := range EntryCategories(entry) {
+		err := f.queries.AddArticleCategory(ctx, database.AddArticleCategoryParams{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			ArticleID:     articleID,
+			ArxivCategory: category,
+			IsPrimary:     category == entry.PrimaryCategory,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// newFeedServer serves a recorded feed fixture at /rss/cs.LG
+func newFeedServer(t *testing.T, fixture string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rss/cs.LG" {
+			http.NotFound(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("User-Agent"), "reSearch/") {
+			t.Errorf("User-Agent = %q, want reSearch's", r.Header.Get("User-Agent"))
+		}
+		http.ServeFile(w, r, fixture)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchNew(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	server := newFeedServer(t, "testdata/rss.xml")
+	search := dbtest.CreateSearch(t, queries, "long documents", server.URL+"/rss/cs.LG")
+	f := NewFetcher(queries, server.Client())
+
+	result, err := f.FetchNew(ctx, search)
+	if err != nil {
+		t.Fatalf("FetchNew: %v", err)
+	}
+	if result.Seen != 2 || len(result.Created) != 2 || result.Skipped != 0 {
+		t.Fatalf("first fetch: seen %d, created %d, skipped %d; want 2, 2, 0", result.Seen, len(result.Created), result.Skipped)
+	}
+
+	article, err := queries.GetArticleByURL(ctx, "https://arxiv.org/abs/2401.01234")
+	if err != nil {
+		t.Fatalf("GetArticleByURL: %v", err)
+	}
+	if article.ArticleTitle != "Sparse Attention for Long Documents" || article.ArticleAuthors != "Alice Smith, Bob Jones" {
+		t.Errorf("stored article = %+v", article)
+	}
+	categories, err := queries.ListArticleCategories(ctx, article.ID)
+	if err != nil {
+		t.Fatalf("ListArticleCategories: %v", err)
+	}
+	if len(categories) != 2 {
+		t.Errorf("stored %d categories, want 2", len(categories))
+	}
+
+	updated, err := queries.GetSearchByID(ctx, search.ID)
+	if err != nil {
+		t.Fatalf("GetSearchByID: %v", err)
+	}
+	if !updated.LastFetchDate.Valid {
+		t.Error("last fetch date was not recorded")
+	}
+
+	// Fetching again stores nothing new
+	result, err = f.FetchNew(ctx, search)
+	if err != nil {
+		t.Fatalf("second FetchNew: %v", err)
+	}
+	if len(result.Created) != 0 || result.Skipped != 2 {
+		t.Errorf("second fetch: created %d, skipped %d; want 0, 2", len(result.Created), result.Skipped)
+	}
+}
+
+// Two searches over the same feed each get their own copy of every paper
+func TestFetchNewDeduplicatesPerSearch(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	server := newFeedServer(t, "testdata/rss.xml")
+	first := dbtest.CreateSearch(t, queries, "first", server.URL+"/rss/cs.LG")
+	second := dbtest.CreateSearch(t, queries, "second", server.URL+"/rss/cs.LG")
+	f := NewFetcher(queries, server.Client())
+
+	for _, search := range []database.Search{first, second} {
+		result, err := f.FetchNew(ctx, search)
+		if err != nil {
+			t.Fatalf("%s search: FetchNew: %v", search.Description, err)
+		}
+		if len(result.Created) != 2 || result.Skipped != 0 {
+			t.Errorf("%s search: created %d, skipped %d; want 2, 0", search.Description, len(result.Created), result.Skipped)
+		}
+		count, err := queries.CountArticlesBySearch(ctx, search.ID)
+		if err != nil {
+			t.Fatalf("CountArticlesBySearch: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("%s search has %d articles, want 2", search.Description, count)
+		}
+	}
+}
+
+func TestFetchNewErrors(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	server := newFeedServer(t, "testdata/rss.xml")
+	f := NewFetcher(queries, server.Client())
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"missing feed", server.URL + "/rss/none", "404"},
+		{"local file", "file:///etc/passwd", "unsupported protocol scheme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := dbtest.CreateSearch(t, queries, tt.name, tt.url)
+			_, err := f.FetchNew(ctx, search)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FetchNew error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// The default client must not read local files either
+	search := dbtest.CreateSearch(t, queries, "default client", "file:///etc/passwd")
+	if _, err := NewFetcher(queries, nil).FetchNew(ctx, search); err == nil {
+		t.Error("the default client fetched a file:// URL")
+	}
+}
@@ -0,0 +1,54 @@
+package fetcher
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// hostRateLimiter is an http.RoundTripper that spaces out requests to the same
+// host by at least interval, so concurrent fetches still respect arXiv's
+// request rate. Requests to different hosts do not wait on each other.
+type hostRateLimiter struct {
+	next     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	slot map[string]time.Time
+}
+
+func (l *hostRateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Reserve the next free slot for this host, then wait for it outside the lock
+	l.mu.Lock()
+	now := time.Now()
+	at := l.slot[req.URL.Host]
+	if at.Before(now) {
+		at = now
+	}
+	l.slot[req.URL.Host] = at.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait := time.Until(at); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return l.next.RoundTrip(req)
+}
+
+// SetHostRateLimit makes every request wait until at least interval has passed
+// since the previous request to the same host. It is safe to fetch several
+// searches concurrently once this is set.
+func (f *Fetcher) SetHostRateLimit(interval time.Duration) {
+	next := f.client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client := *f.client
+	client.Transport = &hostRateLimiter{next: next, interval: interval, slot: map[string]time.Time{}}
+	f.client = &client
+}
@@ -0,0 +1,189 @@
+package fetcher
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Bounds on results_per_fetch, matching the CHECK constraint on searches and
+// the floor applied by DecrementSearchFetchResults
+const (
+	MinPageSize = 10
+	MaxPageSize = 1999
+)
+
+// Thresholds the Sizer uses to decide whether a page size should change
+const (
+	// GrowNewRatio is the share of new articles in a full page above which the page grows
+	GrowNewRatio = 0.9
+	// ShrinkDuplicateRatio is the share of already-stored articles above which the page shrinks
+	ShrinkDuplicateRatio = 0.5
+	// ShrinkRelevantRatio is the share of relevant scored articles below which the page shrinks
+	ShrinkRelevantRatio = 0.2
+	// MinScoredForRelevance is how many verdicts a run needs before relevance is considered
+	MinScoredForRelevance = 5
+)
+
+// Yield describes what a single fetch run produced
+type Yield struct {
+	// Pages is the number of API pages requested
+	Pages int
+	// Fetched is the number of entries the API returned
+	Fetched int
+	// New is the number of entries stored as new articles
+	New int
+	// Duplicates is the number of entries that were already stored
+	Duplicates int
+	// Scored is the number of this run's articles with a relevance verdict (0 if not scored)
+	Scored int
+	// Relevant is the number of scored articles at or above the relevance threshold
+	Relevant int
+}
+
+// BackfillYield summarises a backfill run for the Sizer. The page size only
+// applies to API pages, so RSS fetches are never used to resize a search.
+func BackfillYield(result *BackfillResult) Yield {
+	return Yield{Pages: result.Pages, Fetched: result.Seen, New: len(result.Created), Duplicates: result.Skipped}
+}
+
+// PlanPageSize decides the next page size for a search from the yield of its
+// last run. It grows the page by half when a run came back full and almost
+// entirely new, and shrinks it by a quarter when most results were duplicates
+// or few were relevant. The reason is empty when the size should stay put.
+func PlanPageSize(current int64, y Yield) (int64, string) {
+	if y.Fetched == 0 {
+		return current, ""
+	}
+
+	next, reason := current, ""
+	duplicateRatio := float64(y.Duplicates) / float64(y.Fetched)
+	newRatio := float64(y.New) / float64(y.Fetched)
+	switch {
+	case duplicateRatio >= ShrinkDuplicateRatio:
+		next = current - max(current/4, MinPageSize)
+		reason = fmt.Sprintf("%d of %d results were already stored", y.Duplicates, y.Fetched)
+	case y.Scored >= MinScoredForRelevance && float64(y.Relevant)/float64(y.Scored) < ShrinkRelevantRatio:
+		next = current - max(current/4, MinPageSize)
+		reason = fmt.Sprintf("only %d of %d scored articles were relevant", y.Relevant, y.Scored)
+	case int64(y.Fetched) >= current*int64(max(y.Pages, 1)) && newRatio >= GrowNewRatio:
+		next = current + max(current/2, MinPageSize)
+		reason = fmt.Sprintf("pages came back full and %d of %d results were new", y.New, y.Fetched)
+	}
+
+	next = min(max(next, MinPageSize), MaxPageSize)
+	if next == current {
+		return current, ""
+	}
+	return next, reason
+}
+
+// Sizer adjusts a search's results_per_fetch after each run and records why
+type Sizer struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewSizer creates a new fetch-size controller
+func NewSizer(db *sql.DB, queries *database.Queries) *Sizer {
+	return &Sizer{db: db, queries: queries}
+}
+
+// AdjustBackfill feeds a backfill run to Adjust. Verdicts already stored for
+// the run's new articles count towards the decision, with those scoring at or
+// above minRelevance counted as relevant, so the run is judged on relevance
+// whenever its articles were scored before the call.
+func (s *Sizer) AdjustBackfill(ctx context.Context, search database.Search, result *BackfillResult, minRelevance float64) (*database.SearchFetchAdjustment, error) {
+	yield := BackfillYield(result)
+	for _, article := range result.Created {
+		relevance, err := s.queries.GetArticleRelevance(ctx, article.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading relevance of %s: %w", article.ArticleUrl, err)
+		}
+		yield.Scored++
+		if relevance.Score >= minRelevance {
+			yield.Relevant++
+		}
+	}
+	return s.Adjust(ctx, search, yield)
+}
+
+// Adjust applies PlanPageSize to the search and, if the size changes, stores
+// the new size and an audit row in one transaction. It returns nil when the
+// size was left alone.
+func (s *Sizer) Adjust(ctx context.Context, search database.Search, y Yield) (*database.SearchFetchAdjustment, error) {
+	current := int64(50)
+	if search.ResultsPerFetch.Valid {
+		current = search.ResultsPerFetch.Int64
+	}
+
+	next, reason := PlanPageSize(current, y)
+	if reason == "" {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	q := s.queries.WithTx(tx)
+
+	now := time.Now()
+	var updated database.Search
+	switch {
+	case !search.ResultsPerFetch.Valid:
+		// NULL arithmetic stays NULL, so an unset size is written outright
+		updated, err = q.UpdateSearchFetchRate(ctx, database.UpdateSearchFetchRateParams{
+			UpdatedAt:       now,
+			ResultsPerFetch: sql.NullInt64{Int64: next, Valid: true},
+			ID:              search.ID,
+		})
+	case next > current:
+		updated, err = q.IncrementSearchFetchResults(ctx, database.IncrementSearchFetchResultsParams{
+			UpdatedAt:       now,
+			ResultsPerFetch: sql.NullInt64{Int64: next - current, Valid: true},
+			ID:              search.ID,
+		})
+	default:
+		updated, err = q.DecrementSearchFetchResults(ctx, database.DecrementSearchFetchResultsParams{
+			UpdatedAt:       now,
+			ResultsPerFetch: sql.NullInt64{Int64: current - next, Valid: true},
+			ID:              search.ID,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("updating results per fetch: %w", err)
+	}
+
+	adjustment, err := q.CreateSearchFetchAdjustment(ctx, database.CreateSearchFetchAdjustmentParams{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		SearchID:     search.ID,
+		PreviousSize: current,
+		NewSize:      updated.ResultsPerFetch.Int64,
+		Fetched:      int64(y.Fetched),
+		NewArticles:  int64(y.New),
+		Duplicates:   int64(y.Duplicates),
+		Scored:       int64(y.Scored),
+		Relevant:     int64(y.Relevant),
+		Reason:       reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recording fetch size change: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
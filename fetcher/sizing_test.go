@@ -0,0 +1,62 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// Verdicts stored for a backfill's new articles shrink the page when few of
+// them are relevant, however the articles came to be scored
+func TestAdjustBackfillCountsStoredVerdicts(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+
+	result := &BackfillResult{Pages: 1}
+	for i := 0; i < 20; i++ {
+		article := dbtest.CreateArticle(t, queries, search.ID, fmt.Sprintf("https://arxiv.org/abs/2401.%05d", i),
+			"Title", "Abstract.", "Ada Author")
+		result.Created = append(result.Created, article)
+		result.Seen++
+		// Only the first six are scored, and only one of those is relevant
+		if i >= 6 {
+			continue
+		}
+		score := 0.1
+		if i == 0 {
+			score = 0.9
+		}
+		now := time.Now()
+		if _, err := queries.UpsertArticleRelevance(ctx, database.UpsertArticleRelevanceParams{
+			ID:         uuid.New(),
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			ArticleID:  article.ID,
+			SearchID:   search.ID,
+			Score:      score,
+			Rationale:  "test",
+			KeyAuthors: "[]",
+			Model:      "test",
+		}); err != nil {
+			t.Fatalf("UpsertArticleRelevance: %v", err)
+		}
+	}
+
+	adjustment, err := NewSizer(db, queries).AdjustBackfill(ctx, search, result, 0.5)
+	if err != nil {
+		t.Fatalf("AdjustBackfill: %v", err)
+	}
+	if adjustment == nil {
+		t.Fatal("page size left alone, want it shrunk")
+	}
+	if adjustment.Scored != 6 || adjustment.Relevant != 1 || adjustment.NewSize >= adjustment.PreviousSize {
+		t.Errorf("adjustment = %+v, want 6 scored, 1 relevant and a smaller page", adjustment)
+	}
+}
@@ -0,0 +1,137 @@
+package fetcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultRSSBaseURL is the arXiv RSS endpoint that searches subscribe to
+const DefaultRSSBaseURL = "http://rss.arxiv.org/rss/"
+
+// Taxonomy maps the arXiv category codes reSearch knows about to their names.
+// It covers the archives most relevant to AI/ML talent searches rather than
+// the whole of arXiv.
+var Taxonomy = map[string]string{
+	// Computer Science
+	"cs.AI": "Artificial Intelligence",
+	"cs.AR": "Hardware Architecture",
+	"cs.CC": "Computational Complexity",
+	"cs.CE": "Computational Engineering, Finance, and Science",
+	"cs.CG": "Computational Geometry",
+	"cs.CL": "Computation and Language",
+	"cs.CR": "Cryptography and Security",
+	"cs.CV": "Computer Vision and Pattern Recognition",
+	"cs.CY": "Computers and Society",
+	"cs.DB": "Databases",
+	"cs.DC": "Distributed, Parallel, and Cluster Computing",
+	"cs.DL": "Digital Libraries",
+	"cs.DM": "Discrete Mathematics",
+	"cs.DS": "Data Structures and Algorithms",
+	"cs.ET": "Emerging Technologies",
+	"cs.FL": "Formal Languages and Automata Theory",
+	"cs.GL": "General Literature",
+	"cs.GR": "Graphics",
+	"cs.GT": "Computer Science and Game Theory",
+	"cs.HC": "Human-Computer Interaction",
+	"cs.IR": "Information Retrieval",
+	"cs.IT": "Information Theory",
+	"cs.LG": "Machine Learning",
+	"cs.LO": "Logic in Computer Science",
+	"cs.MA": "Multiagent Systems",
+	"cs.MM": "Multimedia",
+	"cs.MS": "Mathematical Software",
+	"cs.NA": "Numerical Analysis",
+	"cs.NE": "Neural and Evolutionary Computing",
+	"cs.NI": "Networking and Internet Architecture",
+	"cs.OH": "Other Computer Science",
+	"cs.OS": "Operating Systems",
+	"cs.PF": "Performance",
+	"cs.PL": "Programming Languages",
+	"cs.RO": "Robotics",
+	"cs.SC": "Symbolic Computation",
+	"cs.SD": "Sound",
+	"cs.SE": "Software Engineering",
+	"cs.SI": "Social and Information Networks",
+	"cs.SY": "Systems and Control",
+
+	// Statistics
+	"stat.AP": "Statistics Applications",
+	"stat.CO": "Statistics Computation",
+	"stat.ME": "Statistics Methodology",
+	"stat.ML": "Machine Learning (Statistics)",
+	"stat.OT": "Other Statistics",
+	"stat.TH": "Statistics Theory",
+
+	// Electrical Engineering and Systems Science
+	"eess.AS": "Audio and Speech Processing",
+	"eess.IV": "Image and Video Processing",
+	"eess.SP": "Signal Processing",
+	"eess.SY": "Systems and Control (EESS)",
+
+	// Mathematics
+	"math.IT": "Information Theory (Mathematics)",
+	"math.NA": "Numerical Analysis (Mathematics)",
+	"math.OC": "Optimization and Control",
+	"math.PR": "Probability",
+	"math.ST": "Statistics Theory (Mathematics)",
+
+	// Physics
+	"cond-mat.dis-nn": "Disordered Systems and Neural Networks",
+	"physics.comp-ph": "Computational Physics",
+	"physics.data-an": "Data Analysis, Statistics and Probability",
+	"physics.soc-ph":  "Physics and Society",
+	"quant-ph":        "Quantum Physics",
+
+	// Quantitative Biology
+	"q-bio.BM": "Biomolecules",
+	"q-bio.GN": "Genomics",
+	"q-bio.NC": "Neurons and Cognition",
+	"q-bio.QM": "Quantitative Methods",
+
+	// Quantitative Finance
+	"q-fin.CP": "Computational Finance",
+	"q-fin.ST": "Statistical Finance",
+	"q-fin.TR": "Trading and Market Microstructure",
+
+	// Economics
+	"econ.EM": "Econometrics",
+	"econ.GN": "General Economics",
+	"econ.TH": "Theoretical Economics",
+}
+
+// TaxonomyListing renders the taxonomy as "code: name" lines sorted by code,
+// for inclusion in prompts
+func TaxonomyListing() string {
+	codes := make([]string, 0, len(Taxonomy))
+	for code := range Taxonomy {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var sb strings.Builder
+	for _, code := range codes {
+		fmt.Fprintf(&sb, "%s: %s\n", code, Taxonomy[code])
+	}
+	return sb.String()
+}
+
+// FeedURL builds the RSS feed URL for a set of categories, e.g.
+// "http://rss.arxiv.org/rss/cs.LG+cs.PL". Unknown categories are rejected.
+func FeedURL(categories []string) (string, error) {
+	if len(categories) == 0 {
+		return "", fmt.Errorf("at least one category is required")
+	}
+
+	var unknown []string
+	for _, category := range categories {
+		if _, ok := Taxonomy[category]; !ok {
+			unknown = append(unknown, category)
+		}
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown arXiv categories: %s", strings.Join(unknown, ", "))
+	}
+
+	return DefaultRSSBaseURL + strings.Join(categories, "+"), nil
+}
@@ -0,0 +1,385 @@
+// Package fulltext runs ranked searches over the FTS5 indexes of articles and
+// candidate names that migration 016 creates. The queries are written by hand
+// because sqlc cannot resolve the hidden column an FTS5 table has under its
+// own name, which MATCH and bm25() are given. FTS5 is only compiled into the
+// sqlite3 driver under the sqlite_fts5 build tag; without the index the
+// searches fall back to substring matching.
+package fulltext
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Index searches the full-text indexes
+type Index struct {
+	db database.DBTX
+}
+
+// NewIndex creates a new index over the database
+func NewIndex(db database.DBTX) *Index {
+	return &Index{db: db}
+}
+
+const searchArticles = `
+SELECT
+  a.id,
+  a.fetched_at,
+  a.article_url,
+  a.article_title,
+  a.article_summary,
+  a.article_authors,
+  a.search_id,
+  bm25(articles_fts, 10.0, 1.0, 2.0) AS rank,
+  snippet(articles_fts, -1, ?2, ?3, '...', 24) AS snippet
+FROM articles_fts
+JOIN articles a ON a.rowid = articles_fts.rowid
+WHERE
+  articles_fts MATCH ?1 AND
+  (?4 IS NULL OR a.search_id = ?4) AND
+  (?5 IS NULL OR a.fetched_at >= ?5) AND
+  (?6 IS NULL OR a.fetched_at < ?6)
+ORDER BY rank, a.fetched_at DESC
+LIMIT ?7
+OFFSET ?8
+`
+
+// ArticleQuery is a full-text search over articles
+type ArticleQuery struct {
+	// Query is an FTS5 query, e.g. `"graph neural" AND (protein OR molecule)`
+	Query string
+	// MarkStart and MarkEnd surround the matched terms in the snippet
+	MarkStart string
+	MarkEnd   string
+	// SearchID, FetchedAfter and FetchedBefore are ignored when nil or invalid
+	SearchID      interface{}
+	FetchedAfter  sql.NullTime
+	FetchedBefore sql.NullTime
+	Limit         int64
+	Offset        int64
+}
+
+// ArticleMatch is an article matching an ArticleQuery
+type ArticleMatch struct {
+	ID             interface{}
+	FetchedAt      time.Time
+	ArticleUrl     string
+	ArticleTitle   string
+	ArticleSummary string
+	ArticleAuthors string
+	SearchID       interface{}
+	// Rank is the BM25 score; lower is a better match
+	Rank    float64
+	Snippet string
+}
+
+// Articles returns the articles matching the query, best match first. A match
+// in the title weighs ten times, and one in the authors twice, as much as a
+// match in the summary. The snippet comes from whichever column matched best.
+func (x *Index) Articles(ctx context.Context, arg ArticleQuery) ([]ArticleMatch, error) {
+	rows, err := x.db.QueryContext(ctx, searchArticles,
+		arg.Query,
+		arg.MarkStart,
+		arg.MarkEnd,
+		arg.SearchID,
+		utcNullTime(arg.FetchedAfter),
+		utcNullTime(arg.FetchedBefore),
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		if isFullTextMissing(err) {
+			return x.articlesBySubstring(ctx, arg)
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ArticleMatch
+	for rows.Next() {
+		var i ArticleMatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.FetchedAt,
+			&i.ArticleUrl,
+			&i.ArticleTitle,
+			&i.ArticleSummary,
+			&i.ArticleAuthors,
+			&i.SearchID,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findCandidates = `
+SELECT c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url, c.normalized_name
+FROM candidates_fts
+JOIN candidates c ON c.rowid = candidates_fts.rowid
+WHERE candidates_fts MATCH ?
+ORDER BY bm25(candidates_fts), c.created_at DESC
+LIMIT ?
+OFFSET ?
+`
+
+// CandidateQuery is a full-text search over candidate names
+type CandidateQuery struct {
+	// Query is an FTS5 query; TermsQuery(name, true) builds one from a partial name
+	Query  string
+	Limit  int64
+	Offset int64
+}
+
+// Candidates returns the candidates whose name matches the query, best match
+// first
+func (x *Index) Candidates(ctx context.Context, arg CandidateQuery) ([]database.Candidate, error) {
+	rows, err := x.db.QueryContext(ctx, findCandidates, arg.Query, arg.Limit, arg.Offset)
+	if err != nil {
+		if isFullTextMissing(err) {
+			return x.candidatesBySubstring(ctx, arg)
+		}
+		return nil, err
+	}
+	return scanCandidates(rows)
+}
+
+// scanCandidates reads every row of a candidates query
+func scanCandidates(rows *sql.Rows) ([]database.Candidate, error) {
+	defer rows.Close()
+	var items []database.Candidate
+	for rows.Next() {
+		var i database.Candidate
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.LinkedinUrl,
+			&i.GithubUrl,
+			&i.NormalizedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// isFullTextMissing reports whether a query failed because the full-text
+// tables do not exist or SQLite lacks the FTS5 module they were created with
+func isFullTextMissing(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no such table: articles_fts") ||
+		strings.Contains(msg, "no such table: candidates_fts") ||
+		strings.Contains(msg, "no such module: fts5")
+}
+
+// articlesBySubstring answers Articles without the full-text index. Every
+// word of the query, with the operators and syntax left out, must appear
+// somewhere in the title, summary or authors; matches are listed newest first
+// with an unranked snippet.
+func (x *Index) articlesBySubstring(ctx context.Context, arg ArticleQuery) ([]ArticleMatch, error) {
+	words := queryWords(arg.Query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	query := `
+SELECT a.id, a.fetched_at, a.article_url, a.article_title, a.article_summary, a.article_authors, a.search_id
+FROM articles a
+WHERE
+  (?1 IS NULL OR a.search_id = ?1) AND
+  (?2 IS NULL OR a.fetched_at >= ?2) AND
+  (?3 IS NULL OR a.fetched_at < ?3)`
+	args := []interface{}{arg.SearchID, utcNullTime(arg.FetchedAfter), utcNullTime(arg.FetchedBefore)}
+	for _, word := range words {
+		args = append(args, likePattern(word))
+		query += fmt.Sprintf(" AND\n  LOWER(a.article_title || ' ' || a.article_summary || ' ' || a.article_authors) LIKE ?%d ESCAPE '\\'", len(args))
+	}
+	query += fmt.Sprintf("\nORDER BY a.fetched_at DESC\nLIMIT ?%d\nOFFSET ?%d", len(args)+1, len(args)+2)
+	args = append(args, arg.Limit, arg.Offset)
+
+	rows, err := x.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ArticleMatch
+	for rows.Next() {
+		var i ArticleMatch
+		if err := rows.Scan(
+			&i.ID,
+			&i.FetchedAt,
+			&i.ArticleUrl,
+			&i.ArticleTitle,
+			&i.ArticleSummary,
+			&i.ArticleAuthors,
+			&i.SearchID,
+		); err != nil {
+			return nil, err
+		}
+		for _, text := range []string{i.ArticleSummary, i.ArticleTitle, i.ArticleAuthors} {
+			if i.Snippet = substringSnippet(text, words, arg.MarkStart, arg.MarkEnd); i.Snippet != "" {
+				break
+			}
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// candidatesBySubstring answers Candidates without the full-text index: every
+// word of the query must appear in the name
+func (x *Index) candidatesBySubstring(ctx context.Context, arg CandidateQuery) ([]database.Candidate, error) {
+	words := queryWords(arg.Query)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	query := `
+SELECT c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url, c.normalized_name
+FROM candidates c
+WHERE`
+	var args []interface{}
+	for i, word := range words {
+		if i > 0 {
+			query += " AND"
+		}
+		args = append(args, likePattern(word))
+		query += fmt.Sprintf("\n  LOWER(c.name) LIKE ?%d ESCAPE '\\'", len(args))
+	}
+	query += fmt.Sprintf("\nORDER BY c.created_at DESC\nLIMIT ?%d\nOFFSET ?%d", len(args)+1, len(args)+2)
+	args = append(args, arg.Limit, arg.Offset)
+
+	rows, err := x.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanCandidates(rows)
+}
+
+// queryWords returns the lowercased words of an FTS5 query with its quotes,
+// prefix stars, parentheses, column filters and operators removed
+func queryWords(query string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(query, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"()*^:`, r)
+	}) {
+		switch word {
+		case "AND", "OR", "NOT", "NEAR":
+			continue
+		}
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		words = append(words, strings.ToLower(word))
+	}
+	return words
+}
+
+// likePattern matches text containing word, escaping LIKE's wildcards
+func likePattern(word string) string {
+	word = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(word)
+	return "%" + word + "%"
+}
+
+// substringSnippet returns up to 24 words of text around the first word
+// containing one of words, with each such word between markStart and markEnd.
+// It returns "" if none of the words appear.
+func substringSnippet(text string, words []string, markStart, markEnd string) string {
+	fields := strings.Fields(text)
+	contains := func(field string) bool {
+		field = strings.ToLower(field)
+		for _, word := range words {
+			if strings.Contains(field, word) {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := -1
+	for i, field := range fields {
+		if contains(field) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start := max(0, first-8)
+	end := min(len(fields), start+24)
+	var snippet []string
+	for _, field := range fields[start:end] {
+		if contains(field) {
+			field = markStart + field + markEnd
+		}
+		snippet = append(snippet, field)
+	}
+	text = strings.Join(snippet, " ")
+	if start > 0 {
+		text = "..." + text
+	}
+	if end < len(fields) {
+		text += "..."
+	}
+	return text
+}
+
+// TermsQuery turns free text into an FTS5 query that matches every word, with
+// each word quoted so punctuation such as "self-supervised" is not read as
+// query syntax. With prefix set, each word also matches longer words it
+// starts, so "J Smi" finds "John Smith". It returns "" if the text has no
+// letters or digits.
+func TermsQuery(text string, prefix bool) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
+
+// utcNullTime converts a valid time to UTC, since timestamps are stored as
+// text and only compare correctly in the same zone
+func utcNullTime(t sql.NullTime) sql.NullTime {
+	if t.Valid {
+		t.Time = t.Time.UTC()
+	}
+	return t
+}
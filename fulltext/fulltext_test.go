@@ -0,0 +1,157 @@
+package fulltext
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// titles returns the titles of the articles matching query, best match first
+func titles(t *testing.T, index *Index, query string) []string {
+	t.Helper()
+	matches, err := index.Articles(context.Background(), ArticleQuery{Query: query, Limit: 10})
+	if err != nil {
+		t.Fatalf("Articles(%q): %v", query, err)
+	}
+	var out []string
+	for _, match := range matches {
+		out = append(out, match.ArticleTitle)
+	}
+	return out
+}
+
+// requireFTS5 skips a test of the index itself when SQLite was built without
+// FTS5, i.e. without the sqlite_fts5 build tag
+func requireFTS5(t *testing.T, db *sql.DB) {
+	t.Helper()
+	var available bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available); err != nil {
+		t.Fatal(err)
+	}
+	if !available {
+		t.Skip("SQLite lacks FTS5; run with -tags sqlite_fts5")
+	}
+}
+
+// The index follows inserts, edits and deletes, ranks title matches over
+// summary matches and ignores accents
+func TestArticlesFollowTable(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	requireFTS5(t, db)
+	index := NewIndex(db)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+
+	inSummary := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/1", "Scaling laws", "We study protein folding at scale.", "Zoë Müller")
+	dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2", "Protein structure prediction", "A new model.", "Ann Lee")
+
+	if got, want := fmt.Sprint(titles(t, index, "protein")), "[Protein structure prediction Scaling laws]"; got != want {
+		t.Errorf("protein matched %s, want %s", got, want)
+	}
+	if got := titles(t, index, "muller"); len(got) != 1 {
+		t.Errorf("muller matched %v, want the article by Zoë Müller", got)
+	}
+
+	_, err := queries.UpdateArticleDetails(ctx, database.UpdateArticleDetailsParams{
+		ArticleTitle:   inSummary.ArticleTitle,
+		ArticleSummary: "We study graph networks.",
+		ArticleAuthors: inSummary.ArticleAuthors,
+		ID:             inSummary.ID,
+	})
+	if err != nil {
+		t.Fatalf("UpdateArticleDetails: %v", err)
+	}
+	if got, want := fmt.Sprint(titles(t, index, "protein")), "[Protein structure prediction]"; got != want {
+		t.Errorf("after the edit protein matched %s, want %s", got, want)
+	}
+	if got := titles(t, index, "graph"); len(got) != 1 {
+		t.Errorf("after the edit graph matched %v, want the edited article", got)
+	}
+
+	if err := queries.DeleteArticle(ctx, inSummary.ID); err != nil {
+		t.Fatalf("DeleteArticle: %v", err)
+	}
+	if got := titles(t, index, "graph"); len(got) != 0 {
+		t.Errorf("after the delete graph matched %v", got)
+	}
+}
+
+// A partial name finds the candidate by word prefix
+func TestCandidatesByPartialName(t *testing.T) {
+	ctx := context.Background()
+	db, _ := dbtest.Open(t)
+	requireFTS5(t, db)
+	index := NewIndex(db)
+	if _, err := db.ExecContext(ctx, `INSERT INTO candidates (id, created_at, updated_at, name, normalized_name) VALUES ('c1', datetime('now'), datetime('now'), 'John Smith', 'john smith'), ('c2', datetime('now'), datetime('now'), 'Jane Doe', 'jane doe')`); err != nil {
+		t.Fatalf("inserting candidates: %v", err)
+	}
+
+	found, err := index.Candidates(ctx, CandidateQuery{Query: TermsQuery("J Smi", true), Limit: 10})
+	if err != nil {
+		t.Fatalf("Candidates: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "John Smith" {
+		t.Errorf("J Smi found %v, want John Smith", found)
+	}
+}
+
+// Without the index every word must appear somewhere in the article, newest
+// first, with the query syntax ignored and the matched words marked
+func TestSubstringFallback(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	index := NewIndex(db)
+	for _, statement := range []string{
+		"DROP TRIGGER IF EXISTS articles_fts_insert",
+		"DROP TRIGGER IF EXISTS articles_fts_update",
+		"DROP TRIGGER IF EXISTS articles_fts_delete",
+		"DROP TRIGGER IF EXISTS candidates_fts_insert",
+		"DROP TRIGGER IF EXISTS candidates_fts_update",
+		"DROP TRIGGER IF EXISTS candidates_fts_delete",
+		"DROP TABLE IF EXISTS articles_fts",
+		"DROP TABLE IF EXISTS candidates_fts",
+	} {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/1", "Scaling laws", "We study protein folding at 100% scale.", "Ann Lee")
+	dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2", "Protein structure prediction", "A new model.", "Ann Lee")
+
+	if got := titles(t, index, `"Protein" AND folding*`); fmt.Sprint(got) != "[Scaling laws]" {
+		t.Errorf("protein folding matched %v, want [Scaling laws]", got)
+	}
+	if got := titles(t, index, "100%"); fmt.Sprint(got) != "[Scaling laws]" {
+		t.Errorf("100%% matched %v, want [Scaling laws]", got)
+	}
+	if got := titles(t, index, "10_"); len(got) != 0 {
+		t.Errorf("10_ matched %v, want nothing since _ is not a wildcard", got)
+	}
+	if got := titles(t, index, "lee"); len(got) != 2 {
+		t.Errorf("lee matched %v, want both articles", got)
+	}
+
+	matches, err := index.Articles(ctx, ArticleQuery{Query: "folding", MarkStart: "[", MarkEnd: "]", Limit: 10})
+	if err != nil {
+		t.Fatalf("Articles: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Snippet != "We study protein [folding] at 100% scale." {
+		t.Errorf("folding matched %+v, want the snippet with folding marked", matches)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO candidates (id, created_at, updated_at, name, normalized_name) VALUES ('c1', datetime('now'), datetime('now'), 'John Smith', 'john smith')`); err != nil {
+		t.Fatalf("inserting candidate: %v", err)
+	}
+	found, err := index.Candidates(ctx, CandidateQuery{Query: TermsQuery("J Smi", true), Limit: 10})
+	if err != nil {
+		t.Fatalf("Candidates: %v", err)
+	}
+	if len(found) != 1 || found[0].Name != "John Smith" {
+		t.Errorf("J Smi found %v, want John Smith", found)
+	}
+}
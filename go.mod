@@ -5,10 +5,13 @@ go 1.23.0
 toolchain go1.23.9
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/anthropics/anthropic-sdk-go v1.2.1
 	github.com/google/uuid v1.6.0
 	github.com/invopop/jsonschema v0.13.0
+	github.com/joho/godotenv v1.5.1
 	github.com/mattn/go-sqlite3 v1.14.28
+	golang.org/x/text v0.16.0
 )
 
 require (
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Supported export formats
+const (
+	FormatDOT  = "dot"
+	FormatGEXF = "gexf"
+)
+
+// Formats lists every supported export format
+var Formats = []string{FormatDOT, FormatGEXF}
+
+// Write writes the graph in the given format
+func Write(w io.Writer, format string, g *Graph) error {
+	switch format {
+	case FormatDOT:
+		return WriteDOT(w, g)
+	case FormatGEXF:
+		return WriteGEXF(w, g)
+	}
+	return fmt.Errorf("unknown graph format %q (expected one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteDOT writes the graph for GraphViz, e.g. `neato -Tsvg`. Nodes are
+// labelled with names, and edges are labelled and thickened by the number of
+// shared papers.
+func WriteDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("graph coauthors {\n")
+	b.WriteString("  node [shape=ellipse];\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, papers=%d, degree=%d, betweenness=%s, component=%d];\n",
+			dotQuote(node.ID), dotQuote(node.Name), node.Papers, node.Degree, formatScore(node.Betweenness), node.Component)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -- %s [weight=%d, penwidth=%d, label=%d];\n",
+			dotQuote(edge.From), dotQuote(edge.To), edge.Weight(), edge.Weight(), edge.Weight())
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote quotes an ID or label for DOT
+func dotQuote(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// The GEXF 1.3 document, as read by Gephi. Only the elements written here are
+// modelled.
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator     string `xml:"creator"`
+	Description string `xml:"description"`
+}
+
+type gexfGraph struct {
+	Mode            string         `xml:"mode,attr"`
+	DefaultEdgeType string         `xml:"defaultedgetype,attr"`
+	Attributes      gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode     `xml:"nodes>node"`
+	Edges           []gexfEdge     `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Weight int    `xml:"weight,attr"`
+}
+
+// gexfNodeAttributes are the node metrics written to GEXF, in attvalue order
+var gexfNodeAttributes = []gexfAttribute{
+	{ID: "papers", Title: "papers", Type: "integer"},
+	{ID: "degree", Title: "degree", Type: "integer"},
+	{ID: "betweenness", Title: "betweenness", Type: "double"},
+	{ID: "component", Title: "component", Type: "integer"},
+}
+
+// WriteGEXF writes the graph as GEXF 1.3 for Gephi. Each node carries its
+// paper count, degree, betweenness and component as attributes, and each edge
+// is weighted by the number of shared papers.
+func WriteGEXF(w io.Writer, g *Graph) error {
+	doc := gexfDocument{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Meta: gexfMeta{
+			Creator:     "reSearch",
+			Description: "Co-authorship graph of candidates",
+		},
+		Graph: gexfGraph{
+			Mode:            "static",
+			DefaultEdgeType: "undirected",
+			Attributes:      gexfAttributes{Class: "node", Attributes: gexfNodeAttributes},
+		},
+	}
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:    node.ID,
+			Label: node.Name,
+			AttValues: []gexfAttValue{
+				{For: "papers", Value: strconv.Itoa(node.Papers)},
+				{For: "degree", Value: strconv.Itoa(node.Degree)},
+				{For: "betweenness", Value: formatScore(node.Betweenness)},
+				{For: "component", Value: strconv.Itoa(node.Component)},
+			},
+		})
+	}
+	for i, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID:     strconv.Itoa(i),
+			Source: edge.From,
+			Target: edge.To,
+			Weight: edge.Weight(),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// formatScore writes a score with enough precision to rank by and no more
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 4, 64)
+}
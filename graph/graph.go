@@ -0,0 +1,360 @@
+// Package graph analyses the co-authorship network that candidate_articles
+// implies: candidates are nodes, and two candidates are joined when they
+// wrote a paper together. It ranks candidates by degree and betweenness
+// centrality, splits the network into connected components, lists a
+// candidate's strongest collaborators and exports the graph for GraphViz or
+// Gephi.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Node is a candidate in the co-authorship graph
+type Node struct {
+	ID   string
+	Name string
+	// Papers counts the candidate's papers in the graph
+	Papers int
+	// Degree is the number of distinct co-authors
+	Degree int
+	// DegreeCentrality is Degree divided by the most co-authors anyone could have
+	DegreeCentrality float64
+	// Betweenness is the share of shortest paths between other candidates that
+	// pass through this one, from 0 to 1. High betweenness marks the people who
+	// connect otherwise separate groups.
+	Betweenness float64
+	// Component numbers the connected component, largest first from 1
+	Component int
+}
+
+// Edge joins two candidates who share at least one paper. From sorts before To.
+type Edge struct {
+	From string
+	To   string
+	// Papers are the titles of the shared papers, sorted
+	Papers []string
+}
+
+// Weight is the number of papers the two candidates share
+func (e Edge) Weight() int {
+	return len(e.Papers)
+}
+
+// Collaborator is one of a candidate's co-authors
+type Collaborator struct {
+	Node   *Node
+	Papers []string
+}
+
+// Graph is an undirected co-authorship graph with its metrics computed
+type Graph struct {
+	// Nodes are sorted by ID
+	Nodes []*Node
+	// Edges are sorted by From, then To
+	Edges []Edge
+	// Components lists the size of each connected component, largest first
+	Components []int
+
+	byID      map[string]int
+	neighbors [][]int
+	// edgeIndex finds an edge by its two node indexes, lower first
+	edgeIndex map[[2]int]int
+}
+
+// Load builds the graph from the candidate-article links of one search, or of
+// every search when searchID is nil
+func Load(ctx context.Context, queries *database.Queries, searchID interface{}) (*Graph, error) {
+	rows, err := queries.ListCoauthorshipRefs(ctx, searchID)
+	if err != nil {
+		return nil, fmt.Errorf("listing candidate articles: %w", err)
+	}
+	return build(rows), nil
+}
+
+// build creates the nodes and edges and computes every metric
+func build(rows []database.ListCoauthorshipRefsRow) *Graph {
+	g := &Graph{byID: map[string]int{}, edgeIndex: map[[2]int]int{}}
+
+	// Group the authors of each paper; a paper is identified by its URL
+	authors := map[string][]int{}
+	titles := map[string]string{}
+	var urls []string
+	for _, row := range rows {
+		id := fmt.Sprint(row.CandidateID)
+		i, ok := g.byID[id]
+		if !ok {
+			i = len(g.Nodes)
+			g.byID[id] = i
+			g.Nodes = append(g.Nodes, &Node{ID: id, Name: row.CandidateName})
+		}
+		if _, seen := titles[row.ArticleUrl]; !seen {
+			urls = append(urls, row.ArticleUrl)
+			titles[row.ArticleUrl] = row.ArticleTitle
+		}
+		if !containsIndex(authors[row.ArticleUrl], i) {
+			authors[row.ArticleUrl] = append(authors[row.ArticleUrl], i)
+			g.Nodes[i].Papers++
+		}
+	}
+
+	g.neighbors = make([][]int, len(g.Nodes))
+	sort.Strings(urls)
+	for _, url := range urls {
+		list := authors[url]
+		for x := 0; x < len(list); x++ {
+			for y := x + 1; y < len(list); y++ {
+				g.addPaper(list[x], list[y], titles[url])
+			}
+		}
+	}
+
+	g.sortNodes()
+	g.computeDegree()
+	g.computeBetweenness()
+	g.computeComponents()
+	return g
+}
+
+// addPaper records a shared paper between two nodes, creating their edge if
+// needed. Endpoints are put in ID order, whatever order the rows came in, so
+// once sortNodes has numbered the nodes by ID an edge's From always has the
+// lower index, which is how Collaborators looks edges up.
+func (g *Graph) addPaper(a, b int, title string) {
+	if g.Nodes[a].ID > g.Nodes[b].ID {
+		a, b = b, a
+	}
+	key := [2]int{min(a, b), max(a, b)}
+	i, ok := g.edgeIndex[key]
+	if !ok {
+		i = len(g.Edges)
+		g.edgeIndex[key] = i
+		g.Edges = append(g.Edges, Edge{From: g.Nodes[a].ID, To: g.Nodes[b].ID})
+		g.neighbors[a] = append(g.neighbors[a], b)
+		g.neighbors[b] = append(g.neighbors[b], a)
+	}
+	g.Edges[i].Papers = append(g.Edges[i].Papers, title)
+}
+
+// sortNodes orders nodes by ID and edges by their endpoints, renumbering the
+// indexes, so output does not depend on query order
+func (g *Graph) sortNodes() {
+	order := make([]int, len(g.Nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(x, y int) bool { return g.Nodes[order[x]].ID < g.Nodes[order[y]].ID })
+
+	renumber := make([]int, len(order))
+	nodes := make([]*Node, len(order))
+	for newIndex, oldIndex := range order {
+		renumber[oldIndex] = newIndex
+		nodes[newIndex] = g.Nodes[oldIndex]
+	}
+	neighbors := make([][]int, len(order))
+	for oldIndex, list := range g.neighbors {
+		for _, n := range list {
+			neighbors[renumber[oldIndex]] = append(neighbors[renumber[oldIndex]], renumber[n])
+		}
+	}
+	for i := range neighbors {
+		sort.Ints(neighbors[i])
+	}
+	g.Nodes, g.neighbors = nodes, neighbors
+
+	g.byID = map[string]int{}
+	for i, node := range g.Nodes {
+		g.byID[node.ID] = i
+	}
+	for i := range g.Edges {
+		sort.Strings(g.Edges[i].Papers)
+	}
+	sort.Slice(g.Edges, func(x, y int) bool {
+		if g.Edges[x].From != g.Edges[y].From {
+			return g.Edges[x].From < g.Edges[y].From
+		}
+		return g.Edges[x].To < g.Edges[y].To
+	})
+	g.edgeIndex = map[[2]int]int{}
+	for i, edge := range g.Edges {
+		g.edgeIndex[[2]int{g.byID[edge.From], g.byID[edge.To]}] = i
+	}
+}
+
+func (g *Graph) computeDegree() {
+	for i, node := range g.Nodes {
+		node.Degree = len(g.neighbors[i])
+		if len(g.Nodes) > 1 {
+			node.DegreeCentrality = float64(node.Degree) / float64(len(g.Nodes)-1)
+		}
+	}
+}
+
+// computeBetweenness runs Brandes' algorithm over the unweighted graph, which
+// takes O(nodes × edges) time
+func (g *Graph) computeBetweenness() {
+	n := len(g.Nodes)
+	scores := make([]float64, n)
+	for s := 0; s < n; s++ {
+		// Breadth-first search from s, counting shortest paths
+		var stack []int
+		predecessors := make([][]int, n)
+		paths := make([]float64, n)
+		distance := make([]int, n)
+		for i := range distance {
+			distance[i] = -1
+		}
+		paths[s], distance[s] = 1, 0
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range g.neighbors[v] {
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					paths[w] += paths[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		// Walk back from the farthest nodes, accumulating dependencies
+		dependency := make([]float64, n)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				dependency[v] += paths[v] / paths[w] * (1 + dependency[w])
+			}
+			if w != s {
+				scores[w] += dependency[w]
+			}
+		}
+	}
+
+	// Every path was counted from both ends; normalize by the number of pairs
+	// of other nodes so scores run from 0 to 1
+	if n > 2 {
+		scale := 1 / float64((n-1)*(n-2))
+		for i, node := range g.Nodes {
+			node.Betweenness = scores[i] * scale
+		}
+	}
+}
+
+// computeComponents labels connected components, numbering them from the
+// largest; ties go to the component with the lowest node ID
+func (g *Graph) computeComponents() {
+	label := make([]int, len(g.Nodes))
+	var members [][]int
+	for start := range g.Nodes {
+		if label[start] != 0 {
+			continue
+		}
+		label[start] = len(members) + 1
+		component := []int{start}
+		for queue := []int{start}; len(queue) > 0; queue = queue[1:] {
+			for _, w := range g.neighbors[queue[0]] {
+				if label[w] == 0 {
+					label[w] = label[start]
+					component = append(component, w)
+					queue = append(queue, w)
+				}
+			}
+		}
+		members = append(members, component)
+	}
+
+	// Components were found in node order, so a stable sort keeps ties by lowest ID
+	sort.SliceStable(members, func(x, y int) bool { return len(members[x]) > len(members[y]) })
+	g.Components = make([]int, len(members))
+	for number, component := range members {
+		g.Components[number] = len(component)
+		for _, i := range component {
+			g.Nodes[i].Component = number + 1
+		}
+	}
+}
+
+// Node finds a candidate's node by ID
+func (g *Graph) Node(id string) (*Node, bool) {
+	i, ok := g.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return g.Nodes[i], true
+}
+
+// Ranking orders nodes by a metric, highest first, breaking ties by name
+type Ranking string
+
+// Supported rankings
+const (
+	ByDegree      Ranking = "degree"
+	ByBetweenness Ranking = "betweenness"
+)
+
+// Central returns up to limit nodes ranked by the metric, or all of them when
+// limit is zero. Degree ties go to the higher betweenness and vice versa.
+func (g *Graph) Central(by Ranking, limit int) ([]*Node, error) {
+	primary, secondary := func(n *Node) float64 { return float64(n.Degree) }, func(n *Node) float64 { return n.Betweenness }
+	switch by {
+	case ByDegree:
+	case ByBetweenness:
+		primary, secondary = secondary, primary
+	default:
+		return nil, fmt.Errorf("unknown ranking %q (expected %s or %s)", by, ByDegree, ByBetweenness)
+	}
+
+	nodes := append([]*Node(nil), g.Nodes...)
+	sort.SliceStable(nodes, func(x, y int) bool {
+		if primary(nodes[x]) != primary(nodes[y]) {
+			return primary(nodes[x]) > primary(nodes[y])
+		}
+		if secondary(nodes[x]) != secondary(nodes[y]) {
+			return secondary(nodes[x]) > secondary(nodes[y])
+		}
+		return nodes[x].Name < nodes[y].Name
+	})
+	if limit > 0 && len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	return nodes, nil
+}
+
+// Collaborators returns a candidate's co-authors, those sharing the most
+// papers first
+func (g *Graph) Collaborators(id string) []Collaborator {
+	i, ok := g.byID[id]
+	if !ok {
+		return nil
+	}
+	var out []Collaborator
+	for _, n := range g.neighbors[i] {
+		a, b := min(i, n), max(i, n)
+		out = append(out, Collaborator{Node: g.Nodes[n], Papers: g.Edges[g.edgeIndex[[2]int{a, b}]].Papers})
+	}
+	sort.SliceStable(out, func(x, y int) bool {
+		if len(out[x].Papers) != len(out[y].Papers) {
+			return len(out[x].Papers) > len(out[y].Papers)
+		}
+		return out[x].Node.Name < out[y].Node.Name
+	})
+	return out
+}
+
+func containsIndex(list []int, i int) bool {
+	for _, item := range list {
+		if item == i {
+			return true
+		}
+	}
+	return false
+}
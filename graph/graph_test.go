@@ -0,0 +1,167 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// testRows describes a triangle a-b-c with d hanging off c, a pair e-f and g
+// on a paper of their own. a and b share two papers. The rows run in
+// descending candidate ID order, the opposite of what the query returns.
+func testRows() []database.ListCoauthorshipRefsRow {
+	papers := []struct {
+		title   string
+		authors []string
+	}{
+		{"Paper One", []string{"a", "b", "c"}},
+		{"Paper Two", []string{"c", "d"}},
+		{"Paper Three", []string{"a", "b"}},
+		{"Paper Four", []string{"e", "f"}},
+		{"Paper Five", []string{"g"}},
+	}
+	var rows []database.ListCoauthorshipRefsRow
+	for _, id := range []string{"g", "f", "e", "d", "c", "b", "a"} {
+		for _, paper := range papers {
+			for _, author := range paper.authors {
+				if author == id {
+					rows = append(rows, database.ListCoauthorshipRefsRow{
+						CandidateID:   id,
+						CandidateName: strings.ToUpper(id) + " Author",
+						ArticleTitle:  paper.title,
+						ArticleUrl:    "https://arxiv.org/abs/" + paper.title,
+					})
+				}
+			}
+		}
+	}
+	return rows
+}
+
+func TestBuild(t *testing.T) {
+	g := build(testRows())
+
+	var ids []string
+	for _, node := range g.Nodes {
+		ids = append(ids, node.ID)
+	}
+	if got := strings.Join(ids, ""); got != "abcdefg" {
+		t.Errorf("nodes in order %s, want abcdefg", got)
+	}
+	for _, edge := range g.Edges {
+		if edge.From >= edge.To {
+			t.Errorf("edge %s -- %s is not in ID order", edge.From, edge.To)
+		}
+	}
+
+	want := map[string]struct {
+		papers, degree, component int
+		betweenness               float64
+	}{
+		"a": {2, 2, 1, 0},
+		"b": {2, 2, 1, 0},
+		// c is on both shortest paths from d to the others, counted each way,
+		// out of 6 × 5 ordered pairs of other nodes
+		"c": {2, 3, 1, 4.0 / 30},
+		"d": {1, 1, 1, 0},
+		"e": {1, 1, 2, 0},
+		"f": {1, 1, 2, 0},
+		"g": {1, 0, 3, 0},
+	}
+	for _, node := range g.Nodes {
+		w := want[node.ID]
+		if node.Papers != w.papers || node.Degree != w.degree || node.Component != w.component || math.Abs(node.Betweenness-w.betweenness) > 1e-9 {
+			t.Errorf("%s: papers %d, degree %d, component %d, betweenness %v; want %d, %d, %d, %v",
+				node.ID, node.Papers, node.Degree, node.Component, node.Betweenness, w.papers, w.degree, w.component, w.betweenness)
+		}
+	}
+	if got := g.Nodes[2].DegreeCentrality; math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("c has degree centrality %v, want 3 of 6", got)
+	}
+	if got := fmt.Sprint(g.Components); got != "[4 2 1]" {
+		t.Errorf("components %s, want [4 2 1]", got)
+	}
+}
+
+// Collaborators finds every edge whichever end is asked about, strongest first
+func TestCollaborators(t *testing.T) {
+	g := build(testRows())
+
+	var got []string
+	for _, c := range g.Collaborators("a") {
+		got = append(got, fmt.Sprintf("%s %v", c.Node.ID, c.Papers))
+	}
+	if want := "b [Paper One Paper Three], c [Paper One]"; strings.Join(got, ", ") != want {
+		t.Errorf("collaborators of a: %s, want %s", strings.Join(got, ", "), want)
+	}
+	got = nil
+	for _, c := range g.Collaborators("d") {
+		got = append(got, fmt.Sprintf("%s %v", c.Node.ID, c.Papers))
+	}
+	if want := "c [Paper Two]"; strings.Join(got, ", ") != want {
+		t.Errorf("collaborators of d: %s, want %s", strings.Join(got, ", "), want)
+	}
+	if c := g.Collaborators("g"); len(c) != 0 {
+		t.Errorf("g has collaborators %v", c)
+	}
+	if c := g.Collaborators("missing"); c != nil {
+		t.Errorf("an unknown candidate has collaborators %v", c)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := build([]database.ListCoauthorshipRefsRow{
+		{CandidateID: "b", CandidateName: `Bo "B." Chen`, ArticleTitle: "Paper", ArticleUrl: "https://arxiv.org/abs/1"},
+		{CandidateID: "a", CandidateName: `Ann\Lee`, ArticleTitle: "Paper", ArticleUrl: "https://arxiv.org/abs/1"},
+	})
+	var b bytes.Buffer
+	if err := Write(&b, FormatDOT, g); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	want := `graph coauthors {
+  node [shape=ellipse];
+  "a" [label="Ann\\Lee", papers=1, degree=1, betweenness=0.0000, component=1];
+  "b" [label="Bo \"B.\" Chen", papers=1, degree=1, betweenness=0.0000, component=1];
+  "a" -- "b" [weight=1, penwidth=1, label=1];
+}
+`
+	if b.String() != want {
+		t.Errorf("DOT output:\n%s\nwant:\n%s", b.String(), want)
+	}
+	if err := Write(&b, "svg", g); err == nil {
+		t.Error("writing an unknown format succeeded")
+	}
+}
+
+// The GEXF output reads back with each node's metrics and each edge's weight
+func TestWriteGEXF(t *testing.T) {
+	g := build(testRows())
+	var b bytes.Buffer
+	if err := WriteGEXF(&b, g); err != nil {
+		t.Fatalf("WriteGEXF: %v", err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header) {
+		t.Error("GEXF output has no XML declaration")
+	}
+
+	var doc gexfDocument
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatalf("parsing GEXF: %v", err)
+	}
+	if doc.Version != "1.3" || doc.Graph.DefaultEdgeType != "undirected" || len(doc.Graph.Nodes) != 7 || len(doc.Graph.Edges) != 5 {
+		t.Fatalf("GEXF document %+v", doc)
+	}
+	c := doc.Graph.Nodes[2]
+	if got := fmt.Sprint(c.AttValues); c.ID != "c" || c.Label != "C Author" || got != "[{papers 2} {degree 3} {betweenness 0.1333} {component 1}]" {
+		t.Errorf("node c: %s %q %s", c.ID, c.Label, got)
+	}
+	ab := doc.Graph.Edges[0]
+	if ab.Source != "a" || ab.Target != "b" || ab.Weight != 2 {
+		t.Errorf("first edge %+v, want a to b weighing 2", ab)
+	}
+}
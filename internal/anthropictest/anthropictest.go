@@ -0,0 +1,185 @@
+// Package anthropictest stands in for the Anthropic Messages API so code that
+// talks to Claude can be tested offline. The server streams canned replies in
+// the same server-sent event format as the real API.
+package anthropictest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// Model is the model every reply claims to come from
+const Model = "claude-test"
+
+// Reply is one canned response
+type Reply struct {
+	// Text is the whole of the assistant's reply
+	Text string
+	// Endless streams Text over and over, never finishing the reply, until
+	// the client gives up on the request
+	Endless bool
+	// Started, if set, is closed once an endless reply has been started
+	Started chan struct{}
+	// Blocks are content blocks, as JSON objects, streamed before the text
+	// the way the API streams them: a block's input arrives as a JSON delta.
+	// A reply with blocks and no text has no text block at all.
+	Blocks []string
+	// StopReason is the reply's stop reason, "end_turn" if empty
+	StopReason string
+}
+
+// Server answers Messages API requests with its replies in order, repeating
+// the last one once they run out
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	replies  []Reply
+	requests []json.RawMessage
+}
+
+// NewServer starts a server that is shut down when the test ends
+func NewServer(t testing.TB, replies ...Reply) *Server {
+	t.Helper()
+	s := &Server{replies: replies}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns an Anthropic client that talks to the server and never retries
+func (s *Server) Client() anthropic.Client {
+	return anthropic.NewClient(
+		option.WithBaseURL(s.URL),
+		option.WithAPIKey("test"),
+		option.WithMaxRetries(0),
+	)
+}
+
+// Requests returns the body of every request received so far
+func (s *Server) Requests() []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]json.RawMessage(nil), s.requests...)
+}
+
+// next records a request and picks its reply
+func (s *Server) next(body []byte) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, body)
+	if len(s.replies) == 0 {
+		return Reply{}
+	}
+	reply := s.replies[0]
+	if len(s.replies) > 1 {
+		s.replies = s.replies[1:]
+	}
+	return reply
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/messages" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reply := s.next(body)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	send := func(event string, data any) {
+		encoded, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	send("message_start", map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id": "msg_test", "type": "message", "role": "assistant", "model": Model,
+			"content": []any{},
+			"usage":   map[string]any{"input_tokens": 10, "output_tokens": 1},
+		},
+	})
+	for i, raw := range reply.Blocks {
+		var block map[string]any
+		if err := json.Unmarshal([]byte(raw), &block); err != nil {
+			panic(fmt.Sprintf("anthropictest: block %d is not a JSON object: %v", i, err))
+		}
+		input, hasInput := block["input"]
+		if hasInput {
+			block["input"] = map[string]any{}
+		}
+		send("content_block_start", map[string]any{"type": "content_block_start", "index": i, "content_block": block})
+		if hasInput {
+			partial, _ := json.Marshal(input)
+			send("content_block_delta", map[string]any{
+				"type": "content_block_delta", "index": i,
+				"delta": map[string]any{"type": "input_json_delta", "partial_json": string(partial)},
+			})
+		}
+		send("content_block_stop", map[string]any{"type": "content_block_stop", "index": i})
+	}
+
+	index := len(reply.Blocks)
+	if reply.Text == "" && index > 0 && !reply.Endless {
+		send("message_delta", map[string]any{
+			"type":  "message_delta",
+			"delta": map[string]any{"stop_reason": stopReasonOf(reply)},
+			"usage": map[string]any{"output_tokens": 20},
+		})
+		send("message_stop", map[string]any{"type": "message_stop"})
+		return
+	}
+	send("content_block_start", map[string]any{
+		"type": "content_block_start", "index": index,
+		"content_block": map[string]any{"type": "text", "text": ""},
+	})
+	if reply.Endless {
+		if reply.Started != nil {
+			close(reply.Started)
+		}
+		for r.Context().Err() == nil {
+			send("content_block_delta", map[string]any{
+				"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "text_delta", "text": reply.Text},
+			})
+		}
+		return
+	}
+	send("content_block_delta", map[string]any{
+		"type": "content_block_delta", "index": index,
+		"delta": map[string]any{"type": "text_delta", "text": reply.Text},
+	})
+	send("content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+	send("message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": stopReasonOf(reply)},
+		"usage": map[string]any{"output_tokens": 20},
+	})
+	send("message_stop", map[string]any{"type": "message_stop"})
+}
+
+// stopReasonOf returns the reply's stop reason, defaulting to the end of a turn
+func stopReasonOf(reply Reply) string {
+	if reply.StopReason == "" {
+		return "end_turn"
+	}
+	return reply.StopReason
+}
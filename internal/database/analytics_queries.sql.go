@@ -381,7 +381,7 @@ func (q *Queries) GetSearchStatistics(ctx context.Context, id interface{}) (GetS
 
 const getTopSearches = `-- name: GetTopSearches :many
 SELECT 
-  s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date,
+  s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date, s.backfill_cursor, s.monthly_budget, s.fetch_failures, s.next_fetch_after,
   COUNT(DISTINCT cs.candidate_id) AS candidate_count,
   AVG(cs.relevance_score) AS avg_relevance
 FROM searches s
@@ -399,6 +399,10 @@ type GetTopSearchesRow struct {
 	ArvixUrl        string
 	ResultsPerFetch sql.NullInt64
 	LastFetchDate   sql.NullTime
+	BackfillCursor  sql.NullTime
+	MonthlyBudget   sql.NullFloat64
+	FetchFailures   int64
+	NextFetchAfter  sql.NullTime
 	CandidateCount  int64
 	AvgRelevance    sql.NullFloat64
 }
@@ -420,6 +424,10 @@ func (q *Queries) GetTopSearches(ctx context.Context, limit int64) ([]GetTopSear
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 			&i.CandidateCount,
 			&i.AvgRelevance,
 		); err != nil {
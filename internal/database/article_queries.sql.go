@@ -11,15 +11,22 @@ import (
 	"time"
 )
 
-const checkArticleExists = `-- name: CheckArticleExists :one
+const checkSearchArticleExists = `-- name: CheckSearchArticleExists :one
 SELECT EXISTS (
   SELECT 1 FROM articles
-  WHERE article_url = ?
+  WHERE article_url = ? AND search_id = ?
 ) AS article_exists
 `
 
-func (q *Queries) CheckArticleExists(ctx context.Context, articleUrl string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, checkArticleExists, articleUrl)
+type CheckSearchArticleExistsParams struct {
+	ArticleUrl string
+	SearchID   interface{}
+}
+
+// Each search keeps its own copy of an article, so a paper already stored for
+// another search is still new to this one
+func (q *Queries) CheckSearchArticleExists(ctx context.Context, arg CheckSearchArticleExistsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, checkSearchArticleExists, arg.ArticleUrl, arg.SearchID)
 	var article_exists int64
 	err := row.Scan(&article_exists)
 	return article_exists, err
@@ -120,9 +127,11 @@ func (q *Queries) GetArticleByID(ctx context.Context, id interface{}) (Article,
 const getArticleByURL = `-- name: GetArticleByURL :one
 SELECT id, fetched_at, article_url, article_title, article_summary, article_authors, search_id FROM articles
 WHERE article_url = ?
+ORDER BY fetched_at DESC
 LIMIT 1
 `
 
+// A paper found by several searches has a row in each; the latest is returned
 func (q *Queries) GetArticleByURL(ctx context.Context, articleUrl string) (Article, error) {
 	row := q.db.QueryRowContext(ctx, getArticleByURL, articleUrl)
 	var i Article
@@ -279,22 +288,16 @@ func (q *Queries) ListArticlesByAuthor(ctx context.Context, arg ListArticlesByAu
 	return items, nil
 }
 
-const listArticlesBySearch = `-- name: ListArticlesBySearch :many
+const listArticlesByIDPrefix = `-- name: ListArticlesByIDPrefix :many
 SELECT id, fetched_at, article_url, article_title, article_summary, article_authors, search_id FROM articles
-WHERE search_id = ?
+WHERE id LIKE ?1 || '%'
 ORDER BY fetched_at DESC
-LIMIT ?
-OFFSET ?
+LIMIT 2
 `
 
-type ListArticlesBySearchParams struct {
-	SearchID interface{}
-	Limit    int64
-	Offset   int64
-}
-
-func (q *Queries) ListArticlesBySearch(ctx context.Context, arg ListArticlesBySearchParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, listArticlesBySearch, arg.SearchID, arg.Limit, arg.Offset)
+// Two rows are enough to tell whether the prefix is ambiguous
+func (q *Queries) ListArticlesByIDPrefix(ctx context.Context, prefix sql.NullString) ([]Article, error) {
+	rows, err := q.db.QueryContext(ctx, listArticlesByIDPrefix, prefix)
 	if err != nil {
 		return nil, err
 	}
@@ -324,14 +327,22 @@ func (q *Queries) ListArticlesBySearch(ctx context.Context, arg ListArticlesBySe
 	return items, nil
 }
 
-const listRecentArticles = `-- name: ListRecentArticles :many
+const listArticlesBySearch = `-- name: ListArticlesBySearch :many
 SELECT id, fetched_at, article_url, article_title, article_summary, article_authors, search_id FROM articles
+WHERE search_id = ?
 ORDER BY fetched_at DESC
 LIMIT ?
+OFFSET ?
 `
 
-func (q *Queries) ListRecentArticles(ctx context.Context, limit int64) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, listRecentArticles, limit)
+type ListArticlesBySearchParams struct {
+	SearchID interface{}
+	Limit    int64
+	Offset   int64
+}
+
+func (q *Queries) ListArticlesBySearch(ctx context.Context, arg ListArticlesBySearchParams) ([]Article, error) {
+	rows, err := q.db.QueryContext(ctx, listArticlesBySearch, arg.SearchID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -361,31 +372,14 @@ func (q *Queries) ListRecentArticles(ctx context.Context, limit int64) ([]Articl
 	return items, nil
 }
 
-const searchArticlesByKeyword = `-- name: SearchArticlesByKeyword :many
-SELECT id, fetched_at, article_url, article_title, article_summary, article_authors, search_id
-FROM articles
-WHERE 
-  LOWER(article_title) LIKE LOWER('%' || ? || '%') OR
-  LOWER(article_summary) LIKE LOWER('%' || ? || '%')
+const listRecentArticles = `-- name: ListRecentArticles :many
+SELECT id, fetched_at, article_url, article_title, article_summary, article_authors, search_id FROM articles
 ORDER BY fetched_at DESC
 LIMIT ?
-OFFSET ?
 `
 
-type SearchArticlesByKeywordParams struct {
-	Column1 sql.NullString
-	Column2 sql.NullString
-	Limit   int64
-	Offset  int64
-}
-
-func (q *Queries) SearchArticlesByKeyword(ctx context.Context, arg SearchArticlesByKeywordParams) ([]Article, error) {
-	rows, err := q.db.QueryContext(ctx, searchArticlesByKeyword,
-		arg.Column1,
-		arg.Column2,
-		arg.Limit,
-		arg.Offset,
-	)
+func (q *Queries) ListRecentArticles(ctx context.Context, limit int64) ([]Article, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentArticles, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -30,6 +30,27 @@ func (q *Queries) CheckArticleCandidateLinkExists(ctx context.Context, arg Check
 	return link_exists, err
 }
 
+const checkCandidateAuthoredPaper = `-- name: CheckCandidateAuthoredPaper :one
+SELECT EXISTS (
+  SELECT 1 FROM candidate_articles ca
+  JOIN articles a ON a.id = ca.article_id
+  WHERE ca.candidate_id = ? AND a.article_url = ?
+) AS authored
+`
+
+type CheckCandidateAuthoredPaperParams struct {
+	CandidateID interface{}
+	ArticleUrl  string
+}
+
+// Whether the candidate wrote the paper at the URL in any search
+func (q *Queries) CheckCandidateAuthoredPaper(ctx context.Context, arg CheckCandidateAuthoredPaperParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, checkCandidateAuthoredPaper, arg.CandidateID, arg.ArticleUrl)
+	var authored int64
+	err := row.Scan(&authored)
+	return authored, err
+}
+
 const getArticlesByCandidate = `-- name: GetArticlesByCandidate :many
 SELECT 
   a.id, a.fetched_at, a.article_url, a.article_title, a.article_summary, a.article_authors, a.search_id,
@@ -142,7 +163,7 @@ func (q *Queries) GetCandidateDiscoveryArticles(ctx context.Context, candidateID
 
 const getCandidatesByArticle = `-- name: GetCandidatesByArticle :many
 SELECT 
-  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url,
+  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url, c.normalized_name,
   ca.created_at as discovery_date
 FROM candidates c
 JOIN candidate_articles ca ON c.id = ca.candidate_id
@@ -151,13 +172,14 @@ ORDER BY ca.created_at DESC
 `
 
 type GetCandidatesByArticleRow struct {
-	ID            interface{}
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	LinkedinUrl   sql.NullString
-	GithubUrl     sql.NullString
-	DiscoveryDate time.Time
+	ID             interface{}
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	LinkedinUrl    sql.NullString
+	GithubUrl      sql.NullString
+	NormalizedName string
+	DiscoveryDate  time.Time
 }
 
 func (q *Queries) GetCandidatesByArticle(ctx context.Context, articleID interface{}) ([]GetCandidatesByArticleRow, error) {
@@ -176,6 +198,7 @@ func (q *Queries) GetCandidatesByArticle(ctx context.Context, articleID interfac
 			&i.Name,
 			&i.LinkedinUrl,
 			&i.GithubUrl,
+			&i.NormalizedName,
 			&i.DiscoveryDate,
 		); err != nil {
 			return nil, err
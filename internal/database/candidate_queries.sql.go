@@ -21,6 +21,7 @@ INSERT INTO candidate_categories (
 ) VALUES (
   ?, ?, ?, ?, ?
 )
+ON CONFLICT(candidate_id, arxiv_category) DO UPDATE SET updated_at = excluded.updated_at
 RETURNING id, created_at, updated_at, candidate_id, arxiv_category
 `
 
@@ -71,6 +72,18 @@ func (q *Queries) CheckCandidateExists(ctx context.Context, arg CheckCandidateEx
 	return candidate_exists, err
 }
 
+const countCandidatesBySearch = `-- name: CountCandidatesBySearch :one
+SELECT COUNT(*) FROM candidate_searches
+WHERE search_id = ?
+`
+
+func (q *Queries) CountCandidatesBySearch(ctx context.Context, searchID interface{}) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCandidatesBySearch, searchID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createCandidate = `-- name: CreateCandidate :one
 INSERT INTO candidates (
   id,
@@ -78,20 +91,22 @@ INSERT INTO candidates (
   updated_at,
   name,
   linkedin_url,
-  github_url
+  github_url,
+  normalized_name
 ) VALUES (
-  ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?
 )
-RETURNING id, created_at, updated_at, name, linkedin_url, github_url
+RETURNING id, created_at, updated_at, name, linkedin_url, github_url, normalized_name
 `
 
 type CreateCandidateParams struct {
-	ID          interface{}
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Name        string
-	LinkedinUrl sql.NullString
-	GithubUrl   sql.NullString
+	ID             interface{}
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	LinkedinUrl    sql.NullString
+	GithubUrl      sql.NullString
+	NormalizedName string
 }
 
 func (q *Queries) CreateCandidate(ctx context.Context, arg CreateCandidateParams) (Candidate, error) {
@@ -102,6 +117,7 @@ func (q *Queries) CreateCandidate(ctx context.Context, arg CreateCandidateParams
 		arg.Name,
 		arg.LinkedinUrl,
 		arg.GithubUrl,
+		arg.NormalizedName,
 	)
 	var i Candidate
 	err := row.Scan(
@@ -111,6 +127,7 @@ func (q *Queries) CreateCandidate(ctx context.Context, arg CreateCandidateParams
 		&i.Name,
 		&i.LinkedinUrl,
 		&i.GithubUrl,
+		&i.NormalizedName,
 	)
 	return i, err
 }
@@ -130,53 +147,79 @@ func (q *Queries) DeleteCandidateCategory(ctx context.Context, arg DeleteCandida
 	return err
 }
 
-const findCandidateByName = `-- name: FindCandidateByName :many
-SELECT id, created_at, updated_at, name, linkedin_url, github_url FROM candidates
-WHERE LOWER(name) LIKE LOWER('%' || ? || '%')
-ORDER BY created_at DESC
-LIMIT ?
-OFFSET ?
+const getCandidateByID = `-- name: GetCandidateByID :one
+SELECT id, created_at, updated_at, name, linkedin_url, github_url, normalized_name FROM candidates
+WHERE id = ?
+LIMIT 1
 `
 
-type FindCandidateByNameParams struct {
-	Column1 sql.NullString
-	Limit   int64
-	Offset  int64
+func (q *Queries) GetCandidateByID(ctx context.Context, id interface{}) (Candidate, error) {
+	row := q.db.QueryRowContext(ctx, getCandidateByID, id)
+	var i Candidate
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.LinkedinUrl,
+		&i.GithubUrl,
+		&i.NormalizedName,
+	)
+	return i, err
 }
 
-func (q *Queries) FindCandidateByName(ctx context.Context, arg FindCandidateByNameParams) ([]Candidate, error) {
-	rows, err := q.db.QueryContext(ctx, findCandidateByName, arg.Column1, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Candidate
-	for rows.Next() {
-		var i Candidate
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Name,
-			&i.LinkedinUrl,
-			&i.GithubUrl,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+const getCandidateByNormalizedName = `-- name: GetCandidateByNormalizedName :one
+SELECT id, created_at, updated_at, name, linkedin_url, github_url, normalized_name FROM candidates
+WHERE normalized_name = ?
+ORDER BY created_at ASC
+LIMIT 1
+`
+
+func (q *Queries) GetCandidateByNormalizedName(ctx context.Context, normalizedName string) (Candidate, error) {
+	row := q.db.QueryRowContext(ctx, getCandidateByNormalizedName, normalizedName)
+	var i Candidate
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.LinkedinUrl,
+		&i.GithubUrl,
+		&i.NormalizedName,
+	)
+	return i, err
+}
+
+const getCandidateSearchLink = `-- name: GetCandidateSearchLink :one
+SELECT id, created_at, updated_at, candidate_id, search_id, relevance_score, notes, status FROM candidate_searches
+WHERE candidate_id = ? AND search_id = ?
+LIMIT 1
+`
+
+type GetCandidateSearchLinkParams struct {
+	CandidateID interface{}
+	SearchID    interface{}
+}
+
+func (q *Queries) GetCandidateSearchLink(ctx context.Context, arg GetCandidateSearchLinkParams) (CandidateSearch, error) {
+	row := q.db.QueryRowContext(ctx, getCandidateSearchLink, arg.CandidateID, arg.SearchID)
+	var i CandidateSearch
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CandidateID,
+		&i.SearchID,
+		&i.RelevanceScore,
+		&i.Notes,
+		&i.Status,
+	)
+	return i, err
 }
 
 const getCandidateWithCategories = `-- name: GetCandidateWithCategories :many
 SELECT 
-  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url,
+  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url, c.normalized_name,
   cc.arxiv_category
 FROM candidates c
 LEFT JOIN candidate_categories cc ON c.id = cc.candidate_id
@@ -184,13 +227,14 @@ WHERE c.id = ?
 `
 
 type GetCandidateWithCategoriesRow struct {
-	ID            interface{}
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	LinkedinUrl   sql.NullString
-	GithubUrl     sql.NullString
-	ArxivCategory sql.NullString
+	ID             interface{}
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	LinkedinUrl    sql.NullString
+	GithubUrl      sql.NullString
+	NormalizedName string
+	ArxivCategory  sql.NullString
 }
 
 func (q *Queries) GetCandidateWithCategories(ctx context.Context, id interface{}) ([]GetCandidateWithCategoriesRow, error) {
@@ -209,6 +253,7 @@ func (q *Queries) GetCandidateWithCategories(ctx context.Context, id interface{}
 			&i.Name,
 			&i.LinkedinUrl,
 			&i.GithubUrl,
+			&i.NormalizedName,
 			&i.ArxivCategory,
 		); err != nil {
 			return nil, err
@@ -226,7 +271,7 @@ func (q *Queries) GetCandidateWithCategories(ctx context.Context, id interface{}
 
 const getCandidatesByCategory = `-- name: GetCandidatesByCategory :many
 SELECT 
-  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url
+  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url, c.normalized_name
 FROM candidates c
 JOIN candidate_categories cc ON c.id = cc.candidate_id
 WHERE cc.arxiv_category = ?
@@ -257,6 +302,7 @@ func (q *Queries) GetCandidatesByCategory(ctx context.Context, arg GetCandidates
 			&i.Name,
 			&i.LinkedinUrl,
 			&i.GithubUrl,
+			&i.NormalizedName,
 		); err != nil {
 			return nil, err
 		}
@@ -271,6 +317,19 @@ func (q *Queries) GetCandidatesByCategory(ctx context.Context, arg GetCandidates
 	return items, nil
 }
 
+const isCandidateOrphaned = `-- name: IsCandidateOrphaned :one
+SELECT
+  NOT EXISTS (SELECT 1 FROM candidate_articles ca WHERE ca.candidate_id = ?1)
+  AND NOT EXISTS (SELECT 1 FROM candidate_searches cs WHERE cs.candidate_id = ?1) AS orphaned
+`
+
+func (q *Queries) IsCandidateOrphaned(ctx context.Context, candidateID interface{}) (int64, error) {
+	row := q.db.QueryRowContext(ctx, isCandidateOrphaned, candidateID)
+	var orphaned int64
+	err := row.Scan(&orphaned)
+	return orphaned, err
+}
+
 const linkCandidateToSearch = `-- name: LinkCandidateToSearch :one
 INSERT INTO candidate_searches (
   id,
@@ -283,7 +342,7 @@ INSERT INTO candidate_searches (
 ) VALUES (
   ?, ?, ?, ?, ?, ?, ?
 )
-RETURNING id, created_at, updated_at, candidate_id, search_id, relevance_score, notes
+RETURNING id, created_at, updated_at, candidate_id, search_id, relevance_score, notes, status
 `
 
 type LinkCandidateToSearchParams struct {
@@ -315,15 +374,193 @@ func (q *Queries) LinkCandidateToSearch(ctx context.Context, arg LinkCandidateTo
 		&i.SearchID,
 		&i.RelevanceScore,
 		&i.Notes,
+		&i.Status,
 	)
 	return i, err
 }
 
+const listCandidateCoauthorNames = `-- name: ListCandidateCoauthorNames :many
+SELECT DISTINCT c.normalized_name FROM candidate_articles mine
+JOIN candidate_articles theirs ON theirs.article_id = mine.article_id AND theirs.candidate_id != mine.candidate_id
+JOIN candidates c ON c.id = theirs.candidate_id
+WHERE mine.candidate_id = ?
+`
+
+// The normalized names of everyone the candidate has written an article with
+func (q *Queries) ListCandidateCoauthorNames(ctx context.Context, candidateID interface{}) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listCandidateCoauthorNames, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var normalized_name string
+		if err := rows.Scan(&normalized_name); err != nil {
+			return nil, err
+		}
+		items = append(items, normalized_name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCandidateIDsBySearch = `-- name: ListCandidateIDsBySearch :many
+SELECT candidate_id FROM candidate_searches
+WHERE candidate_searches.search_id = ?1
+UNION
+SELECT ca.candidate_id FROM candidate_articles ca
+JOIN articles a ON a.id = ca.article_id
+WHERE a.search_id = ?1
+`
+
+// Every candidate linked to the search, directly or through one of its articles
+func (q *Queries) ListCandidateIDsBySearch(ctx context.Context, searchID interface{}) ([]interface{}, error) {
+	rows, err := q.db.QueryContext(ctx, listCandidateIDsBySearch, searchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []interface{}
+	for rows.Next() {
+		var candidate_id interface{}
+		if err := rows.Scan(&candidate_id); err != nil {
+			return nil, err
+		}
+		items = append(items, candidate_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCandidatesByGithubURL = `-- name: ListCandidatesByGithubURL :many
+SELECT id, created_at, updated_at, name, linkedin_url, github_url, normalized_name FROM candidates
+WHERE LOWER(RTRIM(github_url, '/')) = LOWER(RTRIM(?1, '/'))
+ORDER BY created_at ASC
+`
+
+// URLs are compared ignoring case and trailing slashes
+func (q *Queries) ListCandidatesByGithubURL(ctx context.Context, githubUrl string) ([]Candidate, error) {
+	rows, err := q.db.QueryContext(ctx, listCandidatesByGithubURL, githubUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Candidate
+	for rows.Next() {
+		var i Candidate
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.LinkedinUrl,
+			&i.GithubUrl,
+			&i.NormalizedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCandidatesByLinkedinURL = `-- name: ListCandidatesByLinkedinURL :many
+SELECT id, created_at, updated_at, name, linkedin_url, github_url, normalized_name FROM candidates
+WHERE LOWER(RTRIM(linkedin_url, '/')) = LOWER(RTRIM(?1, '/'))
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListCandidatesByLinkedinURL(ctx context.Context, linkedinUrl string) ([]Candidate, error) {
+	rows, err := q.db.QueryContext(ctx, listCandidatesByLinkedinURL, linkedinUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Candidate
+	for rows.Next() {
+		var i Candidate
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.LinkedinUrl,
+			&i.GithubUrl,
+			&i.NormalizedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCandidatesByNormalizedName = `-- name: ListCandidatesByNormalizedName :many
+SELECT id, created_at, updated_at, name, linkedin_url, github_url, normalized_name FROM candidates
+WHERE normalized_name = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListCandidatesByNormalizedName(ctx context.Context, normalizedName string) ([]Candidate, error) {
+	rows, err := q.db.QueryContext(ctx, listCandidatesByNormalizedName, normalizedName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Candidate
+	for rows.Next() {
+		var i Candidate
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.LinkedinUrl,
+			&i.GithubUrl,
+			&i.NormalizedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCandidatesBySearch = `-- name: ListCandidatesBySearch :many
 SELECT 
-  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url,
+  c.id, c.created_at, c.updated_at, c.name, c.linkedin_url, c.github_url, c.normalized_name,
   cs.relevance_score,
-  cs.notes
+  cs.notes,
+  cs.status
 FROM candidates c
 JOIN candidate_searches cs ON c.id = cs.candidate_id
 WHERE cs.search_id = ?
@@ -345,8 +582,10 @@ type ListCandidatesBySearchRow struct {
 	Name           string
 	LinkedinUrl    sql.NullString
 	GithubUrl      sql.NullString
+	NormalizedName string
 	RelevanceScore sql.NullFloat64
 	Notes          sql.NullString
+	Status         string
 }
 
 func (q *Queries) ListCandidatesBySearch(ctx context.Context, arg ListCandidatesBySearchParams) ([]ListCandidatesBySearchRow, error) {
@@ -365,8 +604,10 @@ func (q *Queries) ListCandidatesBySearch(ctx context.Context, arg ListCandidates
 			&i.Name,
 			&i.LinkedinUrl,
 			&i.GithubUrl,
+			&i.NormalizedName,
 			&i.RelevanceScore,
 			&i.Notes,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -381,6 +622,43 @@ func (q *Queries) ListCandidatesBySearch(ctx context.Context, arg ListCandidates
 	return items, nil
 }
 
+const setCandidateSearchStatus = `-- name: SetCandidateSearchStatus :one
+UPDATE candidate_searches
+SET
+  updated_at = ?,
+  status = ?
+WHERE candidate_id = ? AND search_id = ?
+RETURNING id, created_at, updated_at, candidate_id, search_id, relevance_score, notes, status
+`
+
+type SetCandidateSearchStatusParams struct {
+	UpdatedAt   time.Time
+	Status      string
+	CandidateID interface{}
+	SearchID    interface{}
+}
+
+func (q *Queries) SetCandidateSearchStatus(ctx context.Context, arg SetCandidateSearchStatusParams) (CandidateSearch, error) {
+	row := q.db.QueryRowContext(ctx, setCandidateSearchStatus,
+		arg.UpdatedAt,
+		arg.Status,
+		arg.CandidateID,
+		arg.SearchID,
+	)
+	var i CandidateSearch
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CandidateID,
+		&i.SearchID,
+		&i.RelevanceScore,
+		&i.Notes,
+		&i.Status,
+	)
+	return i, err
+}
+
 const updateCandidate = `-- name: UpdateCandidate :one
 UPDATE candidates
 SET 
@@ -389,7 +667,7 @@ SET
   linkedin_url = ?,
   github_url = ?
 WHERE id = ?
-RETURNING id, created_at, updated_at, name, linkedin_url, github_url
+RETURNING id, created_at, updated_at, name, linkedin_url, github_url, normalized_name
 `
 
 type UpdateCandidateParams struct {
@@ -416,6 +694,44 @@ func (q *Queries) UpdateCandidate(ctx context.Context, arg UpdateCandidateParams
 		&i.Name,
 		&i.LinkedinUrl,
 		&i.GithubUrl,
+		&i.NormalizedName,
+	)
+	return i, err
+}
+
+const updateCandidateNotes = `-- name: UpdateCandidateNotes :one
+UPDATE candidate_searches
+SET
+  updated_at = ?,
+  notes = ?
+WHERE candidate_id = ? AND search_id = ?
+RETURNING id, created_at, updated_at, candidate_id, search_id, relevance_score, notes, status
+`
+
+type UpdateCandidateNotesParams struct {
+	UpdatedAt   time.Time
+	Notes       sql.NullString
+	CandidateID interface{}
+	SearchID    interface{}
+}
+
+func (q *Queries) UpdateCandidateNotes(ctx context.Context, arg UpdateCandidateNotesParams) (CandidateSearch, error) {
+	row := q.db.QueryRowContext(ctx, updateCandidateNotes,
+		arg.UpdatedAt,
+		arg.Notes,
+		arg.CandidateID,
+		arg.SearchID,
+	)
+	var i CandidateSearch
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CandidateID,
+		&i.SearchID,
+		&i.RelevanceScore,
+		&i.Notes,
+		&i.Status,
 	)
 	return i, err
 }
@@ -427,7 +743,7 @@ SET
   relevance_score = ?,
   notes = ?
 WHERE candidate_id = ? AND search_id = ?
-RETURNING id, created_at, updated_at, candidate_id, search_id, relevance_score, notes
+RETURNING id, created_at, updated_at, candidate_id, search_id, relevance_score, notes, status
 `
 
 type UpdateCandidateRelevanceParams struct {
@@ -455,6 +771,7 @@ func (q *Queries) UpdateCandidateRelevance(ctx context.Context, arg UpdateCandid
 		&i.SearchID,
 		&i.RelevanceScore,
 		&i.Notes,
+		&i.Status,
 	)
 	return i, err
 }
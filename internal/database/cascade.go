@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DeleteSearchResult reports how many rows a cascading search delete removed
+type DeleteSearchResult struct {
+	FetchAdjustments    int64
+	StatusChanges       int64
+	ArticleRelevance    int64
+	ArticleCategories   int64
+	ArticleTerms        int64
+	CandidateArticles   int64
+	CandidateSearches   int64
+	Articles            int64
+	AgentMessages       int64
+	AgentSessions       int64
+	LLMCalls            int64
+	CandidateCategories int64
+	CandidateEnrichment int64
+	CandidateLinks      int64
+	Candidates          int64
+}
+
+// DeleteSearchCascade deletes a search together with every row that depends on
+// it, children first so foreign keys are never violated. Everything runs in a
+// single transaction: either the whole search goes or nothing does. LLM calls
+// made for the search are kept for the costs report, detached from it. When
+// removeOrphans is set, candidates that were linked to this search and are
+// left with no article or search links (and their categories, enrichment and
+// profile links) are removed as well. Candidates that were never linked to
+// the search, such as imported ones not yet matched to any, are kept.
+func DeleteSearchCascade(ctx context.Context, db *sql.DB, searchID interface{}, removeOrphans bool) (DeleteSearchResult, error) {
+	var result DeleteSearchResult
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	q := New(db).WithTx(tx)
+
+	// The search's candidates can only be told apart before their links go
+	var linked []interface{}
+	if removeOrphans {
+		if linked, err = q.ListCandidateIDsBySearch(ctx, searchID); err != nil {
+			return result, fmt.Errorf("listing the search's candidates: %w", err)
+		}
+	}
+
+	if result.FetchAdjustments, err = q.DeleteSearchFetchAdjustmentsBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing fetch size history: %w", err)
+	}
+	if result.StatusChanges, err = q.DeleteCandidateStatusChangesBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing candidate status history: %w", err)
+	}
+	if result.ArticleRelevance, err = q.DeleteArticleRelevanceBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing article relevance: %w", err)
+	}
+	if result.ArticleCategories, err = q.DeleteArticleCategoriesBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing article categories: %w", err)
+	}
+	if result.ArticleTerms, err = q.DeleteArticleTermsBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing similarity index terms: %w", err)
+	}
+	if result.LLMCalls, err = q.DetachLLMCallsBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("detaching LLM calls: %w", err)
+	}
+	if result.CandidateArticles, err = q.DeleteCandidateArticlesBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing candidate article links: %w", err)
+	}
+	if result.CandidateSearches, err = q.DeleteCandidateSearchesBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing candidate associations: %w", err)
+	}
+	if result.Articles, err = q.DeleteArticlesBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing articles: %w", err)
+	}
+	if result.AgentMessages, err = q.DeleteAgentMessagesBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing conversation messages: %w", err)
+	}
+	if result.AgentSessions, err = q.DeleteAgentSessionsBySearchID(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing conversations: %w", err)
+	}
+	if err = q.DeleteSearch(ctx, searchID); err != nil {
+		return result, fmt.Errorf("removing search: %w", err)
+	}
+
+	for _, candidateID := range linked {
+		orphaned, err := q.IsCandidateOrphaned(ctx, candidateID)
+		if err != nil {
+			return result, fmt.Errorf("checking candidate links: %w", err)
+		}
+		if orphaned == 0 {
+			continue
+		}
+		var n int64
+		if n, err = q.DeleteCandidateCategories(ctx, candidateID); err != nil {
+			return result, fmt.Errorf("removing orphaned candidate categories: %w", err)
+		}
+		result.CandidateCategories += n
+		if n, err = q.DeleteCandidateEnrichments(ctx, candidateID); err != nil {
+			return result, fmt.Errorf("removing orphaned candidate enrichment: %w", err)
+		}
+		result.CandidateEnrichment += n
+		if n, err = q.DeleteCandidateProfileLinks(ctx, candidateID); err != nil {
+			return result, fmt.Errorf("removing orphaned candidate profile links: %w", err)
+		}
+		result.CandidateLinks += n
+		if n, err = q.DeleteCandidate(ctx, candidateID); err != nil {
+			return result, fmt.Errorf("removing orphaned candidates: %w", err)
+		}
+		result.Candidates += n
+	}
+
+	if err = tx.Commit(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// Removing orphans after a delete only touches candidates of the deleted
+// search: those shared with another search, and imported candidates never
+// linked to any, are kept
+func TestDeleteSearchCascadeKeepsOtherCandidates(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	deleted := dbtest.CreateSearch(t, queries, "deleted", "http://rss.arxiv.org/rss/cs.LG")
+	kept := dbtest.CreateSearch(t, queries, "kept", "http://rss.arxiv.org/rss/cs.PL")
+	article := dbtest.CreateArticle(t, queries, deleted.ID, "https://arxiv.org/abs/1", "Title", "Summary.", "Only Here, Also Elsewhere")
+
+	for _, statement := range []string{
+		`INSERT INTO candidates (id, created_at, updated_at, name, normalized_name) VALUES
+  ('only', datetime('now'), datetime('now'), 'Only Here', 'only here'),
+  ('shared', datetime('now'), datetime('now'), 'Also Elsewhere', 'also elsewhere'),
+  ('imported', datetime('now'), datetime('now'), 'Imported Person', 'imported person')`,
+		`INSERT INTO candidate_articles (id, created_at, updated_at, candidate_id, article_id) VALUES
+  ('ca1', datetime('now'), datetime('now'), 'only', ?1),
+  ('ca2', datetime('now'), datetime('now'), 'shared', ?1)`,
+		`INSERT INTO candidate_searches (id, created_at, updated_at, candidate_id, search_id) VALUES
+  ('cs1', datetime('now'), datetime('now'), 'only', ?2),
+  ('cs2', datetime('now'), datetime('now'), 'shared', ?2),
+  ('cs3', datetime('now'), datetime('now'), 'shared', ?3)`,
+		`INSERT INTO candidate_categories (id, created_at, updated_at, candidate_id, arxiv_category) VALUES
+  ('cc1', datetime('now'), datetime('now'), 'only', 'cs.LG'),
+  ('cc2', datetime('now'), datetime('now'), 'imported', 'cs.LG')`,
+	} {
+		if _, err := db.ExecContext(ctx, statement, article.ID, deleted.ID, kept.ID); err != nil {
+			t.Fatalf("inserting candidates: %v", err)
+		}
+	}
+
+	result, err := database.DeleteSearchCascade(ctx, db, deleted.ID, true)
+	if err != nil {
+		t.Fatalf("DeleteSearchCascade: %v", err)
+	}
+	if result.Candidates != 1 || result.CandidateCategories != 1 {
+		t.Errorf("removed %d candidates and %d categories, want 1 and 1", result.Candidates, result.CandidateCategories)
+	}
+	for id, want := range map[string]bool{"only": false, "shared": true, "imported": true} {
+		_, err := queries.GetCandidateByID(ctx, id)
+		if exists := err == nil; exists != want {
+			t.Errorf("candidate %s exists = %v, want %v (%v)", id, exists, want, err)
+		}
+	}
+}
@@ -9,6 +9,24 @@ import (
 	"time"
 )
 
+type AgentMessage struct {
+	SessionID interface{}
+	Position  int64
+	CreatedAt time.Time
+	Role      string
+	Content   string
+}
+
+type AgentSession struct {
+	ID         interface{}
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Kind       string
+	Title      string
+	SearchID   interface{}
+	FinishedAt sql.NullTime
+}
+
 type Article struct {
 	ID             interface{}
 	FetchedAt      time.Time
@@ -19,13 +37,46 @@ type Article struct {
 	SearchID       interface{}
 }
 
+type ArticleCategory struct {
+	ID            interface{}
+	CreatedAt     time.Time
+	ArticleID     interface{}
+	ArxivCategory string
+	IsPrimary     bool
+}
+
+type ArticleRelevance struct {
+	ID         interface{}
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ArticleID  interface{}
+	SearchID   interface{}
+	Score      float64
+	Rationale  string
+	KeyAuthors string
+	Model      string
+}
+
+type ArticleTerm struct {
+	ArticleID interface{}
+	Term      string
+	Count     int64
+}
+
+type ArticlesFt struct {
+	ArticleTitle   string
+	ArticleSummary string
+	ArticleAuthors string
+}
+
 type Candidate struct {
-	ID          interface{}
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Name        string
-	LinkedinUrl sql.NullString
-	GithubUrl   sql.NullString
+	ID             interface{}
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	LinkedinUrl    sql.NullString
+	GithubUrl      sql.NullString
+	NormalizedName string
 }
 
 type CandidateArticle struct {
@@ -44,6 +95,31 @@ type CandidateCategory struct {
 	ArxivCategory string
 }
 
+type CandidateEnrichment struct {
+	ID           interface{}
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	CandidateID  interface{}
+	Source       string
+	ProfileLogin sql.NullString
+	ProfileUrl   sql.NullString
+	Confidence   float64
+	Evidence     string
+}
+
+type CandidateProfileLink struct {
+	ID          interface{}
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CandidateID interface{}
+	Kind        string
+	Url         string
+	SourceUrl   string
+	SourceTitle string
+	CitedText   string
+	Model       string
+}
+
 type CandidateSearch struct {
 	ID             interface{}
 	CreatedAt      time.Time
@@ -52,6 +128,45 @@ type CandidateSearch struct {
 	SearchID       interface{}
 	RelevanceScore sql.NullFloat64
 	Notes          sql.NullString
+	Status         string
+}
+
+type CandidateStatusChange struct {
+	ID          interface{}
+	CreatedAt   time.Time
+	CandidateID interface{}
+	SearchID    interface{}
+	FromStatus  string
+	ToStatus    string
+	ChangedBy   string
+	Note        sql.NullString
+}
+
+type CandidatesFt struct {
+	Name string
+}
+
+type HttpCache struct {
+	CacheKey   string
+	FetchedAt  time.Time
+	StatusCode int64
+	Body       string
+}
+
+type LlmCall struct {
+	ID                       interface{}
+	CreatedAt                time.Time
+	Purpose                  string
+	Model                    string
+	InputTokens              int64
+	OutputTokens             int64
+	CacheCreationInputTokens int64
+	CacheReadInputTokens     int64
+	WebSearchRequests        int64
+	LatencyMs                int64
+	SearchID                 interface{}
+	ArticleID                interface{}
+	SessionID                interface{}
 }
 
 type Search struct {
@@ -62,4 +177,22 @@ type Search struct {
 	ArvixUrl        string
 	ResultsPerFetch sql.NullInt64
 	LastFetchDate   sql.NullTime
+	BackfillCursor  sql.NullTime
+	MonthlyBudget   sql.NullFloat64
+	FetchFailures   int64
+	NextFetchAfter  sql.NullTime
+}
+
+type SearchFetchAdjustment struct {
+	ID           interface{}
+	CreatedAt    time.Time
+	SearchID     interface{}
+	PreviousSize int64
+	NewSize      int64
+	Fetched      int64
+	NewArticles  int64
+	Duplicates   int64
+	Scored       int64
+	Relevant     int64
+	Reason       string
 }
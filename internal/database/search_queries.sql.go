@@ -19,11 +19,12 @@ INSERT INTO searches (
   description, 
   arvix_url, 
   results_per_fetch,
-  last_fetch_date
+  last_fetch_date,
+  monthly_budget
 ) VALUES (
-  ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?
 )
-RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 `
 
 type CreateSearchParams struct {
@@ -34,6 +35,7 @@ type CreateSearchParams struct {
 	ArvixUrl        string
 	ResultsPerFetch sql.NullInt64
 	LastFetchDate   sql.NullTime
+	MonthlyBudget   sql.NullFloat64
 }
 
 func (q *Queries) CreateSearch(ctx context.Context, arg CreateSearchParams) (Search, error) {
@@ -45,6 +47,7 @@ func (q *Queries) CreateSearch(ctx context.Context, arg CreateSearchParams) (Sea
 		arg.ArvixUrl,
 		arg.ResultsPerFetch,
 		arg.LastFetchDate,
+		arg.MonthlyBudget,
 	)
 	var i Search
 	err := row.Scan(
@@ -55,6 +58,10 @@ func (q *Queries) CreateSearch(ctx context.Context, arg CreateSearchParams) (Sea
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
@@ -65,7 +72,7 @@ SET
   updated_at = ?,
   results_per_fetch = MAX(results_per_fetch - ?, 10)
 WHERE id = ?
-RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 `
 
 type DecrementSearchFetchResultsParams struct {
@@ -85,10 +92,69 @@ func (q *Queries) DecrementSearchFetchResults(ctx context.Context, arg Decrement
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
 
+const deleteArticleRelevanceBySearchID = `-- name: DeleteArticleRelevanceBySearchID :execrows
+DELETE FROM article_relevance
+WHERE search_id = ?
+`
+
+func (q *Queries) DeleteArticleRelevanceBySearchID(ctx context.Context, searchID interface{}) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteArticleRelevanceBySearchID, searchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteArticlesBySearchID = `-- name: DeleteArticlesBySearchID :execrows
+DELETE FROM articles
+WHERE search_id = ?
+`
+
+func (q *Queries) DeleteArticlesBySearchID(ctx context.Context, searchID interface{}) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteArticlesBySearchID, searchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCandidateArticlesBySearchID = `-- name: DeleteCandidateArticlesBySearchID :execrows
+DELETE FROM candidate_articles
+WHERE article_id IN (
+  SELECT id FROM articles
+  WHERE search_id = ?
+)
+`
+
+func (q *Queries) DeleteCandidateArticlesBySearchID(ctx context.Context, searchID interface{}) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCandidateArticlesBySearchID, searchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCandidateSearchesBySearchID = `-- name: DeleteCandidateSearchesBySearchID :execrows
+DELETE FROM candidate_searches
+WHERE search_id = ?
+`
+
+func (q *Queries) DeleteCandidateSearchesBySearchID(ctx context.Context, searchID interface{}) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCandidateSearchesBySearchID, searchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteSearch = `-- name: DeleteSearch :exec
 DELETE FROM searches
 WHERE id = ?
@@ -113,7 +179,7 @@ func (q *Queries) GetLatestFetchTime(ctx context.Context, searchID interface{})
 }
 
 const getSearchByID = `-- name: GetSearchByID :one
-SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date FROM searches
+SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after FROM searches
 WHERE id = ?
 LIMIT 1
 `
@@ -129,13 +195,17 @@ func (q *Queries) GetSearchByID(ctx context.Context, id interface{}) (Search, er
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
 
 const getSearchWithStats = `-- name: GetSearchWithStats :one
 SELECT 
-  s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date,
+  s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date, s.backfill_cursor, s.monthly_budget, s.fetch_failures, s.next_fetch_after,
   COUNT(DISTINCT a.id) AS article_count,
   COUNT(DISTINCT cs.candidate_id) AS candidate_count,
   s.last_fetch_date,
@@ -155,6 +225,10 @@ type GetSearchWithStatsRow struct {
 	ArvixUrl        string
 	ResultsPerFetch sql.NullInt64
 	LastFetchDate   sql.NullTime
+	BackfillCursor  sql.NullTime
+	MonthlyBudget   sql.NullFloat64
+	FetchFailures   int64
+	NextFetchAfter  sql.NullTime
 	ArticleCount    int64
 	CandidateCount  int64
 	LastFetchDate_2 sql.NullTime
@@ -172,6 +246,10 @@ func (q *Queries) GetSearchWithStats(ctx context.Context, id interface{}) (GetSe
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 		&i.ArticleCount,
 		&i.CandidateCount,
 		&i.LastFetchDate_2,
@@ -181,7 +259,7 @@ func (q *Queries) GetSearchWithStats(ctx context.Context, id interface{}) (GetSe
 }
 
 const getSearchesByArxivCategory = `-- name: GetSearchesByArxivCategory :many
-SELECT s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date
+SELECT s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date, s.backfill_cursor, s.monthly_budget, s.fetch_failures, s.next_fetch_after
 FROM searches s
 WHERE s.arvix_url LIKE '%' || ? || '%'
 ORDER BY s.created_at DESC
@@ -204,6 +282,10 @@ func (q *Queries) GetSearchesByArxivCategory(ctx context.Context, dollar_1 sql.N
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 		); err != nil {
 			return nil, err
 		}
@@ -219,22 +301,26 @@ func (q *Queries) GetSearchesByArxivCategory(ctx context.Context, dollar_1 sql.N
 }
 
 const getSearchesWithoutRecentFetches = `-- name: GetSearchesWithoutRecentFetches :many
-SELECT s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date
+SELECT s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date, s.backfill_cursor, s.monthly_budget, s.fetch_failures, s.next_fetch_after
 FROM searches s
-WHERE s.last_fetch_date IS NULL OR s.last_fetch_date < ?
+WHERE
+  (s.last_fetch_date IS NULL OR s.last_fetch_date < ?) AND
+  (s.next_fetch_after IS NULL OR s.next_fetch_after <= ?)
 ORDER BY 
+  s.fetch_failures ASC,
   CASE WHEN s.last_fetch_date IS NULL THEN 0 ELSE 1 END ASC,
   s.last_fetch_date ASC
 LIMIT ?
 `
 
 type GetSearchesWithoutRecentFetchesParams struct {
-	LastFetchDate sql.NullTime
-	Limit         int64
+	LastFetchDate  sql.NullTime
+	NextFetchAfter sql.NullTime
+	Limit          int64
 }
 
 func (q *Queries) GetSearchesWithoutRecentFetches(ctx context.Context, arg GetSearchesWithoutRecentFetchesParams) ([]Search, error) {
-	rows, err := q.db.QueryContext(ctx, getSearchesWithoutRecentFetches, arg.LastFetchDate, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getSearchesWithoutRecentFetches, arg.LastFetchDate, arg.NextFetchAfter, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -250,6 +336,10 @@ func (q *Queries) GetSearchesWithoutRecentFetches(ctx context.Context, arg GetSe
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 		); err != nil {
 			return nil, err
 		}
@@ -270,7 +360,7 @@ SET
   updated_at = ?,
   results_per_fetch = MIN(results_per_fetch + ?, 1999)
 WHERE id = ?
-RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 `
 
 type IncrementSearchFetchResultsParams struct {
@@ -290,13 +380,17 @@ func (q *Queries) IncrementSearchFetchResults(ctx context.Context, arg Increment
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
 
 const listActiveSearches = `-- name: ListActiveSearches :many
 SELECT 
-  s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date,
+  s.id, s.created_at, s.updated_at, s.description, s.arvix_url, s.results_per_fetch, s.last_fetch_date, s.backfill_cursor, s.monthly_budget, s.fetch_failures, s.next_fetch_after,
   COUNT(a.id) AS article_count
 FROM searches s
 LEFT JOIN articles a ON s.id = a.search_id
@@ -319,6 +413,10 @@ type ListActiveSearchesRow struct {
 	ArvixUrl        string
 	ResultsPerFetch sql.NullInt64
 	LastFetchDate   sql.NullTime
+	BackfillCursor  sql.NullTime
+	MonthlyBudget   sql.NullFloat64
+	FetchFailures   int64
+	NextFetchAfter  sql.NullTime
 	ArticleCount    int64
 }
 
@@ -339,6 +437,10 @@ func (q *Queries) ListActiveSearches(ctx context.Context, arg ListActiveSearches
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 			&i.ArticleCount,
 		); err != nil {
 			return nil, err
@@ -355,7 +457,7 @@ func (q *Queries) ListActiveSearches(ctx context.Context, arg ListActiveSearches
 }
 
 const listAllSearches = `-- name: ListAllSearches :many
-SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date FROM searches
+SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after FROM searches
 ORDER BY created_at DESC
 `
 
@@ -376,6 +478,10 @@ func (q *Queries) ListAllSearches(ctx context.Context) ([]Search, error) {
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 		); err != nil {
 			return nil, err
 		}
@@ -391,7 +497,7 @@ func (q *Queries) ListAllSearches(ctx context.Context) ([]Search, error) {
 }
 
 const listRecentSearches = `-- name: ListRecentSearches :many
-SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date FROM searches
+SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after FROM searches
 ORDER BY updated_at DESC
 LIMIT ?
 `
@@ -413,6 +519,10 @@ func (q *Queries) ListRecentSearches(ctx context.Context, limit int64) ([]Search
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 		); err != nil {
 			return nil, err
 		}
@@ -427,8 +537,43 @@ func (q *Queries) ListRecentSearches(ctx context.Context, limit int64) ([]Search
 	return items, nil
 }
 
+const recordSearchFetchFailure = `-- name: RecordSearchFetchFailure :one
+UPDATE searches
+SET
+  updated_at = ?,
+  fetch_failures = fetch_failures + 1,
+  next_fetch_after = ?
+WHERE id = ?
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
+`
+
+type RecordSearchFetchFailureParams struct {
+	UpdatedAt      time.Time
+	NextFetchAfter sql.NullTime
+	ID             interface{}
+}
+
+func (q *Queries) RecordSearchFetchFailure(ctx context.Context, arg RecordSearchFetchFailureParams) (Search, error) {
+	row := q.db.QueryRowContext(ctx, recordSearchFetchFailure, arg.UpdatedAt, arg.NextFetchAfter, arg.ID)
+	var i Search
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.ArvixUrl,
+		&i.ResultsPerFetch,
+		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
+	)
+	return i, err
+}
+
 const searchByDescription = `-- name: SearchByDescription :many
-SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+SELECT id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 FROM searches
 WHERE LOWER(description) LIKE LOWER('%' || ? || '%')
 ORDER BY created_at DESC
@@ -451,6 +596,10 @@ func (q *Queries) SearchByDescription(ctx context.Context, dollar_1 sql.NullStri
 			&i.ArvixUrl,
 			&i.ResultsPerFetch,
 			&i.LastFetchDate,
+			&i.BackfillCursor,
+			&i.MonthlyBudget,
+			&i.FetchFailures,
+			&i.NextFetchAfter,
 		); err != nil {
 			return nil, err
 		}
@@ -465,6 +614,40 @@ func (q *Queries) SearchByDescription(ctx context.Context, dollar_1 sql.NullStri
 	return items, nil
 }
 
+const setSearchMonthlyBudget = `-- name: SetSearchMonthlyBudget :one
+UPDATE searches
+SET
+  updated_at = ?,
+  monthly_budget = ?
+WHERE id = ?
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
+`
+
+type SetSearchMonthlyBudgetParams struct {
+	UpdatedAt     time.Time
+	MonthlyBudget sql.NullFloat64
+	ID            interface{}
+}
+
+func (q *Queries) SetSearchMonthlyBudget(ctx context.Context, arg SetSearchMonthlyBudgetParams) (Search, error) {
+	row := q.db.QueryRowContext(ctx, setSearchMonthlyBudget, arg.UpdatedAt, arg.MonthlyBudget, arg.ID)
+	var i Search
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.ArvixUrl,
+		&i.ResultsPerFetch,
+		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
+	)
+	return i, err
+}
+
 const updateSearch = `-- name: UpdateSearch :one
 UPDATE searches
 SET 
@@ -474,7 +657,7 @@ SET
   results_per_fetch = ?,
   last_fetch_date = ?
 WHERE id = ?
-RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 `
 
 type UpdateSearchParams struct {
@@ -504,6 +687,44 @@ func (q *Queries) UpdateSearch(ctx context.Context, arg UpdateSearchParams) (Sea
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
+	)
+	return i, err
+}
+
+const updateSearchBackfillCursor = `-- name: UpdateSearchBackfillCursor :one
+UPDATE searches
+SET
+  updated_at = ?,
+  backfill_cursor = ?
+WHERE id = ?
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
+`
+
+type UpdateSearchBackfillCursorParams struct {
+	UpdatedAt      time.Time
+	BackfillCursor sql.NullTime
+	ID             interface{}
+}
+
+func (q *Queries) UpdateSearchBackfillCursor(ctx context.Context, arg UpdateSearchBackfillCursorParams) (Search, error) {
+	row := q.db.QueryRowContext(ctx, updateSearchBackfillCursor, arg.UpdatedAt, arg.BackfillCursor, arg.ID)
+	var i Search
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.ArvixUrl,
+		&i.ResultsPerFetch,
+		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
@@ -514,7 +735,7 @@ SET
   updated_at = ?,
   results_per_fetch = ?
 WHERE id = ?
-RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 `
 
 type UpdateSearchFetchRateParams struct {
@@ -534,6 +755,10 @@ func (q *Queries) UpdateSearchFetchRate(ctx context.Context, arg UpdateSearchFet
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
@@ -542,9 +767,11 @@ const updateSearchLastFetchDate = `-- name: UpdateSearchLastFetchDate :one
 UPDATE searches
 SET
   updated_at = ?,
-  last_fetch_date = ?
+  last_fetch_date = ?,
+  fetch_failures = 0,
+  next_fetch_after = NULL
 WHERE id = ?
-RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date
+RETURNING id, created_at, updated_at, description, arvix_url, results_per_fetch, last_fetch_date, backfill_cursor, monthly_budget, fetch_failures, next_fetch_after
 `
 
 type UpdateSearchLastFetchDateParams struct {
@@ -553,6 +780,7 @@ type UpdateSearchLastFetchDateParams struct {
 	ID            interface{}
 }
 
+// A successful fetch also ends any backoff from earlier failures
 func (q *Queries) UpdateSearchLastFetchDate(ctx context.Context, arg UpdateSearchLastFetchDateParams) (Search, error) {
 	row := q.db.QueryRowContext(ctx, updateSearchLastFetchDate, arg.UpdatedAt, arg.LastFetchDate, arg.ID)
 	var i Search
@@ -564,6 +792,10 @@ func (q *Queries) UpdateSearchLastFetchDate(ctx context.Context, arg UpdateSearc
 		&i.ArvixUrl,
 		&i.ResultsPerFetch,
 		&i.LastFetchDate,
+		&i.BackfillCursor,
+		&i.MonthlyBudget,
+		&i.FetchFailures,
+		&i.NextFetchAfter,
 	)
 	return i, err
 }
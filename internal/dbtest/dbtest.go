@@ -0,0 +1,76 @@
+// Package dbtest opens a scratch SQLite database with every migration applied,
+// for tests that need the real schema
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/sql/schema"
+)
+
+// Open creates a migrated database in the test's temporary directory, set up
+// as the program sets it up at startup. It is closed when the test ends.
+func Open(t testing.TB) (*sql.DB, *database.Queries) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := schema.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("applying migrations: %v", err)
+	}
+	if _, err := schema.EnableFullText(context.Background(), db); err != nil {
+		t.Fatalf("setting up full-text search: %v", err)
+	}
+	return db, database.New(db)
+}
+
+// CreateSearch stores a search for the feed URL
+func CreateSearch(t testing.TB, queries *database.Queries, description, feedURL string) database.Search {
+	t.Helper()
+	now := time.Now()
+	search, err := queries.CreateSearch(context.Background(), database.CreateSearchParams{
+		ID:              uuid.New(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Description:     description,
+		ArvixUrl:        feedURL,
+		ResultsPerFetch: sql.NullInt64{Int64: 50, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("creating search: %v", err)
+	}
+	return search
+}
+
+// CreateArticle stores an article for the search
+func CreateArticle(t testing.TB, queries *database.Queries, searchID interface{}, url, title, summary, authors string) database.Article {
+	t.Helper()
+	article, err := queries.CreateArticle(context.Background(), database.CreateArticleParams{
+		ID:             uuid.New(),
+		FetchedAt:      time.Now(),
+		ArticleUrl:     url,
+		ArticleTitle:   title,
+		ArticleSummary: summary,
+		ArticleAuthors: authors,
+		SearchID:       searchID,
+	})
+	if err != nil {
+		t.Fatalf("creating article: %v", err)
+	}
+	return article
+}
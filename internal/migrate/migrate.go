@@ -0,0 +1,290 @@
+// Package migrate applies goose-annotated SQL migrations without needing the
+// goose binary. It reads and writes goose's own goose_db_version table, so a
+// database migrated with goose and one migrated by reSearch are interchangeable.
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionTable is the table goose uses to record applied migrations
+const versionTable = "goose_db_version"
+
+// Migration is a single goose migration file split into its Up and Down sections
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+
+	// upFunc runs after the Up section, for data changes SQL cannot express
+	upFunc Func
+	// downFunc runs before the Down section, e.g. to refuse a rollback that
+	// would lose data
+	downFunc Func
+}
+
+// Func is Go code that is part of a migration. It runs in the migration's
+// transaction, so it is rolled back with the SQL if either fails.
+type Func func(ctx context.Context, tx *sql.Tx) error
+
+// Status describes whether a migration has been applied
+type Status struct {
+	Migration Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies a set of migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator loads every *.sql migration in fsys. File names must start with
+// their version number, e.g. "001_searches.sql".
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", file, err)
+		}
+		migration, err := parseMigration(file, string(content))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s",
+				migrations[i].Version, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// AfterUp attaches fn to a migration, to run after its Up section. Code that
+// needs the application's own logic, such as normalizing names exactly as the
+// app does, belongs here rather than in SQL.
+func (m *Migrator) AfterUp(version int64, fn Func) error {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			m.migrations[i].upFunc = fn
+			return nil
+		}
+	}
+	return fmt.Errorf("no migration with version %d", version)
+}
+
+// BeforeDown attaches fn to a migration, to run before its Down section. If
+// fn returns an error nothing is rolled back.
+func (m *Migrator) BeforeDown(version int64, fn Func) error {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			m.migrations[i].downFunc = fn
+			return nil
+		}
+	}
+	return fmt.Errorf("no migration with version %d", version)
+}
+
+// Up applies every pending migration in version order and returns those applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.apply(ctx, nil, migration.Up, migration.upFunc, "INSERT INTO "+versionTable+" (version_id, is_applied) VALUES (?, 1)", migration.Version)
+		if err != nil {
+			return ran, fmt.Errorf("applying %s: %w", migration.Name, err)
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+// Down rolls back the most recently applied migration. It returns false if
+// there was nothing to roll back.
+func (m *Migrator) Down(ctx context.Context) (Migration, bool, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err := m.apply(ctx, migration.downFunc, migration.Down, nil, "DELETE FROM "+versionTable+" WHERE version_id = ?", migration.Version)
+		if err != nil {
+			return migration, false, fmt.Errorf("rolling back %s: %w", migration.Name, err)
+		}
+		return migration, true, nil
+	}
+	return Migration{}, false, nil
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses[i] = Status{Migration: migration, Applied: ok, AppliedAt: appliedAt}
+	}
+	return statuses, nil
+}
+
+// apply runs before, a migration section and then after, skipping any that
+// are missing, and records the version change in one transaction
+func (m *Migrator) apply(ctx context.Context, before Func, statements string, after Func, versionQuery string, version int64) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if before != nil {
+		if err := before(ctx, tx); err != nil {
+			return err
+		}
+	}
+	// The sqlite3 driver executes every statement in a multi-statement string,
+	// so a section (including trigger bodies) can run as a single Exec
+	if strings.TrimSpace(statements) != "" {
+		if _, err := tx.ExecContext(ctx, statements); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if err := after(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, versionQuery, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appliedVersions ensures the version table exists and returns the applied
+// versions with the time each was applied
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id INTEGER NOT NULL,
+		is_applied INTEGER NOT NULL,
+		tstamp TIMESTAMP DEFAULT (datetime('now'))
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", versionTable, err)
+	}
+
+	// goose seeds the table with version 0 so an empty table means "never initialised"
+	_, err = m.db.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied)
+		SELECT 0, 1 WHERE NOT EXISTS (SELECT 1 FROM `+versionTable+`)`)
+	if err != nil {
+		return nil, fmt.Errorf("initialising %s: %w", versionTable, err)
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version_id, is_applied, tstamp FROM `+versionTable+` ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, err
+		}
+		if isApplied {
+			applied[version] = tstamp.Time
+		} else {
+			delete(applied, version)
+		}
+	}
+	return applied, rows.Err()
+}
+
+// parseMigration splits a goose migration into its Up and Down sections
+func parseMigration(file, content string) (Migration, error) {
+	name := path.Base(file)
+	prefix, _, ok := strings.Cut(name, "_")
+	if !ok {
+		return Migration{}, fmt.Errorf("migration %s: name must start with a version number", name)
+	}
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil {
+		return Migration{}, fmt.Errorf("migration %s: invalid version %q", name, prefix)
+	}
+
+	migration := Migration{Version: version, Name: name}
+	var up, down strings.Builder
+	var current *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if annotation, ok := strings.CutPrefix(trimmed, "-- +goose "); ok {
+			switch strings.TrimSpace(annotation) {
+			case "Up":
+				current = &up
+			case "Down":
+				current = &down
+			}
+			// StatementBegin/StatementEnd only matter to drivers that split statements
+			continue
+		}
+		if current != nil {
+			current.WriteString(line)
+			current.WriteString("\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, fmt.Errorf("migration %s: %w", name, err)
+	}
+	if current == nil {
+		return Migration{}, fmt.Errorf("migration %s: missing -- +goose Up annotation", name)
+	}
+
+	migration.Up = up.String()
+	migration.Down = down.String()
+	return migration, nil
+}
@@ -0,0 +1,21 @@
+// Package names holds the name normalization candidates are deduplicated by.
+// It lives apart from the candidates package so migrations can use it too.
+package names
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// Normalize reduces a name to the form used to deduplicate candidates: NFC
+// composed, lower case, periods treated as spaces and whitespace collapsed, so
+// that "J.  Smith" and "j. smith" compare equal, as do a "José" typed with a
+// combining accent and one typed with the precomposed letter
+func Normalize(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(norm.NFC.String(name), ".", " "))
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(name, " "))
+}
@@ -0,0 +1,36 @@
+package names
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"already normal", "alice smith", "alice smith"},
+		{"case", "Alice SMITH", "alice smith"},
+		{"periods and spacing", "  J.  Smith ", "j smith"},
+		{"initials without spaces", "J.R.R. Tolkien", "j r r tolkien"},
+		{"tabs and newlines", "Alice\t\nSmith", "alice smith"},
+		{"precomposed accent", "José García", "josé garcía"},
+		{"combining accent is composed", "Jose\u0301 Garci\u0301a", "josé garcía"},
+		{"accented capitals", "ÉMILIE MÜLLER", "émilie müller"},
+		{"accents are kept", "René", "rené"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	// The two spellings of a name are the same candidate, but a name that
+	// differs only by an accent is not
+	if Normalize("Jose\u0301") != Normalize("José") {
+		t.Error("combining and precomposed accents normalize differently")
+	}
+	if Normalize("José") == Normalize("Jose") {
+		t.Error("an accent was stripped")
+	}
+}
@@ -4,49 +4,177 @@ import (
 	"bufio"
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/config"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/enrich"
+	"github.com/jessewalker/reSearch/fetcher"
+	"github.com/jessewalker/reSearch/fulltext"
 	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/migrate"
+	"github.com/jessewalker/reSearch/scorer"
+	"github.com/jessewalker/reSearch/similar"
+	"github.com/jessewalker/reSearch/sql/schema"
 )
 
 func main() {
-	fmt.Println("Welcome to reSearch - AI Research Talent Exploration Tool")
+	// Settings come from defaults, the config file, .env, the environment and
+	// any global flags; what remains of the arguments selects a subcommand
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Print(usage)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Subcommands are meant for scripts and pipelines, so their startup chatter
+	// goes to stderr and stdout carries only the command's own output
+	logOut := io.Writer(os.Stdout)
+	if len(args) > 0 {
+		logOut = os.Stderr
+	} else {
+		fmt.Println("Welcome to reSearch - AI Research Talent Exploration Tool")
+	}
 
 	// Open database connection
-	fmt.Println("[DEBUG] Opening database connection...")
-	db, err := sql.Open("sqlite3", "research.db")
+	fmt.Fprintln(logOut, "[DEBUG] Opening database connection...")
+	db, err := sql.Open("sqlite3", cfg.DBPath)
 	if err != nil {
-		fmt.Printf("Error opening database: %v\n", err)
-		return
+		fmt.Fprintf(logOut, "Error opening database: %v\n", err)
+		os.Exit(1)
 	}
 	defer db.Close()
-	fmt.Println("[DEBUG] Database connection opened successfully")
+	fmt.Fprintln(logOut, "[DEBUG] Database connection opened successfully")
 
 	// Test database connection
 	err = db.Ping()
 	if err != nil {
-		fmt.Printf("Error pinging database: %v\n", err)
+		fmt.Fprintf(logOut, "Error pinging database: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(logOut, "[DEBUG] Database ping successful")
+
+	// Create context for database operations
+	ctx := context.Background()
+
+	// Load the embedded schema migrations
+	migrator, err := schema.NewMigrator(db)
+	if err != nil {
+		fmt.Fprintf(logOut, "Error loading migrations: %v\n", err)
+		os.Exit(1)
+	}
+
+	// "reSearch migrate <up|down|status>" manages the schema and exits
+	if len(args) > 0 && args[0] == "migrate" {
+		runMigrateCommand(ctx, migrator, args[1:])
 		return
 	}
-	fmt.Println("[DEBUG] Database ping successful")
+
+	// Bring the schema up to date before anything touches the tables
+	fmt.Fprintln(logOut, "[DEBUG] Applying pending migrations...")
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		fmt.Fprintf(logOut, "Error applying migrations: %v\n", err)
+		os.Exit(1)
+	}
+	for _, migration := range applied {
+		fmt.Fprintf(logOut, "Applied migration %s\n", migration.Name)
+	}
+	fmt.Fprintln(logOut, "[DEBUG] Database schema is up to date")
+
+	// Keep the full-text index only if SQLite has FTS5
+	fullTextAvailable, err := schema.EnableFullText(ctx, db)
+	if err != nil {
+		fmt.Fprintf(logOut, "Error setting up full-text search: %v\n", err)
+		os.Exit(1)
+	}
+	if !fullTextAvailable {
+		fmt.Fprintln(logOut, "[DEBUG] SQLite lacks FTS5 (build with -tags sqlite_fts5); searches fall back to substring matching")
+	}
 
 	// Initialize database queries
-	fmt.Println("[DEBUG] Initializing database queries...")
+	fmt.Fprintln(logOut, "[DEBUG] Initializing database queries...")
 	queries := database.New(db)
-	fmt.Println("[DEBUG] Database queries initialized")
+	fmt.Fprintln(logOut, "[DEBUG] Database queries initialized")
+
+	// Initialize the arXiv feed fetcher
+	feedFetcher := fetcher.NewFetcher(queries, nil)
+
+	// Initialize the fetch-size controller
+	fetchSizer := fetcher.NewSizer(db, queries)
+
+	// Initialize the Anthropic client
+	client := anthropic.NewClient(option.WithAPIKey(cfg.APIKey))
+
+	// Initialize the ledger every model call is recorded in
+	usageLedger := costs.NewLedger(queries, cfg.Prices())
+
+	// Initialize the relevance scorer
+	articleScorer := scorer.NewScorer(queries, client, cfg.AgentOptions(), usageLedger)
+
+	// Initialize the candidate linker
+	candidateLinker := candidates.NewLinker(db, queries)
+
+	// Initialize the candidate pipeline
+	candidatePipeline := candidates.NewPipeline(db, queries)
+
+	// Initialize the GitHub profile enricher
+	githubClient := enrich.NewGitHubClient(queries, nil, cfg.GitHub.BaseURL, cfg.GitHub.Token, cfg.GitHub.CacheTTL)
+	candidateEnricher := enrich.NewEnricher(db, queries, githubClient, cfg.GitHub.MinConfidence)
+	profileDiscoverer := enrich.NewWebDiscoverer(db, queries, client, cfg.AgentOptions(), usageLedger)
+
+	// Initialize the "more like this" similarity index
+	articleIndex := similar.NewIndex(db, queries)
+
+	// Initialize full-text search over articles and candidate names
+	fullText := fulltext.NewIndex(db)
+
+	// Any other arguments select a non-interactive subcommand
+	if len(args) > 0 {
+		cli := &app{
+			cfg:      cfg,
+			db:       db,
+			queries:  queries,
+			fetcher:  feedFetcher,
+			sizer:    fetchSizer,
+			scorer:   articleScorer,
+			linker:   candidateLinker,
+			pipeline: candidatePipeline,
+			enricher: candidateEnricher,
+			profiles: profileDiscoverer,
+			index:    articleIndex,
+			fullText: fullText,
+			client:   client,
+			ledger:   usageLedger,
+		}
+		if err := runCommand(ctx, cli, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Create a scanner to read user input
 	scanner := bufio.NewScanner(os.Stdin)
 
-	// Create context for database operations
-	ctx := context.Background()
-
 	// Main application loop
 	for {
 		fmt.Println("[DEBUG] Displaying main menu")
@@ -62,24 +190,37 @@ func main() {
 		switch choice {
 		case "1":
 			fmt.Println("\n--- Create New Search ---")
-			createNewSearch(ctx, queries, scanner)
+			createNewSearch(ctx, cfg, db, queries, client, usageLedger, scanner)
 			pressEnterToContinue(scanner)
 		case "2":
 			fmt.Println("\n--- Manage Searches ---")
-			manageSearches(ctx, queries, scanner)
+			manageSearches(ctx, db, queries, feedFetcher, articleScorer, candidateLinker, candidatePipeline, articleIndex, cfg.User, scanner)
 			pressEnterToContinue(scanner)
 		case "3":
 			fmt.Println("\n--- Check New Results ---")
-			fmt.Println("This feature will be implemented soon.")
+			checkNewResults(ctx, queries, feedFetcher)
 			pressEnterToContinue(scanner)
 		case "4":
 			fmt.Println("\n--- Fetch Older Results ---")
-			fmt.Println("This feature will be implemented soon.")
+			fetchOlderResults(ctx, cfg, queries, feedFetcher, fetchSizer, articleScorer, scanner)
 			pressEnterToContinue(scanner)
 		case "5":
 			fmt.Println("\n--- Delete Search ---")
-			fmt.Println("This feature will be implemented soon.")
+			if search, ok := selectSearch(ctx, queries, scanner); ok {
+				deleteSearch(ctx, db, search.ID, search.Description, scanner)
+			}
 			pressEnterToContinue(scanner)
+		case "6":
+			fmt.Println("\n--- Research Assistant ---")
+			if session, history, ok := chooseAssistantSession(ctx, db, queries, scanner); ok {
+				if err := runAssistant(ctx, cfg, queries, fullText, candidateLinker, client, usageLedger, session, history, scanner); err != nil {
+					fmt.Printf("Error during conversation: %v\n", err)
+				}
+			}
+			pressEnterToContinue(scanner)
+		case "7":
+			fmt.Println("\n--- Search Articles ---")
+			searchArticlesScreen(ctx, fullText, scanner)
 		case "q", "Q", "exit", "quit":
 			fmt.Println("Exiting reSearch. Goodbye!")
 			return
@@ -98,6 +239,8 @@ func displayMainMenu() {
 	fmt.Println("3. Check New Results")
 	fmt.Println("4. Fetch Older Results")
 	fmt.Println("5. Delete Search")
+	fmt.Println("6. Research Assistant")
+	fmt.Println("7. Search Articles")
 	fmt.Println("q. Quit")
 }
 
@@ -108,7 +251,7 @@ func pressEnterToContinue(scanner *bufio.Scanner) {
 }
 
 // manageSearches allows viewing and managing existing searches
-func manageSearches(ctx context.Context, queries *database.Queries, scanner *bufio.Scanner) {
+func manageSearches(ctx context.Context, db *sql.DB, queries *database.Queries, feedFetcher *fetcher.Fetcher, articleScorer *scorer.Scorer, candidateLinker *candidates.Linker, candidatePipeline *candidates.Pipeline, articleIndex *similar.Index, user string, scanner *bufio.Scanner) {
 	fmt.Println("[DEBUG] Starting manageSearches function")
 	
 	// List active searches with pagination
@@ -202,7 +345,7 @@ func manageSearches(ctx context.Context, queries *database.Queries, scanner *buf
 			var selectedIndex int
 			_, err := fmt.Sscanf(choice, "%d", &selectedIndex)
 			if err == nil && selectedIndex > 0 && selectedIndex <= len(searches) {
-				viewSearchDetails(ctx, queries, searches[selectedIndex-1].ID, scanner)
+				viewSearchDetails(ctx, db, queries, feedFetcher, articleScorer, candidateLinker, candidatePipeline, articleIndex, user, searches[selectedIndex-1].ID, scanner)
 			} else {
 				// Check if the user entered a letter that's for the details view
 				if choice == "d" || choice == "e" || choice == "f" {
@@ -217,7 +360,7 @@ func manageSearches(ctx context.Context, queries *database.Queries, scanner *buf
 }
 
 // viewSearchDetails displays detailed information about a specific search
-func viewSearchDetails(ctx context.Context, queries *database.Queries, searchID interface{}, scanner *bufio.Scanner) {
+func viewSearchDetails(ctx context.Context, db *sql.DB, queries *database.Queries, feedFetcher *fetcher.Fetcher, articleScorer *scorer.Scorer, candidateLinker *candidates.Linker, candidatePipeline *candidates.Pipeline, articleIndex *similar.Index, user string, searchID interface{}, scanner *bufio.Scanner) {
 	fmt.Printf("[DEBUG] Viewing search details for ID: %v\n", searchID)
 	
 	// Get detailed search information
@@ -243,6 +386,12 @@ func viewSearchDetails(ctx context.Context, queries *database.Queries, searchID
 		lastFetch = searchStats.LastFetchDate.Time.Format("2006-01-02 15:04:05")
 	}
 	fmt.Printf("Last Fetch:      %s\n", lastFetch)
+
+	backfilledTo := "Not started"
+	if searchStats.BackfillCursor.Valid {
+		backfilledTo = searchStats.BackfillCursor.Time.Format("2006-01-02 15:04")
+	}
+	fmt.Printf("Backfilled To:   %s\n", backfilledTo)
 	
 	// Show statistics
 	fmt.Printf("Article Count:   %d\n", searchStats.ArticleCount)
@@ -251,6 +400,9 @@ func viewSearchDetails(ctx context.Context, queries *database.Queries, searchID
 	// Display options
 	fmt.Println("\nOptions:")
 	fmt.Println("  f - Fetch new results")
+	fmt.Println("  s - Score unscored articles")
+	fmt.Println("  c - Extract candidates from relevant articles")
+	fmt.Println("  r - Review candidates")
 	fmt.Println("  e - Edit search parameters")
 	fmt.Println("  d - Delete search")
 	fmt.Println("  b - Back to search list")
@@ -263,17 +415,47 @@ func viewSearchDetails(ctx context.Context, queries *database.Queries, searchID
 	
 	switch choice {
 	case "f":
-		fmt.Println("\n[COMING SOON] Fetch new results feature will be implemented soon.")
-		fmt.Println("This will allow you to retrieve the latest articles from arXiv based on your search criteria.")
+		search, err := queries.GetSearchByID(ctx, searchID)
+		if err != nil {
+			fmt.Printf("Error retrieving search: %v\n", err)
+			return
+		}
+		fetchSearch(ctx, feedFetcher, search)
+		pressEnterToContinue(scanner)
+		return // Return to search list after action
+	case "s":
+		search, err := queries.GetSearchByID(ctx, searchID)
+		if err != nil {
+			fmt.Printf("Error retrieving search: %v\n", err)
+			return
+		}
+		scoreSearch(ctx, articleScorer, search)
+		pressEnterToContinue(scanner)
+		return // Return to search list after action
+	case "c":
+		search, err := queries.GetSearchByID(ctx, searchID)
+		if err != nil {
+			fmt.Printf("Error retrieving search: %v\n", err)
+			return
+		}
+		extractCandidates(ctx, candidateLinker, search)
 		pressEnterToContinue(scanner)
 		return // Return to search list after action
+	case "r":
+		search, err := queries.GetSearchByID(ctx, searchID)
+		if err != nil {
+			fmt.Printf("Error retrieving search: %v\n", err)
+			return
+		}
+		reviewCandidates(ctx, queries, candidatePipeline, articleIndex, user, search, scanner)
+		return // Return to search list after action
 	case "e":
 		fmt.Println("\n[COMING SOON] Edit search parameters feature will be implemented soon.")
 		fmt.Println("This will allow you to modify the search description, arXiv URL, and other parameters.")
 		pressEnterToContinue(scanner)
 		return // Return to search list after action
 	case "d":
-		deleteSearch(ctx, queries, searchID, searchStats.Description, scanner)
+		deleteSearch(ctx, db, searchID, searchStats.Description, scanner)
 		return // Return to search list after action
 	case "b":
 		return // Return to search list
@@ -284,6 +466,172 @@ func viewSearchDetails(ctx context.Context, queries *database.Queries, searchID
 	}
 }
 
+// checkNewResults fetches the latest arXiv feed for every search
+func checkNewResults(ctx context.Context, queries *database.Queries, feedFetcher *fetcher.Fetcher) {
+	fmt.Println("[DEBUG] Starting checkNewResults function")
+
+	searches, err := queries.ListAllSearches(ctx)
+	if err != nil {
+		fmt.Printf("Error listing searches: %v\n", err)
+		return
+	}
+	if len(searches) == 0 {
+		fmt.Println("No searches found. Create a new search first.")
+		return
+	}
+
+	for _, search := range searches {
+		fetchSearch(ctx, feedFetcher, search)
+	}
+}
+
+// fetchSearch fetches new results for a single search and reports what was stored
+func fetchSearch(ctx context.Context, feedFetcher *fetcher.Fetcher, search database.Search) {
+	fmt.Printf("\nFetching new results for \"%s\"...\n", search.Description)
+	fmt.Printf("[DEBUG] Fetching feed: %s\n", search.ArvixUrl)
+
+	result, err := feedFetcher.FetchNew(ctx, search)
+	if err != nil {
+		fmt.Printf("Error fetching results: %v\n", err)
+		if result == nil {
+			return
+		}
+	}
+
+	fmt.Printf("Feed entries: %d, new articles: %d, already seen: %d\n",
+		result.Seen, len(result.Created), result.Skipped)
+	for _, article := range result.Created {
+		fmt.Printf("  + %s\n", article.ArticleTitle)
+	}
+}
+
+// scoreSearch asks Claude to judge every article of the search that has not been scored yet
+func scoreSearch(ctx context.Context, articleScorer *scorer.Scorer, search database.Search) {
+	fmt.Printf("\nScoring articles for \"%s\"...\n", search.Description)
+
+	result, err := articleScorer.ScoreSearch(ctx, search, 100)
+	if err != nil {
+		fmt.Printf("Error scoring articles: %v\n", err)
+		if result == nil {
+			return
+		}
+	}
+
+	for _, scored := range result.Results {
+		if scored.Err != nil {
+			fmt.Printf("  ! %s: %v\n", scored.Article.ArticleTitle, scored.Err)
+			continue
+		}
+		fmt.Printf("  %.2f %s\n", scored.Verdict.Score, scored.Article.ArticleTitle)
+	}
+	fmt.Printf("Scored: %d, failed: %d\n", result.Scored, result.Failed)
+}
+
+// extractCandidates creates candidates from the authors of the search's relevant articles
+func extractCandidates(ctx context.Context, candidateLinker *candidates.Linker, search database.Search) {
+	fmt.Printf("\nExtracting candidates for \"%s\" (articles scoring %.2f or higher)...\n",
+		search.Description, candidates.DefaultMinRelevance)
+
+	result, err := candidateLinker.LinkSearch(ctx, search, candidates.DefaultMinRelevance)
+	if err != nil {
+		fmt.Printf("Error extracting candidates: %v\n", err)
+	}
+
+	fmt.Printf("Articles: %d, new candidates: %d, existing candidates: %d, new search links: %d\n",
+		result.Articles, result.Created, result.Matched, result.SearchLinks)
+}
+
+// fetchOlderResults backfills older papers for a chosen search through the arXiv
+// API, optionally scoring them so their relevance informs the next page size
+func fetchOlderResults(ctx context.Context, cfg config.Config, queries *database.Queries, feedFetcher *fetcher.Fetcher, fetchSizer *fetcher.Sizer, articleScorer *scorer.Scorer, scanner *bufio.Scanner) {
+	fmt.Println("[DEBUG] Starting fetchOlderResults function")
+
+	search, ok := selectSearch(ctx, queries, scanner)
+	if !ok {
+		return
+	}
+
+	opts := cfg.BackfillOptions()
+	fmt.Printf("How many pages to fetch? (default %d): ", opts.MaxPages)
+	scanner.Scan()
+	if input := strings.TrimSpace(scanner.Text()); input != "" {
+		if _, err := fmt.Sscanf(input, "%d", &opts.MaxPages); err != nil || opts.MaxPages < 1 {
+			fmt.Println("Invalid page count.")
+			return
+		}
+	}
+
+	fmt.Printf("\nFetching older results for \"%s\"...\n", search.Description)
+	result, err := feedFetcher.FetchOlder(ctx, search, opts)
+	if err != nil {
+		fmt.Printf("Error fetching older results: %v\n", err)
+		if result == nil {
+			return
+		}
+	}
+
+	fmt.Printf("Pages: %d, entries: %d, new articles: %d, already seen: %d\n",
+		result.Pages, result.Seen, len(result.Created), result.Skipped)
+	if result.Cursor.Valid {
+		fmt.Printf("Backfilled to: %s\n", result.Cursor.Time.Format("2006-01-02 15:04"))
+	}
+	if result.Exhausted {
+		fmt.Println("No older papers remain for this search.")
+	}
+	if err != nil {
+		return
+	}
+
+	if len(result.Created) > 0 {
+		fmt.Print("Score the new articles now? (y/n): ")
+		scanner.Scan()
+		if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer == "y" || answer == "yes" {
+			scoreSearch(ctx, articleScorer, search)
+		}
+	}
+
+	// Tune the page size for next time based on how this run went
+	adjustment, err := fetchSizer.AdjustBackfill(ctx, search, result, candidates.DefaultMinRelevance)
+	if err != nil {
+		fmt.Printf("Error adjusting fetch size: %v\n", err)
+		return
+	}
+	if adjustment != nil {
+		fmt.Printf("Results per fetch: %d -> %d (%s)\n", adjustment.PreviousSize, adjustment.NewSize, adjustment.Reason)
+	}
+}
+
+// selectSearch lists all searches and lets the user pick one by number
+func selectSearch(ctx context.Context, queries *database.Queries, scanner *bufio.Scanner) (database.Search, bool) {
+	searches, err := queries.ListAllSearches(ctx)
+	if err != nil {
+		fmt.Printf("Error listing searches: %v\n", err)
+		return database.Search{}, false
+	}
+	if len(searches) == 0 {
+		fmt.Println("No searches found. Create a new search first.")
+		return database.Search{}, false
+	}
+
+	for i, search := range searches {
+		fmt.Printf("%d. %s (%s)\n", i+1, search.Description, search.ArvixUrl)
+	}
+	fmt.Print("\nSelect a search (or b to go back): ")
+	scanner.Scan()
+	choice := strings.TrimSpace(scanner.Text())
+	if choice == "b" {
+		return database.Search{}, false
+	}
+
+	var selectedIndex int
+	_, err = fmt.Sscanf(choice, "%d", &selectedIndex)
+	if err != nil || selectedIndex < 1 || selectedIndex > len(searches) {
+		fmt.Println("Invalid selection.")
+		return database.Search{}, false
+	}
+	return searches[selectedIndex-1], true
+}
+
 // defaultIfNullInt64 returns the default value if the SQL nullable value is not valid
 func defaultIfNullInt64(value sql.NullInt64, defaultValue int64) int64 {
 	if value.Valid {
@@ -293,7 +641,7 @@ func defaultIfNullInt64(value sql.NullInt64, defaultValue int64) int64 {
 }
 
 // deleteSearch handles the deletion of a search and its related records
-func deleteSearch(ctx context.Context, queries *database.Queries, searchID interface{}, description string, scanner *bufio.Scanner) {
+func deleteSearch(ctx context.Context, db *sql.DB, searchID interface{}, description string, scanner *bufio.Scanner) {
 	fmt.Printf("[DEBUG] Starting deleteSearch function for ID: %v\n", searchID)
 	
 	// Show warning and confirmation
@@ -309,43 +657,31 @@ func deleteSearch(ctx context.Context, queries *database.Queries, searchID inter
 		fmt.Println("Deletion cancelled.")
 		return
 	}
+
+	fmt.Print("Also remove this search's candidates that are left with no other links? (y/n): ")
+	scanner.Scan()
+	cleanup := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	removeOrphans := cleanup == "y" || cleanup == "yes"
 	
-	// Note: In a production environment, this should ideally be done in a transaction
-	// to ensure atomicity, but we'll handle the deletes sequentially for simplicity
-	
-	// Step 1: First delete candidate associations (if we had the query)
-	fmt.Println("Removing candidate associations...")
-	// Note: We don't have the specific query yet, so we're just showing the message
-	// In a complete implementation, you would call a query like:
-	// err := queries.DeleteCandidateSearchesBySearchID(ctx, searchID)
-	// if err != nil {
-	//     fmt.Printf("Error removing candidate associations: %v\n", err)
-	//     return
-	// }
-	
-	// Step 2: Delete articles related to this search (if we had the query)
-	fmt.Println("Removing related articles...")
-	// Note: We don't have the specific query yet, so we're just showing the message
-	// In a complete implementation, you would call a query like:
-	// err := queries.DeleteArticlesBySearchID(ctx, searchID)
-	// if err != nil {
-	//     fmt.Printf("Error removing articles: %v\n", err)
-	//     return
-	// }
-	
-	// Step 3: Finally delete the search itself
-	fmt.Println("Removing search record...")
-	err := queries.DeleteSearch(ctx, searchID)
+	// Articles, candidate links and the search itself are removed in one transaction
+	fmt.Println("Removing search and related records...")
+	result, err := database.DeleteSearchCascade(ctx, db, searchID, removeOrphans)
 	if err != nil {
 		fmt.Printf("Error deleting search: %v\n", err)
+		fmt.Println("No changes were made.")
 		return
 	}
 	
+	fmt.Printf("Removed %d articles, %d relevance verdicts, %d candidate article links and %d candidate associations.\n",
+		result.Articles, result.ArticleRelevance, result.CandidateArticles, result.CandidateSearches)
+	if removeOrphans {
+		fmt.Printf("Removed %d orphaned candidates.\n", result.Candidates)
+	}
 	fmt.Println("Search and all related data deleted successfully.")
 }
 
 // createNewSearch handles the creation of a new search
-func createNewSearch(ctx context.Context, queries *database.Queries, scanner *bufio.Scanner) {
+func createNewSearch(ctx context.Context, cfg config.Config, db *sql.DB, queries *database.Queries, client anthropic.Client, ledger *costs.Ledger, scanner *bufio.Scanner) {
 	fmt.Println("[DEBUG] Starting createNewSearch function")
 	// Get search description
 	fmt.Print("Enter search description: ")
@@ -356,18 +692,42 @@ func createNewSearch(ctx context.Context, queries *database.Queries, scanner *bu
 		return
 	}
 
-	// Get arXiv URL
-	fmt.Print("Enter arXiv URL (e.g., http://rss.arxiv.org/rss/cs.LG+cs.PL): ")
+	// Either work out the categories with Claude or take a URL directly
+	fmt.Print("Discuss which arXiv categories to follow with Claude? (y/n): ")
 	scanner.Scan()
-	arxivURL := strings.TrimSpace(scanner.Text())
-	if arxivURL == "" {
-		fmt.Println("arXiv URL cannot be empty.")
-		return
+	var arxivURL string
+	var session *agent.Session
+	if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer == "y" || answer == "yes" {
+		// The conversation is saved as it goes, so it can be resumed if cut short
+		var err error
+		session, err = agent.NewSession(ctx, db, queries, agent.SessionDiscovery, description, nil)
+		if err != nil {
+			fmt.Printf("Error starting conversation: %v\n", err)
+			return
+		}
+		arxivURL = discoverCategories(ctx, client, cfg.AgentOptions(), ledger, session, nil, description, scanner)
+		if arxivURL == "" {
+			return
+		}
+	} else {
+		fmt.Print("Enter arXiv URL (e.g., http://rss.arxiv.org/rss/cs.LG+cs.PL): ")
+		scanner.Scan()
+		arxivURL = strings.TrimSpace(scanner.Text())
+		if arxivURL == "" {
+			fmt.Println("arXiv URL cannot be empty.")
+			return
+		}
 	}
 
+	saveNewSearch(ctx, cfg, queries, ledger, description, arxivURL, session)
+}
+
+// saveNewSearch stores a new search and ties the category-discovery session
+// that chose its feed, if there was one, and the calls it made to it
+func saveNewSearch(ctx context.Context, cfg config.Config, queries *database.Queries, ledger *costs.Ledger, description, arxivURL string, session *agent.Session) {
 	// Set up search parameters
 	now := time.Now()
-	defaultResultsPerFetch := sql.NullInt64{Int64: 50, Valid: true}
+	defaultResultsPerFetch := sql.NullInt64{Int64: cfg.Fetch.ResultsPerFetch, Valid: true}
 
 	// Create search record
 	fmt.Println("[DEBUG] Creating search parameters")
@@ -391,4 +751,235 @@ func createNewSearch(ctx context.Context, queries *database.Queries, scanner *bu
 	}
 
 	fmt.Printf("Search created successfully with ID: %v\n", search.ID)
+	if session != nil {
+		if err := session.SetSearch(ctx, search.ID); err != nil {
+			fmt.Printf("Error saving the conversation with the search: %v\n", err)
+		}
+		if err := ledger.AttachSession(ctx, session.ID, search.ID); err != nil {
+			fmt.Printf("Error recording the conversation's costs against the search: %v\n", err)
+		}
+	}
+}
+
+// discoverCategories runs a category-discovery conversation with Claude, saved
+// to session and continuing from history if resumed, and returns the confirmed
+// feed URL, or an empty string if the user backs out
+func discoverCategories(ctx context.Context, client anthropic.Client, opts agent.Options, ledger *costs.Ledger, session *agent.Session, history []anthropic.MessageParam, description string, scanner *bufio.Scanner) string {
+	fmt.Println("[DEBUG] Starting category discovery conversation")
+	fmt.Println("Chat with Claude to choose categories (type 'cancel' to stop).")
+
+	getUserMessage := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "cancel" {
+			return "", false
+		}
+		return text, true
+	}
+
+	discoveryAgent, proposal := agent.NewCategoryDiscoveryAgent(client, getUserMessage, description, opts)
+	discoveryAgent.SetSession(session, history)
+	// The search does not exist yet; saveNewSearch attaches these calls to it
+	target := costs.Target{Purpose: costs.PurposeCategoryDiscovery}
+	if session != nil {
+		target.SessionID = session.ID
+	}
+	discoveryAgent.SetUsageRecorder(ledger.For(target))
+
+	// Render the conversation until the agent is closed
+	console := agent.NewConsoleClient(os.Stdout)
+	console.Replay(history)
+	rendered := make(chan struct{})
+	go func() {
+		console.Run(discoveryAgent)
+		close(rendered)
+	}()
+
+	err := discoveryAgent.Run(ctx)
+	discoveryAgent.Close()
+	<-rendered
+	if err != nil {
+		fmt.Printf("Error during category discovery: %v\n", err)
+		return ""
+	}
+	if len(proposal.Categories) == 0 {
+		fmt.Println("No categories were chosen. Search not created.")
+		fmt.Printf("The conversation was saved; continue it with: reSearch sessions resume %v\n", session.ID)
+		return ""
+	}
+
+	fmt.Println("\n=== Proposed Categories ===")
+	for _, category := range proposal.Categories {
+		fmt.Printf("  %-16s %s\n", category, fetcher.Taxonomy[category])
+	}
+	fmt.Printf("Rationale: %s\n", proposal.Rationale)
+	fmt.Printf("Feed URL:  %s\n", proposal.FeedURL)
+
+	fmt.Print("Create the search with this feed? (y/n): ")
+	scanner.Scan()
+	confirm := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	if confirm != "y" && confirm != "yes" {
+		fmt.Println("Search not created.")
+		return ""
+	}
+	return proposal.FeedURL
+}
+
+// runAssistant chats with a research assistant that can browse and annotate
+// the database, and read and write files inside the configured workspace. The
+// conversation is saved to session, continuing from history if resumed.
+func runAssistant(ctx context.Context, cfg config.Config, queries *database.Queries, fullText *fulltext.Index, candidateLinker *candidates.Linker, client anthropic.Client, ledger *costs.Ledger, session *agent.Session, history []anthropic.MessageParam, scanner *bufio.Scanner) error {
+	fmt.Println("[DEBUG] Starting research assistant")
+
+	tools := agent.NewResearchTools(ctx, queries, fullText, candidateLinker, cfg.Anthropic.Model)
+	if cfg.WorkspaceRoot != "" {
+		workspace, err := agent.NewWorkspace(cfg.WorkspaceRoot)
+		if err != nil {
+			return fmt.Errorf("opening workspace: %w", err)
+		}
+		tools = append(tools, workspace.Tools()...)
+		fmt.Printf("File tools are limited to %s\n", workspace.Root())
+	}
+	fmt.Println("Chat with the assistant (type 'exit' to return to the menu).")
+
+	getUserMessage := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "exit" {
+			return "", false
+		}
+		return text, true
+	}
+
+	assistant := agent.NewResearchAssistant(client, getUserMessage, tools, cfg.AgentOptions())
+	assistant.SetSession(session, history)
+	assistant.SetUsageRecorder(ledger.For(costs.Target{Purpose: costs.PurposeAssistant}))
+
+	// Render the conversation until the agent is closed
+	console := agent.NewConsoleClient(os.Stdout)
+	console.Replay(history)
+	rendered := make(chan struct{})
+	go func() {
+		console.Run(assistant)
+		close(rendered)
+	}()
+
+	err := assistant.Run(ctx)
+	assistant.Close()
+	<-rendered
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Conversation saved; continue it with: reSearch sessions resume %v\n", session.ID)
+	return nil
+}
+
+// assistantSessionChoices is how many recent conversations the menu offers to resume
+const assistantSessionChoices = 5
+
+// chooseAssistantSession offers to resume one of the most recent research
+// assistant conversations, and otherwise starts a new one
+func chooseAssistantSession(ctx context.Context, db *sql.DB, queries *database.Queries, scanner *bufio.Scanner) (*agent.Session, []anthropic.MessageParam, bool) {
+	recent, err := queries.ListAgentSessions(ctx, database.ListAgentSessionsParams{
+		Kind:  agent.SessionAssistant,
+		Limit: assistantSessionChoices,
+	})
+	if err != nil {
+		fmt.Printf("Error listing conversations: %v\n", err)
+		return nil, nil, false
+	}
+
+	if len(recent) > 0 {
+		fmt.Println("Recent conversations:")
+		for i, row := range recent {
+			title := row.Title
+			if title == "" {
+				title = "(no messages yet)"
+			}
+			fmt.Printf("%d. %s  [%s, %d messages]\n", i+1, title, row.UpdatedAt.Local().Format("2006-01-02 15:04"), row.MessageCount)
+		}
+		fmt.Print("Resume a conversation (number), or press Enter to start a new one: ")
+		scanner.Scan()
+		if choice := strings.TrimSpace(scanner.Text()); choice != "" {
+			var index int
+			if _, err := fmt.Sscanf(choice, "%d", &index); err != nil || index < 1 || index > len(recent) {
+				fmt.Println("Invalid selection.")
+				return nil, nil, false
+			}
+			row, err := queries.GetAgentSession(ctx, recent[index-1].ID)
+			if err != nil {
+				fmt.Printf("Error loading conversation: %v\n", err)
+				return nil, nil, false
+			}
+			session, history, err := agent.ResumeSession(ctx, db, queries, row)
+			if err != nil {
+				fmt.Printf("Error loading conversation: %v\n", err)
+				return nil, nil, false
+			}
+			return session, history, true
+		}
+	}
+
+	session, err := agent.NewSession(ctx, db, queries, agent.SessionAssistant, "", nil)
+	if err != nil {
+		fmt.Printf("Error starting conversation: %v\n", err)
+		return nil, nil, false
+	}
+	return session, nil, true
+}
+
+// runMigrateCommand handles "migrate up", "migrate down" and "migrate status"
+func runMigrateCommand(ctx context.Context, migrator *migrate.Migrator, args []string) {
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied migration %s\n", migration.Name)
+		}
+		if err != nil {
+			fmt.Printf("Error applying migrations: %v\n", err)
+			os.Exit(1)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations.")
+		}
+	case "down":
+		migration, ok, err := migrator.Down(ctx)
+		if err != nil {
+			fmt.Printf("Error rolling back migration: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			fmt.Println("No migrations to roll back.")
+			return
+		}
+		fmt.Printf("Rolled back migration %s\n", migration.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Printf("Error reading migration status: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("    Applied At                  Migration")
+		fmt.Println("    =======================================")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("Mon Jan _2 15:04:05 2006")
+			}
+			fmt.Printf("    %-24s -- %s\n", appliedAt, status.Migration.Name)
+		}
+	default:
+		fmt.Printf("Unknown migrate command %q (expected up, down or status)\n", command)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/similar"
+)
+
+// reviewPageSize is the number of candidates shown per page of the review screen
+const reviewPageSize = int64(10)
+
+// reviewAction is a one-key review decision, made as a move to its stage
+type reviewAction struct {
+	key   string
+	label string
+	stage string
+}
+
+// reviewActions are offered whenever the pipeline allows their move from the
+// candidate's current stage
+var reviewActions = []reviewAction{
+	{"a", "Approve", candidates.StatusApproved},
+	{"s", "Shortlist", candidates.StatusShortlisted},
+	{"r", "Reject", candidates.StatusRejected},
+	{"u", "Undo decision (back to new)", candidates.StatusNew},
+}
+
+// reviewActionFor returns the review decision that moves a candidate to stage
+func reviewActionFor(stage string) (reviewAction, bool) {
+	for _, action := range reviewActions {
+		if action.stage == stage {
+			return action, true
+		}
+	}
+	return reviewAction{}, false
+}
+
+// reviewCandidates pages through a search's candidates, most relevant first,
+// and opens any of them to move them along the pipeline
+func reviewCandidates(ctx context.Context, queries *database.Queries, pipeline *candidates.Pipeline, index *similar.Index, user string, search database.Search, scanner *bufio.Scanner) {
+	offset := int64(0)
+
+	for {
+		total, err := queries.CountCandidatesBySearch(ctx, search.ID)
+		if err != nil {
+			fmt.Printf("Error counting candidates: %v\n", err)
+			return
+		}
+		if total == 0 {
+			fmt.Println("No candidates yet. Score the search's articles and extract candidates first.")
+			pressEnterToContinue(scanner)
+			return
+		}
+		// A move never removes a row, but the last page can empty if rows were deleted elsewhere
+		if offset >= total {
+			offset = (total - 1) / reviewPageSize * reviewPageSize
+		}
+
+		rows, err := queries.ListCandidatesBySearch(ctx, database.ListCandidatesBySearchParams{
+			SearchID: search.ID,
+			Limit:    reviewPageSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			fmt.Printf("Error listing candidates: %v\n", err)
+			return
+		}
+
+		fmt.Printf("\n=== Review Candidates: %s ===\n", search.Description)
+		fmt.Printf("Page %d of %d (%d candidates)\n", offset/reviewPageSize+1, (total+reviewPageSize-1)/reviewPageSize, total)
+		fmt.Println("+--------+--------------------------------+-----------+-------------+")
+		fmt.Println("| NUMBER | NAME                           | RELEVANCE | STAGE       |")
+		fmt.Println("+--------+--------------------------------+-----------+-------------+")
+		for i, row := range rows {
+			relevance := "-"
+			if row.RelevanceScore.Valid {
+				relevance = fmt.Sprintf("%.2f", row.RelevanceScore.Float64)
+			}
+			fmt.Printf("| %-6d | %s | %-9s | %-11s |\n", i+1, fitColumn(row.Name, 30), relevance, row.Status)
+		}
+		fmt.Println("+--------+--------------------------------+-----------+-------------+")
+
+		fmt.Println("\nOptions:")
+		fmt.Println("  [number] - Open candidate")
+		if offset > 0 {
+			fmt.Println("  p - Previous page")
+		}
+		if offset+reviewPageSize < total {
+			fmt.Println("  n - Next page")
+		}
+		fmt.Println("  b - Back to search details")
+
+		fmt.Print("\nEnter choice: ")
+		scanner.Scan()
+		choice := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case choice == "b":
+			return
+		case choice == "n" && offset+reviewPageSize < total:
+			offset += reviewPageSize
+		case choice == "p" && offset > 0:
+			offset -= reviewPageSize
+		default:
+			var selectedIndex int
+			_, err := fmt.Sscanf(choice, "%d", &selectedIndex)
+			if err != nil || selectedIndex < 1 || selectedIndex > len(rows) {
+				fmt.Println("Invalid selection. Please enter a candidate number, 'n' (next), 'p' (previous), or 'b' (back).")
+				time.Sleep(1 * time.Second) // Brief pause to let user see the message
+				continue
+			}
+			reviewCandidate(ctx, queries, pipeline, index, user, search, rows[selectedIndex-1], scanner)
+		}
+	}
+}
+
+// reviewCandidate shows a candidate's profile, discovering papers and
+// pipeline history, and moves them to another stage or edits their notes
+func reviewCandidate(ctx context.Context, queries *database.Queries, pipeline *candidates.Pipeline, index *similar.Index, user string, search database.Search, candidate database.ListCandidatesBySearchRow, scanner *bufio.Scanner) {
+	for {
+		// Re-read the link so the screen reflects the latest stage and notes
+		link, err := queries.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{
+			CandidateID: candidate.ID,
+			SearchID:    search.ID,
+		})
+		if err != nil {
+			fmt.Printf("Error retrieving candidate: %v\n", err)
+			return
+		}
+		articles, err := queries.GetCandidateDiscoveryArticles(ctx, candidate.ID)
+		if err != nil {
+			fmt.Printf("Error retrieving papers: %v\n", err)
+			return
+		}
+		history, err := queries.ListCandidateStatusChanges(ctx, database.ListCandidateStatusChangesParams{
+			CandidateID: candidate.ID,
+			SearchID:    search.ID,
+		})
+		if err != nil {
+			fmt.Printf("Error retrieving history: %v\n", err)
+			return
+		}
+
+		fmt.Printf("\n=== Candidate: %s ===\n", candidate.Name)
+		fmt.Printf("Stage:      %s\n", link.Status)
+		relevance := "Not scored"
+		if link.RelevanceScore.Valid {
+			relevance = fmt.Sprintf("%.2f", link.RelevanceScore.Float64)
+		}
+		fmt.Printf("Relevance:  %s\n", relevance)
+		fmt.Printf("GitHub:     %s\n", valueOr(candidate.GithubUrl, "-"))
+		fmt.Printf("LinkedIn:   %s\n", valueOr(candidate.LinkedinUrl, "-"))
+		fmt.Printf("Notes:      %s\n", valueOr(link.Notes, "-"))
+
+		fmt.Println("\nDiscovering papers:")
+		if len(articles) == 0 {
+			fmt.Println("  (none)")
+		}
+		for i, article := range articles {
+			fmt.Printf("  %d. %s\n", i+1, article.ArticleTitle)
+			fmt.Printf("     %s\n", article.ArticleUrl)
+			fmt.Printf("     Found by \"%s\" on %s\n", article.SearchDescription, article.DiscoveryDate.Format("2006-01-02"))
+		}
+
+		fmt.Println("\nHistory:")
+		if len(history) == 0 {
+			fmt.Println("  (no changes yet)")
+		}
+		for _, change := range history {
+			fmt.Printf("  %s  %s -> %s by %s", change.CreatedAt.Format("2006-01-02 15:04"), change.FromStatus, change.ToStatus, change.ChangedBy)
+			if change.Note.Valid {
+				fmt.Printf(" (%s)", change.Note.String)
+			}
+			fmt.Println()
+		}
+
+		// The review decisions keep their one-key shortcuts; any other allowed
+		// move is offered by number
+		var actions []reviewAction
+		var next []string
+		for _, stage := range candidates.NextStages(link.Status) {
+			if action, ok := reviewActionFor(stage); ok {
+				actions = append(actions, action)
+			} else {
+				next = append(next, stage)
+			}
+		}
+		fmt.Println("\nOptions:")
+		for _, action := range actions {
+			fmt.Printf("  %s - %s\n", action.key, action.label)
+		}
+		for i, stage := range next {
+			fmt.Printf("  %d - Move to %s\n", i+1, stage)
+		}
+		fmt.Println("  e - Edit notes")
+		if len(articles) > 0 {
+			fmt.Println("  m - More like this: similar papers, to find more people like them")
+		}
+		fmt.Println("  b - Back to candidate list")
+
+		fmt.Print("\nEnter choice: ")
+		scanner.Scan()
+		choice := strings.TrimSpace(scanner.Text())
+
+		stage := ""
+		for _, action := range actions {
+			if choice == action.key {
+				stage = action.stage
+			}
+		}
+		switch choice {
+		case "e":
+			editCandidateNotes(ctx, queries, link, scanner)
+			continue
+		case "m":
+			if len(articles) > 0 {
+				showSimilarPapers(ctx, queries, index, articles, scanner)
+				continue
+			}
+		case "b":
+			return
+		}
+
+		if stage == "" {
+			var selectedIndex int
+			_, err = fmt.Sscanf(choice, "%d", &selectedIndex)
+			if err != nil || selectedIndex < 1 || selectedIndex > len(next) {
+				fmt.Println("Invalid selection. Please choose from the options listed.")
+				time.Sleep(1 * time.Second) // Brief pause
+				continue
+			}
+			stage = next[selectedIndex-1]
+		}
+
+		fmt.Print("Note for this change (optional): ")
+		scanner.Scan()
+		note := strings.TrimSpace(scanner.Text())
+
+		if _, err := pipeline.Move(ctx, candidate.ID, search.ID, stage, user, note); err != nil {
+			fmt.Printf("Error moving candidate: %v\n", err)
+			pressEnterToContinue(scanner)
+			continue
+		}
+		fmt.Printf("%s moved to %s.\n", candidate.Name, stage)
+		// A move finishes the candidate for now, so go straight back to the list
+		return
+	}
+}
+
+// editCandidateNotes replaces the notes on a candidate's link to a search
+func editCandidateNotes(ctx context.Context, queries *database.Queries, link database.CandidateSearch, scanner *bufio.Scanner) {
+	fmt.Println("Enter new notes (leave blank to keep the current notes, '-' to clear them):")
+	scanner.Scan()
+	text := strings.TrimSpace(scanner.Text())
+	if text == "" {
+		return
+	}
+
+	notes := sql.NullString{String: text, Valid: true}
+	if text == "-" {
+		notes = sql.NullString{}
+	}
+	_, err := queries.UpdateCandidateNotes(ctx, database.UpdateCandidateNotesParams{
+		UpdatedAt:   time.Now(),
+		Notes:       notes,
+		CandidateID: link.CandidateID,
+		SearchID:    link.SearchID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("The candidate is no longer linked to this search.")
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error saving notes: %v\n", err)
+		return
+	}
+	fmt.Println("Notes saved.")
+}
+
+// showSimilarPapers lists the stored papers most like the candidate's, whose
+// authors are likely to work on the same things
+func showSimilarPapers(ctx context.Context, queries *database.Queries, index *similar.Index, articles []database.GetCandidateDiscoveryArticlesRow, scanner *bufio.Scanner) {
+	model, err := index.Load(ctx)
+	if err != nil {
+		fmt.Printf("Error loading the similarity index: %v\n", err)
+		return
+	}
+	ids := make([]string, len(articles))
+	for i, article := range articles {
+		ids[i] = fmt.Sprint(article.ID)
+	}
+
+	matches := model.SimilarToArticles(ids, similar.Options{})
+	fmt.Println("\nSimilar papers:")
+	if len(matches) == 0 {
+		fmt.Println("  (none)")
+	}
+	for i, match := range matches {
+		article, err := queries.GetArticleByID(ctx, match.ArticleID)
+		if err != nil {
+			fmt.Printf("Error retrieving article: %v\n", err)
+			return
+		}
+		fmt.Printf("  %d. %s (%.2f)\n", i+1, article.ArticleTitle, match.Score)
+		fmt.Printf("     %s\n", article.ArticleUrl)
+		fmt.Printf("     %s\n", article.ArticleAuthors)
+	}
+	pressEnterToContinue(scanner)
+}
+
+// valueOr returns the string, or fallback if it is NULL or empty
+func valueOr(s sql.NullString, fallback string) string {
+	if !s.Valid || s.String == "" {
+		return fallback
+	}
+	return s.String
+}
+
+// fitColumn truncates s to width characters, ending it with "..." if it was
+// cut, and pads it to that width. Unlike %-*s it counts characters rather than
+// bytes, so accented names neither split mid-character nor misalign the table.
+func fitColumn(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		s = string([]rune(s)[:width-3]) + "..."
+		n = width
+	}
+	return s + strings.Repeat(" ", width-n)
+}
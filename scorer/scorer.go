@@ -0,0 +1,188 @@
+package scorer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/google/uuid"
+	"github.com/invopop/jsonschema"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// Verdict is the structured relevance judgement Claude returns for an article
+type Verdict struct {
+	Score      float64  `json:"score" jsonschema_description:"Relevance from 0.0 (unrelated) to 1.0 (exactly the kind of work the search describes)"`
+	Rationale  string   `json:"rationale" jsonschema_description:"One or two sentences explaining the score"`
+	KeyAuthors []string `json:"key_authors" jsonschema_description:"Authors of this paper worth following up on as candidates, copied exactly as written in the author list. Empty if the paper is not relevant."`
+}
+
+// Result summarises a scoring run over a search's articles
+type Result struct {
+	Scored  int
+	Failed  int
+	Results []ScoredArticle
+}
+
+// ScoredArticle pairs an article with the verdict recorded for it
+type ScoredArticle struct {
+	Article database.Article
+	Verdict Verdict
+	Err     error
+}
+
+// Scorer asks Claude to judge how relevant fetched articles are to their search.
+// The Anthropic client is passed in, so it can be pointed at a local fake with
+// option.WithBaseURL or option.WithHTTPClient.
+type Scorer struct {
+	queries *database.Queries
+	client  anthropic.Client
+	options agent.Options
+	ledger  *costs.Ledger
+}
+
+// NewScorer creates a new relevance scorer whose agents use opts and record
+// their calls in ledger
+func NewScorer(queries *database.Queries, client anthropic.Client, opts agent.Options, ledger *costs.Ledger) *Scorer {
+	return &Scorer{queries: queries, client: client, options: opts, ledger: ledger}
+}
+
+// ScoreArticle judges a single article against the search description and
+// persists the verdict
+func (s *Scorer) ScoreArticle(ctx context.Context, search database.Search, article database.Article) (Verdict, error) {
+	a := agent.NewAgent(s.client, nil, systemPrompt(search.Description), nil, s.options)
+	a.SetWebSearchEnabled(false)
+	a.SetUsageRecorder(s.ledger.For(costs.Target{Purpose: costs.PurposeScoring, SearchID: search.ID, ArticleID: article.ID}))
+	// Scoring is non-interactive, so the streamed output is discarded
+	go agent.NewConsoleClient(io.Discard).Run(a)
+	defer a.Close()
+
+	message, err := a.Complete(ctx, articlePrompt(article))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("scoring article %v: %w", article.ID, err)
+	}
+
+	verdict, err := ParseVerdict(messageText(message))
+	if err != nil {
+		return Verdict{}, fmt.Errorf("scoring article %v: %w", article.ID, err)
+	}
+
+	keyAuthors, err := json.Marshal(verdict.KeyAuthors)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	now := time.Now()
+	_, err = s.queries.UpsertArticleRelevance(ctx, database.UpsertArticleRelevanceParams{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ArticleID:  article.ID,
+		SearchID:   search.ID,
+		Score:      verdict.Score,
+		Rationale:  verdict.Rationale,
+		KeyAuthors: string(keyAuthors),
+		Model:      string(message.Model),
+	})
+	if err != nil {
+		return Verdict{}, fmt.Errorf("saving verdict for article %v: %w", article.ID, err)
+	}
+	return verdict, nil
+}
+
+// ScoreSearch scores up to limit articles of the search that have no verdict yet.
+// A failure on one article is recorded in the result and does not stop the run,
+// but the run stops with an error wrapping costs.ErrBudgetExceeded once the
+// search has spent its monthly budget.
+func (s *Scorer) ScoreSearch(ctx context.Context, search database.Search, limit int64) (*Result, error) {
+	articles, err := s.queries.ListUnscoredArticlesBySearch(ctx, database.ListUnscoredArticlesBySearchParams{
+		SearchID: search.ID,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing unscored articles: %w", err)
+	}
+
+	result := &Result{}
+	for _, article := range articles {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if err := s.ledger.CheckBudget(ctx, search.ID); err != nil {
+			return result, err
+		}
+
+		verdict, err := s.ScoreArticle(ctx, search, article)
+		if errors.Is(err, costs.ErrBudgetExceeded) {
+			return result, err
+		}
+		if err != nil {
+			result.Failed++
+		} else {
+			result.Scored++
+		}
+		result.Results = append(result.Results, ScoredArticle{Article: article, Verdict: verdict, Err: err})
+	}
+	return result, nil
+}
+
+// ParseVerdict extracts the JSON verdict from Claude's reply, tolerating any
+// prose or code fences around the object
+func ParseVerdict(text string) (Verdict, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return Verdict{}, fmt.Errorf("no JSON object in response: %q", text)
+	}
+
+	var verdict Verdict
+	if err := json.Unmarshal([]byte(text[start:end+1]), &verdict); err != nil {
+		return Verdict{}, fmt.Errorf("invalid verdict JSON: %w", err)
+	}
+	if verdict.Score < 0 || verdict.Score > 1 {
+		return Verdict{}, fmt.Errorf("score %v is outside 0.0-1.0", verdict.Score)
+	}
+	return verdict, nil
+}
+
+// systemPrompt instructs Claude to act as a relevance judge for the search
+func systemPrompt(description string) string {
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties: false,
+		DoNotReference:            true,
+	}
+	schema, _ := json.MarshalIndent(reflector.Reflect(Verdict{}), "", "  ")
+
+	return fmt.Sprintf(`You are screening arXiv papers for a research talent search.
+
+The search is looking for: %s
+
+For each paper you are given, judge how relevant it is to the search and reply with ONLY a JSON object matching this schema:
+
+%s`, description, schema)
+}
+
+// articlePrompt formats an article for scoring
+func articlePrompt(article database.Article) string {
+	return fmt.Sprintf("Title: %s\nAuthors: %s\nURL: %s\n\nAbstract:\n%s",
+		article.ArticleTitle, article.ArticleAuthors, article.ArticleUrl, article.ArticleSummary)
+}
+
+// messageText concatenates the text blocks of a message
+func messageText(message *anthropic.Message) string {
+	var sb strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,218 @@
+package scorer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessewalker/reSearch/agent"
+	"github.com/jessewalker/reSearch/costs"
+	"github.com/jessewalker/reSearch/internal/anthropictest"
+	"github.com/jessewalker/reSearch/internal/database"
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Verdict
+		wantErr string
+	}{
+		{
+			name: "bare object",
+			text: `{"score": 0.8, "rationale": "On topic.", "key_authors": ["Alice Smith"]}`,
+			want: Verdict{Score: 0.8, Rationale: "On topic.", KeyAuthors: []string{"Alice Smith"}},
+		},
+		{
+			name: "fenced",
+			text: "```json\n{\"score\": 0.25, \"rationale\": \"Tangential.\", \"key_authors\": []}\n```",
+			want: Verdict{Score: 0.25, Rationale: "Tangential.", KeyAuthors: []string{}},
+		},
+		{
+			name: "surrounded by prose",
+			text: "Here is my verdict:\n{\"score\": 1, \"rationale\": \"Exactly it.\", \"key_authors\": null}\nHope that helps.",
+			want: Verdict{Score: 1, Rationale: "Exactly it."},
+		},
+		{
+			name: "bounds are inclusive",
+			text: `{"score": 0, "rationale": "Unrelated."}`,
+			want: Verdict{Score: 0, Rationale: "Unrelated."},
+		},
+		{name: "score above one", text: `{"score": 1.5, "rationale": "x"}`, wantErr: "outside 0.0-1.0"},
+		{name: "negative score", text: `{"score": -0.1, "rationale": "x"}`, wantErr: "outside 0.0-1.0"},
+		{name: "no JSON", text: "I cannot judge this paper.", wantErr: "no JSON object"},
+		{name: "braces in the wrong order", text: "} then {", wantErr: "no JSON object"},
+		{name: "malformed JSON", text: `{"score": high}`, wantErr: "invalid verdict JSON"},
+		{name: "wrong types", text: `{"score": "0.5"}`, wantErr: "invalid verdict JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVerdict(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseVerdict error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVerdict: %v", err)
+			}
+			if got.Score != tt.want.Score || got.Rationale != tt.want.Rationale ||
+				strings.Join(got.KeyAuthors, "|") != strings.Join(tt.want.KeyAuthors, "|") {
+				t.Errorf("ParseVerdict = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newScorer returns a scorer backed by a fake Messages API that gives the
+// replies in order, with a search and one of its articles to score
+func newScorer(t *testing.T, replies ...anthropictest.Reply) (*Scorer, *database.Queries, *anthropictest.Server, database.Search, database.Article) {
+	t.Helper()
+	_, queries := dbtest.Open(t)
+	server := anthropictest.NewServer(t, replies...)
+	search := dbtest.CreateSearch(t, queries, "efficient transformers for long documents", "http://rss.arxiv.org/rss/cs.LG")
+	article := dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.01234",
+		"Sparse Attention for Long Documents", "We study sparse attention.", "Alice Smith, Bob Jones")
+
+	ledger := costs.NewLedger(queries, costs.Prices{Models: costs.DefaultModelPrices()})
+	return NewScorer(queries, server.Client(), agent.DefaultOptions(), ledger), queries, server, search, article
+}
+
+func TestScoreArticle(t *testing.T) {
+	ctx := context.Background()
+	s, queries, server, search, article := newScorer(t, anthropictest.Reply{
+		Text: "```json\n{\"score\": 0.9, \"rationale\": \"Directly on topic.\", \"key_authors\": [\"Alice Smith\"]}\n```",
+	})
+
+	verdict, err := s.ScoreArticle(ctx, search, article)
+	if err != nil {
+		t.Fatalf("ScoreArticle: %v", err)
+	}
+	if verdict.Score != 0.9 || len(verdict.KeyAuthors) != 1 || verdict.KeyAuthors[0] != "Alice Smith" {
+		t.Errorf("verdict = %+v", verdict)
+	}
+
+	stored, err := queries.GetArticleRelevance(ctx, article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleRelevance: %v", err)
+	}
+	if stored.Score != 0.9 || stored.Rationale != "Directly on topic." || stored.KeyAuthors != `["Alice Smith"]` || stored.Model != anthropictest.Model {
+		t.Errorf("stored verdict = %+v", stored)
+	}
+
+	// The request carries the search description and the article, and
+	// scoring never searches the web
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(requests))
+	}
+	body := string(requests[0])
+	for _, want := range []string{search.Description, article.ArticleTitle, article.ArticleSummary} {
+		if !strings.Contains(body, want) {
+			t.Errorf("request does not mention %q", want)
+		}
+	}
+	if strings.Contains(body, "web_search") {
+		t.Error("scoring request offers web search")
+	}
+
+	// The call is recorded against the search and article
+	calls, err := queries.ListLLMCalls(ctx, database.ListLLMCallsParams{SearchID: search.ID})
+	if err != nil {
+		t.Fatalf("ListLLMCalls: %v", err)
+	}
+	if len(calls) != 1 || calls[0].Purpose != costs.PurposeScoring || calls[0].OutputTokens != 20 {
+		t.Errorf("recorded calls = %+v", calls)
+	}
+}
+
+func TestScoreArticleRejectsBadVerdicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		{"out of range", `{"score": 7, "rationale": "Very relevant.", "key_authors": []}`, "outside 0.0-1.0"},
+		{"no JSON", "This paper looks interesting.", "no JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, queries, _, search, article := newScorer(t, anthropictest.Reply{Text: tt.reply})
+
+			_, err := s.ScoreArticle(ctx, search, article)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ScoreArticle error = %v, want one containing %q", err, tt.want)
+			}
+			if _, err := queries.GetArticleRelevance(ctx, article.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("a verdict was stored for a bad reply (lookup error %v)", err)
+			}
+		})
+	}
+}
+
+func TestScoreSearch(t *testing.T) {
+	ctx := context.Background()
+	s, queries, _, search, _ := newScorer(t,
+		anthropictest.Reply{Text: `{"score": 0.6, "rationale": "Related.", "key_authors": []}`},
+		anthropictest.Reply{Text: "no verdict"},
+	)
+	dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.05678", "Second", "Another.", "Carol White")
+
+	result, err := s.ScoreSearch(ctx, search, 10)
+	if err != nil {
+		t.Fatalf("ScoreSearch: %v", err)
+	}
+	if result.Scored != 1 || result.Failed != 1 {
+		t.Errorf("scored %d, failed %d; want 1, 1", result.Scored, result.Failed)
+	}
+
+	// Only the failed article is left to score
+	unscored, err := queries.ListUnscoredArticlesBySearch(ctx, database.ListUnscoredArticlesBySearchParams{SearchID: search.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUnscoredArticlesBySearch: %v", err)
+	}
+	if len(unscored) != 1 {
+		t.Errorf("%d articles left unscored, want 1", len(unscored))
+	}
+}
+
+// Scoring stops once the search has spent its monthly budget, leaving the
+// rest of its articles unscored
+func TestScoreSearchStopsAtBudget(t *testing.T) {
+	ctx := context.Background()
+	_, queries := dbtest.Open(t)
+	server := anthropictest.NewServer(t, anthropictest.Reply{Text: `{"score": 0.6, "rationale": "Related.", "key_authors": []}`})
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	for _, url := range []string{"https://arxiv.org/abs/1", "https://arxiv.org/abs/2", "https://arxiv.org/abs/3"} {
+		dbtest.CreateArticle(t, queries, search.ID, url, "Title", "Abstract.", "Carol White")
+	}
+	search, err := queries.SetSearchMonthlyBudget(ctx, database.SetSearchMonthlyBudgetParams{
+		UpdatedAt:     time.Now(),
+		MonthlyBudget: sql.NullFloat64{Float64: 1, Valid: true},
+		ID:            search.ID,
+	})
+	if err != nil {
+		t.Fatalf("SetSearchMonthlyBudget: %v", err)
+	}
+	// Each reply's 20 output tokens cost $1
+	ledger := costs.NewLedger(queries, costs.Prices{Models: map[string]costs.Price{anthropictest.Model: {Output: 50000}}})
+	s := NewScorer(queries, server.Client(), agent.DefaultOptions(), ledger)
+
+	result, err := s.ScoreSearch(ctx, search, 10)
+	if !errors.Is(err, costs.ErrBudgetExceeded) {
+		t.Fatalf("ScoreSearch = %v, want ErrBudgetExceeded", err)
+	}
+	if result.Scored != 1 || result.Failed != 0 {
+		t.Errorf("scored %d, failed %d before the budget ran out; want 1, 0", result.Scored, result.Failed)
+	}
+	if requests := server.Requests(); len(requests) != 1 {
+		t.Errorf("sent %d requests, want 1", len(requests))
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jessewalker/reSearch/fulltext"
+)
+
+// articleSearchPageSize is the number of matches shown by the menu's article search
+const articleSearchPageSize = int64(10)
+
+// searchArticlesScreen asks for full-text queries and shows the best matching
+// articles across every search until an empty query is entered
+func searchArticlesScreen(ctx context.Context, fullText *fulltext.Index, scanner *bufio.Scanner) {
+	fmt.Println(`Words must all appear; use "quotes" for phrases, OR and NOT to combine, and a trailing * for prefixes.`)
+	for {
+		fmt.Print("\nSearch articles (or Enter to go back): ")
+		scanner.Scan()
+		query := strings.TrimSpace(scanner.Text())
+		if query == "" {
+			return
+		}
+
+		markStart, markEnd := highlightMarks()
+		rows, err := findArticles(ctx, fullText, fulltext.ArticleQuery{
+			Query:     query,
+			MarkStart: markStart,
+			MarkEnd:   markEnd,
+			Limit:     articleSearchPageSize,
+		})
+		if err != nil {
+			fmt.Printf("Error searching articles: %v\n", err)
+			continue
+		}
+		if len(rows) == 0 {
+			fmt.Println("No matching articles.")
+			continue
+		}
+		printArticleMatches(rows)
+	}
+}
+
+// findArticles runs an FTS5 query. Text that is not valid query syntax, such
+// as "self-supervised" or "BERT: pre-training", is retried as plain words.
+func findArticles(ctx context.Context, fullText *fulltext.Index, params fulltext.ArticleQuery) ([]fulltext.ArticleMatch, error) {
+	rows, err := fullText.Articles(ctx, params)
+	if err == nil || !isQuerySyntaxError(err) {
+		return rows, err
+	}
+	if params.Query = fulltext.TermsQuery(params.Query, false); params.Query == "" {
+		return nil, fmt.Errorf("the query has no words to search for")
+	}
+	return fullText.Articles(ctx, params)
+}
+
+// isQuerySyntaxError reports whether FTS5 rejected the query itself. A word
+// followed by a colon is read as a column filter, hence "no such column".
+func isQuerySyntaxError(err error) bool {
+	msg := err.Error()
+	for _, reason := range []string{"fts5: syntax error", "unterminated string", "no such column"} {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// printArticleMatches lists matches best first, each with its snippet
+func printArticleMatches(rows []fulltext.ArticleMatch) {
+	for i, row := range rows {
+		fmt.Printf("\n%d. %s\n", i+1, row.ArticleTitle)
+		fmt.Printf("   %s  fetched %s\n", row.ArticleUrl, row.FetchedAt.Format("2006-01-02"))
+		fmt.Printf("   %s\n", strings.Join(strings.Fields(row.Snippet), " "))
+	}
+}
+
+// highlightMarks returns the markers put around matched terms: bold on a
+// terminal, Markdown-style asterisks when the output is piped
+func highlightMarks() (string, string) {
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return "\x1b[1m", "\x1b[0m"
+	}
+	return "**", "**"
+}
@@ -0,0 +1,260 @@
+// Package similar finds "more like this" articles among everything already
+// fetched. Articles are compared by the cosine similarity of TF-IDF vectors
+// over their title and summary, worked out locally from term counts kept in
+// SQLite, so no GPU or external service is involved.
+package similar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// DefaultLimit is the number of similar articles returned when none is given
+const DefaultLimit = 10
+
+// Options narrows a similarity query. Zero values match everything.
+type Options struct {
+	// Limit is the most matches returned, DefaultLimit when zero
+	Limit int
+	// SearchID keeps only articles of this search
+	SearchID string
+}
+
+// Match is an article similar to the query
+type Match struct {
+	ArticleID string
+	SearchID  string
+	URL       string
+	// Score is the cosine similarity, from 0 (nothing in common) to 1
+	Score float64
+}
+
+// noTerms is stored, with a count of zero, for an article whose title and
+// summary yield no terms at all, so it is marked as indexed rather than
+// tokenized again on every load. Real terms are never empty.
+const noTerms = ""
+
+// Index keeps the term counts of every article up to date and loads them
+// into a Model for querying
+type Index struct {
+	db      *sql.DB
+	queries *database.Queries
+}
+
+// NewIndex creates a new similarity index
+func NewIndex(db *sql.DB, queries *database.Queries) *Index {
+	return &Index{db: db, queries: queries}
+}
+
+// Update counts the terms of every article not yet indexed, in a single
+// transaction, and returns how many articles were indexed
+func (ix *Index) Update(ctx context.Context) (int, error) {
+	articles, err := ix.queries.ListUnindexedArticles(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("listing unindexed articles: %w", err)
+	}
+	if len(articles) == 0 {
+		return 0, nil
+	}
+
+	tx, err := ix.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	q := ix.queries.WithTx(tx)
+
+	for _, article := range articles {
+		terms := Terms(article.ArticleTitle, article.ArticleSummary)
+		if len(terms) == 0 {
+			terms = map[string]int{noTerms: 0}
+		}
+		for term, count := range terms {
+			err := q.AddArticleTerm(ctx, database.AddArticleTermParams{
+				ArticleID: article.ID,
+				Term:      term,
+				Count:     int64(count),
+			})
+			if err != nil {
+				return 0, fmt.Errorf("indexing %s: %w", article.ArticleUrl, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(articles), nil
+}
+
+// Load indexes any new articles and reads the whole index into memory.
+// Loading once and querying the Model many times avoids re-reading the index.
+func (ix *Index) Load(ctx context.Context) (*Model, error) {
+	if _, err := ix.Update(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := ix.queries.ListArticleTerms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading the similarity index: %w", err)
+	}
+	return newModel(rows), nil
+}
+
+// document is one indexed article
+type document struct {
+	id       string
+	searchID string
+	url      string
+	vector   map[string]float64
+}
+
+// posting is one document's weight for a term
+type posting struct {
+	doc    int
+	weight float64
+}
+
+// Model is the similarity index held in memory. Its TF-IDF weights use the
+// document frequencies at the time it was loaded.
+type Model struct {
+	docs     []document
+	byID     map[string]int
+	postings map[string][]posting
+	// docFreq counts the documents each term appears in
+	docFreq map[string]int
+}
+
+// newModel weighs the term counts, which must be grouped by article
+func newModel(rows []database.ListArticleTermsRow) *Model {
+	m := &Model{byID: map[string]int{}, postings: map[string][]posting{}, docFreq: map[string]int{}}
+
+	counts := []map[string]int{}
+	for _, row := range rows {
+		id := fmt.Sprint(row.ArticleID)
+		i, ok := m.byID[id]
+		if !ok {
+			i = len(m.docs)
+			m.byID[id] = i
+			m.docs = append(m.docs, document{id: id, searchID: fmt.Sprint(row.SearchID), url: row.ArticleUrl})
+			counts = append(counts, map[string]int{})
+		}
+		counts[i][row.Term] = int(row.Count)
+		m.docFreq[row.Term]++
+	}
+
+	for i := range m.docs {
+		m.docs[i].vector = m.weigh(counts[i])
+		for term, weight := range m.docs[i].vector {
+			m.postings[term] = append(m.postings[term], posting{doc: i, weight: weight})
+		}
+	}
+	return m
+}
+
+// Size is the number of indexed articles
+func (m *Model) Size() int {
+	return len(m.docs)
+}
+
+// weigh turns term counts into a unit-length TF-IDF vector. Term frequency
+// is dampened logarithmically so a word repeated ten times does not count ten
+// times over, and the smoothed IDF keeps terms found in every article above zero.
+func (m *Model) weigh(counts map[string]int) map[string]float64 {
+	vector := map[string]float64{}
+	n := float64(len(m.docs))
+	for term, count := range counts {
+		if count <= 0 {
+			continue
+		}
+		idf := math.Log((1+n)/(1+float64(m.docFreq[term]))) + 1
+		vector[term] = (1 + math.Log(float64(count))) * idf
+	}
+	return normalize(vector)
+}
+
+// SimilarToArticles returns the articles most similar to the given ones taken
+// together, best first. The given articles, and other copies of the same
+// papers stored under other searches, are left out. Unindexed IDs are ignored.
+func (m *Model) SimilarToArticles(articleIDs []string, opts Options) []Match {
+	centroid := map[string]float64{}
+	exclude := map[string]bool{}
+	for _, id := range articleIDs {
+		i, ok := m.byID[id]
+		if !ok {
+			continue
+		}
+		for term, weight := range m.docs[i].vector {
+			centroid[term] += weight
+		}
+		exclude[m.docs[i].url] = true
+	}
+	return m.rank(normalize(centroid), exclude, opts)
+}
+
+// SimilarToText returns the articles most similar to a free-text
+// description, best first
+func (m *Model) SimilarToText(text string, opts Options) []Match {
+	return m.rank(m.weigh(Terms("", text)), nil, opts)
+}
+
+// rank scores every document sharing a term with the query vector and keeps
+// the best match for each paper
+func (m *Model) rank(query map[string]float64, excludeURLs map[string]bool, opts Options) []Match {
+	scores := map[int]float64{}
+	for term, weight := range query {
+		for _, p := range m.postings[term] {
+			scores[p.doc] += weight * p.weight
+		}
+	}
+
+	best := map[string]Match{}
+	for i, score := range scores {
+		doc := m.docs[i]
+		if excludeURLs[doc.url] || (opts.SearchID != "" && doc.searchID != opts.SearchID) {
+			continue
+		}
+		if current, ok := best[doc.url]; !ok || score > current.Score || (score == current.Score && doc.id < current.ArticleID) {
+			best[doc.url] = Match{ArticleID: doc.id, SearchID: doc.searchID, URL: doc.url, Score: math.Min(score, 1)}
+		}
+	}
+
+	matches := make([]Match, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ArticleID < matches[j].ArticleID
+	})
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// normalize scales the vector to unit length in place and returns it
+func normalize(vector map[string]float64) map[string]float64 {
+	var sum float64
+	for _, weight := range vector {
+		sum += weight * weight
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	for term := range vector {
+		vector[term] /= norm
+	}
+	return vector
+}
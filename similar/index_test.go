@@ -0,0 +1,47 @@
+package similar
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jessewalker/reSearch/internal/dbtest"
+)
+
+// An article with nothing indexable is still marked as indexed, so later
+// loads do not tokenize it again, and it never shows up as a match
+func TestIndexMarksArticlesWithoutTerms(t *testing.T) {
+	ctx := context.Background()
+	db, queries := dbtest.Open(t)
+	search := dbtest.CreateSearch(t, queries, "search", "http://rss.arxiv.org/rss/cs.LG")
+	dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.00001",
+		"Sparse attention networks", "Sparse attention for long documents.", "Alice Smith")
+	dbtest.CreateArticle(t, queries, search.ID, "https://arxiv.org/abs/2401.00002", "On it", "Of the 42.", "Bob Jones")
+	ix := NewIndex(db, queries)
+
+	indexed, err := ix.Update(ctx)
+	if err != nil {
+		t.Fatalf("first Update: %v", err)
+	}
+	if indexed != 2 {
+		t.Errorf("first Update indexed %d articles, want 2", indexed)
+	}
+	indexed, err = ix.Update(ctx)
+	if err != nil {
+		t.Fatalf("second Update: %v", err)
+	}
+	if indexed != 0 {
+		t.Errorf("second Update indexed %d articles, want 0", indexed)
+	}
+
+	model, err := ix.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if model.Size() != 1 {
+		t.Errorf("model holds %d articles, want only the one with terms", model.Size())
+	}
+	matches := model.SimilarToText("sparse attention", Options{})
+	if len(matches) != 1 || matches[0].URL != "https://arxiv.org/abs/2401.00001" {
+		t.Errorf("matches = %+v, want the sparse attention paper", matches)
+	}
+}
@@ -0,0 +1,90 @@
+package similar
+
+import (
+	"strings"
+	"unicode"
+)
+
+// titleWeight is how many times each title word is counted, since a title
+// says more about a paper than any one sentence of its abstract
+const titleWeight = 2
+
+// minTermLength is the shortest word, in characters, that is indexed
+const minTermLength = 3
+
+// stopWords are common English and abstract boilerplate words that say
+// nothing about what a paper is about
+var stopWords = wordSet(`
+		about above after again against all also among and any are around based because been before
+		being below between both but can cannot could did does doing down during each either
+		few for from further had has have having here how however into its itself just
+		more most much must not now off once only other our ours out over own same several
+		she should since some such than that the their them then there these they this those
+		through thus too under until upon very via was were what when where which while who
+		whom why will with within without would yet you your
+		approach approaches paper papers propose proposed proposes present presents show shows
+		shown result results method methods using use used new novel work study studies
+		demonstrate demonstrates well first two three one various different given
+		arxiv abstract announce type`)
+
+// Terms counts the indexable words of an article's title and summary. Words
+// are lowercased, stop words, numbers and words shorter than minTermLength
+// are dropped, and plurals are folded into their singular.
+func Terms(title, summary string) map[string]int {
+	counts := map[string]int{}
+	for _, term := range tokenize(title) {
+		counts[term] += titleWeight
+	}
+	for _, term := range tokenize(summary) {
+		counts[term]++
+	}
+	return counts
+}
+
+// tokenize splits text into normalized terms
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var terms []string
+	for _, word := range words {
+		if len([]rune(word)) < minTermLength || stopWords[word] || isNumber(word) {
+			continue
+		}
+		terms = append(terms, singular(word))
+	}
+	return terms
+}
+
+// singular strips common English plural endings, so "networks" and
+// "network" count as one term
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && len(word) > 3 &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// wordSet builds a set from whitespace-separated words
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
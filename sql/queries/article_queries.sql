@@ -18,10 +18,19 @@ WHERE id = ?
 LIMIT 1;
 
 -- name: GetArticleByURL :one
+-- A paper found by several searches has a row in each; the latest is returned
 SELECT * FROM articles
 WHERE article_url = ?
+ORDER BY fetched_at DESC
 LIMIT 1;
 
+-- name: ListArticlesByIDPrefix :many
+-- Two rows are enough to tell whether the prefix is ambiguous
+SELECT * FROM articles
+WHERE id LIKE sqlc.arg(prefix) || '%'
+ORDER BY fetched_at DESC
+LIMIT 2;
+
 -- name: ListArticlesBySearch :many
 SELECT * FROM articles
 WHERE search_id = ?
@@ -41,10 +50,12 @@ ORDER BY fetched_at DESC
 LIMIT ?
 OFFSET ?;
 
--- name: CheckArticleExists :one
+-- name: CheckSearchArticleExists :one
+-- Each search keeps its own copy of an article, so a paper already stored for
+-- another search is still new to this one
 SELECT EXISTS (
   SELECT 1 FROM articles
-  WHERE article_url = ?
+  WHERE article_url = ? AND search_id = ?
 ) AS article_exists;
 
 -- name: GetRecentArticlesWithSearchInfo :many
@@ -62,16 +73,6 @@ SELECT COUNT(*) as article_count
 FROM articles
 WHERE search_id = ?;
 
--- name: SearchArticlesByKeyword :many
-SELECT *
-FROM articles
-WHERE 
-  LOWER(article_title) LIKE LOWER('%' || ? || '%') OR
-  LOWER(article_summary) LIKE LOWER('%' || ? || '%')
-ORDER BY fetched_at DESC
-LIMIT ?
-OFFSET ?;
-
 -- name: GetArticlesFetchedBetween :many
 SELECT *
 FROM articles
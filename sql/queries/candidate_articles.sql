@@ -34,6 +34,14 @@ SELECT EXISTS (
   WHERE candidate_id = ? AND article_id = ?
 ) AS link_exists;
 
+-- name: CheckCandidateAuthoredPaper :one
+-- Whether the candidate wrote the paper at the URL in any search
+SELECT EXISTS (
+  SELECT 1 FROM candidate_articles ca
+  JOIN articles a ON a.id = ca.article_id
+  WHERE ca.candidate_id = ? AND a.article_url = ?
+) AS authored;
+
 -- name: UnlinkArticleFromCandidate :exec
 DELETE FROM candidate_articles
 WHERE candidate_id = ? AND article_id = ?;
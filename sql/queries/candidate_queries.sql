@@ -5,9 +5,10 @@ INSERT INTO candidates (
   updated_at,
   name,
   linkedin_url,
-  github_url
+  github_url,
+  normalized_name
 ) VALUES (
-  ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?
 )
 RETURNING *;
 
@@ -23,7 +24,8 @@ WHERE c.id = ?;
 SELECT 
   c.*,
   cs.relevance_score,
-  cs.notes
+  cs.notes,
+  cs.status
 FROM candidates c
 JOIN candidate_searches cs ON c.id = cs.candidate_id
 WHERE cs.search_id = ?
@@ -61,6 +63,7 @@ INSERT INTO candidate_categories (
 ) VALUES (
   ?, ?, ?, ?, ?
 )
+ON CONFLICT(candidate_id, arxiv_category) DO UPDATE SET updated_at = excluded.updated_at
 RETURNING *;
 
 -- name: LinkCandidateToSearch :one
@@ -96,10 +99,75 @@ SELECT EXISTS (
 DELETE FROM candidate_categories
 WHERE candidate_id = ? AND arxiv_category = ?;
 
--- name: FindCandidateByName :many
+-- name: GetCandidateByID :one
 SELECT * FROM candidates
-WHERE LOWER(name) LIKE LOWER('%' || ? || '%')
-ORDER BY created_at DESC
-LIMIT ?
-OFFSET ?;
+WHERE id = ?
+LIMIT 1;
+
+-- name: GetCandidateByNormalizedName :one
+SELECT * FROM candidates
+WHERE normalized_name = ?
+ORDER BY created_at ASC
+LIMIT 1;
+
+-- name: ListCandidatesByNormalizedName :many
+SELECT * FROM candidates
+WHERE normalized_name = ?
+ORDER BY created_at ASC;
+
+-- name: ListCandidateCoauthorNames :many
+-- The normalized names of everyone the candidate has written an article with
+SELECT DISTINCT c.normalized_name FROM candidate_articles mine
+JOIN candidate_articles theirs ON theirs.article_id = mine.article_id AND theirs.candidate_id != mine.candidate_id
+JOIN candidates c ON c.id = theirs.candidate_id
+WHERE mine.candidate_id = ?;
+
+-- name: ListCandidatesByGithubURL :many
+-- URLs are compared ignoring case and trailing slashes
+SELECT * FROM candidates
+WHERE LOWER(RTRIM(github_url, '/')) = LOWER(RTRIM(sqlc.arg(github_url), '/'))
+ORDER BY created_at ASC;
+
+-- name: ListCandidatesByLinkedinURL :many
+SELECT * FROM candidates
+WHERE LOWER(RTRIM(linkedin_url, '/')) = LOWER(RTRIM(sqlc.arg(linkedin_url), '/'))
+ORDER BY created_at ASC;
+
+-- name: GetCandidateSearchLink :one
+SELECT * FROM candidate_searches
+WHERE candidate_id = ? AND search_id = ?
+LIMIT 1;
 
+-- name: ListCandidateIDsBySearch :many
+-- Every candidate linked to the search, directly or through one of its articles
+SELECT candidate_id FROM candidate_searches
+WHERE candidate_searches.search_id = sqlc.arg(search_id)
+UNION
+SELECT ca.candidate_id FROM candidate_articles ca
+JOIN articles a ON a.id = ca.article_id
+WHERE a.search_id = sqlc.arg(search_id);
+
+-- name: IsCandidateOrphaned :one
+SELECT
+  NOT EXISTS (SELECT 1 FROM candidate_articles ca WHERE ca.candidate_id = sqlc.arg(candidate_id))
+  AND NOT EXISTS (SELECT 1 FROM candidate_searches cs WHERE cs.candidate_id = sqlc.arg(candidate_id)) AS orphaned;
+
+-- name: CountCandidatesBySearch :one
+SELECT COUNT(*) FROM candidate_searches
+WHERE search_id = ?;
+
+-- name: SetCandidateSearchStatus :one
+UPDATE candidate_searches
+SET
+  updated_at = ?,
+  status = ?
+WHERE candidate_id = ? AND search_id = ?
+RETURNING *;
+
+-- name: UpdateCandidateNotes :one
+UPDATE candidate_searches
+SET
+  updated_at = ?,
+  notes = ?
+WHERE candidate_id = ? AND search_id = ?
+RETURNING *;
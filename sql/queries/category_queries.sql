@@ -0,0 +1,31 @@
+-- name: AddArticleCategory :exec
+INSERT INTO article_categories (
+  id,
+  created_at,
+  article_id,
+  arxiv_category,
+  is_primary
+) VALUES (
+  ?, ?, ?, ?, ?
+)
+ON CONFLICT(article_id, arxiv_category) DO NOTHING;
+
+-- name: ListArticleCategories :many
+SELECT * FROM article_categories
+WHERE article_id = ?
+ORDER BY is_primary DESC, arxiv_category ASC;
+
+-- name: ListUncategorizedArticles :many
+-- Articles fetched before categories were stored, oldest first
+SELECT a.* FROM articles a
+LEFT JOIN article_categories ac ON a.id = ac.article_id
+WHERE ac.id IS NULL
+ORDER BY a.fetched_at ASC
+LIMIT ?;
+
+-- name: DeleteArticleCategoriesBySearchID :execrows
+DELETE FROM article_categories
+WHERE article_id IN (
+  SELECT id FROM articles
+  WHERE search_id = ?
+);
@@ -0,0 +1,67 @@
+-- name: UpsertCandidateEnrichment :one
+INSERT INTO candidate_enrichments (
+  id,
+  created_at,
+  updated_at,
+  candidate_id,
+  source,
+  profile_login,
+  profile_url,
+  confidence,
+  evidence
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(candidate_id, source) DO UPDATE SET
+  updated_at = excluded.updated_at,
+  profile_login = excluded.profile_login,
+  profile_url = excluded.profile_url,
+  confidence = excluded.confidence,
+  evidence = excluded.evidence
+RETURNING *;
+
+-- name: GetCandidateEnrichment :one
+SELECT * FROM candidate_enrichments
+WHERE candidate_id = ? AND source = ?
+LIMIT 1;
+
+-- name: ListUnenrichedCandidates :many
+-- Candidates never looked up on GitHub, oldest first
+SELECT c.* FROM candidates c
+WHERE NOT EXISTS (
+  SELECT 1 FROM candidate_enrichments ce
+  WHERE ce.candidate_id = c.id AND ce.source = 'github'
+)
+ORDER BY c.created_at ASC
+LIMIT ?;
+
+-- name: ListUnenrichedCandidatesBySearch :many
+-- Candidates of a search never looked up on GitHub, most relevant first
+SELECT c.* FROM candidates c
+JOIN candidate_searches cs ON c.id = cs.candidate_id
+WHERE cs.search_id = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM candidate_enrichments ce
+    WHERE ce.candidate_id = c.id AND ce.source = 'github'
+  )
+ORDER BY cs.relevance_score DESC
+LIMIT ?;
+
+-- name: GetHTTPCacheEntry :one
+SELECT * FROM http_cache
+WHERE cache_key = ?
+LIMIT 1;
+
+-- name: PutHTTPCacheEntry :exec
+INSERT INTO http_cache (
+  cache_key,
+  fetched_at,
+  status_code,
+  body
+) VALUES (
+  ?, ?, ?, ?
+)
+ON CONFLICT(cache_key) DO UPDATE SET
+  fetched_at = excluded.fetched_at,
+  status_code = excluded.status_code,
+  body = excluded.body;
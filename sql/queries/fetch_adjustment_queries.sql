@@ -0,0 +1,27 @@
+-- name: CreateSearchFetchAdjustment :one
+INSERT INTO search_fetch_adjustments (
+  id,
+  created_at,
+  search_id,
+  previous_size,
+  new_size,
+  fetched,
+  new_articles,
+  duplicates,
+  scored,
+  relevant,
+  reason
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListSearchFetchAdjustments :many
+SELECT * FROM search_fetch_adjustments
+WHERE search_id = ?
+ORDER BY created_at DESC
+LIMIT ?;
+
+-- name: DeleteSearchFetchAdjustmentsBySearchID :execrows
+DELETE FROM search_fetch_adjustments
+WHERE search_id = ?;
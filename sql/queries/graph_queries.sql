@@ -0,0 +1,14 @@
+-- name: ListCoauthorshipRefs :many
+-- Every candidate-article link with the names and titles needed to draw the
+-- co-authorship graph, optionally limited to the articles of one search
+SELECT
+  ca.candidate_id,
+  c.name AS candidate_name,
+  ca.article_id,
+  a.article_title,
+  a.article_url
+FROM candidate_articles ca
+JOIN candidates c ON ca.candidate_id = c.id
+JOIN articles a ON ca.article_id = a.id
+WHERE sqlc.narg(search_id) IS NULL OR a.search_id = sqlc.narg(search_id)
+ORDER BY ca.candidate_id, a.article_url;
@@ -0,0 +1,65 @@
+-- name: AddLLMCall :exec
+INSERT INTO llm_calls (
+  id,
+  created_at,
+  purpose,
+  model,
+  input_tokens,
+  output_tokens,
+  cache_creation_input_tokens,
+  cache_read_input_tokens,
+  web_search_requests,
+  latency_ms,
+  search_id,
+  article_id,
+  session_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: AttachLLMCallsBySessionID :execrows
+-- A conversation's calls made before its search existed, as category
+-- discovery's are, count toward the search once it is created
+UPDATE llm_calls
+SET search_id = sqlc.arg(search_id)
+WHERE session_id = sqlc.arg(session_id) AND search_id IS NULL;
+
+-- name: SumLLMCallsBySearchSince :many
+-- A search's token use since a point in time, per model, for pricing against
+-- its budget
+SELECT
+  model,
+  COUNT(*) AS calls,
+  CAST(COALESCE(SUM(input_tokens), 0) AS INTEGER) AS input_tokens,
+  CAST(COALESCE(SUM(output_tokens), 0) AS INTEGER) AS output_tokens,
+  CAST(COALESCE(SUM(cache_creation_input_tokens), 0) AS INTEGER) AS cache_creation_input_tokens,
+  CAST(COALESCE(SUM(cache_read_input_tokens), 0) AS INTEGER) AS cache_read_input_tokens,
+  CAST(COALESCE(SUM(web_search_requests), 0) AS INTEGER) AS web_search_requests,
+  CAST(COALESCE(SUM(latency_ms), 0) AS INTEGER) AS latency_ms
+FROM llm_calls
+WHERE search_id = sqlc.arg(search_id) AND created_at >= sqlc.arg(since)
+GROUP BY model;
+
+-- name: ListLLMCalls :many
+-- Calls oldest first, optionally only those of one search or within a time
+-- range (since inclusive, until exclusive), with the search they were for
+SELECT
+  c.*,
+  COALESCE(s.description, '') AS search_description
+FROM llm_calls c
+LEFT JOIN searches s ON s.id = c.search_id
+WHERE
+  (sqlc.narg(search_id) IS NULL OR c.search_id = sqlc.narg(search_id)) AND
+  (sqlc.narg(since) IS NULL OR c.created_at >= sqlc.narg(since)) AND
+  (sqlc.narg(until) IS NULL OR c.created_at < sqlc.narg(until))
+ORDER BY c.created_at;
+
+-- name: DetachLLMCallsBySearchID :execrows
+-- Calls outlive the search they were for, so its spend still shows in the
+-- costs report, just no longer under the search
+UPDATE llm_calls
+SET search_id = NULL, article_id = NULL
+WHERE llm_calls.search_id = sqlc.arg(search_id) OR llm_calls.article_id IN (
+  SELECT a.id FROM articles a
+  WHERE a.search_id = sqlc.arg(search_id)
+);
@@ -0,0 +1,92 @@
+-- name: ListAllCandidates :many
+SELECT * FROM candidates
+ORDER BY created_at ASC, id ASC;
+
+-- name: ListCandidateArticleRefs :many
+-- Every candidate-article link with the article's URL, which identifies the
+-- same paper across searches
+SELECT
+  ca.candidate_id,
+  ca.article_id,
+  a.article_url
+FROM candidate_articles ca
+JOIN articles a ON ca.article_id = a.id;
+
+-- name: ListCandidateSearchLinks :many
+SELECT * FROM candidate_searches
+WHERE candidate_id = ?
+ORDER BY created_at ASC;
+
+-- name: ListCandidateEnrichments :many
+SELECT * FROM candidate_enrichments
+WHERE candidate_id = ?
+ORDER BY source ASC;
+
+-- name: SetCandidateFields :one
+UPDATE candidates
+SET
+  updated_at = ?,
+  name = ?,
+  normalized_name = ?,
+  linkedin_url = ?,
+  github_url = ?
+WHERE id = ?
+RETURNING *;
+
+-- The Move* queries re-point a duplicate's rows at the candidate it is merged
+-- into. Rows the kept candidate already has an equivalent of violate a UNIQUE
+-- constraint and are left behind by OR IGNORE, for the Delete* queries to remove.
+
+-- name: MoveCandidateArticles :execrows
+UPDATE OR IGNORE candidate_articles
+SET candidate_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE candidate_id = sqlc.arg(drop_id);
+
+-- name: DeleteCandidateArticles :execrows
+DELETE FROM candidate_articles
+WHERE candidate_id = ?;
+
+-- name: MoveCandidateSearches :execrows
+UPDATE OR IGNORE candidate_searches
+SET candidate_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE candidate_id = sqlc.arg(drop_id);
+
+-- name: DeleteCandidateSearches :execrows
+DELETE FROM candidate_searches
+WHERE candidate_id = ?;
+
+-- name: MoveCandidateCategories :execrows
+UPDATE OR IGNORE candidate_categories
+SET candidate_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE candidate_id = sqlc.arg(drop_id);
+
+-- name: DeleteCandidateCategories :execrows
+DELETE FROM candidate_categories
+WHERE candidate_id = ?;
+
+-- name: MoveCandidateEnrichments :execrows
+UPDATE OR IGNORE candidate_enrichments
+SET candidate_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE candidate_id = sqlc.arg(drop_id);
+
+-- name: DeleteCandidateEnrichments :execrows
+DELETE FROM candidate_enrichments
+WHERE candidate_id = ?;
+
+-- name: MoveCandidateProfileLinks :execrows
+UPDATE OR IGNORE candidate_profile_links
+SET candidate_id = sqlc.arg(keep_id), updated_at = sqlc.arg(updated_at)
+WHERE candidate_id = sqlc.arg(drop_id);
+
+-- name: DeleteCandidateProfileLinks :execrows
+DELETE FROM candidate_profile_links
+WHERE candidate_id = ?;
+
+-- name: MoveCandidateStatusChanges :execrows
+UPDATE candidate_status_changes
+SET candidate_id = sqlc.arg(keep_id)
+WHERE candidate_id = sqlc.arg(drop_id);
+
+-- name: DeleteCandidate :execrows
+DELETE FROM candidates
+WHERE id = ?;
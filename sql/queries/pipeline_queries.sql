@@ -0,0 +1,59 @@
+-- name: CreateCandidateStatusChange :one
+INSERT INTO candidate_status_changes (
+  id,
+  created_at,
+  candidate_id,
+  search_id,
+  from_status,
+  to_status,
+  changed_by,
+  note
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListCandidateStatusChanges :many
+SELECT * FROM candidate_status_changes
+WHERE candidate_id = ? AND search_id = ?
+ORDER BY created_at DESC;
+
+-- name: ListCandidatesBySearchAndStatus :many
+SELECT
+  c.*,
+  cs.relevance_score,
+  cs.notes,
+  cs.status
+FROM candidates c
+JOIN candidate_searches cs ON c.id = cs.candidate_id
+WHERE cs.search_id = ? AND cs.status = ?
+ORDER BY cs.relevance_score DESC
+LIMIT ?
+OFFSET ?;
+
+-- name: ListCandidatesByStatus :many
+-- Every candidate at a stage, across searches, longest waiting first
+SELECT
+  c.*,
+  cs.search_id,
+  s.description AS search_description,
+  cs.relevance_score,
+  cs.status,
+  cs.updated_at AS status_updated_at
+FROM candidates c
+JOIN candidate_searches cs ON c.id = cs.candidate_id
+JOIN searches s ON cs.search_id = s.id
+WHERE cs.status = ?
+ORDER BY cs.updated_at ASC
+LIMIT ?
+OFFSET ?;
+
+-- name: CountCandidatesByStatus :many
+SELECT status, COUNT(*) AS candidate_count
+FROM candidate_searches
+WHERE search_id = ?
+GROUP BY status;
+
+-- name: DeleteCandidateStatusChangesBySearchID :execrows
+DELETE FROM candidate_status_changes
+WHERE search_id = ?;
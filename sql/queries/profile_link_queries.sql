@@ -0,0 +1,49 @@
+-- name: UpsertCandidateProfileLink :one
+INSERT INTO candidate_profile_links (
+  id,
+  created_at,
+  updated_at,
+  candidate_id,
+  kind,
+  url,
+  source_url,
+  source_title,
+  cited_text,
+  model
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(candidate_id, kind, url) DO UPDATE SET
+  updated_at = excluded.updated_at,
+  source_url = excluded.source_url,
+  source_title = excluded.source_title,
+  cited_text = excluded.cited_text,
+  model = excluded.model
+RETURNING *;
+
+-- name: ListCandidateProfileLinks :many
+SELECT * FROM candidate_profile_links
+WHERE candidate_id = ?
+ORDER BY kind, created_at;
+
+-- name: ListUndiscoveredCandidates :many
+-- Candidates whose profiles were never searched for on the web, oldest first
+SELECT c.* FROM candidates c
+WHERE NOT EXISTS (
+  SELECT 1 FROM candidate_enrichments ce
+  WHERE ce.candidate_id = c.id AND ce.source = 'web_search'
+)
+ORDER BY c.created_at ASC
+LIMIT ?;
+
+-- name: ListUndiscoveredCandidatesBySearch :many
+-- Candidates of a search whose profiles were never searched for, most relevant first
+SELECT c.* FROM candidates c
+JOIN candidate_searches cs ON c.id = cs.candidate_id
+WHERE cs.search_id = ?
+  AND NOT EXISTS (
+    SELECT 1 FROM candidate_enrichments ce
+    WHERE ce.candidate_id = c.id AND ce.source = 'web_search'
+  )
+ORDER BY cs.relevance_score DESC
+LIMIT ?;
@@ -0,0 +1,47 @@
+-- name: UpsertArticleRelevance :one
+INSERT INTO article_relevance (
+  id,
+  created_at,
+  updated_at,
+  article_id,
+  search_id,
+  score,
+  rationale,
+  key_authors,
+  model
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT(article_id) DO UPDATE SET
+  updated_at = excluded.updated_at,
+  score = excluded.score,
+  rationale = excluded.rationale,
+  key_authors = excluded.key_authors,
+  model = excluded.model
+RETURNING *;
+
+-- name: GetArticleRelevance :one
+SELECT * FROM article_relevance
+WHERE article_id = ?
+LIMIT 1;
+
+-- name: ListUnscoredArticlesBySearch :many
+SELECT a.*
+FROM articles a
+LEFT JOIN article_relevance ar ON a.id = ar.article_id
+WHERE a.search_id = ? AND ar.id IS NULL
+ORDER BY a.fetched_at DESC
+LIMIT ?;
+
+-- name: ListScoredArticlesBySearch :many
+SELECT
+  a.*,
+  ar.score,
+  ar.rationale,
+  ar.key_authors
+FROM articles a
+JOIN article_relevance ar ON a.id = ar.article_id
+WHERE a.search_id = ? AND ar.score >= ?
+ORDER BY ar.score DESC
+LIMIT ?
+OFFSET ?;
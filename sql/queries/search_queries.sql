@@ -6,9 +6,10 @@ INSERT INTO searches (
   description, 
   arvix_url, 
   results_per_fetch,
-  last_fetch_date
+  last_fetch_date,
+  monthly_budget
 ) VALUES (
-  ?, ?, ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?
 )
 RETURNING *;
 
@@ -85,8 +86,11 @@ ORDER BY created_at DESC;
 -- name: GetSearchesWithoutRecentFetches :many
 SELECT s.*
 FROM searches s
-WHERE s.last_fetch_date IS NULL OR s.last_fetch_date < ?
+WHERE
+  (s.last_fetch_date IS NULL OR s.last_fetch_date < ?) AND
+  (s.next_fetch_after IS NULL OR s.next_fetch_after <= ?)
 ORDER BY 
+  s.fetch_failures ASC,
   CASE WHEN s.last_fetch_date IS NULL THEN 0 ELSE 1 END ASC,
   s.last_fetch_date ASC
 LIMIT ?;
@@ -108,10 +112,22 @@ WHERE id = ?
 RETURNING *;
 
 -- name: UpdateSearchLastFetchDate :one
+-- A successful fetch also ends any backoff from earlier failures
 UPDATE searches
 SET
   updated_at = ?,
-  last_fetch_date = ?
+  last_fetch_date = ?,
+  fetch_failures = 0,
+  next_fetch_after = NULL
+WHERE id = ?
+RETURNING *;
+
+-- name: RecordSearchFetchFailure :one
+UPDATE searches
+SET
+  updated_at = ?,
+  fetch_failures = fetch_failures + 1,
+  next_fetch_after = ?
 WHERE id = ?
 RETURNING *;
 
@@ -123,3 +139,38 @@ SET
 WHERE id = ?
 RETURNING *;
 
+-- name: UpdateSearchBackfillCursor :one
+UPDATE searches
+SET
+  updated_at = ?,
+  backfill_cursor = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: SetSearchMonthlyBudget :one
+UPDATE searches
+SET
+  updated_at = ?,
+  monthly_budget = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: DeleteArticleRelevanceBySearchID :execrows
+DELETE FROM article_relevance
+WHERE search_id = ?;
+
+-- name: DeleteCandidateArticlesBySearchID :execrows
+DELETE FROM candidate_articles
+WHERE article_id IN (
+  SELECT id FROM articles
+  WHERE search_id = ?
+);
+
+-- name: DeleteCandidateSearchesBySearchID :execrows
+DELETE FROM candidate_searches
+WHERE search_id = ?;
+
+-- name: DeleteArticlesBySearchID :execrows
+DELETE FROM articles
+WHERE search_id = ?;
+
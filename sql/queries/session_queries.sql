@@ -0,0 +1,91 @@
+-- name: CreateAgentSession :one
+INSERT INTO agent_sessions (
+  id,
+  created_at,
+  updated_at,
+  kind,
+  title,
+  search_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: GetAgentSession :one
+SELECT * FROM agent_sessions
+WHERE id = ?;
+
+-- name: ListAgentSessionsByIDPrefix :many
+-- Two rows are enough to tell whether the prefix is ambiguous
+SELECT * FROM agent_sessions
+WHERE id LIKE sqlc.arg(prefix) || '%'
+ORDER BY updated_at DESC
+LIMIT 2;
+
+-- name: ListAgentSessions :many
+-- Sessions most recently active first, optionally only those of one search or
+-- kind, with their message count and the search they belong to
+SELECT
+  s.*,
+  (SELECT COUNT(*) FROM agent_messages m WHERE m.session_id = s.id) AS message_count,
+  COALESCE(searches.description, '') AS search_description
+FROM agent_sessions s
+LEFT JOIN searches ON searches.id = s.search_id
+WHERE
+  (sqlc.narg(search_id) IS NULL OR s.search_id = sqlc.narg(search_id)) AND
+  (sqlc.narg(kind) IS NULL OR s.kind = sqlc.narg(kind))
+ORDER BY s.updated_at DESC
+LIMIT sqlc.arg(limit)
+OFFSET sqlc.arg(offset);
+
+-- name: SetAgentSessionTitle :exec
+UPDATE agent_sessions
+SET title = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: SetAgentSessionSearch :exec
+UPDATE agent_sessions
+SET search_id = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: FinishAgentSession :exec
+UPDATE agent_sessions
+SET finished_at = ?, updated_at = ?
+WHERE id = ?;
+
+-- name: AddAgentMessage :exec
+INSERT INTO agent_messages (
+  session_id,
+  position,
+  created_at,
+  role,
+  content
+) VALUES (
+  ?, ?, ?, ?, ?
+);
+
+-- name: TouchAgentSession :exec
+UPDATE agent_sessions
+SET updated_at = ?
+WHERE id = ?;
+
+-- name: ListAgentMessages :many
+SELECT * FROM agent_messages
+WHERE session_id = ?
+ORDER BY position;
+
+-- name: DeleteAgentMessages :execrows
+DELETE FROM agent_messages
+WHERE session_id = ?;
+
+-- name: DeleteAgentSession :exec
+DELETE FROM agent_sessions
+WHERE id = ?;
+
+-- name: DeleteAgentMessagesBySearchID :execrows
+DELETE FROM agent_messages
+WHERE session_id IN (SELECT id FROM agent_sessions WHERE search_id = ?);
+
+-- name: DeleteAgentSessionsBySearchID :execrows
+DELETE FROM agent_sessions
+WHERE search_id = ?;
@@ -0,0 +1,35 @@
+-- name: ListUnindexedArticles :many
+-- Articles with no terms in the similarity index, oldest first
+SELECT a.*
+FROM articles a
+LEFT JOIN article_terms t ON t.article_id = a.id
+WHERE t.article_id IS NULL
+ORDER BY a.fetched_at ASC;
+
+-- name: AddArticleTerm :exec
+INSERT INTO article_terms (
+  article_id,
+  term,
+  count
+) VALUES (
+  ?, ?, ?
+)
+ON CONFLICT(article_id, term) DO UPDATE SET count = excluded.count;
+
+-- name: ListArticleTerms :many
+-- Every indexed term count, grouped by article. The empty term only marks
+-- an article that was indexed without yielding any terms.
+SELECT
+  t.article_id,
+  t.term,
+  t.count,
+  a.search_id,
+  a.article_url
+FROM article_terms t
+JOIN articles a ON t.article_id = a.id
+WHERE t.term != ''
+ORDER BY t.article_id;
+
+-- name: DeleteArticleTermsBySearchID :execrows
+DELETE FROM article_terms
+WHERE article_id IN (SELECT id FROM articles WHERE search_id = ?);
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE searches ADD COLUMN backfill_cursor TIMESTAMP; -- oldest submission date reached by "Fetch Older Results"
+
+-- +goose Down
+ALTER TABLE searches DROP COLUMN backfill_cursor;
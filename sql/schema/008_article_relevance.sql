@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE article_relevance(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	article_id UUID NOT NULL,
+	search_id UUID NOT NULL,
+	score FLOAT NOT NULL, -- 0.0 (irrelevant) to 1.0 (exactly what the search describes)
+	rationale TEXT NOT NULL,
+	key_authors TEXT NOT NULL, -- JSON array of author names worth following up on
+	model TEXT NOT NULL,
+	FOREIGN KEY(article_id) REFERENCES articles(id),
+	FOREIGN KEY(search_id) REFERENCES searches(id),
+	UNIQUE(article_id)
+);
+
+-- +goose Down
+DROP TABLE article_relevance;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Existing candidates are normalized afterwards in Go, with the same function
+-- the linker uses (see schema.NewMigrator)
+ALTER TABLE candidates ADD COLUMN normalized_name TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_candidates_normalized_name ON candidates(normalized_name);
+
+-- +goose Down
+DROP INDEX idx_candidates_normalized_name;
+ALTER TABLE candidates DROP COLUMN normalized_name;
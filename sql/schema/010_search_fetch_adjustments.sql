@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE search_fetch_adjustments(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	search_id UUID NOT NULL,
+	previous_size INTEGER NOT NULL,
+	new_size INTEGER NOT NULL,
+	fetched INTEGER NOT NULL, -- entries returned by the run that triggered the change
+	new_articles INTEGER NOT NULL,
+	duplicates INTEGER NOT NULL,
+	scored INTEGER NOT NULL, -- articles from the run with a relevance verdict, 0 if not scored
+	relevant INTEGER NOT NULL,
+	reason TEXT NOT NULL,
+	FOREIGN KEY(search_id) REFERENCES searches(id)
+);
+
+CREATE INDEX idx_search_fetch_adjustments_search_id ON search_fetch_adjustments(search_id, created_at);
+
+-- +goose Down
+DROP INDEX idx_search_fetch_adjustments_search_id;
+DROP TABLE search_fetch_adjustments;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE candidate_enrichments(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	candidate_id UUID NOT NULL,
+	source TEXT NOT NULL, -- e.g. "github"
+	profile_login TEXT, -- NULL when no plausible match was found
+	profile_url TEXT,
+	confidence FLOAT NOT NULL, -- 0.0 (no match) to 1.0 (certain)
+	evidence TEXT NOT NULL, -- JSON array of the signals behind the confidence
+	FOREIGN KEY(candidate_id) REFERENCES candidates(id),
+	UNIQUE(candidate_id, source)
+);
+
+-- Raw API responses, so repeated lookups within the TTL never hit the network
+CREATE TABLE http_cache(
+	cache_key TEXT PRIMARY KEY,
+	fetched_at TIMESTAMP NOT NULL,
+	status_code INTEGER NOT NULL,
+	body TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE http_cache;
+DROP TABLE candidate_enrichments;
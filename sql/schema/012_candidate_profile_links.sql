@@ -0,0 +1,19 @@
+-- +goose Up
+-- Profile URLs found by web search, each with the search result that backs it
+CREATE TABLE candidate_profile_links(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	candidate_id UUID NOT NULL,
+	kind TEXT NOT NULL CHECK (kind IN ('linkedin', 'github', 'homepage')),
+	url TEXT NOT NULL,
+	source_url TEXT NOT NULL, -- the cited search result
+	source_title TEXT NOT NULL,
+	cited_text TEXT NOT NULL, -- empty when the URL was a search result but not quoted
+	model TEXT NOT NULL,
+	FOREIGN KEY(candidate_id) REFERENCES candidates(id),
+	UNIQUE(candidate_id, kind, url)
+);
+
+-- +goose Down
+DROP TABLE candidate_profile_links;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- The review decision for a candidate within a search: new, shortlisted, approved or rejected
+ALTER TABLE candidate_searches ADD COLUMN status TEXT NOT NULL DEFAULT 'new';
+CREATE INDEX idx_candidate_searches_status ON candidate_searches(search_id, status);
+
+-- +goose Down
+DROP INDEX idx_candidate_searches_status;
+ALTER TABLE candidate_searches DROP COLUMN status;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- candidate_searches.status becomes a recruiting pipeline stage: new,
+-- reviewing, shortlisted, approved, contacted, responded, interviewing,
+-- rejected or hired. The review decisions made before the pipeline existed
+-- (new, shortlisted, approved, rejected) are stages of their own, so every
+-- link keeps its status as it is.
+CREATE TABLE candidate_status_changes(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	candidate_id UUID NOT NULL,
+	search_id UUID NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status TEXT NOT NULL,
+	changed_by TEXT NOT NULL,
+	note TEXT,
+	FOREIGN KEY(candidate_id) REFERENCES candidates(id),
+	FOREIGN KEY(search_id) REFERENCES searches(id)
+);
+CREATE INDEX idx_candidate_status_changes_link ON candidate_status_changes(candidate_id, search_id, created_at);
+
+-- +goose Down
+-- Rolling back would drop the pipeline history and leave links at stages the
+-- review screen no longer knows, so it is refused (see refusePipelineRollback)
+-- unless every link is still at new, shortlisted, approved or rejected and no
+-- move has been recorded.
+DROP INDEX idx_candidate_status_changes_link;
+DROP TABLE candidate_status_changes;
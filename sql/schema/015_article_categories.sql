@@ -0,0 +1,17 @@
+-- +goose Up
+-- The arXiv categories an article is listed in: its primary category and any
+-- it was cross-listed to
+CREATE TABLE article_categories(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	article_id UUID NOT NULL,
+	arxiv_category TEXT NOT NULL,
+	is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+	FOREIGN KEY(article_id) REFERENCES articles(id),
+	UNIQUE(article_id, arxiv_category)
+);
+CREATE INDEX idx_article_categories_category ON article_categories(arxiv_category);
+
+-- +goose Down
+DROP INDEX idx_article_categories_category;
+DROP TABLE article_categories;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Full-text indexes over articles and candidate names. They need SQLite built
+-- with FTS5, which the sqlite3 driver only includes under the sqlite_fts5 build
+-- tag, so the tables and triggers are created by rebuildFullText (see
+-- full_text.go) only when the module is there. A build without it falls back
+-- to substring search, and EnableFullText builds the index the first time the
+-- database is opened by a build with it.
+
+-- +goose Down
+-- dropFullText removes the index
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Term counts behind the "more like this" similarity index. Counts are stored
+-- rather than TF-IDF weights because document frequencies change with every
+-- fetch; weights are worked out from the counts when the index is loaded.
+-- An article is indexed lazily the first time the index is used after it is
+-- stored, and editing its title or summary drops its terms to be re-indexed.
+CREATE TABLE article_terms(
+	article_id UUID NOT NULL,
+	term TEXT NOT NULL,
+	count INTEGER NOT NULL,
+	PRIMARY KEY(article_id, term),
+	FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER article_terms_stale AFTER UPDATE OF article_title, article_summary ON articles BEGIN
+	DELETE FROM article_terms WHERE article_id = old.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER article_terms_stale;
+DROP TABLE article_terms;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Conversations with Claude, saved after every turn so a crashed or closed
+-- session can be resumed. kind is "discovery" for category discovery, whose
+-- session is tied to the search it created, or "assistant" for the research
+-- assistant, which is not tied to a search. finished_at is set when
+-- discovery proposes its categories.
+CREATE TABLE agent_sessions(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	kind TEXT NOT NULL,
+	title TEXT NOT NULL,
+	search_id UUID,
+	finished_at TIMESTAMP,
+	FOREIGN KEY(search_id) REFERENCES searches(id)
+);
+CREATE INDEX idx_agent_sessions_search_id ON agent_sessions(search_id, updated_at);
+
+-- Each message is stored as the JSON array of content blocks sent to the API
+CREATE TABLE agent_messages(
+	session_id UUID NOT NULL,
+	position INTEGER NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	PRIMARY KEY(session_id, position),
+	FOREIGN KEY(session_id) REFERENCES agent_sessions(id)
+);
+
+-- +goose Down
+DROP TABLE agent_messages;
+DROP INDEX idx_agent_sessions_search_id;
+DROP TABLE agent_sessions;
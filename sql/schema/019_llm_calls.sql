@@ -0,0 +1,35 @@
+-- +goose Up
+-- Every model request, recorded once its response has streamed in. purpose
+-- says which feature made it ("scoring", "profile_discovery",
+-- "category_discovery" or "assistant"), and search_id and article_id what it
+-- was for, when anything. Costs are not stored: the costs report works them
+-- out from the token counts with the configured price table, so correcting a
+-- price also corrects past months.
+CREATE TABLE llm_calls(
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	purpose TEXT NOT NULL,
+	model TEXT NOT NULL,
+	input_tokens INTEGER NOT NULL,
+	output_tokens INTEGER NOT NULL,
+	cache_creation_input_tokens INTEGER NOT NULL,
+	cache_read_input_tokens INTEGER NOT NULL,
+	web_search_requests INTEGER NOT NULL,
+	latency_ms INTEGER NOT NULL,
+	search_id UUID,
+	article_id UUID,
+	FOREIGN KEY(search_id) REFERENCES searches(id),
+	FOREIGN KEY(article_id) REFERENCES articles(id)
+);
+CREATE INDEX idx_llm_calls_search_id ON llm_calls(search_id, created_at);
+CREATE INDEX idx_llm_calls_created_at ON llm_calls(created_at);
+
+-- Once a search's calls this calendar month (UTC) cost monthly_budget US
+-- dollars, its LLM work pauses until the next month. NULL means no budget.
+ALTER TABLE searches ADD COLUMN monthly_budget REAL;
+
+-- +goose Down
+ALTER TABLE searches DROP COLUMN monthly_budget;
+DROP INDEX idx_llm_calls_created_at;
+DROP INDEX idx_llm_calls_search_id;
+DROP TABLE llm_calls;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- An article row belongs to one search, so a paper found by several searches
+-- is stored once for each of them, but never twice for the same one
+CREATE UNIQUE INDEX idx_articles_search_url ON articles(search_id, article_url);
+
+-- +goose Down
+DROP INDEX idx_articles_search_url;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Databases that applied migration 016 while it was empty had their full-text
+-- tables created at startup instead, keyed on an unindexed UUID column, so
+-- every article update or delete scanned the whole index. rebuildFullText (see
+-- full_text.go) replaces whatever is there with the external-content tables
+-- of migration 016 and rebuilds them.
+
+-- +goose Down
+-- Migration 016 owns these tables, so rolling this one back leaves them as they are
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Name normalization now composes accents (NFC), so a name typed with a
+-- combining accent matches one typed with the precomposed letter. Existing
+-- candidates are normalized again in Go (see schema.NewMigrator).
+
+-- +goose Down
+-- Nothing to undo: the NFC form is as good a key for the older linker
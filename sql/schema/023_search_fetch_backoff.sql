@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE searches ADD COLUMN fetch_failures INTEGER NOT NULL DEFAULT 0; -- consecutive failed fetches
+ALTER TABLE searches ADD COLUMN next_fetch_after TIMESTAMP; -- the daemon leaves a failing search alone until then
+
+-- +goose Down
+ALTER TABLE searches DROP COLUMN next_fetch_after;
+ALTER TABLE searches DROP COLUMN fetch_failures;
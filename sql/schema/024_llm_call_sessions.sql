@@ -0,0 +1,11 @@
+-- +goose Up
+-- The conversation a call was made in, so category discovery's calls, made
+-- before its search exists, can be put against the search once it is created.
+-- It is not a foreign key: calls outlive the conversations they were made in,
+-- so their spend still shows in the costs report.
+ALTER TABLE llm_calls ADD COLUMN session_id UUID;
+CREATE INDEX idx_llm_calls_session_id ON llm_calls(session_id);
+
+-- +goose Down
+DROP INDEX idx_llm_calls_session_id;
+ALTER TABLE llm_calls DROP COLUMN session_id;
@@ -0,0 +1,168 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// fullTextTriggers are the triggers that keep the full-text tables in step
+// with articles and candidates
+var fullTextTriggers = []string{
+	"articles_fts_insert",
+	"articles_fts_update",
+	"articles_fts_delete",
+	"candidates_fts_insert",
+	"candidates_fts_update",
+	"candidates_fts_delete",
+}
+
+// createFullText creates the full-text indexes over articles and candidate
+// names. They are external-content tables: they store only the index and read
+// the text back from articles and candidates by rowid, which the triggers use
+// to find the entry to change. remove_diacritics lets "Muller" find "Müller".
+var createFullText = []string{`
+CREATE VIRTUAL TABLE articles_fts USING fts5(
+	article_title,
+	article_summary,
+	article_authors,
+	content = 'articles',
+	content_rowid = 'rowid',
+	tokenize = 'unicode61 remove_diacritics 2'
+)`, `
+INSERT INTO articles_fts (articles_fts) VALUES ('rebuild')`, `
+CREATE VIRTUAL TABLE candidates_fts USING fts5(
+	name,
+	content = 'candidates',
+	content_rowid = 'rowid',
+	tokenize = 'unicode61 remove_diacritics 2'
+)`, `
+INSERT INTO candidates_fts (candidates_fts) VALUES ('rebuild')`, `
+CREATE TRIGGER articles_fts_insert AFTER INSERT ON articles BEGIN
+	INSERT INTO articles_fts (rowid, article_title, article_summary, article_authors)
+	VALUES (new.rowid, new.article_title, new.article_summary, new.article_authors);
+END`, `
+CREATE TRIGGER articles_fts_update AFTER UPDATE OF article_title, article_summary, article_authors ON articles BEGIN
+	INSERT INTO articles_fts (articles_fts, rowid, article_title, article_summary, article_authors)
+	VALUES ('delete', old.rowid, old.article_title, old.article_summary, old.article_authors);
+	INSERT INTO articles_fts (rowid, article_title, article_summary, article_authors)
+	VALUES (new.rowid, new.article_title, new.article_summary, new.article_authors);
+END`, `
+CREATE TRIGGER articles_fts_delete AFTER DELETE ON articles BEGIN
+	INSERT INTO articles_fts (articles_fts, rowid, article_title, article_summary, article_authors)
+	VALUES ('delete', old.rowid, old.article_title, old.article_summary, old.article_authors);
+END`, `
+CREATE TRIGGER candidates_fts_insert AFTER INSERT ON candidates BEGIN
+	INSERT INTO candidates_fts (rowid, name) VALUES (new.rowid, new.name);
+END`, `
+CREATE TRIGGER candidates_fts_update AFTER UPDATE OF name ON candidates BEGIN
+	INSERT INTO candidates_fts (candidates_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+	INSERT INTO candidates_fts (rowid, name) VALUES (new.rowid, new.name);
+END`, `
+CREATE TRIGGER candidates_fts_delete AFTER DELETE ON candidates BEGIN
+	INSERT INTO candidates_fts (candidates_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+END`,
+}
+
+// fullTextAvailable reports whether SQLite was built with FTS5, which the
+// sqlite3 driver only compiles in under the sqlite_fts5 build tag
+func fullTextAvailable(ctx context.Context, tx *sql.Tx) (bool, error) {
+	var available bool
+	if err := tx.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available); err != nil {
+		return false, fmt.Errorf("checking for FTS5: %w", err)
+	}
+	return available, nil
+}
+
+// dropFullTextTriggers stops the full-text tables from being kept up to date
+func dropFullTextTriggers(ctx context.Context, tx *sql.Tx) error {
+	for _, trigger := range fullTextTriggers {
+		if _, err := tx.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+trigger); err != nil {
+			return fmt.Errorf("dropping %s: %w", trigger, err)
+		}
+	}
+	return nil
+}
+
+// rebuildFullText replaces whatever full-text tables there are with the ones
+// createFullText describes and fills them from articles and candidates. It
+// runs after migration 016, and again after migration 021 for databases whose
+// index was created at startup keyed on an unindexed UUID column, so every
+// article update or delete scanned the whole index. Without FTS5 it only
+// drops the triggers, since they could not write to the tables.
+func rebuildFullText(ctx context.Context, tx *sql.Tx) error {
+	if err := dropFullTextTriggers(ctx, tx); err != nil {
+		return err
+	}
+	available, err := fullTextAvailable(ctx, tx)
+	if err != nil || !available {
+		return err
+	}
+	for _, statement := range append([]string{
+		"DROP TABLE IF EXISTS candidates_fts",
+		"DROP TABLE IF EXISTS articles_fts",
+	}, createFullText...) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("creating the full-text index: %w", err)
+		}
+	}
+	return nil
+}
+
+// dropFullText rolls back migration 016. The tables can only be dropped with
+// FTS5 available; without it they are left behind with no triggers, and
+// migrating up again with FTS5 replaces them.
+func dropFullText(ctx context.Context, tx *sql.Tx) error {
+	if err := dropFullTextTriggers(ctx, tx); err != nil {
+		return err
+	}
+	available, err := fullTextAvailable(ctx, tx)
+	if err != nil || !available {
+		return err
+	}
+	for _, table := range []string{"candidates_fts", "articles_fts"} {
+		if _, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+table); err != nil {
+			return fmt.Errorf("dropping %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// EnableFullText brings the full-text index in line with the SQLite the
+// program was built with and reports whether it is available. Without FTS5 it
+// drops the index triggers, so a database first opened by a build with FTS5
+// can still be written to, and searches fall back to substring matching. With
+// FTS5 and any trigger missing, the index is rebuilt, since rows may have
+// changed while it was not being kept up to date.
+func EnableFullText(ctx context.Context, db *sql.DB) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	available, err := fullTextAvailable(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	if !available {
+		if err := dropFullTextTriggers(ctx, tx); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+
+	var existing int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN (?, ?, ?, ?, ?, ?)`,
+		fullTextTriggers[0], fullTextTriggers[1], fullTextTriggers[2],
+		fullTextTriggers[3], fullTextTriggers[4], fullTextTriggers[5]).Scan(&existing)
+	if err != nil {
+		return false, fmt.Errorf("checking the full-text triggers: %w", err)
+	}
+	if existing < len(fullTextTriggers) {
+		if err := rebuildFullText(ctx, tx); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,47 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jessewalker/reSearch/internal/names"
+)
+
+// normalizeCandidateNames fills in normalized_name for the candidates that
+// existed before migration 009, and again after migration 022 for names
+// normalized before NFC composition was added. SQL's LOWER only folds ASCII and REPLACE
+// cannot collapse runs of spaces, so the names go through the same function
+// the linker matches with (candidates.NormalizeName), or existing candidates
+// would never be matched.
+func normalizeCandidateNames(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name FROM candidates`)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id   interface{}
+		name string
+	}
+	var list []row
+	for rows.Next() {
+		var c row
+		if err := rows.Scan(&c.id, &c.name); err != nil {
+			rows.Close()
+			return err
+		}
+		list = append(list, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range list {
+		_, err := tx.ExecContext(ctx, `UPDATE candidates SET normalized_name = ? WHERE id = ?`, names.Normalize(c.name), c.id)
+		if err != nil {
+			return fmt.Errorf("normalizing %q: %w", c.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// refusePipelineRollback stops migration 014 from being rolled back while it
+// would lose data: the history of pipeline moves, or links at stages that did
+// not exist before the pipeline and have no review decision to go back to.
+func refusePipelineRollback(ctx context.Context, tx *sql.Tx) error {
+	var changes, staged int64
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM candidate_status_changes`).Scan(&changes)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM candidate_searches
+		WHERE status NOT IN ('new', 'shortlisted', 'approved', 'rejected')`).Scan(&staged)
+	if err != nil {
+		return err
+	}
+	if changes > 0 || staged > 0 {
+		return fmt.Errorf("refusing to roll back the candidate pipeline: it would delete %d recorded stage changes and strand %d candidates at stages that did not exist before it; restore a backup taken before migration 014 instead", changes, staged)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Package schema embeds the goose migrations so the binary can apply them itself
+package schema
+
+import (
+	"database/sql"
+	"embed"
+
+	"github.com/jessewalker/reSearch/internal/migrate"
+)
+
+// Migrations holds every migration in this directory
+//
+//go:embed *.sql
+var Migrations embed.FS
+
+// NewMigrator returns a migrator for the embedded migrations, with the Go
+// code that some of them run after their SQL
+func NewMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	migrator, err := migrate.NewMigrator(db, Migrations)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.AfterUp(9, normalizeCandidateNames); err != nil {
+		return nil, err
+	}
+	if err := migrator.AfterUp(16, rebuildFullText); err != nil {
+		return nil, err
+	}
+	if err := migrator.AfterUp(21, rebuildFullText); err != nil {
+		return nil, err
+	}
+	if err := migrator.AfterUp(22, normalizeCandidateNames); err != nil {
+		return nil, err
+	}
+	if err := migrator.BeforeDown(14, refusePipelineRollback); err != nil {
+		return nil, err
+	}
+	if err := migrator.BeforeDown(16, dropFullText); err != nil {
+		return nil, err
+	}
+	return migrator, nil
+}
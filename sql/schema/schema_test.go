@@ -0,0 +1,352 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/jessewalker/reSearch/candidates"
+	"github.com/jessewalker/reSearch/internal/database"
+)
+
+// migrateTo applies migrations up to and including version, one at a time
+// from the top, by rolling back everything above it after a full Up
+func migrateTo(t *testing.T, db *sql.DB, version int64) {
+	t.Helper()
+	ctx := context.Background()
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	for {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("Status: %v", err)
+		}
+		latest := int64(0)
+		for _, status := range statuses {
+			if status.Applied {
+				latest = status.Migration.Version
+			}
+		}
+		if latest <= version {
+			return
+		}
+		if _, _, err := migrator.Down(ctx); err != nil {
+			t.Fatalf("Down from %d: %v", latest, err)
+		}
+	}
+}
+
+// Candidates stored before migration 009 get the same normalized name the
+// linker computes, so linking their names again matches instead of duplicating
+func TestNormalizedNameBackfill(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrateTo(t, db, 8)
+
+	existing := map[string]string{
+		"J.  Smith":    "j smith",
+		"ZOË MÜLLER":   "zoë müller",
+		" Ann\tLee ":   "ann lee",
+		"José Álvarez": "josé álvarez",
+		// An e followed by a combining acute accent
+		"Rene\u0301 Dubois": "rené dubois",
+	}
+	searchID := uuid.New()
+	_, err = db.ExecContext(ctx, `INSERT INTO searches (id, created_at, updated_at, description, arvix_url) VALUES (?, datetime('now'), datetime('now'), 'search', 'http://rss.arxiv.org/rss/cs.LG')`, searchID)
+	if err != nil {
+		t.Fatalf("inserting search: %v", err)
+	}
+	for name := range existing {
+		candidateID := uuid.New()
+		_, err := db.ExecContext(ctx, `INSERT INTO candidates (id, created_at, updated_at, name) VALUES (?, datetime('now'), datetime('now'), ?)`, candidateID, name)
+		if err != nil {
+			t.Fatalf("inserting %q: %v", name, err)
+		}
+		_, err = db.ExecContext(ctx, `INSERT INTO candidate_searches (id, created_at, updated_at, candidate_id, search_id) VALUES (?, datetime('now'), datetime('now'), ?, ?)`, uuid.New(), candidateID, searchID)
+		if err != nil {
+			t.Fatalf("linking %q: %v", name, err)
+		}
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	queries := database.New(db)
+	all, err := queries.ListAllCandidates(ctx)
+	if err != nil {
+		t.Fatalf("ListAllCandidates: %v", err)
+	}
+	for _, candidate := range all {
+		if want := existing[candidate.Name]; candidate.NormalizedName != want {
+			t.Errorf("%q normalized to %q, want %q", candidate.Name, candidate.NormalizedName, want)
+		}
+		if candidate.NormalizedName != candidates.NormalizeName(candidate.Name) {
+			t.Errorf("%q: backfill and NormalizeName disagree", candidate.Name)
+		}
+	}
+
+	// Linking a paper of their search by the same people, spelled as arXiv
+	// would, twice over matches the migrated rows every time
+	article, err := queries.CreateArticle(ctx, database.CreateArticleParams{
+		ID:             uuid.New(),
+		FetchedAt:      time.Now(),
+		ArticleUrl:     "https://arxiv.org/abs/2401.01234",
+		ArticleTitle:   "Title",
+		ArticleSummary: "Abstract.",
+		ArticleAuthors: "J. Smith, Zoë Müller, Ann Lee, José Álvarez and René Dubois",
+		SearchID:       searchID,
+	})
+	if err != nil {
+		t.Fatalf("CreateArticle: %v", err)
+	}
+	linker := candidates.NewLinker(db, queries)
+	for run := 1; run <= 2; run++ {
+		result, err := linker.LinkArticle(ctx, article, sql.NullFloat64{})
+		if err != nil {
+			t.Fatalf("LinkArticle run %d: %v", run, err)
+		}
+		if result.Created != 0 || result.Matched != len(existing) {
+			t.Errorf("run %d: created %d, matched %d; want 0, %d", run, result.Created, result.Matched, len(existing))
+		}
+	}
+	all, err = queries.ListAllCandidates(ctx)
+	if err != nil {
+		t.Fatalf("ListAllCandidates: %v", err)
+	}
+	if len(all) != len(existing) {
+		t.Errorf("%d candidates after linking, want %d", len(all), len(existing))
+	}
+}
+
+// Names normalized before migration 022 are composed again, so a decomposed
+// accent no longer keeps a candidate from being matched
+func TestCandidateNamesNFCMigration(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrateTo(t, db, 21)
+
+	_, err = db.ExecContext(ctx, `INSERT INTO candidates (id, created_at, updated_at, name, normalized_name) VALUES (?, datetime('now'), datetime('now'), ?, ?)`,
+		uuid.New(), "Rene\u0301 Dubois", "rene\u0301 dubois")
+	if err != nil {
+		t.Fatalf("inserting candidate: %v", err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	found, err := database.New(db).ListCandidatesByNormalizedName(ctx, candidates.NormalizeName("René Dubois"))
+	if err != nil {
+		t.Fatalf("ListCandidatesByNormalizedName: %v", err)
+	}
+	if len(found) != 1 {
+		t.Errorf("found %d candidates by the precomposed name, want 1", len(found))
+	}
+}
+
+// Review decisions made before migration 014 stay as pipeline stages, and the
+// migration refuses to roll back once the pipeline holds anything it would lose
+func TestPipelineMigration(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrateTo(t, db, 13)
+
+	searchID := uuid.New()
+	_, err = db.ExecContext(ctx, `INSERT INTO searches (id, created_at, updated_at, description, arvix_url) VALUES (?, datetime('now'), datetime('now'), 'search', 'http://rss.arxiv.org/rss/cs.LG')`, searchID)
+	if err != nil {
+		t.Fatalf("inserting search: %v", err)
+	}
+	decisions := map[uuid.UUID]string{}
+	for _, status := range []string{"new", "shortlisted", "approved", "rejected"} {
+		candidateID := uuid.New()
+		decisions[candidateID] = status
+		_, err := db.ExecContext(ctx, `INSERT INTO candidates (id, created_at, updated_at, name, normalized_name) VALUES (?, datetime('now'), datetime('now'), ?, ?)`, candidateID, status, status)
+		if err != nil {
+			t.Fatalf("inserting candidate: %v", err)
+		}
+		_, err = db.ExecContext(ctx, `INSERT INTO candidate_searches (id, created_at, updated_at, candidate_id, search_id, status) VALUES (?, datetime('now'), datetime('now'), ?, ?, ?)`, uuid.New(), candidateID, searchID, status)
+		if err != nil {
+			t.Fatalf("inserting link: %v", err)
+		}
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	queries := database.New(db)
+	var shortlisted uuid.UUID
+	for candidateID, want := range decisions {
+		link, err := queries.GetCandidateSearchLink(ctx, database.GetCandidateSearchLinkParams{CandidateID: candidateID, SearchID: searchID})
+		if err != nil {
+			t.Fatalf("GetCandidateSearchLink: %v", err)
+		}
+		if link.Status != want {
+			t.Errorf("%s link migrated to %q", want, link.Status)
+		}
+		if want == candidates.StatusShortlisted {
+			shortlisted = candidateID
+		}
+	}
+
+	pipeline := candidates.NewPipeline(db, queries)
+	if _, err := pipeline.Move(ctx, shortlisted, searchID, candidates.StatusContacted, "tester", ""); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	for {
+		migration, ok, err := migrator.Down(ctx)
+		if err != nil {
+			if migration.Version != 14 {
+				t.Fatalf("Down from %d: %v", migration.Version, err)
+			}
+			break
+		}
+		if !ok || migration.Version <= 14 {
+			t.Fatalf("rolled back past the pipeline migration")
+		}
+	}
+	var changes int64
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM candidate_status_changes`).Scan(&changes); err != nil {
+		t.Fatalf("pipeline history is gone: %v", err)
+	}
+	if changes != 1 {
+		t.Errorf("%d stage changes after the refused rollback, want 1", changes)
+	}
+}
+
+// A full-text index created at startup under the old, empty migration 016 is
+// replaced by the external-content tables, keeping every row searchable
+func TestFullTextRowidMigration(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var available bool
+	if err := db.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available); err != nil {
+		t.Fatal(err)
+	}
+	if !available {
+		t.Skip("SQLite lacks FTS5; run with -tags sqlite_fts5")
+	}
+	migrateTo(t, db, 20)
+
+	_, err = db.ExecContext(ctx, `
+DROP TRIGGER articles_fts_insert;
+DROP TRIGGER articles_fts_update;
+DROP TRIGGER articles_fts_delete;
+DROP TABLE articles_fts;
+CREATE VIRTUAL TABLE articles_fts USING fts5(article_id UNINDEXED, article_title, article_summary, article_authors);
+INSERT INTO searches (id, created_at, updated_at, description, arvix_url) VALUES ('s1', datetime('now'), datetime('now'), 'search', 'http://rss.arxiv.org/rss/cs.LG');
+INSERT INTO articles (id, fetched_at, article_url, article_title, article_summary, article_authors, search_id)
+VALUES ('a1', datetime('now'), 'https://arxiv.org/abs/1', 'Protein folding', 'Abstract.', 'Ann Lee', 's1');`)
+	if err != nil {
+		t.Fatalf("recreating the startup index: %v", err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	var legacy int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('articles_fts') WHERE name = 'article_id'`).Scan(&legacy); err != nil {
+		t.Fatal(err)
+	}
+	if legacy != 0 {
+		t.Errorf("articles_fts still has its article_id column")
+	}
+	var id string
+	err = db.QueryRowContext(ctx, `SELECT a.id FROM articles_fts JOIN articles a ON a.rowid = articles_fts.rowid WHERE articles_fts MATCH 'protein'`).Scan(&id)
+	if err != nil || id != "a1" {
+		t.Errorf("protein matched %q (%v), want a1", id, err)
+	}
+}
+
+// A build without FTS5 drops the index triggers so articles can still be
+// written, and the next start with FTS5 rebuilds the index to take in what
+// was written meanwhile
+func TestEnableFullText(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrateTo(t, db, 23)
+	available, err := EnableFullText(ctx, db)
+	if err != nil {
+		t.Fatalf("EnableFullText: %v", err)
+	}
+
+	if available {
+		// What a start without FTS5 would have left behind
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dropFullTextTriggers(ctx, tx); err != nil {
+			t.Fatalf("dropFullTextTriggers: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO searches (id, created_at, updated_at, description, arvix_url) VALUES ('s1', datetime('now'), datetime('now'), 'search', 'http://rss.arxiv.org/rss/cs.LG')`)
+	if err != nil {
+		t.Fatalf("inserting search: %v", err)
+	}
+	_, err = db.ExecContext(ctx, `INSERT INTO articles (id, fetched_at, article_url, article_title, article_summary, article_authors, search_id)
+VALUES ('a1', datetime('now'), 'https://arxiv.org/abs/1', 'Protein folding', 'Abstract.', 'Ann Lee', 's1')`)
+	if err != nil {
+		t.Fatalf("inserting an article without the index triggers: %v", err)
+	}
+	if !available {
+		return
+	}
+
+	if _, err := EnableFullText(ctx, db); err != nil {
+		t.Fatalf("EnableFullText: %v", err)
+	}
+	var id string
+	err = db.QueryRowContext(ctx, `SELECT a.id FROM articles_fts JOIN articles a ON a.rowid = articles_fts.rowid WHERE articles_fts MATCH 'protein'`).Scan(&id)
+	if err != nil || id != "a1" {
+		t.Errorf("protein matched %q (%v), want a1", id, err)
+	}
+}